@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/miretskiy/rollingstone/simulator"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -23,11 +32,68 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// Heartbeat settings: keep idle connections (paused sim, zero write rate) alive behind
+// proxies/load balancers that drop WebSockets with no traffic
+const (
+	heartbeatInterval = 30 * time.Second      // How often to send ping messages
+	pongWait          = 2 * heartbeatInterval // Close the connection if the client is silent this long
+)
+
+// Shutdown settings: on SIGINT/SIGTERM or /quitquitquit, HTTP requests in flight finish and every
+// WebSocket client gets a final status and a close frame before the process exits
+const (
+	shutdownTimeout  = 10 * time.Second // Give up draining connections after this long
+	closeGracePeriod = 2 * time.Second  // How long a client has to answer the close frame
+)
+
+// shutdown is closed once the server starts shutting down (see requestShutdown)
+var (
+	shutdown     = make(chan struct{})
+	shutdownOnce sync.Once
+	wsConns      sync.WaitGroup // Open WebSocket connections, drained on shutdown
+	wsConnsMu    sync.Mutex     // Orders wsConns.Add against closing shutdown (see trackWebSocket)
+)
+
+// requestShutdown starts a graceful shutdown; safe to call more than once
+func requestShutdown() {
+	shutdownOnce.Do(func() {
+		wsConnsMu.Lock()
+		defer wsConnsMu.Unlock()
+		close(shutdown)
+	})
+}
+
+// trackWebSocket adds a WebSocket connection to wsConns unless the server is shutting down.
+// The check and the Add happen under wsConnsMu, which requestShutdown holds while closing
+// shutdown, so no Add can race with serve's wsConns.Wait
+func trackWebSocket() bool {
+	wsConnsMu.Lock()
+	defer wsConnsMu.Unlock()
+	if shuttingDown() {
+		return false
+	}
+	wsConns.Add(1)
+	return true
+}
+
+// shuttingDown reports whether requestShutdown has been called
+func shuttingDown() bool {
+	select {
+	case <-shutdown:
+		return true
+	default:
+		return false
+	}
+}
 
 // Client message types
 type ClientMessage struct {
-	Type   string               `json:"type"`
-	Config *simulator.SimConfig `json:"config,omitempty"`
+	Type            string               `json:"type"`
+	Config          *simulator.SimConfig `json:"config,omitempty"`
+	SpeedMultiplier *int                 `json:"speedMultiplier,omitempty"` // For "set_speed"
+	Level           *int                 `json:"level,omitempty"`           // For "pause_level", "resume_level" and "manual_compaction" (omitted = whole tree)
+	VirtualTime     *float64             `json:"virtualTime,omitempty"`     // For "set_breakpoint" (0 clears the breakpoint)
+	Scenario        json.RawMessage      `json:"scenario,omitempty"`        // For "load_scenario" (parsed by simulator.LoadScenario)
 }
 
 // Server message types
@@ -39,6 +105,14 @@ type ServerMessage struct {
 	State   map[string]interface{} `json:"state,omitempty"`
 	Error   *string                `json:"error,omitempty"` // Validation or runtime errors
 	Log     *string                `json:"log,omitempty"`   // Event log message
+
+	TimeBreakdown    map[string]float64 `json:"timeBreakdown,omitempty"`    // Where virtual time went (response to "time_breakdown")
+	RecommendedStyle *string            `json:"recommendedStyle,omitempty"` // Suggested compaction style (response to "recommend_style")
+	Rationale        *string            `json:"rationale,omitempty"`        // Why RecommendedStyle was suggested
+	Violations       []string           `json:"violations,omitempty"`       // Internal consistency violations (response to "selfcheck"; empty = healthy)
+
+	CompactionHistory []simulator.CompactionRecord `json:"compactionHistory,omitempty"` // Completed compactions, oldest first (response to "compaction_history")
+	Preview           *simulator.CompactionPreview `json:"preview,omitempty"`           // Compaction the compactor would pick next (response to "preview_compaction"; absent = none due)
 }
 
 // simState manages the simulation state and UI pacing
@@ -48,7 +122,48 @@ type simState struct {
 	paused  bool
 	mu      sync.Mutex
 	stopCh  chan struct{}
+	stopped sync.Once   // stop closes stopCh once, from the shutdown path or on disconnect
 	logCh   chan string // Buffered channel for log events
+
+	breakpoint float64 // Virtual time to pause at (0 = none; guarded by mu, cleared once hit)
+
+	droppedLogs atomic.Int64 // Log events dropped because logCh was full (reported and reset by logForwardLoop)
+}
+
+// simRegistry tracks the simulations of connected clients, so HTTP endpoints can read them
+// without a WebSocket. Each connection registers its simState for as long as it is open.
+type simRegistry struct {
+	mu     sync.Mutex
+	states []*simState // In connection order
+}
+
+var activeSims = &simRegistry{}
+
+func (r *simRegistry) add(state *simState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, state)
+}
+
+func (r *simRegistry) remove(state *simState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.states {
+		if s == state {
+			r.states = append(r.states[:i], r.states[i+1:]...)
+			return
+		}
+	}
+}
+
+// latest returns the most recently connected client's simulation and how many are active (nil, 0 = none)
+func (r *simRegistry) latest() (*simState, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.states) == 0 {
+		return nil, 0
+	}
+	return r.states[len(r.states)-1], len(r.states)
 }
 
 func newSimState(config simulator.SimConfig) (*simState, error) {
@@ -60,23 +175,27 @@ func newSimState(config simulator.SimConfig) (*simState, error) {
 	// Create log channel with reasonable buffer (don't block simulation)
 	logCh := make(chan string, 100)
 
+	state := &simState{
+		sim:     sim,
+		running: false,
+		paused:  false,
+		stopCh:  make(chan struct{}),
+		logCh:   logCh,
+	}
+
 	// Set up log event callback
 	sim.LogEvent = func(msg string) {
 		select {
 		case logCh <- msg:
 			// Sent successfully
 		default:
-			// Buffer full, drop message (don't block simulation)
+			// Buffer full, drop message (don't block simulation) but count it so the
+			// log stream can say how much is missing
+			state.droppedLogs.Add(1)
 		}
 	}
 
-	return &simState{
-		sim:     sim,
-		running: false,
-		paused:  false,
-		stopCh:  make(chan struct{}),
-		logCh:   logCh,
-	}, nil
+	return state, nil
 }
 
 // start begins the simulation
@@ -125,6 +244,61 @@ func (s *simState) updateConfig(config simulator.SimConfig) error {
 	return s.sim.UpdateConfig(config)
 }
 
+// setSpeed changes the simulation speed multiplier without a full config update
+func (s *simState) setSpeed(multiplier int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.SetSpeedMultiplier(multiplier)
+}
+
+// loadScenario replaces the simulator with one running the scenario, reset and ready to start,
+// with a breakpoint at the scenario's duration so it pauses when the scenario ends
+func (s *simState) loadScenario(scenario *simulator.Scenario) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sim, err := simulator.NewScenarioSimulator(scenario)
+	if err != nil {
+		return err
+	}
+	sim.LogEvent = s.sim.LogEvent
+	if err := sim.Reset(); err != nil {
+		return fmt.Errorf("failed to reset simulation: %w", err)
+	}
+	s.sim = sim
+	s.running = false
+	s.paused = false
+	s.breakpoint = scenario.DurationSeconds
+	return nil
+}
+
+// setBreakpoint makes the simulation pause once virtual time reaches t (0 clears the breakpoint)
+func (s *simState) setBreakpoint(t float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t < 0 {
+		return fmt.Errorf("breakpoint must be >= 0 (0 clears it)")
+	}
+	if t > 0 && t <= s.sim.VirtualTime() {
+		return fmt.Errorf("breakpoint t=%.3fs is not after the current virtual time t=%.3fs", t, s.sim.VirtualTime())
+	}
+	s.breakpoint = t
+	return nil
+}
+
+// checkBreakpoint pauses the simulation and clears the breakpoint if virtual time has reached it.
+// step stops at the breakpoint (see Simulator.StepToward), so the pause lands exactly on it.
+func (s *simState) checkBreakpoint() (hit bool, breakpoint, virtualTime float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.breakpoint <= 0 || s.sim.VirtualTime() < s.breakpoint {
+		return false, 0, 0
+	}
+	breakpoint = s.breakpoint
+	s.breakpoint = 0
+	s.paused = true
+	return true, breakpoint, s.sim.VirtualTime()
+}
+
 // isRunning returns true if simulation is running and not paused
 func (s *simState) isRunning() bool {
 	s.mu.Lock()
@@ -132,11 +306,14 @@ func (s *simState) isRunning() bool {
 	return s.running && !s.paused
 }
 
-// getConfig returns the current simulator configuration
+// getConfig returns the current simulator configuration, with randomSeed set to the seed the run
+// actually uses (so a randomSeed=0 run can be reproduced)
 func (s *simState) getConfig() simulator.SimConfig {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.sim.Config()
+	config := s.sim.Config()
+	config.RandomSeed = s.sim.Seed()
+	return config
 }
 
 // step advances simulation by one step (called by UI ticker)
@@ -164,7 +341,12 @@ func (s *simState) step() (errMsg string) {
 		return "Simulation OOM killed"
 	}
 
-	s.sim.Step()
+	// Stop exactly at the breakpoint rather than at the end of the step that crosses it
+	if s.breakpoint > 0 {
+		s.sim.StepToward(s.breakpoint)
+	} else {
+		s.sim.Step()
+	}
 
 	// Check if OOM occurred during this step
 	if s.sim.Metrics().IsOOMKilled {
@@ -190,6 +372,83 @@ func (s *simState) state() map[string]interface{} {
 	return s.sim.State()
 }
 
+func (s *simState) levelDetails() []simulator.LevelDetail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.LevelDetails()
+}
+
+func (s *simState) timeBreakdown() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.TimeBreakdown()
+}
+
+func (s *simState) recommendCompactionStyle() (simulator.CompactionStyle, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.RecommendCompactionStyle()
+}
+
+func (s *simState) resetMetrics() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sim.ResetMetrics()
+}
+
+func (s *simState) restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sim.Restart()
+}
+
+func (s *simState) setLevelPaused(level int, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if paused {
+		return s.sim.PauseLevelCompaction(level)
+	}
+	return s.sim.ResumeLevelCompaction(level)
+}
+
+func (s *simState) manualCompaction(level int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.RequestManualCompaction(level)
+}
+
+func (s *simState) selfCheck() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.SelfCheck()
+}
+
+func (s *simState) compactionHistory() []simulator.CompactionRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.CompactionHistory()
+}
+
+func (s *simState) peekCompaction() *simulator.CompactionPreview {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.PeekCompaction()
+}
+
+// metricsJSON encodes the current metrics and state. Encoding happens under the lock: Metrics
+// returns the simulator's live struct, which the simulation loop keeps updating.
+func (s *simState) metricsJSON(simulations int) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Marshal(struct {
+		Simulations int                    `json:"simulations"` // Active simulations (this is the most recently connected one)
+		Running     bool                   `json:"running"`
+		VirtualTime float64                `json:"virtualTime"`
+		Metrics     *simulator.Metrics     `json:"metrics"`
+		State       map[string]interface{} `json:"state"`
+	}{simulations, s.running && !s.paused, s.sim.VirtualTime(), s.sim.Metrics(), s.sim.State()})
+}
+
 // resetAggregateStats resets aggregate compaction stats after UI update
 func (s *simState) resetAggregateStats() {
 	s.mu.Lock()
@@ -199,7 +458,7 @@ func (s *simState) resetAggregateStats() {
 
 // stop signals the UI loop to stop
 func (s *simState) stop() {
-	close(s.stopCh)
+	s.stopped.Do(func() { close(s.stopCh) })
 }
 
 // logForwardLoop forwards log events from the simulator to the WebSocket
@@ -229,7 +488,10 @@ func logForwardLoop(conn *safeConn, state *simState) {
 			}
 
 		case <-ticker.C:
-			// Periodically flush batch
+			// Report drops since the last tick, then periodically flush batch
+			if dropped := state.droppedLogs.Swap(0); dropped > 0 {
+				batch = append(batch, fmt.Sprintf("[%d log messages dropped]", dropped))
+			}
 			if len(batch) > 0 {
 				sendLogBatch(conn, batch)
 				batch = batch[:0] // Reset slice, keep capacity
@@ -351,6 +613,51 @@ func uiUpdateLoop(conn *safeConn, state *simState) {
 
 				// Reset aggregate stats after UI update (for fast simulations)
 				state.resetAggregateStats()
+
+				// Pause at the breakpoint, after the UI has the state it stopped in
+				if hit, breakpoint, virtualTime := state.checkBreakpoint(); hit {
+					log.Printf("Breakpoint t=%.3fs hit at t=%.3fs - simulator paused", breakpoint, virtualTime)
+					sendLogBatch(conn, []string{fmt.Sprintf("[t=%.1fs] BREAKPOINT: paused at breakpoint t=%.3fs", virtualTime, breakpoint)})
+					running := false
+					config := state.getConfig()
+					statusMsg := ServerMessage{
+						Type:    "status",
+						Running: &running,
+						Config:  &config,
+					}
+					if err := conn.WriteJSON(statusMsg); err != nil {
+						log.Printf("Error sending breakpoint status: %v", err)
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// heartbeatLoop periodically sends a ping so idle connections aren't dropped by proxies.
+// Sends both an application-level "ping" message (visible to the UI) and a WebSocket
+// control ping (answered automatically by browsers with a pong).
+// This runs in its own goroutine
+func heartbeatLoop(conn *safeConn, state *simState) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-state.stopCh:
+			return
+
+		case <-ticker.C:
+			pingMsg := ServerMessage{Type: "ping"}
+			if err := conn.WriteJSON(pingMsg); err != nil {
+				log.Printf("Error sending ping: %v", err)
+				return
+			}
+			// WriteControl is safe to call concurrently with other writes
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				log.Printf("Error sending control ping: %v", err)
+				return
 			}
 		}
 	}
@@ -368,7 +675,34 @@ func (sc *safeConn) WriteJSON(v interface{}) error {
 	return sc.Conn.WriteJSON(v)
 }
 
+// closeForShutdown stops the connection's loops, sends a final status and a close frame, and
+// bounds how long the read loop waits for the client to answer it
+func closeForShutdown(conn *safeConn, state *simState) {
+	state.stop()
+	state.pause()
+	running := false
+	config := state.getConfig()
+	statusMsg := ServerMessage{
+		Type:    "status",
+		Running: &running,
+		Config:  &config,
+	}
+	if err := conn.WriteJSON(statusMsg); err != nil {
+		log.Printf("Error sending shutdown status: %v", err)
+	}
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	if err := conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(closeGracePeriod)); err != nil {
+		log.Printf("Error sending close frame: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(closeGracePeriod))
+}
+
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !trackWebSocket() {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer wsConns.Done()
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Error upgrading connection: %v", err)
@@ -389,8 +723,11 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Error creating simulator: %v", err)
 		return
 	}
+	activeSims.add(state)
+	defer activeSims.remove(state)
 
 	// Send initial status
+	// Defaults rather than getConfig(): a client adopting them keeps randomSeed=0 (a new seed per run)
 	running := false
 	statusMsg := ServerMessage{
 		Type:    "status",
@@ -408,10 +745,37 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Start log forwarding loop
 	go logForwardLoop(safeConn, state)
 
+	// Start heartbeat loop
+	go heartbeatLoop(safeConn, state)
+
+	// Say goodbye if the server shuts down while the client is connected
+	go func() {
+		select {
+		case <-state.stopCh:
+		case <-shutdown:
+			closeForShutdown(safeConn, state)
+		}
+	}()
+
+	// Any pong or message from the client proves the connection is alive
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	conn.SetPingHandler(func(appData string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(10*time.Second))
+	})
+
 	// Handle messages from client
 	for {
 		var msg ClientMessage
 		err := conn.ReadJSON(&msg)
+		if err != nil && shuttingDown() {
+			// The client answered the close frame, or didn't within closeGracePeriod
+			log.Printf("Client connection closed for shutdown")
+			break
+		}
 		if err != nil {
 			// Log all errors, not just unexpected close errors
 			// JSON unmarshaling errors (e.g., invalid enum values) are not close errors
@@ -429,6 +793,13 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		// Heartbeat replies are not commands - don't log them
+		if msg.Type == "pong" {
+			continue
+		}
+
 		log.Printf("Received command: %s", msg.Type)
 
 		switch msg.Type {
@@ -532,7 +903,219 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 						Config:  &updatedFullConfig,
 					}
 					safeConn.WriteJSON(statusMsg)
+
+					// Surface suspicious-but-valid settings in the log panel without blocking the update
+					if warnings, _ := msg.Config.ValidateWithWarnings(); len(warnings) > 0 {
+						for i, warning := range warnings {
+							warnings[i] = "⚠️ Config warning: " + warning
+						}
+						sendLogBatch(safeConn, warnings)
+					}
+				}
+			}
+
+		case "time_breakdown":
+			breakdownMsg := ServerMessage{
+				Type:          "time_breakdown",
+				TimeBreakdown: state.timeBreakdown(),
+			}
+			safeConn.WriteJSON(breakdownMsg)
+
+		case "recommend_style":
+			style, rationale := state.recommendCompactionStyle()
+			styleName := style.String()
+			recommendMsg := ServerMessage{
+				Type:             "recommend_style",
+				RecommendedStyle: &styleName,
+				Rationale:        &rationale,
+			}
+			safeConn.WriteJSON(recommendMsg)
+
+		case "reset_metrics":
+			// Start measuring fresh without rebuilding the LSM or clearing the event queue
+			state.resetMetrics()
+			log.Println("Metrics reset (LSM state kept)")
+			metricsMsg := ServerMessage{
+				Type:    "metrics",
+				Metrics: state.metrics(),
+			}
+			safeConn.WriteJSON(metricsMsg)
+
+		case "restart":
+			// Simulated process restart: the block cache goes (partly) cold, everything else is kept
+			state.restart()
+			log.Println("Simulated restart (block cache re-warming)")
+			metricsMsg := ServerMessage{
+				Type:    "metrics",
+				Metrics: state.metrics(),
+			}
+			safeConn.WriteJSON(metricsMsg)
+
+		case "selfcheck":
+			violations := state.selfCheck()
+			if len(violations) > 0 {
+				log.Printf("Self-check found %d violations", len(violations))
+			}
+			selfCheckMsg := ServerMessage{
+				Type:       "selfcheck",
+				Violations: violations,
+			}
+			safeConn.WriteJSON(selfCheckMsg)
+
+		case "compaction_history":
+			historyMsg := ServerMessage{
+				Type:              "compaction_history",
+				CompactionHistory: state.compactionHistory(),
+			}
+			safeConn.WriteJSON(historyMsg)
+
+		case "preview_compaction":
+			// Dry run: what the compactor would pick next, without scheduling it or disturbing the run
+			previewMsg := ServerMessage{
+				Type:    "preview",
+				Preview: state.peekCompaction(),
+			}
+			safeConn.WriteJSON(previewMsg)
+
+		case "pause_level", "resume_level":
+			// Freeze or unfreeze compaction out of one level; the rest of the tree keeps compacting
+			paused := msg.Type == "pause_level"
+			var err error
+			if msg.Level == nil {
+				err = fmt.Errorf("%s requires level", msg.Type)
+			} else {
+				err = state.setLevelPaused(*msg.Level, paused)
+			}
+			if err != nil {
+				log.Printf("Error in %s: %v", msg.Type, err)
+				errStr := err.Error()
+				errorMsg := ServerMessage{
+					Type:  "error",
+					Error: &errStr,
+				}
+				safeConn.WriteJSON(errorMsg)
+			} else {
+				log.Printf("L%d compaction paused=%v", *msg.Level, paused)
+				stateMsg := ServerMessage{
+					Type:  "state",
+					State: state.state(),
+				}
+				safeConn.WriteJSON(stateMsg)
+			}
+
+		case "manual_compaction":
+			// Compact one level (or the whole tree) at the next compaction check, score or no score
+			level := -1
+			if msg.Level != nil {
+				level = *msg.Level
+			}
+			if err := state.manualCompaction(level); err != nil {
+				log.Printf("Error requesting manual compaction: %v", err)
+				errStr := err.Error()
+				errorMsg := ServerMessage{
+					Type:  "error",
+					Error: &errStr,
+				}
+				safeConn.WriteJSON(errorMsg)
+			} else {
+				log.Printf("Manual compaction requested (level %d)", level)
+				stateMsg := ServerMessage{
+					Type:  "state",
+					State: state.state(),
+				}
+				safeConn.WriteJSON(stateMsg)
+			}
+
+		case "set_breakpoint":
+			// Pause automatically once virtual time reaches the breakpoint (see checkBreakpoint)
+			var err error
+			if msg.VirtualTime == nil {
+				err = fmt.Errorf("set_breakpoint requires virtualTime")
+			} else {
+				err = state.setBreakpoint(*msg.VirtualTime)
+			}
+			if err != nil {
+				log.Printf("Error setting breakpoint: %v", err)
+				errStr := err.Error()
+				errorMsg := ServerMessage{
+					Type:  "error",
+					Error: &errStr,
+				}
+				safeConn.WriteJSON(errorMsg)
+			} else if *msg.VirtualTime == 0 {
+				log.Println("Breakpoint cleared")
+			} else {
+				log.Printf("Breakpoint set at t=%.3fs", *msg.VirtualTime)
+			}
+
+		case "load_scenario":
+			// Swap in a fresh simulator for the scenario; the client starts it like after a reset
+			var scenario *simulator.Scenario
+			var err error
+			if len(msg.Scenario) == 0 {
+				err = fmt.Errorf("load_scenario requires scenario")
+			} else if scenario, err = simulator.LoadScenario(bytes.NewReader(msg.Scenario)); err == nil {
+				err = state.loadScenario(scenario)
+			}
+			if err != nil {
+				log.Printf("Error loading scenario: %v", err)
+				errStr := err.Error()
+				errorMsg := ServerMessage{
+					Type:  "error",
+					Error: &errStr,
+				}
+				safeConn.WriteJSON(errorMsg)
+			} else {
+				log.Printf("Scenario %q loaded (%.0fs, %d timeline changes)", scenario.Name, scenario.DurationSeconds, len(scenario.Timeline))
+				metricsMsg := ServerMessage{
+					Type:    "metrics",
+					Metrics: state.metrics(),
+				}
+				safeConn.WriteJSON(metricsMsg)
+
+				stateMsg := ServerMessage{
+					Type:  "state",
+					State: state.state(),
+				}
+				safeConn.WriteJSON(stateMsg)
+
+				// Send status last
+				running := false
+				cfg := state.getConfig()
+				statusMsg := ServerMessage{
+					Type:    "status",
+					Running: &running,
+					Config:  &cfg,
+				}
+				safeConn.WriteJSON(statusMsg)
+			}
+
+		case "set_speed":
+			// Playback speed only: skip config_update's validation, reset check, and event rescheduling
+			var err error
+			if msg.SpeedMultiplier == nil {
+				err = fmt.Errorf("set_speed requires speedMultiplier")
+			} else {
+				err = state.setSpeed(*msg.SpeedMultiplier)
+			}
+			if err != nil {
+				log.Printf("Error setting speed: %v", err)
+				errStr := err.Error()
+				errorMsg := ServerMessage{
+					Type:  "error",
+					Error: &errStr,
 				}
+				safeConn.WriteJSON(errorMsg)
+			} else {
+				log.Printf("Simulation speed set to %dx", *msg.SpeedMultiplier)
+				running := state.isRunning()
+				cfg := state.getConfig()
+				statusMsg := ServerMessage{
+					Type:    "status",
+					Running: &running,
+					Config:  &cfg,
+				}
+				safeConn.WriteJSON(statusMsg)
 			}
 
 		case "reset_config":
@@ -586,21 +1169,85 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, filepath.Join("web", "dist", "index.html"))
 }
 
+// wantsJSONMetrics reports whether a /metrics request asks for JSON (?format=json or an Accept
+// header naming application/json) rather than the Prometheus exposition format scrapers get
+func wantsJSONMetrics(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// metricsJSONHandler serves the metrics and state of the most recently connected client's
+// simulation as JSON, for polling from scripts and dashboards without a browser
+func metricsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	state, simulations := activeSims.latest()
+	if state == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no active simulation (connect a client first)"})
+		return
+	}
+	data, err := state.metricsJSON(simulations)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encode metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Write(data)
+}
+
 func quitHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("🛑 Shutdown requested via /quitquitquit")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, "Server shutting down...")
+	requestShutdown()
+}
+
+// serve runs srv until it fails, ctx is done or requestShutdown is called, then shuts it down
+// gracefully: the listener closes, requests in flight finish and WebSocket clients are closed
+// (see closeForShutdown), all within shutdownTimeout
+func serve(ctx context.Context, srv *http.Server) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+		log.Println("🛑 Shutdown requested by signal")
+	case <-shutdown:
+	}
+	requestShutdown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down HTTP server: %w", err)
+	}
 
+	// Shutdown doesn't track hijacked connections, so wait for the WebSocket handlers separately
+	drained := make(chan struct{})
 	go func() {
-		time.Sleep(100 * time.Millisecond)
-		log.Println("👋 Server stopped")
-		os.Exit(0)
+		wsConns.Wait()
+		close(drained)
 	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		return fmt.Errorf("WebSocket clients still connected after %s", shutdownTimeout)
+	}
+	return nil
 }
 
 func main() {
+	openMetrics := flag.Bool("openmetrics", false, "Serve /metrics in OpenMetrics format to scrapers that request it (Prometheus text format otherwise)")
+	flag.Parse()
+
 	// Initialize Prometheus metrics
 	initPrometheusMetrics()
+	metricsHandler := promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: *openMetrics,
+		}))
 
 	// Serve static files from web/dist (React build output)
 	distDir := filepath.Join("web", "dist")
@@ -623,9 +1270,18 @@ func main() {
 			quitHandler(w, r)
 			return
 		}
-		// Prometheus metrics endpoint
+		// Metrics endpoint: Prometheus exposition format, or JSON when asked for
 		if r.URL.Path == "/metrics" {
-			promhttp.Handler().ServeHTTP(w, r)
+			if wantsJSONMetrics(r) {
+				metricsJSONHandler(w, r)
+				return
+			}
+			metricsHandler.ServeHTTP(w, r)
+			return
+		}
+		// Prometheus exposition format only, for scrapers that can't send Accept headers
+		if r.URL.Path == "/metrics/prometheus" {
+			metricsHandler.ServeHTTP(w, r)
 			return
 		}
 		// Static files (favicon, assets, etc.) - serve if file exists
@@ -644,7 +1300,14 @@ func main() {
 	log.Printf("🚀 Server starting on http://localhost%s", addr)
 	log.Printf("📁 Serving React app from: %s", distDir)
 	log.Printf("📡 WebSocket endpoint: ws://localhost%s/ws", addr)
+	log.Printf("📊 Metrics: http://localhost%s/metrics (JSON: /metrics?format=json, Prometheus only: /metrics/prometheus)", addr)
 	log.Printf("🛑 Shutdown endpoint: http://localhost%s/quitquitquit", addr)
 	log.Printf("🎨 Favicon: http://localhost%s/vite.svg", addr)
-	log.Fatal(http.ListenAndServe(addr, nil))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := serve(ctx, &http.Server{Addr: addr}); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("👋 Server stopped")
 }
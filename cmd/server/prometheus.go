@@ -1,6 +1,9 @@
 package main
 
 import (
+	"strconv"
+	"sync"
+
 	"github.com/miretskiy/rollingstone/simulator"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -52,6 +55,121 @@ var (
 	}
 )
 
+// Per-level gauges, labeled by level number
+var (
+	levelSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lsm_level_size_bytes",
+		Help: "Bytes stored in each LSM level",
+	}, []string{"level"})
+	levelFileCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lsm_level_file_count",
+		Help: "SST files in each LSM level",
+	}, []string{"level"})
+	levelTargetBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lsm_level_target_size_bytes",
+		Help: "Target size of each LSM level",
+	}, []string{"level"})
+)
+
+const bytesPerMB = 1024 * 1024
+
+// counterCollector exposes the simulator's cumulative totals as _total counters. Values are
+// snapshotted on each UI update and rendered at scrape time as const metrics, because a reset
+// restarts the simulation's totals, which a registered prometheus.Counter cannot express
+// (Prometheus treats the drop as a counter reset, as it would for a restarted process).
+type counterCollector struct {
+	mu     sync.Mutex
+	values []float64 // Indexed like counterDescs
+}
+
+var counterDescs = []*prometheus.Desc{
+	prometheus.NewDesc("lsm_compactions_total", "Compactions completed", nil, nil),
+	prometheus.NewDesc("lsm_compaction_read_bytes_total", "Bytes read by compactions (excludes trivial moves)", nil, nil),
+	prometheus.NewDesc("lsm_compaction_written_bytes_total", "Bytes written by compactions", nil, nil),
+	prometheus.NewDesc("lsm_user_written_bytes_total", "Bytes written by the user workload", nil, nil),
+	prometheus.NewDesc("lsm_wal_written_bytes_total", "Bytes written to the WAL", nil, nil),
+	prometheus.NewDesc("lsm_write_stall_seconds_total", "Virtual seconds writes spent stalled", nil, nil),
+}
+
+var simCounters = &counterCollector{}
+
+func (c *counterCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range counterDescs {
+		ch <- desc
+	}
+}
+
+func (c *counterCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, value := range c.values {
+		ch <- prometheus.MustNewConstMetric(counterDescs[i], prometheus.CounterValue, value)
+	}
+}
+
+func (c *counterCollector) update(metrics *simulator.Metrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = []float64{
+		float64(metrics.TotalCompactionsCompleted),
+		metrics.CompactionReadMB * bytesPerMB,
+		metrics.CompactionWrittenMB * bytesPerMB,
+		metrics.TotalDataWrittenMB * bytesPerMB,
+		metrics.WALBytesWritten * bytesPerMB,
+		metrics.StallDurationSeconds,
+	}
+}
+
+// liveCollector exposes the most recently connected client's simulation as rollingstone_* series,
+// read at scrape time rather than from the UI loop's last update. It emits nothing while no
+// client is connected.
+type liveCollector struct{}
+
+var (
+	liveRunningDesc        = prometheus.NewDesc("rollingstone_running", "Whether the simulation is running (1) or paused/stopped (0)", nil, nil)
+	liveVirtualTimeDesc    = prometheus.NewDesc("rollingstone_virtual_time_seconds", "Simulated time elapsed", nil, nil)
+	liveWriteAmpDesc       = prometheus.NewDesc("rollingstone_write_amplification", "Bytes written to disk per byte flushed", nil, nil)
+	liveSpaceAmpDesc       = prometheus.NewDesc("rollingstone_space_amplification", "Bytes on disk per live byte", nil, nil)
+	liveDiskUtilDesc       = prometheus.NewDesc("rollingstone_disk_utilization_percent", "Percentage of disk bandwidth in use", nil, nil)
+	liveCompactionDebtDesc = prometheus.NewDesc("rollingstone_compaction_debt_mb", "MB by which levels exceed their targets", nil, nil)
+	liveStallsDesc         = prometheus.NewDesc("rollingstone_write_stalls_total", "Write stalls completed", nil, nil)
+	liveLevelFilesDesc     = prometheus.NewDesc("rollingstone_level_files", "SST files in each LSM level", []string{"level"}, nil)
+	liveDescs              = []*prometheus.Desc{liveRunningDesc, liveVirtualTimeDesc, liveWriteAmpDesc, liveSpaceAmpDesc, liveDiskUtilDesc, liveCompactionDebtDesc, liveStallsDesc, liveLevelFilesDesc}
+)
+
+func (liveCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range liveDescs {
+		ch <- desc
+	}
+}
+
+// Collect reads the simulation under its lock: Metrics returns the simulator's live struct,
+// which the simulation loop keeps updating
+func (liveCollector) Collect(ch chan<- prometheus.Metric) {
+	state, _ := activeSims.latest()
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	running := 0.0
+	if state.running && !state.paused {
+		running = 1
+	}
+	metrics := state.sim.Metrics()
+	ch <- prometheus.MustNewConstMetric(liveRunningDesc, prometheus.GaugeValue, running)
+	ch <- prometheus.MustNewConstMetric(liveVirtualTimeDesc, prometheus.GaugeValue, state.sim.VirtualTime())
+	ch <- prometheus.MustNewConstMetric(liveWriteAmpDesc, prometheus.GaugeValue, metrics.WriteAmplification)
+	ch <- prometheus.MustNewConstMetric(liveSpaceAmpDesc, prometheus.GaugeValue, metrics.SpaceAmplification)
+	ch <- prometheus.MustNewConstMetric(liveDiskUtilDesc, prometheus.GaugeValue, metrics.DiskUtilizationPercent)
+	ch <- prometheus.MustNewConstMetric(liveCompactionDebtDesc, prometheus.GaugeValue, metrics.CompactionDebtMB)
+	ch <- prometheus.MustNewConstMetric(liveStallsDesc, prometheus.CounterValue, float64(metrics.StallCount))
+	for _, level := range state.sim.LevelDetails() {
+		ch <- prometheus.MustNewConstMetric(liveLevelFilesDesc, prometheus.GaugeValue, float64(level.FileCount), strconv.Itoa(level.Level))
+	}
+}
+
 func initPrometheusMetrics() {
 	prometheus.MustRegister(
 		promMetrics.writeAmp,
@@ -62,6 +180,11 @@ func initPrometheusMetrics() {
 		promMetrics.diskUtil,
 		promMetrics.writeThroughput,
 		promMetrics.readThroughput,
+		levelSizeBytes,
+		levelFileCount,
+		levelTargetBytes,
+		simCounters,
+		liveCollector{},
 	)
 }
 
@@ -69,19 +192,23 @@ func updatePrometheusMetrics(metrics *simulator.Metrics, state *simState) {
 	promMetrics.writeAmp.Set(metrics.WriteAmplification)
 	promMetrics.readAmp.Set(metrics.ReadAmplification)
 
-	// Get L0 count from state
-	lsmState := state.state()
-	if levels, ok := lsmState["levels"].([]interface{}); ok && len(levels) > 0 {
-		if l0, ok := levels[0].(map[string]interface{}); ok {
-			if count, ok := l0["fileCount"].(int); ok {
-				promMetrics.l0Files.Set(float64(count))
-			}
-		}
+	var totalSizeMB float64
+	levels := state.levelDetails()
+	levelSizeBytes.Reset() // Drop levels that no longer exist after a numLevels change
+	levelFileCount.Reset()
+	levelTargetBytes.Reset()
+	for _, level := range levels {
+		label := strconv.Itoa(level.Level)
+		levelSizeBytes.WithLabelValues(label).Set(level.SizeMB * bytesPerMB)
+		levelFileCount.WithLabelValues(label).Set(float64(level.FileCount))
+		levelTargetBytes.WithLabelValues(label).Set(level.TargetSizeMB * bytesPerMB)
+		totalSizeMB += level.SizeMB
 	}
-
-	if totalSize, ok := lsmState["totalSizeMB"].(float64); ok {
-		promMetrics.totalSizeMB.Set(totalSize)
+	if len(levels) > 0 {
+		promMetrics.l0Files.Set(float64(levels[0].FileCount))
 	}
+	promMetrics.totalSizeMB.Set(totalSizeMB)
+	simCounters.update(metrics)
 
 	if metrics.IsStalled {
 		promMetrics.isStalled.Set(1.0)
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/miretskiy/rollingstone/simulator"
+)
+
+// metricDiff compares one metric between the config and the baseline (lower is better)
+type metricDiff struct {
+	Config   float64 `json:"config"`
+	Baseline float64 `json:"baseline"`
+	Delta    float64 `json:"delta"`  // config - baseline
+	Winner   string  `json:"winner"` // "config", "baseline" or "tie"
+}
+
+// levelDiff compares one level's final shape between the config and the baseline
+type levelDiff struct {
+	Level          int     `json:"level"`
+	ConfigSizeMB   float64 `json:"configSizeMB"`
+	BaselineSizeMB float64 `json:"baselineSizeMB"`
+	DeltaSizeMB    float64 `json:"deltaSizeMB"`
+	ConfigFiles    int     `json:"configFiles"`
+	BaselineFiles  int     `json:"baselineFiles"`
+	DeltaFiles     int     `json:"deltaFiles"`
+}
+
+// runDiff is the output of an A/B run (-baseline)
+type runDiff struct {
+	Config      string                `json:"config"`      // Config file
+	Baseline    string                `json:"baseline"`    // Baseline config file
+	Seed        int64                 `json:"seed"`        // Seed both runs used
+	VirtualTime float64               `json:"virtualTime"` // Virtual seconds the config run reached
+	Metrics     map[string]metricDiff `json:"metrics"`     // Keyed by Metrics JSON name (compactionBytesMB = read + written)
+	OOMKilled   struct {
+		Config   bool   `json:"config"`
+		Baseline bool   `json:"baseline"`
+		Winner   string `json:"winner"`
+	} `json:"oomKilled"`
+	Levels []levelDiff `json:"levels"` // Final level distribution
+}
+
+// comparedMetrics are the metrics an A/B run diffs, in summary order
+var comparedMetrics = []struct {
+	name  string
+	value func(*simulator.Metrics) float64
+}{
+	{"writeAmplification", func(m *simulator.Metrics) float64 { return m.WriteAmplification }},
+	{"spaceAmplification", func(m *simulator.Metrics) float64 { return m.SpaceAmplification }},
+	{"compactionBytesMB", func(m *simulator.Metrics) float64 { return m.CompactionReadMB + m.CompactionWrittenMB }},
+}
+
+// runComparison simulates configPath and then baselinePath for the same duration and seed (the
+// config's randomSeed, or the seed drawn for it), and diffs the results. Scenarios carry their own
+// durations, so two scenarios are compared only if their durations match.
+func runComparison(configPath, baselinePath string, opts runOptions) (*runDiff, int, error) {
+	if opts.scenario {
+		configDuration, err := scenarioDuration(configPath)
+		if err != nil {
+			return nil, 0, err
+		}
+		baselineDuration, err := scenarioDuration(baselinePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		if configDuration != baselineDuration {
+			return nil, 0, fmt.Errorf("scenario durations differ: %s runs %gs, %s runs %gs",
+				filepath.Base(configPath), configDuration, filepath.Base(baselinePath), baselineDuration)
+		}
+	}
+	config := runConfigFile(configPath, opts, "config")
+	if config.err != nil {
+		return nil, 0, config.err
+	}
+	opts.seed = config.results["seed"].(int64)
+	baseline := runConfigFile(baselinePath, opts, "baseline")
+	if baseline.err != nil {
+		return nil, 0, baseline.err
+	}
+
+	diff := &runDiff{
+		Config:      filepath.Base(configPath),
+		Baseline:    filepath.Base(baselinePath),
+		Seed:        opts.seed,
+		VirtualTime: config.results["virtualTime"].(float64),
+		Metrics:     make(map[string]metricDiff, len(comparedMetrics)),
+	}
+	configMetrics := config.results["metrics"].(*simulator.Metrics)
+	baselineMetrics := baseline.results["metrics"].(*simulator.Metrics)
+	for _, metric := range comparedMetrics {
+		c, b := metric.value(configMetrics), metric.value(baselineMetrics)
+		diff.Metrics[metric.name] = metricDiff{Config: c, Baseline: b, Delta: c - b, Winner: winner(c < b, b < c)}
+	}
+	diff.OOMKilled.Config = configMetrics.IsOOMKilled
+	diff.OOMKilled.Baseline = baselineMetrics.IsOOMKilled
+	diff.OOMKilled.Winner = winner(!configMetrics.IsOOMKilled && baselineMetrics.IsOOMKilled,
+		configMetrics.IsOOMKilled && !baselineMetrics.IsOOMKilled)
+
+	// The configs may have different numbers of levels; a missing level is empty
+	configLevels := config.results["lsm"].(simulator.SimState).Levels
+	baselineLevels := baseline.results["lsm"].(simulator.SimState).Levels
+	for i := 0; i < max(len(configLevels), len(baselineLevels)); i++ {
+		d := levelDiff{Level: i}
+		if i < len(configLevels) {
+			d.ConfigSizeMB, d.ConfigFiles = configLevels[i].SizeMB, configLevels[i].FileCount
+		}
+		if i < len(baselineLevels) {
+			d.BaselineSizeMB, d.BaselineFiles = baselineLevels[i].SizeMB, baselineLevels[i].FileCount
+		}
+		d.DeltaSizeMB = d.ConfigSizeMB - d.BaselineSizeMB
+		d.DeltaFiles = d.ConfigFiles - d.BaselineFiles
+		diff.Levels = append(diff.Levels, d)
+	}
+	return diff, config.selfCheckFailures + baseline.selfCheckFailures, nil
+}
+
+// scenarioDuration returns the virtual seconds the scenario at path runs for
+func scenarioDuration(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("Error reading scenario file: %w", err)
+	}
+	scenario, err := simulator.LoadScenario(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing scenario %s: %w", filepath.Base(path), err)
+	}
+	return scenario.DurationSeconds, nil
+}
+
+// winner names the better side of a comparison
+func winner(configBetter, baselineBetter bool) string {
+	switch {
+	case configBetter:
+		return "config"
+	case baselineBetter:
+		return "baseline"
+	default:
+		return "tie"
+	}
+}
+
+// printComparison writes one line per compared metric to stderr, with the side that wins it
+func printComparison(diff *runDiff) {
+	fmt.Fprintf(os.Stderr, "Comparing %s (config) against %s (baseline), seed %d\n", diff.Config, diff.Baseline, diff.Seed)
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tCONFIG\tBASELINE\tDELTA\tWINNER")
+	for _, metric := range comparedMetrics {
+		d := diff.Metrics[metric.name]
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%+.2f\t%s\n", metric.name, d.Config, d.Baseline, d.Delta, d.Winner)
+	}
+	fmt.Fprintf(w, "oomKilled\t%v\t%v\t-\t%s\n", diff.OOMKilled.Config, diff.OOMKilled.Baseline, diff.OOMKilled.Winner)
+	w.Flush()
+}
@@ -1,116 +1,349 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/miretskiy/rollingstone/simulator"
 )
 
+// runOptions are the command-line settings shared by every simulation in a run or sweep
+type runOptions struct {
+	durationSec      int
+	speedMultiplier  int
+	verbose          bool
+	snapshotInterval float64
+	snapshotPrefix   string
+	selfCheck        bool
+	scenario         bool  // The file is a scenario (config, initial files, timeline, duration) rather than a config
+	seed             int64 // Overrides the config's randomSeed (0 = keep it; see runComparison)
+}
+
+// runResult is the outcome of simulating one config file
+type runResult struct {
+	file              string
+	results           map[string]interface{}
+	selfCheckFailures int
+	err               error
+}
+
 func main() {
 	// Parse command line flags
-	configFile := flag.String("config", "", "Path to JSON configuration file")
+	configPath := flag.String("config", "", "Path to JSON configuration file, or a directory of *.json configs to run as a sweep")
+	scenarioPath := flag.String("scenario", "", "Path to a JSON scenario (config, initial LSM files, timeline and duration); use instead of -config, overrides -duration")
 	durationSec := flag.Int("duration", 3600, "Simulation duration in virtual seconds")
 	outputFile := flag.String("output", "", "Path to output JSON file (optional, prints to stdout if not specified)")
 	speedMultiplier := flag.Int("speed", 100, "Simulation speed multiplier (each Step simulates N seconds)")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging from simulator")
+	snapshotInterval := flag.Float64("snapshot-interval", 0, "Write metrics+state JSON to a numbered file every N virtual seconds (0 = disabled)")
+	selfCheck := flag.Bool("selfcheck", false, "Run internal consistency checks after every step; exit non-zero if any fail")
+	parallel := flag.Int("parallel", 1, "Sweep mode: run up to N configs concurrently")
+	baselinePath := flag.String("baseline", "", "A/B mode: also run this config (or scenario) for the same duration and seed, and output the differences")
 	flag.Parse()
 
-	if *configFile == "" {
-		fmt.Fprintf(os.Stderr, "Usage: %s -config <config.json> [-duration <seconds>] [-output <output.json>] [-speed <multiplier>] [-verbose]\n", os.Args[0])
+	if (*configPath == "") == (*scenarioPath == "") {
+		fmt.Fprintf(os.Stderr, "Usage: %s -config <config.json|config-dir> | -scenario <scenario.json> [-duration <seconds>] [-output <output.json>] [-speed <multiplier>] [-snapshot-interval <seconds>] [-selfcheck] [-parallel <n>] [-baseline <config.json>] [-verbose]\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	// Read configuration from file
-	configData, err := os.ReadFile(*configFile)
+	opts := runOptions{
+		durationSec:      *durationSec,
+		speedMultiplier:  *speedMultiplier,
+		verbose:          *verbose,
+		snapshotInterval: *snapshotInterval,
+		snapshotPrefix:   snapshotFilePrefix(*outputFile),
+		selfCheck:        *selfCheck,
+		scenario:         *scenarioPath != "",
+	}
+	if opts.scenario {
+		*configPath = *scenarioPath
+	}
+
+	info, err := os.Stat(*configPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	var config simulator.SimConfig
-	if err := json.Unmarshal(configData, &config); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing config JSON: %v\n", err)
+	var output interface{}
+	failed := false
+	if info.IsDir() && *baselinePath != "" {
+		fmt.Fprintf(os.Stderr, "Error: -baseline compares two configs; it can't be used with a sweep directory\n")
 		os.Exit(1)
 	}
+	if *baselinePath != "" {
+		diff, selfCheckFailures, err := runComparison(*configPath, *baselinePath, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if selfCheckFailures > 0 {
+			fmt.Fprintf(os.Stderr, "Self-check found %d violations\n", selfCheckFailures)
+			failed = true
+		}
+		printComparison(diff)
+		output = diff
+	} else if info.IsDir() {
+		results, err := runSweep(*configPath, opts, *parallel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printSummary(results)
+		combined := make([]map[string]interface{}, 0, len(results))
+		for _, r := range results {
+			entry := map[string]interface{}{"file": r.file}
+			if r.err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", r.err)
+				entry["error"] = r.err.Error()
+				failed = true
+			}
+			for k, v := range r.results {
+				entry[k] = v
+			}
+			if r.selfCheckFailures > 0 {
+				fmt.Fprintf(os.Stderr, "%s: self-check found %d violations\n", r.file, r.selfCheckFailures)
+				failed = true
+			}
+			combined = append(combined, entry)
+		}
+		output = combined
+	} else {
+		r := runConfigFile(*configPath, opts, "")
+		if r.err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", r.err)
+			os.Exit(1)
+		}
+		if r.selfCheckFailures > 0 {
+			fmt.Fprintf(os.Stderr, "Self-check found %d violations\n", r.selfCheckFailures)
+			failed = true
+		}
+		output = r.results
+	}
+
+	// Output results
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling results: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *outputFile != "" {
+		if err := os.WriteFile(*outputFile, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Results written to %s\n", *outputFile)
+	} else {
+		fmt.Println(string(data))
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runSweep runs every *.json config in dir, up to parallel at a time, and returns the results
+// in filename order. Each config gets its own Simulator, so runs don't share state.
+func runSweep(dir string, opts runOptions, parallel int) ([]runResult, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list configs: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.json configs in %s", dir)
+	}
+	sort.Strings(paths)
+	parallel = max(1, parallel)
+	fmt.Fprintf(os.Stderr, "Sweeping %d configs from %s (%d at a time)\n", len(paths), dir, parallel)
+
+	results := make([]runResult, len(paths))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = runConfigFile(path, opts, filepath.Base(path))
+		}(i, path)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// runConfigFile loads and simulates one config file. In a sweep, name labels log lines and
+// snapshot files (empty for a single run).
+func runConfigFile(path string, opts runOptions, name string) runResult {
+	result := runResult{file: filepath.Base(path)}
+	logPrefix := ""
+	if name != "" {
+		logPrefix = "[" + name + "] "
+		opts.snapshotPrefix += "-" + strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	// Read configuration from file
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		result.err = fmt.Errorf("%sError reading config file: %w", logPrefix, err)
+		return result
+	}
+
+	var config simulator.SimConfig
+	var scenario *simulator.Scenario
+	targetTime := float64(opts.durationSec)
+	if opts.scenario {
+		scenario, err = simulator.LoadScenario(bytes.NewReader(configData))
+		if err != nil {
+			result.err = fmt.Errorf("%sError parsing scenario: %w", logPrefix, err)
+			return result
+		}
+		config = scenario.Config
+		targetTime = scenario.DurationSeconds
+	} else if err := json.Unmarshal(configData, &config); err != nil {
+		result.err = fmt.Errorf("%sError parsing config JSON: %w", logPrefix, err)
+		return result
+	}
+
+	if opts.seed != 0 {
+		config.RandomSeed = opts.seed
+	}
 
 	// Override SimulationSpeedMultiplier if specified via flag
-	if *speedMultiplier > 0 {
-		config.SimulationSpeedMultiplier = *speedMultiplier
-		fmt.Fprintf(os.Stderr, "Using speed multiplier: %d (each Step simulates %d seconds)\n", *speedMultiplier, *speedMultiplier)
+	if opts.speedMultiplier > 0 {
+		config.SimulationSpeedMultiplier = opts.speedMultiplier
+		fmt.Fprintf(os.Stderr, "%sUsing speed multiplier: %d (each Step simulates %d seconds)\n", logPrefix, opts.speedMultiplier, opts.speedMultiplier)
 	} else if config.SimulationSpeedMultiplier == 0 {
 		config.SimulationSpeedMultiplier = 100 // Default to 100x if not set
-		fmt.Fprintf(os.Stderr, "Using default speed multiplier: 100 (each Step simulates 100 seconds)\n")
+		fmt.Fprintf(os.Stderr, "%sUsing default speed multiplier: 100 (each Step simulates 100 seconds)\n", logPrefix)
 	}
 
 	// Validate configuration
 	if err := config.Validate(); err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
-		os.Exit(1)
+		result.err = fmt.Errorf("%sInvalid configuration: %w", logPrefix, err)
+		return result
 	}
 
 	// Create simulator
-	sim, err := simulator.NewSimulator(config)
+	var sim *simulator.Simulator
+	if scenario != nil {
+		scenario.Config = config // With the speed override
+		sim, err = simulator.NewScenarioSimulator(scenario)
+	} else {
+		sim, err = simulator.NewSimulator(config)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating simulator: %v\n", err)
-		os.Exit(1)
+		result.err = fmt.Errorf("%sError creating simulator: %w", logPrefix, err)
+		return result
 	}
 
 	// Set up LogEvent callback to capture simulator logs
-	if *verbose {
+	if opts.verbose {
 		sim.LogEvent = func(msg string) {
-			fmt.Fprintf(os.Stderr, "[SIM] %s\n", msg)
+			fmt.Fprintf(os.Stderr, "[SIM] %s%s\n", logPrefix, msg)
 		}
-		fmt.Fprintf(os.Stderr, "Verbose logging enabled\n")
+		fmt.Fprintf(os.Stderr, "%sVerbose logging enabled\n", logPrefix)
 	}
 
 	// Reset to initialize events
 	if err := sim.Reset(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error resetting simulator: %v\n", err)
-		os.Exit(1)
+		result.err = fmt.Errorf("%sError resetting simulator: %w", logPrefix, err)
+		return result
 	}
 
 	// Run simulation
-	fmt.Fprintf(os.Stderr, "Starting simulation for %d virtual seconds...\n", *durationSec)
+	fmt.Fprintf(os.Stderr, "%sStarting simulation for %.0f virtual seconds...\n", logPrefix, targetTime)
 	startTime := time.Now()
 
-	targetTime := float64(*durationSec)
+	nextSnapshotTime := opts.snapshotInterval
+	snapshotNum := 0
 	for sim.VirtualTime() < targetTime && !sim.IsQueueEmpty() {
 		sim.Step()
+
+		if opts.selfCheck {
+			for _, violation := range sim.SelfCheck() {
+				fmt.Fprintf(os.Stderr, "%s[SELFCHECK] t=%.1fs: %s\n", logPrefix, sim.VirtualTime(), violation)
+				result.selfCheckFailures++
+			}
+		}
+
+		// Periodic snapshot (a single Step may cross several intervals; write one snapshot)
+		if opts.snapshotInterval > 0 && sim.VirtualTime() >= nextSnapshotTime {
+			snapshotNum++
+			snapshotPath := fmt.Sprintf("%s-%04d.json", opts.snapshotPrefix, snapshotNum)
+			if err := writeSnapshot(snapshotPath, sim); err != nil {
+				result.err = fmt.Errorf("%sError writing snapshot: %w", logPrefix, err)
+				return result
+			}
+			fmt.Fprintf(os.Stderr, "%sSnapshot %d written to %s (t=%.1fs)\n", logPrefix, snapshotNum, snapshotPath, sim.VirtualTime())
+			for nextSnapshotTime <= sim.VirtualTime() {
+				nextSnapshotTime += opts.snapshotInterval
+			}
+		}
 	}
 
 	elapsed := time.Since(startTime)
-	fmt.Fprintf(os.Stderr, "Simulation completed in %v (%.1f virtual seconds)\n", elapsed, sim.VirtualTime())
+	fmt.Fprintf(os.Stderr, "%sSimulation completed in %v (%.1f virtual seconds)\n", logPrefix, elapsed, sim.VirtualTime())
 
 	// Gather results
-	metrics := sim.Metrics()
-	lsmState := sim.State()
+	result.results = map[string]interface{}{
+		"config":            config,
+		"seed":              sim.Seed(), // Pass as randomSeed to reproduce the run
+		"virtualTime":       sim.VirtualTime(),
+		"realTime":          elapsed.Seconds(),
+		"metrics":           sim.Metrics(),
+		"state":             sim.State(),
+		"lsm":               sim.StateSnapshot(), // Typed levels/memtables/compactions with a stable schema
+		"timeBreakdown":     sim.TimeBreakdown(),
+		"compactionHistory": sim.CompactionHistory(),
+	}
+	return result
+}
 
-	results := map[string]interface{}{
-		"config":      config,
-		"virtualTime": sim.VirtualTime(),
-		"realTime":    elapsed.Seconds(),
-		"metrics":     metrics,
-		"state":       lsmState,
+// printSummary writes one line per swept config to stderr: write amplification and OOM status
+func printSummary(results []runResult) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONFIG\tWRITE AMP\tOOM\tVIRTUAL TIME")
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(w, "%s\terror\t-\t-\n", r.file)
+			continue
+		}
+		metrics := r.results["metrics"].(*simulator.Metrics)
+		fmt.Fprintf(w, "%s\t%.2f\t%v\t%.0fs\n", r.file, metrics.WriteAmplification, metrics.IsOOMKilled, r.results["virtualTime"])
 	}
+	w.Flush()
+}
 
-	// Output results
-	output, err := json.MarshalIndent(results, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling results: %v\n", err)
-		os.Exit(1)
+// snapshotFilePrefix derives the snapshot file prefix from the output path
+// (results.json → results-snapshot), defaulting to "snapshot" in the working directory
+func snapshotFilePrefix(outputFile string) string {
+	if outputFile == "" {
+		return "snapshot"
 	}
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "-snapshot"
+}
 
-	if *outputFile != "" {
-		if err := os.WriteFile(*outputFile, output, 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Fprintf(os.Stderr, "Results written to %s\n", *outputFile)
-	} else {
-		fmt.Println(string(output))
+// writeSnapshot writes the current metrics and state as indented JSON
+func writeSnapshot(path string, sim *simulator.Simulator) error {
+	snapshot := map[string]interface{}{
+		"virtualTime": sim.VirtualTime(),
+		"metrics":     sim.Metrics(),
+		"state":       sim.State(),
+	}
+	output, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
 	}
+	return os.WriteFile(path, output, 0644)
 }
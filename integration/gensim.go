@@ -192,11 +192,8 @@ func (r *RocksDBModel) Name() string {
 // evaluateImmediateHealthLocked inspects current simulator state to determine instantaneous health.
 func (r *RocksDBModel) evaluateImmediateHealthLocked() (string, string) {
 	if r.sim.IsWriteStalled() {
-		state := r.sim.State()
-		if memtableSizeMB, ok := state["memtableCurrentSizeMB"].(float64); ok {
-			if memtableSizeMB > float64(r.cfg.MemtableFlushSizeMB)*2.0 {
-				return "error", "oom_killed"
-			}
+		if r.sim.StateSnapshot().ActiveMemtableMB > float64(r.cfg.MemtableFlushSizeMB)*2.0 {
+			return "error", "oom_killed"
 		}
 		return "warn", "stalled"
 	}
@@ -450,28 +447,14 @@ func (r *RocksDBModel) buildMetrics(afterMetrics, beforeMetrics *simulator.Metri
 	}
 
 	// Get LSM state to access size information
-	state := r.sim.State()
-	totalSizeMB := 0.0
-	memtableSizeMB := 0.0
-	if val, ok := state["totalSizeMB"].(float64); ok {
-		totalSizeMB = val
-	}
-	if val, ok := state["memtableCurrentSizeMB"].(float64); ok {
-		memtableSizeMB = val
-	}
+	state := r.sim.StateSnapshot()
+	totalSizeMB := state.TotalSizeMB
+	memtableSizeMB := state.ActiveMemtableMB
 
 	// Get per-level file counts from state
 	perLevelFileCounts := make(map[int]int)
-	if levels, ok := state["levels"].([]interface{}); ok {
-		for _, levelInterface := range levels {
-			if levelMap, ok := levelInterface.(map[string]interface{}); ok {
-				if levelNum, ok := levelMap["level"].(float64); ok {
-					if fileCount, ok := levelMap["fileCount"].(float64); ok {
-						perLevelFileCounts[int(levelNum)] = int(fileCount)
-					}
-				}
-			}
-		}
+	for _, level := range state.Levels {
+		perLevelFileCounts[level.Level] = level.FileCount
 	}
 
 	// Check for pending compactions (compactions that should happen but haven't started)
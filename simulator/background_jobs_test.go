@@ -334,3 +334,173 @@ func TestBackgroundJobs_RealWorldScenario(t *testing.T) {
 	t.Logf("SUCCESS: T=%.2fs, L0 files=%d, numImmutable=%d, diskBusyUntil=%.2fs",
 		sim.VirtualTime(), len(sim.lsm.Levels[0].Files), sim.numImmutableMemtables, sim.diskBusyUntil)
 }
+
+// TestBackgroundSlotUtilization tests per-slot busy fraction over the throughput window
+func TestBackgroundSlotUtilization(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxBackgroundJobs = 3
+	config.WriteRateMBps = 0
+	config.TrafficDistribution.WriteRateMBps = 0
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	// Slot 0 busy for the whole 5s window, slot 1 busy for 2.5s, slot 2 idle
+	sim.metrics.RecordSlotOccupancy(0, 0.0, 10.0)
+	sim.metrics.RecordSlotOccupancy(1, 5.0, 7.5)
+
+	sim.metrics.updateBackgroundSlotUtilization(10.0, config.MaxBackgroundJobs)
+
+	util := sim.metrics.BackgroundSlotUtilization
+	require.Len(t, util, 3)
+	require.InDelta(t, 1.0, util[0], 1e-9)
+	require.InDelta(t, 0.5, util[1], 1e-9)
+	require.InDelta(t, 0.0, util[2], 1e-9)
+
+	// Reservations that ended before the window are pruned
+	sim.metrics.updateBackgroundSlotUtilization(20.0, config.MaxBackgroundJobs)
+	require.Empty(t, sim.metrics.slotOccupancy)
+	require.Equal(t, []float64{0, 0, 0}, sim.metrics.BackgroundSlotUtilization)
+}
+
+// TestSubmitBackgroundTask_SharedPoolQueueing tests that flushes and compactions queue
+// for the same workers, with queue depth and per-type wait tracked
+func TestSubmitBackgroundTask_SharedPoolQueueing(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxBackgroundJobs = 1
+	config.WriteRateMBps = 0
+	config.TrafficDistribution.WriteRateMBps = 0
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	// Compaction occupies the only worker for 10s (5s CPU + 5s I/O)
+	start, done := sim.submitBackgroundTask(BackgroundTaskCompaction, 0.0, 5.0, 5.0)
+	require.Equal(t, 0.0, start)
+	require.Equal(t, 10.0, done)
+
+	// Flush arriving at t=2 queues behind it
+	start, done = sim.submitBackgroundTask(BackgroundTaskFlush, 2.0, 1.0, 1.0)
+	require.Equal(t, 10.0, start)
+	require.Equal(t, 12.0, done)
+
+	require.InDelta(t, 8.0, sim.metrics.AvgFlushQueueWaitSeconds, 1e-9)
+	require.InDelta(t, 0.0, sim.metrics.AvgCompactionQueueWaitSeconds, 1e-9)
+
+	sim.metrics.updateBackgroundQueueDepth(5.0)
+	require.Equal(t, 1, sim.metrics.BackgroundQueueDepth, "flush submitted but not started")
+	sim.metrics.updateBackgroundQueueDepth(11.0)
+	require.Equal(t, 0, sim.metrics.BackgroundQueueDepth)
+
+	// Disk time is attributed by task kind
+	breakdown := sim.TimeBreakdown()
+	require.InDelta(t, 5.0, breakdown["compaction"], 1e-9)
+	require.InDelta(t, 1.0, breakdown["flush"], 1e-9)
+}
+
+// TestMaxBackgroundFlushes tests that a dedicated flush pool lets flushes run concurrently
+// without waiting on compaction slots, which clears memtable stalls faster
+func TestMaxBackgroundFlushes(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxBackgroundJobs = 1
+	config.WriteRateMBps = 0
+	config.TrafficDistribution.WriteRateMBps = 0
+
+	// A compaction holds the only slot (and the disk) until T=1; two flushes arrive at T=0
+	submit := func(flushSlots int) (c1, c2 float64) {
+		config.MaxBackgroundFlushes = flushSlots
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		sim.submitBackgroundTask(BackgroundTaskCompaction, 0, 0, 1.0)
+		_, c1 = sim.submitBackgroundTask(BackgroundTaskFlush, 0, 0.85, 0.64)
+		_, c2 = sim.submitBackgroundTask(BackgroundTaskFlush, 0, 0.85, 0.64)
+		return c1, c2
+	}
+
+	// Shared pool: each flush waits for the slot (1.0 → 2.49 → 3.98)
+	c1, c2 := submit(0)
+	require.InDelta(t, 2.49, c1, 1e-9)
+	require.InDelta(t, 3.98, c2, 1e-9)
+
+	// Flush pool: both build SSTables at once and only their I/O queues on the disk
+	c1, c2 = submit(2)
+	require.InDelta(t, 1.64, c1, 1e-9, "I/O waits for the compaction's disk time, not its slot")
+	require.InDelta(t, 2.28, c2, 1e-9)
+
+	// End to end: CPU-bound flushes (64 MB at 10 MB/s = 6.4s each) can't keep up with the writes
+	// on one worker, so memtables stall; three flush workers keep up
+	run := func(flushSlots int) *Simulator {
+		config := DefaultConfig()
+		config.MaxBackgroundJobs = 1
+		config.MaxBackgroundFlushes = flushSlots
+		config.MaxWriteBufferNumber = 4
+		config.L0CompactionTrigger = 100 // Keep compactions out of the way
+		config.SSTableBuildThroughputMBps = 10
+		config.IOThroughputMBps = 500
+		config.WriteRateMBps = 40
+		config.TrafficDistribution.WriteRateMBps = 40
+		config.RandomSeed = 42
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(120)
+		return sim
+	}
+	shared := run(0)
+	dedicated := run(3)
+	require.Greater(t, shared.metrics.StallDurationSeconds, 0.0)
+	require.Zero(t, dedicated.metrics.StallDurationSeconds)
+	require.Greater(t, len(dedicated.lsm.Levels[0].Files), len(shared.lsm.Levels[0].Files))
+	require.Equal(t, 4, dedicated.metrics.MaxBackgroundJobs, "flush slots count toward the pool size")
+	require.Len(t, dedicated.metrics.BackgroundSlotUtilization, 4)
+
+	config.MaxBackgroundFlushes = -1
+	require.Error(t, config.Validate())
+}
+
+// TestCompactionQueueMetrics tests that levels waiting for a background job show up in
+// CompactionQueueDepth and AvgCompactionWaitSec, and that more background jobs shorten the wait
+func TestCompactionQueueMetrics(t *testing.T) {
+	run := func(jobs int) (maxDepth int, metrics *Metrics) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.MaxBackgroundJobs = jobs
+		config.InitialLSMSizeMB = 50000
+		config.WriteRateMBps = 100
+		config.RandomSeed = 7
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		for i := 0; i < 600; i++ {
+			sim.Step()
+			maxDepth = max(maxDepth, sim.metrics.CompactionQueueDepth)
+		}
+		return maxDepth, sim.metrics
+	}
+
+	// One job: a level needing compaction waits for the running compaction to finish
+	depth, starved := run(1)
+	require.Positive(t, depth)
+	require.Greater(t, starved.AvgCompactionWaitSec, 1.0)
+
+	_, plenty := run(8)
+	require.Less(t, plenty.AvgCompactionWaitSec, starved.AvgCompactionWaitSec)
+
+	// A single-level tree compacts within L0, so its backlog is still sampled
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleUniversal
+	config.NumLevels = 1
+	config.MaxBackgroundJobs = 1
+	config.WriteRateMBps = 200
+	config.RandomSeed = 7
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	maxDepth := 0
+	for i := 0; i < 600; i++ {
+		sim.Step()
+		maxDepth = max(maxDepth, sim.metrics.CompactionQueueDepth)
+	}
+	require.Positive(t, maxDepth)
+	require.Positive(t, sim.metrics.compactionWaitCount)
+}
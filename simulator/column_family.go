@@ -0,0 +1,188 @@
+package simulator
+
+// columnFamilyWriteIntervalSeconds is how often a column family's writes are delivered to its memtable
+const columnFamilyWriteIntervalSeconds = 1.0
+
+// columnFamily is one column family: its own memtable and LSM tree, compacted by its own
+// compactor under its own config. The compaction path takes the column family it works on
+// explicitly; the default column family is the simulator's lsm/compactor/config (see
+// Simulator.columnFamily).
+//
+// FIDELITY: RocksDB Reference - ColumnFamilyData (db/column_family.h)
+// https://github.com/facebook/rocksdb/wiki/Column-Families
+//
+// FIDELITY: ✓ Column families share the disk and max_background_jobs; each has its own memtable,
+// write_buffer_size, LSM shape and compaction style
+// FIDELITY: ✓ A column family with max_write_buffer_number immutable memtables stalls every
+// column family's writes (RocksDB's WriteController is DB-wide)
+// FIDELITY: ⚠️ SIMPLIFIED - No shared WAL for extra column families, and their writes arrive in
+// 1s batches
+type columnFamily struct {
+	id                     int // 0 = default column family, i = ColumnFamilies entry i-1
+	name                   string
+	config                 SimConfig // Top-level config with this column family's settings applied
+	lsm                    *LSMTree
+	compactor              Compactor
+	immutableMemtableSizes []float64 // Sizes (MB) of immutable memtables waiting to flush
+}
+
+// newColumnFamily builds the column family for ColumnFamilies entry index
+func newColumnFamily(cf ColumnFamilyConfig, base SimConfig, index int) (*columnFamily, error) {
+	config, err := cf.apply(base, index)
+	if err != nil {
+		return nil, err
+	}
+	return &columnFamily{
+		id:                     index + 1,
+		name:                   cf.Name,
+		config:                 config,
+		lsm:                    NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB)),
+		compactor:              newCompactor(config),
+		immutableMemtableSizes: make([]float64, 0),
+	}, nil
+}
+
+// columnFamily returns column family number cf (1 = first ColumnFamilies entry). cf 0 returns a
+// view of the default column family: the simulator's own lsm, compactor and config, read at the
+// time of the call.
+func (s *Simulator) columnFamily(cf int) *columnFamily {
+	if cf == 0 {
+		return &columnFamily{
+			name:                   "default",
+			config:                 s.config,
+			lsm:                    s.lsm,
+			compactor:              s.compactor,
+			immutableMemtableSizes: s.immutableMemtableSizes,
+		}
+	}
+	return s.columnFamilies[cf-1]
+}
+
+// scheduleCompaction schedules one compaction, trying the column families round-robin so none
+// starves the others of background jobs. With no extra column families this is
+// tryScheduleCompaction on the default column family.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB queues column families that need compaction in FIFO order
+// (DBImpl::compaction_queue_); round-robin gives the same fairness
+func (s *Simulator) scheduleCompaction() bool {
+	n := len(s.columnFamilies) + 1
+	for i := 0; i < n; i++ {
+		cf := (s.nextCompactionColumnFamily + i) % n
+		if s.tryScheduleCompaction(s.columnFamily(cf)) {
+			s.nextCompactionColumnFamily = (cf + 1) % n
+			return true
+		}
+	}
+	return false
+}
+
+// processColumnFamilyWrite adds one interval's writes to a column family's memtable, switching
+// it to an immutable memtable and scheduling a flush once it's full. While writes are stalled
+// (in any column family, see stalledColumnFamily) the interval's writes wait like the default
+// column family's, retrying when the stalled column family's next flush completes.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - A stalled interval is delayed, not queued: the column family's
+// write stream resumes from the stall's end without catching up on the missed intervals
+func (s *Simulator) processColumnFamilyWrite(event *ColumnFamilyWriteEvent) {
+	if cf := s.stalledColumnFamily(); cf >= 0 {
+		s.beginWriteStall(cf)
+		s.queue.Push(NewColumnFamilyWriteEvent(s.stalledWriteRetryTime(), event.ColumnFamily()))
+		return
+	}
+	s.endWriteStall()
+
+	family := s.columnFamily(event.ColumnFamily())
+	sizeMB := family.config.WriteRateMBps * columnFamilyWriteIntervalSeconds
+	family.lsm.AddWrite(sizeMB, s.virtualTime)
+	s.metrics.RecordColumnFamilyWrite(sizeMB)
+	s.metrics.TotalKeys += keysForSizeMB(sizeMB, family.config.AvgKeyValueSizeBytes)
+
+	if family.lsm.NeedsFlush() && len(family.immutableMemtableSizes) < family.config.MaxWriteBufferNumber {
+		frozenMB := family.lsm.MemtableCurrentSize
+		family.immutableMemtableSizes = append(family.immutableMemtableSizes, frozenMB)
+		family.lsm.MemtableCurrentSize = 0
+		family.lsm.MemtableCreatedAt = s.virtualTime
+		s.scheduleColumnFamilyFlush(event.ColumnFamily(), frozenMB)
+		s.updateNextFlushCompletionTime()
+	}
+
+	s.queue.Push(NewColumnFamilyWriteEvent(s.virtualTime+columnFamilyWriteIntervalSeconds, event.ColumnFamily()))
+}
+
+// scheduleColumnFamilyFlush submits a column family's frozen memtable to the background pool,
+// costed like a default column family flush
+func (s *Simulator) scheduleColumnFamilyFlush(cf int, sizeMB float64) {
+	config := s.columnFamily(cf).config
+	var cpuDuration float64
+	if config.SSTableBuildThroughputMBps > 0 {
+		cpuDuration = sizeMB / config.SSTableBuildThroughputMBps
+	}
+	outputSizeMB := sizeMB * config.CompressionFactorForLevel(0) * config.MetadataOverheadFactor()
+	ops := diskOps(1, outputSizeMB)
+	ioDuration := s.diskIOTime(outputSizeMB, ops) + (config.IOLatencyMs / 1000.0)
+
+	cpuStartTime, completionTime := s.submitBackgroundTask(BackgroundTaskFlush, s.virtualTime, cpuDuration, ioDuration)
+	s.metrics.RecordDiskOps(completionTime-ioDuration, completionTime, ops)
+	s.metrics.RecordDiskBytes(completionTime-ioDuration, completionTime, 0, outputSizeMB)
+	s.recordTierDiskTime(completionTime-ioDuration, completionTime, ioDuration, 0)
+	s.metrics.StartWrite(sizeMB, sizeMB, cpuStartTime, completionTime, -1, 0, cf)
+	s.queue.Push(NewColumnFamilyFlushEvent(completionTime, cpuStartTime, sizeMB, cf))
+}
+
+// processColumnFamilyFlush writes a column family's oldest immutable memtable to its L0
+func (s *Simulator) processColumnFamilyFlush(event *FlushEvent) {
+	family := s.columnFamily(event.ColumnFamily())
+	frozenSizeMB := event.SizeMB()
+	if frozenSizeMB == 0 {
+		return
+	}
+
+	file := family.lsm.CreateSSTFile(0, frozenSizeMB*family.config.MetadataOverheadFactor(), s.fileCreatedAt())
+	s.metrics.MetadataBytes += frozenSizeMB * (family.config.MetadataOverheadFactor() - 1)
+	if len(family.immutableMemtableSizes) > 0 {
+		family.immutableMemtableSizes = append([]float64(nil), family.immutableMemtableSizes[1:]...)
+	}
+
+	s.metrics.CompleteWrite(event.Timestamp(), -1, event.ColumnFamily()) // -1 = flush
+	s.metrics.RecordFlush(file.SizeMB, event.StartTime(), event.Timestamp())
+	s.updateNextFlushCompletionTime()
+}
+
+// scheduleColumnFamilyEvents (re)submits the column families' pending flushes and write streams
+// after the event queue has been cleared
+func (s *Simulator) scheduleColumnFamilyEvents() {
+	for i, family := range s.columnFamilies {
+		cf := i + 1
+		for _, sizeMB := range family.immutableMemtableSizes {
+			if sizeMB > 0 {
+				s.scheduleColumnFamilyFlush(cf, sizeMB)
+			}
+		}
+		if family.config.WriteRateMBps > 0 {
+			s.queue.Push(NewColumnFamilyWriteEvent(s.virtualTime+columnFamilyWriteIntervalSeconds, cf))
+		}
+	}
+}
+
+// columnFamilyStates returns each column family's levels and memtables for State: the default
+// column family first, then the ColumnFamilies entries
+func (s *Simulator) columnFamilyStates(defaultState map[string]interface{}) []map[string]interface{} {
+	states := []map[string]interface{}{{
+		"name":                  "default",
+		"writeRateMBps":         s.config.TrafficDistribution.WriteRateMBps,
+		"levels":                defaultState["levels"],
+		"totalSizeMB":           defaultState["totalSizeMB"],
+		"activeMemtableMB":      s.ActiveMemtableSizeMB(),
+		"numImmutableMemtables": s.numImmutableMemtables,
+	}}
+	for _, family := range s.columnFamilies {
+		state := family.lsm.State(s.virtualTime, family.config)
+		state["name"] = family.name
+		state["compactionStyle"] = family.config.CompactionStyle.String()
+		state["writeRateMBps"] = family.config.WriteRateMBps
+		state["activeMemtableMB"] = family.lsm.MemtableCurrentSize
+		state["numImmutableMemtables"] = len(family.immutableMemtableSizes)
+		states = append(states, state)
+	}
+	return states
+}
@@ -51,7 +51,7 @@ func TestOverlapThrottling_NoContentionAllowsCompaction(t *testing.T) {
 	sim.lsm.Levels[1].TargetCompactingFiles = 0
 
 	// Try to schedule a compaction
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 
 	if !scheduled {
 		t.Errorf("Expected compaction to be scheduled when target level has no contention")
@@ -126,7 +126,7 @@ func TestOverlapThrottling_HighContentionBlocksCompaction(t *testing.T) {
 	}
 
 	// Try to schedule - should fail due to contention
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 
 	// It might schedule a different level (like L1→L2), so we need to check
 	// if it scheduled L0→L1 specifically
@@ -192,7 +192,7 @@ func TestOverlapThrottling_ContentionTracking(t *testing.T) {
 	}
 
 	// Schedule a compaction
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 	if !scheduled {
 		t.Fatalf("Expected compaction to be scheduled")
 	}
@@ -278,7 +278,7 @@ func TestOverlapThrottling_MultipleCompactionsSameSource(t *testing.T) {
 	}
 
 	// Schedule first L1→L2 compaction
-	scheduled1 := sim.tryScheduleCompaction()
+	scheduled1 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	if !scheduled1 {
 		t.Fatalf("Expected first compaction to be scheduled")
 	}
@@ -290,7 +290,7 @@ func TestOverlapThrottling_MultipleCompactionsSameSource(t *testing.T) {
 	}
 
 	// Try to schedule another compaction - should be blocked because L1 is already active
-	scheduled2 := sim.tryScheduleCompaction()
+	scheduled2 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	// It should either not schedule anything, or schedule a different level
 	activeComps2 := sim.ActiveCompactions()
 	if scheduled2 && activeComps2 > 1 && len(sim.activeCompactionInfos) > 1 {
@@ -346,7 +346,7 @@ func TestOverlapThrottling_EmptyTargetLevel(t *testing.T) {
 	// L2 is empty
 
 	// Should allow compaction even though target is empty
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 
 	// Compaction might not be scheduled due to threshold (needs 2x for empty target)
 	// But if it is scheduled, it should succeed
@@ -1,5 +1,7 @@
 package simulator
 
+import "fmt"
+
 // Compactor interface for different compaction strategies
 type Compactor interface {
 	// NeedsCompaction checks if a level needs compaction
@@ -17,14 +19,100 @@ type Compactor interface {
 	ExecuteCompaction(job *CompactionJob, lsm *LSMTree, config SimConfig, virtualTime float64) (inputSize, outputSize float64, outputFileCount int)
 }
 
+// clockedCompactor is implemented by compactors whose picking depends on file age. The simulator
+// passes the time file ages are measured against before each PickCompaction.
+type clockedCompactor interface {
+	setVirtualTime(virtualTime float64)
+}
+
+// jobReleaser is implemented by compactors that track picked jobs until they execute. The
+// simulator calls releaseJob for a picked job it decides not to run, so the compactor forgets it
+// and can pick from the same levels again.
+type jobReleaser interface {
+	releaseJob(job *CompactionJob)
+}
+
+// compactionCheckpointer is implemented by compactors that keep bookkeeping between picks
+// (levels or files claimed by picked jobs, cursors, queued follow-ups). checkpoint saves it and
+// returns a function that puts it back, so a pick can be previewed without claiming anything.
+type compactionCheckpointer interface {
+	checkpoint() (restore func())
+}
+
+// quietPicker is implemented by compactors that print debug output while picking. PeekCompaction
+// silences it, since the pick it previews doesn't happen.
+type quietPicker interface {
+	setQuiet(quiet bool)
+}
+
+// pickLogger prints a compactor's picking debug output unless it has been silenced (quietPicker)
+type pickLogger struct {
+	quiet bool
+}
+
+func (l *pickLogger) setQuiet(quiet bool) {
+	l.quiet = quiet
+}
+
+func (l *pickLogger) logf(format string, args ...interface{}) {
+	if !l.quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// trivialMover is implemented by compactors that can tell ahead of execution that a job will only
+// move its files to another level, so the scheduler charges it no CPU or disk time.
+type trivialMover interface {
+	isTrivialMove(job *CompactionJob, lsm *LSMTree) bool
+}
+
 // CompactionJob describes a compaction operation
 type CompactionJob struct {
-	ID          int // Unique ID for this compaction job (assigned by simulator)
-	FromLevel   int
-	ToLevel     int
-	SourceFiles []*SSTFile // Files to compact from source level
-	TargetFiles []*SSTFile // Overlapping files in target level
-	IsIntraL0   bool       // True if this is intra-L0 compaction
+	ID               int // Unique ID for this compaction job (assigned by simulator)
+	FromLevel        int
+	ToLevel          int
+	SourceFiles      []*SSTFile // Files to compact from source level
+	TargetFiles      []*SSTFile // Overlapping files in target level
+	IsIntraL0        bool       // True if this is intra-L0 compaction
+	IsFollowUp       bool       // True if this job is the remainder of an over-large compaction that was split
+	IsSmallFileMerge bool       // True if this job consolidates small files rather than relieving a level over its target
+	Coverage         float64    // Universal only: fraction of the picked sorted runs' bytes this job compacts (< 1 in incremental mode)
+	RetryCount       int        // Times this job failed and was re-run (CompactionFailureRate)
+	ExtraOutputFiles int        // Output files beyond the size-only split, from cutting at target-file boundaries (set by ExecuteCompaction)
+	Reason           string     // Trigger that picked the job, when the job flags don't say (see compactionReason)
+	ColumnFamily     int        // 0 = default column family, i = ColumnFamilies entry i-1 (set by the simulator)
+}
+
+// CompactionRecord is one completed compaction in the simulator's compaction history
+type CompactionRecord struct {
+	ColumnFamily int     `json:"columnFamily"` // 0 = default column family, i = ColumnFamilies entry i-1
+	StartTime    float64 `json:"startTime"`    // Virtual time the job started on a worker
+	EndTime      float64 `json:"endTime"`      // Virtual time the job completed
+	FromLevel    int     `json:"fromLevel"`    // Source level
+	ToLevel      int     `json:"toLevel"`      // Output level
+	InputMB      float64 `json:"inputMB"`      // Bytes read (source + target files)
+	OutputMB     float64 `json:"outputMB"`     // Bytes written (0 for FIFO deletions)
+	TrivialMove  bool    `json:"trivialMove"`  // Files were moved without being rewritten
+	Reason       string  `json:"reason"`       // Why the job was picked (see compactionReason)
+}
+
+// compactionReason names the trigger behind a job: the compactor's own Reason when it set one,
+// otherwise derived from the job's flags
+func compactionReason(job *CompactionJob) string {
+	switch {
+	case job.Reason != "":
+		return job.Reason
+	case job.IsFollowUp:
+		return "follow_up"
+	case job.IsSmallFileMerge:
+		return "small_file_merge"
+	case job.IsIntraL0:
+		return "intra_l0"
+	case job.FromLevel == job.ToLevel:
+		return "deletion" // FIFO: drop the oldest files
+	default:
+		return "level_score"
+	}
 }
 
 // Helper functions shared by both compaction strategies
@@ -37,6 +125,24 @@ func pickFileCount(availableFiles int, minFiles int, dist filePicker) int {
 	return dist.Pick(minFiles, availableFiles)
 }
 
+// jobReadsPausedLevel reports whether any of the job's source files live in a level whose
+// compaction is paused
+func jobReadsPausedLevel(job *CompactionJob, lsm *LSMTree) bool {
+	for _, level := range lsm.Levels {
+		if !level.CompactionPaused {
+			continue
+		}
+		for _, f := range level.Files {
+			for _, src := range job.SourceFiles {
+				if f == src {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // pickOverlapCount estimates overlapping files in target level
 // Uses distribution to model overlaps - Geometric provides better balance than Exponential
 func pickOverlapCount(maxFiles int, dist filePicker) int {
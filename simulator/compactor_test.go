@@ -306,8 +306,8 @@ func TestLeveledCompactorExecuteCompaction(t *testing.T) {
 			t.Errorf("Expected inputSize=192, got %.1f", inputSize)
 		}
 
-		// Output should be reduced by compaction factor (0.9 for L0->L1)
-		expectedOutput := 192 * 0.9
+		// Output should be reduced by compaction factor (0.9 for L0->L1 by default)
+		expectedOutput := 192 * config.CompactionReductionFactor(0)
 		if outputSize != expectedOutput {
 			t.Errorf("Expected outputSize=%.1f, got %.1f", expectedOutput, outputSize)
 		}
@@ -354,9 +354,9 @@ func TestLeveledCompactorExecuteCompaction(t *testing.T) {
 
 		_, outputSize, _ := compactor.ExecuteCompaction(job, lsm, config, 10.0)
 
-		// L1+ should use 0.99 reduction factor
+		// L1+ should use the deep reduction factor (0.99 by default)
 		// Input: 256 + 50 = 306 MB, Output: 306 * 0.99 = 302.94 MB
-		expectedOutput := (256 + 50) * 0.99
+		expectedOutput := (256 + 50) * config.CompactionReductionFactor(1)
 		if outputSize != expectedOutput {
 			t.Errorf("Expected outputSize=%.1f, got %.1f", expectedOutput, outputSize)
 		}
@@ -826,6 +826,64 @@ func TestTrivialMove(t *testing.T) {
 	}
 }
 
+// pickOnceCompactor hands out one fixed job, then defers to the wrapped universal compactor
+type pickOnceCompactor struct {
+	*UniversalCompactor
+	job *CompactionJob
+}
+
+func (c *pickOnceCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
+	job := c.job
+	c.job = nil
+	return job
+}
+
+// TestUniversalTrivialMove tests the universal counterpart of TestTrivialMove: a single sorted run
+// moved into an empty level is relinked without being rewritten, and is scheduled without
+// reserving any disk bandwidth
+func TestUniversalTrivialMove(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleUniversal
+	config.NumLevels = 7
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	l0File := &SSTFile{ID: "L0-1", SizeMB: 64.0, CreatedAt: 0}
+	sim.lsm.Levels[0].AddFile(l0File)
+	sim.diskBusyUntil = 0.5
+	universal := sim.compactor.(*UniversalCompactor)
+	sim.compactor = &pickOnceCompactor{
+		UniversalCompactor: universal,
+		job:                &CompactionJob{FromLevel: 0, ToLevel: 6, SourceFiles: []*SSTFile{l0File}},
+	}
+
+	require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+	require.Len(t, sim.pendingCompactions, 1)
+	var job *CompactionJob
+	for _, j := range sim.pendingCompactions {
+		job = j
+	}
+	require.True(t, universal.isTrivialMove(job, sim.lsm))
+
+	// No disk time is reserved for the move
+	require.Equal(t, 0.5, sim.diskBusyUntil, "A trivial move must not advance diskBusyUntil")
+
+	inputSize, outputSize, outputFileCount := universal.ExecuteCompaction(job, sim.lsm, sim.config, sim.virtualTime)
+	require.Equal(t, 64.0, inputSize)
+	require.Equal(t, inputSize, outputSize, "Trivial move should have outputSize == inputSize")
+	require.Equal(t, 1, outputFileCount)
+	require.Equal(t, 0, sim.lsm.Levels[0].FileCount, "L0 should be empty after trivial move")
+	require.Same(t, l0File, sim.lsm.Levels[6].Files[0], "The file should be moved, not rewritten")
+
+	// Several L0 files are several sorted runs: moving them together needs a merge
+	multi := &CompactionJob{
+		FromLevel:   0,
+		ToLevel:     5,
+		SourceFiles: []*SSTFile{{ID: "a", SizeMB: 1}, {ID: "b", SizeMB: 1}},
+	}
+	require.False(t, universal.isTrivialMove(multi, sim.lsm))
+}
+
 // ============================================================================
 // CRITICAL MISSING TESTS - Universal Compaction TDD Approach
 // ============================================================================
@@ -1290,8 +1348,8 @@ func TestUniversalCompaction_Step18_Property_CompactionNeverIncreasesSize(t *tes
 	// INVARIANT: Output size should be <= input size (compaction reduces via deduplication)
 	require.LessOrEqual(t, outputSize, inputSize, "Output size should be <= input size (compaction reduces size)")
 
-	// L0→L1 uses 90% reduction factor, so output should be exactly input * 0.9
-	expectedOutput := inputSize * 0.9
+	// L0→L1 uses the L0 reduction factor (0.9 by default), so output should be exactly input * factor
+	expectedOutput := inputSize * config.CompactionReductionFactor(0)
 	require.Equal(t, expectedOutput, outputSize, "Output size should match reduction factor")
 }
 
@@ -1460,7 +1518,7 @@ func TestUniversalCompaction_Step23_EdgeCase_VerySmallOutput(t *testing.T) {
 	inputSize, outputSize, outputFileCount := compactor.ExecuteCompaction(job, lsm, config, 0.0)
 
 	require.Equal(t, 1.0, inputSize, "Input size should be 1 MB")
-	require.Equal(t, 0.9, outputSize, "Output size should be 0.9 MB (1 MB * 0.9)")
+	require.Equal(t, config.CompactionReductionFactor(0), outputSize, "Output size should be 0.9 MB (1 MB * 0.9)")
 
 	// Should create at least 1 output file (minimum)
 	require.Equal(t, 1, outputFileCount, "Should create exactly 1 output file (small output)")
@@ -3527,3 +3585,800 @@ func TestPickCompaction_UniversalCompaction_FastChecks(t *testing.T) {
 		require.NotNil(t, job, "Should schedule when L0 score >= 1.0 (ignores compacting files in score calculation)")
 	})
 }
+
+// TestSplitCompaction_DisjointJobsWithinLimit verifies that an over-large Ln→Ln+1 candidate
+// is split into a primary job plus follow-ups, each within max_compaction_bytes
+func TestSplitCompaction_DisjointJobsWithinLimit(t *testing.T) {
+	makeFiles := func(prefix string, n int, sizeMB float64) []*SSTFile {
+		files := make([]*SSTFile, n)
+		for i := range files {
+			files[i] = &SSTFile{ID: fmt.Sprintf("%s%d", prefix, i), SizeMB: sizeMB}
+		}
+		return files
+	}
+
+	// 4 source files x 100 MB, 8 target files x 100 MB = 1200 MB total, limit 400 MB
+	sourceFiles := makeFiles("src", 4, 100)
+	targetFiles := makeFiles("tgt", 8, 100)
+
+	jobs := splitCompaction(1, sourceFiles, targetFiles, 400)
+	require.Len(t, jobs, 4, "Each source file + 2 targets = 300 MB, so one per job")
+	require.False(t, jobs[0].IsFollowUp, "First job is the primary")
+
+	seenTargets := make(map[*SSTFile]bool)
+	for i, job := range jobs {
+		require.Equal(t, 1, job.FromLevel)
+		require.Equal(t, 2, job.ToLevel)
+		require.Equal(t, i > 0, job.IsFollowUp)
+
+		var size float64
+		for _, f := range append(append([]*SSTFile{}, job.SourceFiles...), job.TargetFiles...) {
+			size += f.SizeMB
+		}
+		require.LessOrEqual(t, size, 400.0)
+
+		// Disjoint: no target file shared between jobs
+		for _, f := range job.TargetFiles {
+			require.False(t, seenTargets[f], "Target %s assigned to more than one job", f.ID)
+			seenTargets[f] = true
+		}
+	}
+	require.Len(t, seenTargets, 8, "All target files are covered")
+}
+
+// TestLeveledCompactor_FollowUpJobRunsAfterPrimary verifies queued follow-ups are
+// returned once the source level is free, dropping files consumed in the meantime
+func TestLeveledCompactor_FollowUpJobRunsAfterPrimary(t *testing.T) {
+	lsm := NewLSMTree(3, 64.0)
+	for i := 0; i < 4; i++ {
+		lsm.CreateSSTFile(1, 100, 0)
+	}
+	for i := 0; i < 4; i++ {
+		lsm.CreateSSTFile(2, 100, 0)
+	}
+
+	compactor := NewLeveledCompactor(1)
+	jobs := splitCompaction(1, lsm.Levels[1].Files, lsm.Levels[2].Files, 400)
+	require.Len(t, jobs, 2)
+	compactor.followUpJobs = jobs[1:]
+	compactor.activeCompactions[1] = true
+
+	// Source level busy with the primary: follow-up must wait
+	require.Nil(t, compactor.popFollowUpJob(lsm))
+	require.Equal(t, 1, compactor.PendingFollowUpJobs())
+
+	// Primary done; one of the follow-up's target files was consumed elsewhere
+	compactor.activeCompactions[1] = false
+	consumed := jobs[1].TargetFiles[0]
+	lsm.Levels[2].RemoveFiles([]*SSTFile{consumed})
+
+	job := compactor.popFollowUpJob(lsm)
+	require.NotNil(t, job)
+	require.True(t, job.IsFollowUp)
+	require.NotContains(t, job.TargetFiles, consumed)
+	require.True(t, compactor.activeCompactions[1])
+	require.Equal(t, 0, compactor.PendingFollowUpJobs())
+}
+
+// TestL0SubLevels_IntraL0OutputsShareSubLevel tests that intra-L0 outputs form one sub-level
+// and that read amplification counts sub-levels instead of files when enabled
+func TestL0SubLevels_IntraL0OutputsShareSubLevel(t *testing.T) {
+	compactor := NewLeveledCompactor(0)
+	config := DefaultConfig()
+	config.EnableL0SubLevels = true
+
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	for i := 0; i < 8; i++ {
+		lsm.Levels[0].AddFile(&SSTFile{ID: fmt.Sprintf("L0-%d", i), SizeMB: 16.0, CreatedAt: float64(i)})
+	}
+	require.Equal(t, 8, lsm.Levels[0].SubLevelCount(), "flushed files each form their own sub-level")
+
+	job := &CompactionJob{
+		FromLevel:   0,
+		ToLevel:     0,
+		SourceFiles: append([]*SSTFile{}, lsm.Levels[0].Files...),
+		IsIntraL0:   true,
+	}
+	_, _, outputFileCount := compactor.ExecuteCompaction(job, lsm, config, 10.0)
+	require.Equal(t, 4, outputFileCount)
+
+	// Two more flushes land on top of the merged sub-level
+	lsm.Levels[0].AddFile(&SSTFile{ID: "L0-new-1", SizeMB: 16.0, CreatedAt: 11.0})
+	lsm.Levels[0].AddFile(&SSTFile{ID: "L0-new-2", SizeMB: 16.0, CreatedAt: 12.0})
+	require.Equal(t, 6, lsm.Levels[0].FileCount)
+	require.Equal(t, 3, lsm.Levels[0].SubLevelCount())
+
+	metrics := NewMetrics()
+	metrics.UpdateReadAmplification(lsm, 1, true)
+	require.Equal(t, 3, metrics.L0SubLevelCount)
+	require.Equal(t, float64(1+3+(config.NumLevels-1)), metrics.ReadAmplification)
+
+	metrics.UpdateReadAmplification(lsm, 1, false)
+	require.Equal(t, float64(1+6+(config.NumLevels-1)), metrics.ReadAmplification, "without sub-levels every L0 file is checked")
+}
+
+// TestL0SubLevels_FromKeyOverlap tests that sub-levels stack overlapping files and share one
+// between disjoint files, and that they score L0 when enabled
+func TestL0SubLevels_FromKeyOverlap(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.L0CompactionTrigger = 4
+
+	addFile := func(lsm *LSMTree, id string, createdAt float64, minKey, maxKey uint64) *SSTFile {
+		f := &SSTFile{ID: id, SizeMB: 16.0, CreatedAt: createdAt, MinKey: minKey, MaxKey: maxKey}
+		lsm.Levels[0].AddFile(f)
+		return f
+	}
+
+	// Flushed files span the whole key space: each overlaps the rest and needs its own sub-level
+	overlapping := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	for i := 0; i < 8; i++ {
+		addFile(overlapping, fmt.Sprintf("full-%d", i), float64(i), 0, keySpaceMax)
+	}
+	require.Len(t, overlapping.Levels[0].subLevels(), 8)
+
+	// Disjoint files all fit in one
+	disjoint := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	for i := 0; i < 8; i++ {
+		addFile(disjoint, fmt.Sprintf("slice-%d", i), float64(i), uint64(i*100+1), uint64(i*100+99))
+	}
+	require.Len(t, disjoint.Levels[0].subLevels(), 1)
+
+	// A newer file bridging two older disjoint ones stacks above them; a later disjoint file
+	// drops back to the bottom sub-level
+	mixed := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	a := addFile(mixed, "a", 1, 1, 100)
+	b := addFile(mixed, "b", 2, 200, 300)
+	c := addFile(mixed, "c", 3, 50, 250)
+	d := addFile(mixed, "d", 4, 400, 500)
+	require.Equal(t, [][]*SSTFile{{a, b, d}, {c}}, mixed.Levels[0].subLevels())
+
+	require.Equal(t, 2, mixed.Levels[0].SubLevelCount())
+
+	// File count scores L0 unless EnableL0SubLevels is set
+	require.Equal(t, 2.0, disjoint.calculateCompactionScore(0, config, 0))
+	config.EnableL0SubLevels = true
+	require.Less(t, disjoint.calculateCompactionScore(0, config, 0), 1.0, "one sub-level is below the trigger")
+	require.Equal(t, 2.0, overlapping.calculateCompactionScore(0, config, 0))
+}
+
+// TestLeveledCompactor_SmallFileMerge tests the secondary trigger that consolidates
+// runs of small files when no level's score calls for compaction
+func TestLeveledCompactor_SmallFileMerge(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+	config.L0CompactionTrigger = 8
+
+	t.Run("disabled by default", func(t *testing.T) {
+		compactor := NewLeveledCompactor(0)
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		for i := 0; i < 5; i++ {
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-%d", i), SizeMB: 2.0})
+		}
+		require.Nil(t, compactor.PickCompaction(lsm, config))
+	})
+
+	config.SmallFileMergeThresholdMB = 8
+
+	t.Run("L1 run merges into L2", func(t *testing.T) {
+		compactor := NewLeveledCompactor(0)
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		lsm.Levels[1].AddFile(&SSTFile{ID: "L1-big", SizeMB: 64.0})
+		for i := 0; i < 5; i++ {
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-%d", i), SizeMB: 2.0})
+		}
+
+		job := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, job)
+		require.True(t, job.IsSmallFileMerge)
+		require.Equal(t, 1, job.FromLevel)
+		require.Equal(t, 2, job.ToLevel)
+		require.Len(t, job.SourceFiles, 5, "only the run of small files is picked")
+	})
+
+	t.Run("L0 run merges intra-L0", func(t *testing.T) {
+		compactor := NewLeveledCompactor(0)
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		for i := 0; i < 4; i++ {
+			lsm.Levels[0].AddFile(&SSTFile{ID: fmt.Sprintf("L0-%d", i), SizeMB: 1.0})
+		}
+
+		job := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, job)
+		require.True(t, job.IsSmallFileMerge)
+		require.True(t, job.IsIntraL0)
+		require.Len(t, job.SourceFiles, 4)
+	})
+
+	t.Run("short runs are left alone", func(t *testing.T) {
+		compactor := NewLeveledCompactor(0)
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		for i := 0; i < 3; i++ {
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-small-%d", i), SizeMB: 2.0})
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-big-%d", i), SizeMB: 64.0})
+		}
+		require.Nil(t, compactor.PickCompaction(lsm, config))
+	})
+}
+
+// TestSplitOutputFiles tests the default even split of compaction output, and with a minimum file
+// size, cutting at the target file size and merging a trailing runt into the previous file
+func TestSplitOutputFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    float64
+		target    float64
+		minSize   float64
+		wantSizes []float64
+	}{
+		{"below target", 40, 64, 0, []float64{40}},
+		{"exact multiple", 128, 64, 0, []float64{64, 64}},
+		{"even split when min disabled", 132, 64, 0, []float64{44, 44, 44}},
+		{"runt kept when at least min", 132, 64, 2, []float64{64, 64, 4}},
+		{"runt merged into previous", 132, 64, 8, []float64{64, 68}},
+		{"trailing file above min kept", 160, 64, 8, []float64{64, 64, 32}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes := splitOutputFiles(tt.output, tt.target, tt.minSize)
+			require.Equal(t, tt.wantSizes, sizes)
+		})
+	}
+
+	t.Run("ExecuteCompaction reports merged file count", func(t *testing.T) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.MinOutputFileSizeMB = 8
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		compactor := NewLeveledCompactor(0)
+
+		// L1→L2 keeps 99% of input: 132 MB in → 130.68 MB out. L2 target is 128 MB,
+		// leaving a 2.68 MB runt that is merged into the full file
+		source := &SSTFile{ID: "L1-1", SizeMB: 132, CreatedAt: 0}
+		target := &SSTFile{ID: "L2-1", SizeMB: 0.0001, CreatedAt: 0}
+		lsm.Levels[1].AddFile(source)
+		lsm.Levels[2].AddFile(target)
+		job := &CompactionJob{FromLevel: 1, ToLevel: 2, SourceFiles: []*SSTFile{source}, TargetFiles: []*SSTFile{target}}
+
+		_, outputSize, outputFileCount := compactor.ExecuteCompaction(job, lsm, config, 1.0)
+		require.InDelta(t, 130.68, outputSize, 0.01)
+		require.Equal(t, 1, outputFileCount, "runt trailing file merged into the only full file")
+		require.Equal(t, 1, lsm.Levels[2].FileCount)
+	})
+}
+
+// TestUniversalCompactor_IncrementalMode tests that incremental mode compacts a bounded window of
+// the picked level run per job, advancing through the level, and reports partial coverage
+func TestUniversalCompactor_IncrementalMode(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleUniversal
+	config.L0CompactionTrigger = 4
+	config.MaxCompactionBytesMB = 300
+
+	setup := func() *LSMTree {
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		for i := 0; i < 4; i++ {
+			lsm.Levels[0].AddFile(&SSTFile{ID: fmt.Sprintf("L0-%d", i), SizeMB: 64.0})
+		}
+		for i := 0; i < 4; i++ {
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-%d", i), SizeMB: 50.0})
+		}
+		return lsm
+	}
+
+	t.Run("whole sorted runs by default", func(t *testing.T) {
+		job := NewUniversalCompactor(42).PickCompaction(setup(), config)
+		require.NotNil(t, job)
+		require.Len(t, job.SourceFiles, 8, "4 L0 files plus all of L1")
+		require.Equal(t, 1.0, job.Coverage)
+	})
+
+	config.UniversalIncrementalMode = true
+
+	t.Run("window of the level run", func(t *testing.T) {
+		compactor := NewUniversalCompactor(42)
+		job := compactor.PickCompaction(setup(), config)
+		require.NotNil(t, job)
+		// 256 MB of L0 leaves 44 MB of budget: L1 still contributes one file so the job progresses
+		require.Len(t, job.SourceFiles, 5)
+		require.Equal(t, "L1-0", job.SourceFiles[4].ID)
+		require.InDelta(t, 306.0/456.0, job.Coverage, 1e-9)
+	})
+
+	t.Run("windows advance and wrap", func(t *testing.T) {
+		compactor := NewUniversalCompactor(42)
+		files := setup().Levels[1].Files
+		require.Equal(t, []*SSTFile{files[0], files[1]}, compactor.incrementalWindow(1, files, 100))
+		require.Equal(t, []*SSTFile{files[2], files[3]}, compactor.incrementalWindow(1, files, 120))
+		require.Equal(t, []*SSTFile{files[0]}, compactor.incrementalWindow(1, files, 10))
+	})
+}
+
+// TestOverlapSeed_IndependentOfFileSelection tests that OverlapSeed varies only the overlap
+// realization while file selection stays fixed by the compactor seed
+func TestOverlapSeed_IndependentOfFileSelection(t *testing.T) {
+	overlapConfig := OverlapDistributionConfig{Type: DistUniform}
+	sample := func(c *LeveledCompactor) (files, overlaps []int) {
+		for i := 0; i < 20; i++ {
+			files = append(files, pickFileCount(100, 1, c.fileSelectDist))
+			overlaps = append(overlaps, pickOverlapCount(100, c.overlapSelectDist))
+		}
+		return files, overlaps
+	}
+
+	filesA, overlapsA := sample(NewLeveledCompactorWithOverlapDist(42, 7, overlapConfig))
+	filesB, overlapsB := sample(NewLeveledCompactorWithOverlapDist(42, 7, overlapConfig))
+	filesC, overlapsC := sample(NewLeveledCompactorWithOverlapDist(42, 8, overlapConfig))
+
+	require.Equal(t, filesA, filesB)
+	require.Equal(t, overlapsA, overlapsB, "same seeds reproduce the same overlaps")
+	require.Equal(t, filesA, filesC, "file selection is unaffected by the overlap seed")
+	require.NotEqual(t, overlapsA, overlapsC, "a different overlap seed changes the overlap realization")
+}
+
+// TestCompactionGarbageFraction tests that garbage in compaction input is read but not written,
+// so output shrinks and the read/write byte asymmetry shows up in metrics
+func TestCompactionGarbageFraction(t *testing.T) {
+	run := func(garbage float64) (inputSize, outputSize float64) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.CompactionGarbageFraction = garbage
+
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		source := &SSTFile{ID: "L1-0", SizeMB: 64.0}
+		target := &SSTFile{ID: "L2-0", SizeMB: 36.0}
+		lsm.Levels[1].AddFile(source)
+		lsm.Levels[2].AddFile(target)
+
+		job := &CompactionJob{FromLevel: 1, ToLevel: 2, SourceFiles: []*SSTFile{source}, TargetFiles: []*SSTFile{target}}
+		inputSize, outputSize, _ = NewLeveledCompactor(42).ExecuteCompaction(job, lsm, config, 10.0)
+		return inputSize, outputSize
+	}
+
+	input, clean := run(0)
+	require.Equal(t, 100.0, input)
+	require.InDelta(t, 99.0, clean, 1e-9, "deeper levels keep 99% without garbage")
+
+	input, garbage := run(0.4)
+	require.Equal(t, 100.0, input, "garbage is still read")
+	require.InDelta(t, 99.0*0.6, garbage, 1e-9, "garbage is dropped from output")
+
+	m := NewMetrics()
+	require.Equal(t, 1.0, m.CompactionReadWriteRatio)
+	m.RecordCompaction(input, garbage, 0, 1, 1, 2, 1, false, 0)
+	m.RecordCompaction(64, 64, 1, 1, 1, 1, 1, true, 0) // trivial moves read and write nothing
+	require.Equal(t, 100.0, m.CompactionReadMB)
+	require.InDelta(t, 100.0/(99.0*0.6), m.CompactionReadWriteRatio, 1e-9)
+
+	config := DefaultConfig()
+	config.CompactionGarbageFraction = 1.0
+	require.Error(t, config.Validate())
+}
+
+// TestCompressionModel_PerLevelRatio tests that compaction output is recompressed at the target level's factor
+func TestCompressionModel_PerLevelRatio(t *testing.T) {
+	config := DefaultConfig()
+	require.Equal(t, config.CompressionFactor, config.CompressionFactorForLevel(5), "uniform model uses one factor")
+	require.Equal(t, 1.0, config.RecompressionRatio(1, 5))
+
+	config.CompressionModel = CompressionModelAgeBased
+	config.CompressionFactor = 0.8
+	config.CompressionAgeDecay = 0.9
+	require.InDelta(t, 0.8, config.CompressionFactorForLevel(0), 1e-9)
+	require.InDelta(t, 0.8*0.9*0.9, config.CompressionFactorForLevel(2), 1e-9)
+	require.NoError(t, config.Validate())
+
+	config.CompressionModel = CompressionModelFixed
+	config.CompressionFactorPerLevel = [maxNumLevels]float64{0.9, 0.8, 0.5}
+	require.Equal(t, 0.5, config.CompressionFactorForLevel(2))
+	require.Equal(t, 0.8, config.CompressionFactorForLevel(3), "unset entries fall back to compressionFactor")
+	require.NoError(t, config.Validate())
+
+	// L1 source (64 MB at 0.8) recompressed to L2 (0.5); L2 target already at 0.5
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	source := &SSTFile{ID: "L1-0", SizeMB: 64.0}
+	target := &SSTFile{ID: "L2-0", SizeMB: 36.0}
+	lsm.Levels[1].AddFile(source)
+	lsm.Levels[2].AddFile(target)
+	job := &CompactionJob{FromLevel: 1, ToLevel: 2, SourceFiles: []*SSTFile{source}, TargetFiles: []*SSTFile{target}}
+	inputSize, outputSize, _ := NewLeveledCompactor(42).ExecuteCompaction(job, lsm, config, 10.0)
+	require.Equal(t, 100.0, inputSize)
+	require.InDelta(t, (64.0*0.5/0.8+36.0)*config.CompactionReductionFactor(1), outputSize, 1e-9)
+
+	m := NewMetrics()
+	m.updateLevelCompression(lsm, config)
+	require.InDelta(t, outputSize, m.PhysicalSizeMBPerLevel[2], 1e-9)
+	require.InDelta(t, outputSize*0.9/0.5, m.LogicalSizeMBPerLevel[2], 1e-9, "logical size is at L0's factor")
+
+	config.CompressionFactorPerLevel[4] = 1.5
+	require.Error(t, config.Validate())
+	config.CompressionFactorPerLevel[4] = 0
+	config.CompressionModel = "bogus"
+	require.Error(t, config.Validate())
+}
+
+// TestCompactionReductionFactor tests the configurable compaction output/input ratios
+func TestCompactionReductionFactor(t *testing.T) {
+	config := DefaultConfig()
+	require.Equal(t, 0.9, config.CompactionReductionFactor(0), "unset falls back to the L0 default")
+	require.Equal(t, 0.99, config.CompactionReductionFactor(3), "unset falls back to the deep default")
+	config.DeduplicationFactor = 0.7
+	require.Equal(t, 0.7, config.CompactionReductionFactor(0), "unset L0 factor follows deduplicationFactor")
+	require.Equal(t, 0.99, config.CompactionReductionFactor(1))
+	config.DeduplicationFactor = 0
+	require.Equal(t, 0.9, config.CompactionReductionFactor(0), "unset falls back to the L0 default")
+	config.DeduplicationFactor = 0.9
+
+	config.L0ReductionFactor = 0.5
+	config.DeepReductionFactor = 0.95
+	config.LevelReductionFactors[2] = 0.8
+	require.NoError(t, config.Validate())
+	require.Equal(t, 0.5, config.CompactionReductionFactor(0))
+	require.Equal(t, 0.95, config.CompactionReductionFactor(1))
+	require.Equal(t, 0.8, config.CompactionReductionFactor(2), "per-level override wins")
+	require.Equal(t, 0.95, config.CompactionReductionFactor(3))
+
+	// Overwrite-heavy L0→L1: half the input is merged away
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	source := &SSTFile{ID: "L0-0", SizeMB: 64.0}
+	target := &SSTFile{ID: "L1-0", SizeMB: 36.0}
+	lsm.Levels[0].AddFile(source)
+	lsm.Levels[1].AddFile(target)
+	job := &CompactionJob{FromLevel: 0, ToLevel: 1, SourceFiles: []*SSTFile{source}, TargetFiles: []*SSTFile{target}}
+	inputSize, outputSize, _ := NewLeveledCompactor(42).ExecuteCompaction(job, lsm, config, 10.0)
+	require.Equal(t, 100.0, inputSize)
+	require.InDelta(t, 50.0, outputSize, 1e-9)
+
+	config.L0ReductionFactor = 1.5
+	require.Error(t, config.Validate())
+	config.L0ReductionFactor = 0
+	config.LevelReductionFactors[4] = 0.05
+	require.Error(t, config.Validate())
+}
+
+// TestSplitOutputAtTargetBoundaries tests that output is cut at each target file's boundaries
+func TestSplitOutputAtTargetBoundaries(t *testing.T) {
+	targets := []*SSTFile{{ID: "a", SizeMB: 30}, {ID: "b", SizeMB: 10}, {ID: "c", SizeMB: 60}}
+
+	// 100 MB of output fits in two 64 MB files by size, but spans three target ranges
+	require.Len(t, splitOutputFiles(100, 64, 0), 2)
+	sizes := splitOutputAtBoundaries(100, targets, 64, 0)
+	require.Equal(t, []float64{30, 10, 60}, sizes)
+
+	// Large segments are still split by size (evenly: the 120 MB segment becomes two 60 MB files)
+	sizes = splitOutputAtBoundaries(200, targets, 64, 0)
+	require.Equal(t, []float64{60, 20, 60, 60}, sizes)
+
+	run := func(split bool) (int, *CompactionJob) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.SplitOutputAtTargetBoundaries = split
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		source := &SSTFile{ID: "L1-0", SizeMB: 8}
+		lsm.Levels[1].AddFile(source)
+		job := &CompactionJob{FromLevel: 1, ToLevel: 2, SourceFiles: []*SSTFile{source}}
+		for i := 0; i < 4; i++ {
+			target := &SSTFile{ID: fmt.Sprintf("L2-%d", i), SizeMB: 8}
+			lsm.Levels[2].AddFile(target)
+			job.TargetFiles = append(job.TargetFiles, target)
+		}
+		_, _, outputFiles := NewLeveledCompactor(42).ExecuteCompaction(job, lsm, config, 10.0)
+		require.Equal(t, outputFiles, lsm.Levels[2].FileCount)
+		return outputFiles, job
+	}
+	files, job := run(false)
+	require.Equal(t, 1, files, "39.6 MB fits one 128 MB L2 file")
+	require.Zero(t, job.ExtraOutputFiles)
+
+	files, job = run(true)
+	require.Equal(t, 4, files, "one file per overlapped target range")
+	require.Equal(t, 3, job.ExtraOutputFiles)
+}
+
+// TestLeveledCompactor_EqualScoreTieBreak tests that levels with equal scores are picked
+// deterministically according to EqualScoreTieBreak
+func TestLeveledCompactor_EqualScoreTieBreak(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+
+	// L1 and L2 both hold twice their target (256 MB and 2560 MB)
+	newLSM := func() *LSMTree {
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		for i := 0; i < 8; i++ {
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-%d", i), SizeMB: 64.0})
+		}
+		for i := 0; i < 40; i++ {
+			lsm.Levels[2].AddFile(&SSTFile{ID: fmt.Sprintf("L2-%d", i), SizeMB: 128.0})
+		}
+		return lsm
+	}
+	lsm := newLSM()
+	require.Equal(t, lsm.calculateCompactionScore(1, config, 0), lsm.calculateCompactionScore(2, config, 0))
+
+	for _, tc := range []struct {
+		tieBreak  TieBreak
+		wantLevel int
+	}{
+		{"", 1},
+		{TieBreakShallow, 1},
+		{TieBreakDeep, 2},
+	} {
+		config.EqualScoreTieBreak = tc.tieBreak
+		require.NoError(t, config.Validate())
+		for seed := int64(0); seed < 5; seed++ {
+			job := NewLeveledCompactor(seed).PickCompaction(newLSM(), config)
+			require.NotNil(t, job)
+			require.Equal(t, tc.wantLevel, job.FromLevel, "tieBreak=%q seed=%d", tc.tieBreak, seed)
+		}
+	}
+
+	config.EqualScoreTieBreak = "random"
+	require.Error(t, config.Validate())
+}
+
+// TestKeyRangeOverlap tests that with UseKeyRangeOverlap the leveled picker takes exactly the
+// target files whose key ranges intersect the source files
+func TestKeyRangeOverlap(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+	config.UseKeyRangeOverlap = true
+
+	t.Run("assignKeyRanges partitions by size", func(t *testing.T) {
+		files := []*SSTFile{{SizeMB: 10}, {SizeMB: 30}, {SizeMB: 60}}
+		assignKeyRanges(files, 0, 999)
+		require.Equal(t, uint64(0), files[0].MinKey)
+		require.Equal(t, uint64(99), files[0].MaxKey)
+		require.Equal(t, uint64(100), files[1].MinKey)
+		require.Equal(t, uint64(399), files[1].MaxKey)
+		require.Equal(t, uint64(400), files[2].MinKey)
+		require.Equal(t, uint64(999), files[2].MaxKey)
+	})
+
+	t.Run("Ln to Ln+1 picks intersecting targets", func(t *testing.T) {
+		compactor := NewLeveledCompactor(1)
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		// L1 over target (256 MB) with one file covering keys 0..999
+		lsm.Levels[1].AddFile(&SSTFile{ID: "L1-0", SizeMB: 300, MinKey: 0, MaxKey: 999})
+		for i := 0; i < 10; i++ {
+			lo := uint64(i * 500)
+			lsm.Levels[2].AddFile(&SSTFile{ID: fmt.Sprintf("L2-%d", i), SizeMB: 10, MinKey: lo, MaxKey: lo + 499})
+		}
+
+		job := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, job)
+		require.Equal(t, 1, job.FromLevel)
+		require.Equal(t, []*SSTFile{lsm.Levels[2].Files[0], lsm.Levels[2].Files[1]}, job.TargetFiles)
+
+		compactor.ExecuteCompaction(job, lsm, config, 1.0)
+		minKey, maxKey := keyRangeOf(lsm.Levels[2].Files)
+		require.Equal(t, uint64(0), minKey)
+		require.Equal(t, uint64(4999), maxKey)
+		requireNonOverlapping(t, lsm.Levels[2])
+	})
+
+	t.Run("no intersection is a trivial move", func(t *testing.T) {
+		compactor := NewLeveledCompactor(1)
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		lsm.Levels[1].AddFile(&SSTFile{ID: "L1-0", SizeMB: 300, MinKey: 5000, MaxKey: 5999})
+		lsm.Levels[2].AddFile(&SSTFile{ID: "L2-0", SizeMB: 10, MinKey: 0, MaxKey: 4999})
+
+		job := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, job)
+		require.Empty(t, job.TargetFiles)
+	})
+
+	t.Run("flushed files span the key space", func(t *testing.T) {
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		l0 := lsm.CreateSSTFile(0, 64, 0)
+		require.Equal(t, uint64(0), l0.MinKey)
+		require.Equal(t, keySpaceMax, l0.MaxKey)
+
+		for i := 0; i < 4; i++ {
+			lsm.CreateSSTFile(3, 64, 0)
+		}
+		requireNonOverlapping(t, lsm.Levels[3])
+		minKey, maxKey := keyRangeOf(lsm.Levels[3].Files)
+		require.Equal(t, uint64(0), minKey)
+		require.Equal(t, keySpaceMax, maxKey)
+	})
+
+	t.Run("simulation keeps levels non-overlapping", func(t *testing.T) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.UseKeyRangeOverlap = true
+		config.WriteRateMBps = 50
+		config.RandomSeed = 42
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(600)
+
+		require.Greater(t, sim.metrics.TotalCompactionsCompleted, 0)
+		require.Greater(t, sim.metrics.WriteAmplification, 1.0)
+		for _, level := range sim.lsm.Levels[1:] {
+			requireNonOverlapping(t, level)
+		}
+		checkFileInvariants(t, sim.lsm)
+	})
+}
+
+// requireNonOverlapping fails if any two files in the level share a key
+func requireNonOverlapping(t *testing.T, level *Level) {
+	t.Helper()
+	for i, a := range level.Files {
+		for _, b := range level.Files[i+1:] {
+			aMin, aMax := a.keyRange()
+			require.False(t, b.overlapsKeyRange(aMin, aMax), "L%d: %s [%d, %d] overlaps %s [%d, %d]",
+				level.Number, a.ID, aMin, aMax, b.ID, b.MinKey, b.MaxKey)
+		}
+	}
+}
+
+// TestUniversalAgeBasedTrigger verifies an old base run lowers the size-amplification threshold
+// when UniversalAgeBasedTrigger is set, and that such compactions are marked as age-driven
+func TestUniversalAgeBasedTrigger(t *testing.T) {
+	setup := func() (*UniversalCompactor, *LSMTree) {
+		lsm := NewLSMTree(7, 64.0)
+		lsm.Levels[6].AddFile(&SSTFile{ID: "base", SizeMB: 1000, CreatedAt: 0})
+		lsm.Levels[0].AddFile(&SSTFile{ID: "l0-1", SizeMB: 64, CreatedAt: 100000})
+		lsm.Levels[0].AddFile(&SSTFile{ID: "l0-2", SizeMB: 64, CreatedAt: 100000})
+		return NewUniversalCompactor(42), lsm
+	}
+	config := DefaultConfig()
+	config.L0CompactionTrigger = 2
+	config.MaxSizeAmplificationPercent = 200
+	config.UniversalAgeThresholdSeconds = 3600
+
+	// 12.8% amplification is far below 200%
+	compactor, lsm := setup()
+	compactor.setVirtualTime(100000)
+	triggered, byAge := compactor.sizeAmplificationTrigger(lsm, 6, config)
+	require.False(t, triggered)
+	require.False(t, byAge)
+
+	config.UniversalAgeBasedTrigger = true
+	require.NoError(t, config.Validate())
+
+	// A young base run keeps the full threshold
+	compactor.setVirtualTime(3600)
+	triggered, _ = compactor.sizeAmplificationTrigger(lsm, 6, config)
+	require.False(t, triggered)
+
+	// After ~28 threshold periods the threshold drops below 12.8%
+	compactor.setVirtualTime(100000)
+	triggered, byAge = compactor.sizeAmplificationTrigger(lsm, 6, config)
+	require.True(t, triggered)
+	require.True(t, byAge)
+	require.True(t, compactor.checkSizeAmplification(lsm, 6, config))
+
+	job := compactor.PickCompaction(lsm, config)
+	require.NotNil(t, job)
+	require.Equal(t, "size_amplification_age", job.Reason)
+	require.Equal(t, 6, job.ToLevel)
+
+	// Amplification over the plain threshold is not attributed to age
+	compactor, lsm = setup()
+	for i := 0; i < 40; i++ {
+		lsm.Levels[0].AddFile(&SSTFile{ID: fmt.Sprintf("extra-%d", i), SizeMB: 64, CreatedAt: 100000})
+	}
+	compactor.setVirtualTime(100000)
+	triggered, byAge = compactor.sizeAmplificationTrigger(lsm, 6, config)
+	require.True(t, triggered)
+	require.False(t, byAge)
+
+	config.UniversalAgeThresholdSeconds = 0
+	require.Error(t, config.Validate())
+}
+
+// TestCompactionPriority tests which L1 files each compaction_pri takes, and that the round_robin
+// cursor carries over from one PickCompaction to the next
+func TestCompactionPriority(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+
+	// newLSM builds an L1 over its 256 MB target: five files over consecutive key ranges, written
+	// at different times, with the largest in the middle
+	newLSM := func() *LSMTree {
+		lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+		sizes := []float64{60, 60, 90, 60, 60}
+		createdAt := []float64{50, 10, 30, 40, 20}
+		for i, size := range sizes {
+			lo := uint64(i * 1000)
+			lsm.Levels[1].AddFile(&SSTFile{ID: fmt.Sprintf("L1-%d", i), SizeMB: size, CreatedAt: createdAt[i], MinKey: lo, MaxKey: lo + 999})
+		}
+		return lsm
+	}
+	// firstPick returns the ID of the first source file of the next L1 compaction
+	firstPick := func(compactor *LeveledCompactor, lsm *LSMTree, config SimConfig) string {
+		job := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, job)
+		require.Equal(t, 1, job.FromLevel)
+		compactor.releaseJob(job)
+		return job.SourceFiles[0].ID
+	}
+
+	for priority, want := range map[CompactionPriority]string{
+		CompactionPriorityLevel:             "L1-0",
+		CompactionPriorityBySize:            "L1-2",
+		CompactionPriorityOldestLargestSeq:  "L1-1",
+		CompactionPriorityOldestSmallestSeq: "L1-1",
+	} {
+		config := config
+		config.CompactionPriority = priority
+		require.Equal(t, want, firstPick(NewLeveledCompactor(1), newLSM(), config), "priority %q", priority)
+	}
+
+	t.Run("oldest_smallest_seq follows the oldest data, oldest_largest_seq the newest", func(t *testing.T) {
+		lsm := newLSM()
+		// L1-4 was rewritten at t=60 but still holds data from t=5; L1-1's newest data is the oldest
+		lsm.Levels[1].Files[4].CreatedAt = 60
+		lsm.Levels[1].Files[4].setDataSpan(5, 45)
+		config := config
+		config.CompactionPriority = CompactionPriorityOldestSmallestSeq
+		require.Equal(t, "L1-4", firstPick(NewLeveledCompactor(1), lsm, config))
+		config.CompactionPriority = CompactionPriorityOldestLargestSeq
+		require.Equal(t, "L1-1", firstPick(NewLeveledCompactor(1), lsm, config))
+	})
+
+	t.Run("round_robin cursor persists across picks", func(t *testing.T) {
+		config := config
+		config.CompactionPriority = CompactionPriorityRoundRobin
+		compactor := NewLeveledCompactor(1)
+		lsm := newLSM()
+		// finish forgets a job as ExecuteCompaction does, leaving the files in place
+		finish := func(job *CompactionJob) {
+			delete(compactor.activeCompactions, job.FromLevel)
+			delete(compactor.pickCursors, job.FromLevel)
+		}
+
+		// Each pick starts where the previous one ended, wrapping at the end of the level
+		nextKey := uint64(0)
+		wrapped := false
+		for i := 0; i < 10; i++ {
+			job := compactor.PickCompaction(lsm, config)
+			require.NotNil(t, job)
+			finish(job)
+			minKey, maxKey := keyRangeOf(job.SourceFiles)
+			require.Equal(t, nextKey, minKey, "pick %d", i)
+			require.Equal(t, maxKey+1, compactor.compactCursors[1], "pick %d", i)
+			nextKey = maxKey + 1
+			if nextKey > 4999 {
+				nextKey = 0 // No file starts at or after the cursor
+				wrapped = true
+			}
+		}
+		require.True(t, wrapped, "expected the cursor to wrap within 10 picks of a 5-file level")
+
+		// A released pick never ran: the cursor goes back so its files are picked next time
+		cursor, set := compactor.compactCursors[1]
+		job := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, job)
+		compactor.releaseJob(job)
+		restored, restoredSet := compactor.compactCursors[1]
+		require.Equal(t, set, restoredSet)
+		require.Equal(t, cursor, restored)
+		again := compactor.PickCompaction(lsm, config)
+		require.NotNil(t, again)
+		require.Equal(t, job.SourceFiles[0], again.SourceFiles[0])
+	})
+
+	t.Run("simulation runs under every priority", func(t *testing.T) {
+		for _, priority := range []CompactionPriority{CompactionPriorityBySize, CompactionPriorityOldestLargestSeq,
+			CompactionPriorityOldestSmallestSeq, CompactionPriorityRoundRobin} {
+			config := config
+			config.CompactionPriority = priority
+			config.WriteRateMBps = 50
+			config.RandomSeed = 42
+			sim, err := NewSimulator(config)
+			require.NoError(t, err)
+			require.NoError(t, sim.Reset())
+			sim.StepUntil(600)
+			require.Greater(t, sim.metrics.TotalCompactionsCompleted, 0, "priority %q", priority)
+			checkFileInvariants(t, sim.lsm)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package simulator
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 )
 
 // CompactionStyle represents the compaction strategy
@@ -14,6 +15,7 @@ const (
 	CompactionStyleLeveled   CompactionStyle = iota // Leveled compaction (classic RocksDB style)
 	CompactionStyleUniversal                        // Universal compaction (space-efficient, lower write amp)
 	CompactionStyleFIFO                             // FIFO compaction (time-series optimized, delete old data)
+	CompactionStyleTiered                           // Size-tiered compaction (Cassandra/ScyllaDB STCS, merges similarly-sized files in place)
 )
 
 // TrafficModel represents the traffic distribution model
@@ -22,6 +24,7 @@ type TrafficModel int
 const (
 	TrafficModelConstant      TrafficModel = iota // Constant rate model
 	TrafficModelAdvancedONOFF                     // Advanced ON/OFF lognormal model with spikes
+	TrafficModelBurst                             // Fixed-rate bursts separated by idle periods
 )
 
 // String returns the string representation of TrafficModel
@@ -31,6 +34,8 @@ func (tm TrafficModel) String() string {
 		return "constant"
 	case TrafficModelAdvancedONOFF:
 		return "advanced"
+	case TrafficModelBurst:
+		return "burst"
 	default:
 		return "constant"
 	}
@@ -43,8 +48,10 @@ func ParseTrafficModel(s string) (TrafficModel, error) {
 		return TrafficModelConstant, nil
 	case "advanced":
 		return TrafficModelAdvancedONOFF, nil
+	case "burst":
+		return TrafficModelBurst, nil
 	default:
-		return TrafficModelConstant, fmt.Errorf("invalid traffic model: %s (must be 'constant' or 'advanced')", s)
+		return TrafficModelConstant, fmt.Errorf("invalid traffic model: %s (must be 'constant', 'advanced' or 'burst')", s)
 	}
 }
 
@@ -87,6 +94,11 @@ type TrafficDistributionConfig struct {
 	SpikeAmplitudeSigma float64 `json:"spikeAmplitudeSigma"` // Spike amplitude variance (log space)
 	CapacityLimitMB     float64 `json:"capacityLimitMB"`     // Capacity limit (0 = unlimited)
 	QueueMode           string  `json:"queueMode"`           // "drop" or "queue"
+
+	// Burst model parameters (bulk load, then idle)
+	BurstRateMBps    float64 `json:"burstRateMBps"`    // Write rate during a burst in MB/s
+	BurstDurationSec float64 `json:"burstDurationSec"` // Length of each burst
+	QuietDurationSec float64 `json:"quietDurationSec"` // Idle time between bursts (no writes)
 }
 
 // OverlapDistributionConfig holds overlap distribution parameters
@@ -134,9 +146,156 @@ type ReadWorkloadConfig struct {
 	ScanLatency          LatencySpec `json:"scanLatency"`          // Latency for range scans (default: lognormal, 10.0 ms)
 
 	// Request characteristics
-	AvgScanSizeKB float64 `json:"avgScanSizeKB"` // Average scan size in KB (default: 16 KB)
+	AvgScanSizeKB     float64 `json:"avgScanSizeKB"`     // Average scan size in KB (default: 16 KB)
+	AvgScanLengthKeys int     `json:"avgScanLengthKeys"` // Keys per range scan; enables the multi-level scan model: scans seek every sorted run (no bloom filter) and cost scales with length (0 = fixed-cost scans of avgScanSizeKB)
+
+	// Key access skew
+	ReadKeyDistribution ReadKeyDistribution `json:"readKeyDistribution"` // "uniform" (or ""): every key equally likely; "zipfian": recently written keys are read most
+	ZipfianTheta        float64             `json:"zipfianTheta"`        // Skew of the zipfian distribution, in (0, 1): higher is more skewed (0 = 0.99, the YCSB default)
+}
+
+// ReadKeyDistribution selects which keys point lookups read
+type ReadKeyDistribution string
+
+const (
+	ReadKeyDistributionUniform ReadKeyDistribution = "uniform" // Every key equally likely (empty = uniform)
+	ReadKeyDistributionZipfian ReadKeyDistribution = "zipfian" // Key popularity follows a zipfian distribution, hottest = most recently written
+)
+
+// defaultZipfianTheta is the YCSB zipfian constant, used when ZipfianTheta is 0
+const defaultZipfianTheta = 0.99
+
+// BackoffType represents how retry delays grow across repeated failures
+type BackoffType string
+
+const (
+	BackoffFixed       BackoffType = "fixed"       // Same delay before every retry
+	BackoffExponential BackoffType = "exponential" // Delay doubles per retry, capped at MaxSeconds
+)
+
+// RetryBackoffConfig specifies the delay before retrying a failed background job
+type RetryBackoffConfig struct {
+	Type        BackoffType `json:"type"`        // "fixed" or "exponential" (empty = fixed)
+	BaseSeconds float64     `json:"baseSeconds"` // Delay before the first retry
+	MaxSeconds  float64     `json:"maxSeconds"`  // Upper bound on the delay (exponential only)
+}
+
+// Delay returns the backoff before the given retry attempt (1 = first retry)
+func (b RetryBackoffConfig) Delay(attempt int) float64 {
+	if b.Type != BackoffExponential || attempt <= 1 {
+		return b.BaseSeconds
+	}
+	delay := b.BaseSeconds * math.Pow(2, float64(attempt-1))
+	return math.Min(delay, b.MaxSeconds)
+}
+
+// maxNumLevels is the deepest LSM tree the simulator supports
+const maxNumLevels = 10
+
+// maxColumnFamilies is how many column families the simulator supports besides the default one
+const maxColumnFamilies = 8
+
+// ColumnFamilyConfig describes a column family beyond the default one (which the top-level
+// settings describe). It has its own write stream, memtable and LSM tree, and shares the disk and
+// the background job pool with every other column family. Zero-valued settings are inherited
+// from the top-level config.
+//
+// FIDELITY: RocksDB Reference - ColumnFamilyOptions
+// https://github.com/facebook/rocksdb/wiki/Column-Families
+type ColumnFamilyConfig struct {
+	Name                   string  `json:"name"`                   // Unique, not "default" (an entry with no name is unused)
+	WriteRateMBps          float64 `json:"writeRateMBps"`          // Constant write rate into this column family
+	CompactionStyle        string  `json:"compactionStyle"`        // "leveled", "universal", "fifo" or "tiered" ("" = inherit)
+	MemtableFlushSizeMB    int     `json:"memtableFlushSizeMB"`    // write_buffer_size
+	L0CompactionTrigger    int     `json:"l0CompactionTrigger"`    // level0_file_num_compaction_trigger
+	MaxBytesForLevelBaseMB int     `json:"maxBytesForLevelBaseMB"` // max_bytes_for_level_base
+	LevelMultiplier        int     `json:"levelMultiplier"`        // max_bytes_for_level_multiplier
+	TargetFileSizeMB       int     `json:"targetFileSizeMB"`       // target_file_size_base
+}
+
+// apply returns base with this column family's settings in place of the inherited ones. Each
+// column family draws from its own random streams (index is its position in ColumnFamilies).
+func (cf ColumnFamilyConfig) apply(base SimConfig, index int) (SimConfig, error) {
+	config := base
+	config.ColumnFamilies = [maxColumnFamilies]ColumnFamilyConfig{}
+	config.WriteRateMBps = cf.WriteRateMBps
+	config.TrafficDistribution = TrafficDistributionConfig{Model: TrafficModelConstant, WriteRateMBps: cf.WriteRateMBps}
+	config.InitialLSMSizeMB = 0 // Column families start empty
+	if config.RandomSeed != 0 {
+		config.RandomSeed += int64(index+1) * 1000
+	}
+	if cf.CompactionStyle != "" {
+		style, err := ParseCompactionStyle(cf.CompactionStyle)
+		if err != nil {
+			return config, err
+		}
+		config.CompactionStyle = style
+	}
+	for _, override := range []struct {
+		value  int
+		target *int
+	}{
+		{cf.MemtableFlushSizeMB, &config.MemtableFlushSizeMB},
+		{cf.L0CompactionTrigger, &config.L0CompactionTrigger},
+		{cf.MaxBytesForLevelBaseMB, &config.MaxBytesForLevelBaseMB},
+		{cf.LevelMultiplier, &config.LevelMultiplier},
+		{cf.TargetFileSizeMB, &config.TargetFileSizeMB},
+	} {
+		if override.value != 0 {
+			*override.target = override.value
+		}
+	}
+	return config, nil
 }
 
+// columnFamilyConfigs returns the ColumnFamilies entries in use (those with a name)
+func (c *SimConfig) columnFamilyConfigs() []ColumnFamilyConfig {
+	var configs []ColumnFamilyConfig
+	for _, cf := range c.ColumnFamilies {
+		if cf.Name != "" {
+			configs = append(configs, cf)
+		}
+	}
+	return configs
+}
+
+// CompressionModel selects how the compression ratio varies across levels
+type CompressionModel string
+
+const (
+	CompressionModelUniform  CompressionModel = ""          // compressionFactor at every level
+	CompressionModelFixed    CompressionModel = "fixed"     // compressionFactorPerLevel[i] at level i
+	CompressionModelAgeBased CompressionModel = "age_based" // compressionFactor * compressionAgeDecay^level
+)
+
+// CompactionObjective selects which compaction the scheduler asks the compactor for
+type CompactionObjective string
+
+const (
+	CompactionObjectiveScore      CompactionObjective = ""             // Take the compactor's pick (score-driven, as RocksDB)
+	CompactionObjectiveMinReadAmp CompactionObjective = "min_read_amp" // Run due L0 compactions ahead of deeper levels
+)
+
+// CompactionPriority selects which files leveled compaction takes from the level it compacts
+// (compaction_pri)
+type CompactionPriority string
+
+const (
+	CompactionPriorityLevel             CompactionPriority = ""                    // Files in level order (earliest written first)
+	CompactionPriorityBySize            CompactionPriority = "by_size"             // kByCompensatedSize: largest files first
+	CompactionPriorityOldestLargestSeq  CompactionPriority = "oldest_largest_seq"  // kOldestLargestSeqFirst: files whose newest data is oldest first
+	CompactionPriorityOldestSmallestSeq CompactionPriority = "oldest_smallest_seq" // kOldestSmallestSeqFirst: files holding the oldest data first
+	CompactionPriorityRoundRobin        CompactionPriority = "round_robin"         // kRoundRobin: walk the key space from a per-level cursor
+)
+
+// TieBreak selects which level wins when several levels have the same compaction score
+type TieBreak string
+
+const (
+	TieBreakShallow TieBreak = "shallow" // Prefer the level closest to L0 (also used for "")
+	TieBreakDeep    TieBreak = "deep"    // Prefer the deepest level
+)
+
 // String returns the string representation of CompactionStyle
 func (cs CompactionStyle) String() string {
 	switch cs {
@@ -146,6 +305,8 @@ func (cs CompactionStyle) String() string {
 		return "universal"
 	case CompactionStyleFIFO:
 		return "fifo"
+	case CompactionStyleTiered:
+		return "tiered"
 	default:
 		return "unknown"
 	}
@@ -160,8 +321,10 @@ func ParseCompactionStyle(s string) (CompactionStyle, error) {
 		return CompactionStyleUniversal, nil
 	case "fifo":
 		return CompactionStyleFIFO, nil
+	case "tiered":
+		return CompactionStyleTiered, nil
 	default:
-		return CompactionStyleUniversal, fmt.Errorf("invalid compaction style: %s (must be 'leveled', 'universal', or 'fifo')", s)
+		return CompactionStyleUniversal, fmt.Errorf("invalid compaction style: %s (must be 'leveled', 'universal', 'fifo', or 'tiered')", s)
 	}
 }
 
@@ -192,16 +355,58 @@ type SimConfig struct {
 	MemtableFlushSizeMB  int     `json:"memtableFlushSizeMB"`  // write_buffer_size (default 64MB)
 	MaxWriteBufferNumber int     `json:"maxWriteBufferNumber"` // max_write_buffer_number (default 2)
 
+	// Write Slowdown (graduated memtable backpressure before the hard stall at maxWriteBufferNumber)
+	ImmutableMemtableSlowdownNumber int `json:"immutableMemtableSlowdownNumber"` // Immutable memtable count at which incoming writes start slowing, more steeply for each further memtable (0 = disabled, must be < maxWriteBufferNumber)
+
 	// Compaction Triggers
 	L0CompactionTrigger    int `json:"l0CompactionTrigger"`    // level0_file_num_compaction_trigger (default 4)
 	MaxBytesForLevelBaseMB int `json:"maxBytesForLevelBaseMB"` // Base level target size (default 256MB). In static mode, this is L1. In dynamic mode, this is the base_level (first non-empty level).
 	LevelMultiplier        int `json:"levelMultiplier"`        // max_bytes_for_level_multiplier (default 10)
 
+	// Compaction Picking
+	EqualScoreTieBreak TieBreak           `json:"equalScoreTieBreak"` // Which level leveled compaction picks when levels have equal scores: "shallow" (default) or "deep"
+	CompactionPriority CompactionPriority `json:"compactionPriority"` // compaction_pri: which files leveled compaction takes from an L1+ level: "" (level order), "by_size", "oldest_largest_seq", "oldest_smallest_seq" or "round_robin"
+
+	// L0 Organization
+	EnableL0SubLevels bool `json:"enableL0SubLevels"` // Organize L0 into sub-levels of non-overlapping files (from file age and key ranges); read-amp counts sub-levels instead of files, and leveled compaction scores L0 by them
+	DetailedL0State   bool `json:"detailedL0State"`   // State() adds a per-file L0 view (age, overlap, compaction status, sub-level) plus L0 thresholds, for teaching L0 dynamics
+
+	// File Age Control (for exercising age-based features such as FIFO TTL)
+	CreatedAtJitterSeconds float64 `json:"createdAtJitterSeconds"` // Flushed/ingested files are backdated by a uniform random 0..N seconds, spreading file ages (0 = CreatedAt is the creation time)
+
+	// Small File Consolidation (leveled only)
+	SmallFileMergeThresholdMB int `json:"smallFileMergeThresholdMB"` // Adjacent files below this size are merged proactively when no level needs compaction (0 = disabled)
+
 	// SST Files
 	TargetFileSizeMB         int     `json:"targetFileSizeMB"`         // target_file_size_base (default 64MB)
 	TargetFileSizeMultiplier int     `json:"targetFileSizeMultiplier"` // target_file_size_multiplier (default 1, but 2 makes sense for deeper levels)
 	DeduplicationFactor      float64 `json:"deduplicationFactor"`      // Logical size reduction from tombstones/overwrites (0.9 = 10% dedup, 1.0 = no dedup)
 	CompressionFactor        float64 `json:"compressionFactor"`        // Physical size reduction from compression (0.85 = ~18% with 4KB blocks, 0.7 = ~30% with larger blocks, 1.0 = no compression)
+	AvgKeyValueSizeBytes     int     `json:"avgKeyValueSizeBytes"`     // Average key+value size in bytes, used to translate MB into key counts that size bloom filter blocks (0 = key counts not modeled)
+	MinOutputFileSizeMB      int     `json:"minOutputFileSizeMB"`      // Cut compaction output at the target file size, merging a trailing file smaller than this into the previous file (0 = spread output evenly over files)
+
+	// Output Splitting
+	SplitOutputAtTargetBoundaries bool `json:"splitOutputAtTargetBoundaries"` // Cut compaction output at each overlapped target file's key boundaries as well as at targetFileSize, so merging into a populated level yields more (smaller) files
+
+	// Key-Range Overlap (leveled only)
+	UseKeyRangeOverlap bool `json:"useKeyRangeOverlap"` // Pick compaction target files by intersecting SST key ranges instead of sampling an overlap count from overlapDistribution
+
+	// Garbage Collection During Compaction
+	CompactionGarbageFraction float64 `json:"compactionGarbageFraction"` // Fraction of compaction input that is deleted/expired data (tombstones, shadowed versions, TTL-expired entries): read and processed, then dropped from output (0 = only deduplicationFactor applies)
+
+	// Compaction Output Reduction (leveled and universal)
+	// Overwrite-heavy workloads merge away far more than 10% of L0→base-level input, because
+	// recent flushes repeat the same hot keys; deeper levels rarely overlap
+	L0ReductionFactor     float64               `json:"l0ReductionFactor"`     // Output/input size ratio of compactions out of L0 (0 = deduplicationFactor)
+	DeepReductionFactor   float64               `json:"deepReductionFactor"`   // Output/input size ratio of compactions out of L1+ (0 = 0.99)
+	LevelReductionFactors [maxNumLevels]float64 `json:"levelReductionFactors"` // Output/input size ratio of compactions out of each level, overriding the two above (0 = use them)
+
+	// Per-Level Compression
+	// Older data in deeper levels is more redundant (more versions of similar values, better
+	// dictionary hits), so bottom levels typically compress better than L0
+	CompressionModel          CompressionModel      `json:"compressionModel"`          // "" (uniform compressionFactor), "fixed" (per-level array) or "age_based"
+	CompressionFactorPerLevel [maxNumLevels]float64 `json:"compressionFactorPerLevel"` // fixed model: compression factor at each level (0 = use compressionFactor)
+	CompressionAgeDecay       float64               `json:"compressionAgeDecay"`       // age_based model: factor multiplier per level below L0 (0.95 = each level 5% denser)
 
 	// Compression CPU Performance
 	// RocksDB uses compression algorithms like LZ4, Snappy, or Zstd which consume CPU cycles
@@ -211,6 +416,13 @@ type SimConfig struct {
 	DecompressionThroughputMBps float64 `json:"decompressionThroughputMBps"` // CPU throughput for decompression (MB/s), used for read path modeling
 	BlockSizeKB                 int     `json:"blockSizeKB"`                 // SST block size in KB (RocksDB default: 4 KB) - affects compression efficiency and read amplification
 
+	// Bloom Filters (point lookups skip sorted runs whose filter rules the key out)
+	BloomFilterBitsPerKey int `json:"bloomFilterBitsPerKey"` // Filter bits per key, e.g. 10 for ~1% false positives (0 = no filters, every sorted run is probed)
+
+	// SST Metadata (index and filter blocks are rewritten with every SST; filter blocks are sized from
+	// the key count when both BloomFilterBitsPerKey and AvgKeyValueSizeBytes are set)
+	MetadataOverheadPercent float64 `json:"metadataOverheadPercent"` // Extra % of data size each flushed SST carries as index blocks, e.g. 1-2 (0 = not modeled)
+
 	// SSTable Build CPU Performance (Write Path)
 	// Building an SSTable during flush/compaction involves:
 	//   1. Formatting data blocks (key/value encoding)
@@ -228,33 +440,92 @@ type SimConfig struct {
 
 	// Compaction Parallelism & Performance
 	MaxBackgroundJobs                int             `json:"maxBackgroundJobs"`                // max_background_jobs (default 2) - parallel compactions
+	MaxBackgroundFlushes             int             `json:"maxBackgroundFlushes"`             // max_background_flushes - dedicated flush workers, so immutable memtables flush concurrently without waiting on compaction slots (0 = flushes share maxBackgroundJobs)
 	MaxSubcompactions                int             `json:"maxSubcompactions"`                // max_subcompactions (default 1) - intra-compaction parallelism
+	UrgentL0CompactionTrigger        int             `json:"urgentL0CompactionTrigger"`        // L0 file count at which an L0 compaction waits for the next free slot instead of being deferred when all slots are busy (0 = disabled)
 	MaxCompactionBytesMB             int             `json:"maxCompactionBytesMB"`             // max_compaction_bytes - max total input size for single compaction (0 = auto: 25x target_file_size_base, per db/column_family.cc)
+	MaxActiveCompactionBytesMB       int             `json:"maxActiveCompactionBytesMB"`       // Max total input size across all running compactions; no new compaction starts at or above it (0 = unlimited)
+	CompactionSetupLatencyMs         float64         `json:"compactionSetupLatencyMs"`         // Fixed per-compaction overhead added to every job regardless of size (version/metadata work, iterator and table-builder setup)
+	CompactionMBPerCPUSec            int             `json:"compactionMBPerCPUSec"`            // Compaction input one CPU thread merges and compresses per second; a job lasts at least input / (this x its threads), so on a fast disk compaction turns CPU-bound (0 = CPU never limits the merge)
+	NumCompactionThreads             int             `json:"numCompactionThreads"`             // CPU threads running compactions share for that merge work (0 = one per background job)
 	IOLatencyMs                      float64         `json:"ioLatencyMs"`                      // Disk IO latency in milliseconds (seek time)
-	IOThroughputMBps                 float64         `json:"ioThroughputMBps"`                 // Sequential I/O throughput in MB/s (for compaction duration)
+	IOThroughputMBps                 float64         `json:"ioThroughputMBps"`                 // Sequential write throughput in MB/s (flush, compaction output, WAL, ingest); also the read throughput unless ioReadThroughputMBps is set
+	IOReadThroughputMBps             float64         `json:"ioReadThroughputMBps"`             // Sequential read throughput in MB/s (compaction input, read workload), for devices with asymmetric read/write bandwidth (0 = same as ioThroughputMBps)
+	TierBoundaryLevel                int             `json:"tierBoundaryLevel"`                // First level stored on a slower cold tier: compaction, ingest and read I/O on it and deeper levels moves at coldIOThroughputMBps (0 or >= numLevels = single tier)
+	ColdIOThroughputMBps             int             `json:"coldIOThroughputMBps"`             // Cold tier read and write throughput in MB/s (0 = same as ioThroughputMBps)
+	DiskIOPS                         float64         `json:"diskIOPS"`                         // Disk operations per second; flush, compaction, WAL, ingest and read workload I/O takes whichever is longer of its bytes at IOThroughputMBps or its operations at DiskIOPS (0 = bandwidth-limited only)
+	WarmCompactionReads              bool            `json:"warmCompactionReads"`              // Compaction input already in the block cache skips disk reads (cached fraction estimated from read workload cache hit rate)
+	BackupBandwidthMBps              float64         `json:"backupBandwidthMBps"`              // Link to a backup/replica target that every compaction's output is shipped over; a compaction completes only once its output is shipped (0 = no backup)
+	BottommostCompactionIOPriority   float64         `json:"bottommostCompactionIOPriority"`   // Fraction of disk bandwidth given to compactions into the deepest level, which run longer so shallower jobs aren't starved (0 or 1 = full bandwidth)
+	CompactionRateLimitMBps          int             `json:"compactionRateLimitMBps"`          // rate_limiter bytes_per_sec for compaction I/O only: a budget shared by all compactions that delays their start once spent; flushes and the WAL keep the full IOThroughputMBps (0 = unlimited)
 	NumLevels                        int             `json:"numLevels"`                        // LSM tree depth (default 7)
 	LevelCompactionDynamicLevelBytes bool            `json:"levelCompactionDynamicLevelBytes"` // level_compaction_dynamic_level_bytes (default true) - ONLY applies to leveled compaction, ignored for universal compaction. When true, dynamically adjusts level sizes based on actual data distribution.
 	CompactionStyle                  CompactionStyle `json:"compactionStyle"`                  // compaction_style: "leveled" or "universal" (default "universal")
 
+	// Compactions whose picked target files are already inputs of a running compaction
+	RepickBusyTargetFiles bool `json:"repickBusyTargetFiles"` // false (default): defer the new compaction until the running one releases the files; true: swap the busy target files for free files of the same level (still defers if there aren't enough, or when targets are exact key-range overlaps)
+
+	// How the scheduler chooses among candidate compactions
+	CompactionObjective CompactionObjective `json:"compactionObjective"` // "" (default): the compactor's score-driven pick; "min_read_amp": leveled only, a due L0 compaction runs ahead of higher-scoring deeper levels
+
+	// Column families beyond the default one, sharing the disk, background job pool and write stalls (fixed-size so
+	// SimConfig stays comparable; entries with no name are unused, so all-empty means a single column family)
+	ColumnFamilies [maxColumnFamilies]ColumnFamilyConfig `json:"columnFamilies"`
+
 	// Universal Compaction Options
-	MaxSizeAmplificationPercent int `json:"maxSizeAmplificationPercent"` // max_size_amplification_percent (default 200%, RocksDB allows 0 to UINT_MAX) - max allowed space amplification before compaction triggers. 0 = trigger on any amplification, very high values (e.g., 9000) allow extreme amplification before triggering
+	MaxSizeAmplificationPercent int  `json:"maxSizeAmplificationPercent"` // max_size_amplification_percent (default 200%, RocksDB allows 0 to UINT_MAX) - max allowed space amplification before compaction triggers. 0 = trigger on any amplification, very high values (e.g., 9000) allow extreme amplification before triggering
+	UniversalIncrementalMode    bool `json:"universalIncrementalMode"`    // incremental (default false) - compact a window of each picked level's files per job (bounded by max_compaction_bytes) instead of whole sorted runs: more, smaller compactions with lower transient space usage
+
+	// Universal Age-Based Size Amplification (old data in the base sorted run lowers the trigger)
+	UniversalAgeBasedTrigger     bool    `json:"universalAgeBasedTrigger"`     // Divide maxSizeAmplificationPercent by (1 + base run age / universalAgeThresholdSeconds), so a long-unrewritten base run is compacted sooner
+	UniversalAgeThresholdSeconds float64 `json:"universalAgeThresholdSeconds"` // Base run age at which the size-amplification trigger is halved (must be > 0 when universalAgeBasedTrigger is set)
 
 	// FIFO Compaction Options
 	// RocksDB Reference: https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker_fifo.cc
 	FIFOMaxTableFilesSizeMB int  `json:"fifoMaxTableFilesSizeMB"` // max_table_files_size (default 1024 MB = 1 GB) - total size threshold for deletion
 	FIFOAllowCompaction     bool `json:"fifoAllowCompaction"`     // allow_compaction (default false) - enable intra-L0 compaction to merge small files
+	FIFOTTLSeconds          int  `json:"fifoTTLSeconds"`          // ttl (default 0 = disabled) - delete files older than this many seconds
+
+	// Size-Tiered Compaction Options
+	// Cassandra Reference: https://cassandra.apache.org/doc/latest/cassandra/managing/operating/compaction/stcs.html
+	TieredMinThreshold int     `json:"tieredMinThreshold"` // min_threshold (default 4) - similarly-sized files a bucket must hold before it is compacted
+	TieredBucketLow    float64 `json:"tieredBucketLow"`    // bucket_low (default 0.5) - a file joins a bucket if it is at least this fraction of the bucket's average size
+	TieredBucketHigh   float64 `json:"tieredBucketHigh"`   // bucket_high (default 1.5) - ...and at most this multiple of it
+
+	// Compaction Failures (transient I/O errors retried after a backoff)
+	CompactionFailureRate  float64            `json:"compactionFailureRate"`  // Probability a compaction fails when it finishes and must be redone (0 = never fails)
+	CompactionRetryBackoff RetryBackoffConfig `json:"compactionRetryBackoff"` // Delay before re-running a failed compaction
+
+	// Recompaction Tracking (compaction work wasted by rewriting data another compaction just wrote)
+	RecompactionWindowSeconds float64 `json:"recompactionWindowSeconds"` // Compaction input written by an earlier compaction less than this long ago counts toward recompactionBytes (0 = disabled)
 
 	// Simulation Control
-	InitialLSMSizeMB          int   `json:"initialLSMSizeMB"`          // Pre-populate LSM with this much data (0 = start empty, useful for skipping warmup)
-	SimulationSpeedMultiplier int   `json:"simulationSpeedMultiplier"` // Process N events per step (1 = real-time feel, 10 = 10x faster)
-	RandomSeed                int64 `json:"randomSeed"`                // Random seed for reproducibility (0 = use time-based seed)
-	MaxStalledWriteMemoryMB   int   `json:"maxStalledWriteMemoryMB"`   // OOM threshold: stop simulation if stalled write backlog exceeds this (default 4096 MB = 4GB)
+	InitialLSMSizeMB          int     `json:"initialLSMSizeMB"`          // Pre-populate LSM with this much data (0 = start empty, useful for skipping warmup)
+	SimulationSpeedMultiplier int     `json:"simulationSpeedMultiplier"` // Process N events per step (1 = real-time feel, 10 = 10x faster)
+	BaseStepSeconds           float64 `json:"baseStepSeconds"`           // Virtual seconds advanced per Step iteration (0 = default 1.0; smaller = finer event resolution, larger = faster runs)
+	RecentWritesWindowSeconds float64 `json:"recentWritesWindowSeconds"` // History kept for throughput/disk-utilization estimates and the span their moving average covers (default 5; shorter = responsive but noisy, longer = smooth but laggy; 0 = default)
+	MaxHistorySamples         int     `json:"maxHistorySamples"`         // Step samples kept in levelSizeHistory for charting; older samples are dropped (default 600; 0 = no history)
+	RandomSeed                int64   `json:"randomSeed"`                // Random seed for reproducibility (0 = draw one per run, see Simulator.Seed)
+	OverlapSeed               int64   `json:"overlapSeed"`               // Separate seed for overlap sampling, so overlaps can vary while file selection stays fixed (0 = derive from randomSeed)
+	MaxStalledWriteMemoryMB   int     `json:"maxStalledWriteMemoryMB"`   // OOM threshold: stop simulation if stalled write backlog exceeds this (default 4096 MB = 4GB)
+	OOMWarningSeconds         float64 `json:"oomWarningSeconds"`         // Warn (oomRisk) when the growing stalled write backlog is projected to hit maxStalledWriteMemoryMB within this many seconds (default 30; 0 = disabled)
+
+	// Adaptive stepping: quiescent stretches run in coarse iterations, doubling up to this length, and
+	// drop back to baseStepSeconds as soon as a stall, slowdown, OOM risk or compaction burst approaches
+	AdaptiveStepMaxSeconds float64 `json:"adaptiveStepMaxSeconds"` // Longest Step iteration (0 = disabled: every iteration is baseStepSeconds; otherwise must be >= baseStepSeconds)
 
 	// WAL (Write-Ahead Log) Configuration
 	// RocksDB Reference: https://github.com/facebook/rocksdb/wiki/Write-Ahead-Log
-	EnableWAL        bool    `json:"enableWAL"`        // Enable Write-Ahead Log (default true, matches RocksDB)
-	WALSync          bool    `json:"walSync"`          // Sync WAL after each write (default false, matches RocksDB WriteOptions::sync)
-	WALSyncLatencyMs float64 `json:"walSyncLatencyMs"` // fsync() latency in milliseconds (default 1.5ms for NVMe/SSD)
+	EnableWAL                bool    `json:"enableWAL"`                // Enable Write-Ahead Log (default true, matches RocksDB)
+	WALSync                  bool    `json:"walSync"`                  // Sync WAL after each write (default false, matches RocksDB WriteOptions::sync)
+	WALSyncLatencyMs         float64 `json:"walSyncLatencyMs"`         // fsync() latency in milliseconds (default 1.5ms for NVMe/SSD)
+	WALGroupCommitIntervalMs int     `json:"walGroupCommitIntervalMs"` // Group commit: writes arriving within this window share one WAL write and one sync (0 = each write is written and synced on its own)
+
+	// Ingestion (read-only replica / bulk load)
+	// RocksDB Reference: https://github.com/facebook/rocksdb/wiki/Creating-and-Ingesting-SST-files
+	IngestionMode    bool `json:"ingestionMode"`    // Deliver write traffic as ingested SST files instead of memtable writes (bypasses WAL and memtable)
+	IngestFileSizeMB int  `json:"ingestFileSizeMB"` // Size of each ingested file in ingestion mode (0 = use targetFileSizeMB)
+	IngestBehind     bool `json:"ingestBehind"`     // ingest_behind: ingestion-mode files have non-overlapping key ranges and land in the bottommost level, skipping compaction
 
 	// Traffic Distribution
 	TrafficDistribution TrafficDistributionConfig `json:"trafficDistribution"` // Traffic distribution configuration
@@ -264,6 +535,10 @@ type SimConfig struct {
 
 	// Read Path Modeling
 	ReadWorkload *ReadWorkloadConfig `json:"readWorkload,omitempty"` // Read workload configuration (nil = disabled)
+
+	// Block Cache After Restart (see Simulator.Restart)
+	CachePersistedFraction float64 `json:"cachePersistedFraction"` // Fraction of the block cache still warm after a restart, e.g. from a persistent/secondary cache (0 = fully cold, 1 = no cold-start penalty)
+	CacheWarmupSeconds     float64 `json:"cacheWarmupSeconds"`     // Time constant over which reads re-warm the cache: the cold fraction shrinks by ~63% every this many seconds (0 = default 300s)
 }
 
 // DefaultConfig returns sensible defaults based on RocksDB documentation
@@ -272,35 +547,95 @@ func DefaultConfig() SimConfig {
 		WriteRateMBps:                    10.0,                     // 10 MB/s write rate (deprecated, use TrafficDistribution)
 		MemtableFlushSizeMB:              64,                       // 64MB memtable (RocksDB default)
 		MaxWriteBufferNumber:             2,                        // 2 memtables max (RocksDB default)
+		ImmutableMemtableSlowdownNumber:  0,                        // No slowdown before the stall
 		L0CompactionTrigger:              4,                        // 4 L0 files trigger compaction (RocksDB default)
+		EnableL0SubLevels:                false,                    // Every L0 file is checked on reads and scored
+		DetailedL0State:                  false,                    // Standard per-level State() payload only
+		CreatedAtJitterSeconds:           0,                        // Files are stamped with their actual creation time
+		SmallFileMergeThresholdMB:        0,                        // No proactive small-file merging
 		MaxBytesForLevelBaseMB:           256,                      // 256MB L1 target (RocksDB default)
 		LevelMultiplier:                  10,                       // 10x multiplier (RocksDB default)
+		EqualScoreTieBreak:               TieBreakShallow,          // Equal scores resolve toward L0
+		CompactionPriority:               CompactionPriorityLevel,  // Files in level order
 		TargetFileSizeMB:                 64,                       // 64MB SST files (RocksDB default)
 		TargetFileSizeMultiplier:         2,                        // 2x multiplier per level (L1=64MB, L2=128MB, L3=256MB, etc.)
 		DeduplicationFactor:              0.9,                      // 10% logical reduction (tombstones, overwrites)
 		CompressionFactor:                0.85,                     // 15% physical reduction with 4KB blocks (LZ4/Snappy), more realistic than 0.7
+		AvgKeyValueSizeBytes:             1024,                     // 1 KB average key-value pair
+		MinOutputFileSizeMB:              0,                        // Even split across output files
+		SplitOutputAtTargetBoundaries:    false,                    // Output split by size only
+		UseKeyRangeOverlap:               false,                    // Overlaps sampled from overlapDistribution
+		CompactionGarbageFraction:        0,                        // No garbage beyond deduplicationFactor
+		L0ReductionFactor:                0,                        // deduplicationFactor out of L0
+		DeepReductionFactor:              0,                        // 0.99 out of L1+
+		CompressionModel:                 CompressionModelUniform,  // Same compressionFactor at every level
+		CompressionAgeDecay:              0.95,                     // age_based: each level 5% denser than the one above
 		CompressionThroughputMBps:        750,                      // LZ4 compression speed (single-threaded, from benchmarks) - UNUSED for writes
 		DecompressionThroughputMBps:      3700,                     // LZ4 decompression speed (single-threaded, from benchmarks)
 		BlockSizeKB:                      4,                        // 4 KB block size (RocksDB default, verified in source)
+		BloomFilterBitsPerKey:            0,                        // No filter_policy (RocksDB default)
+		MetadataOverheadPercent:          0,                        // Metadata blocks not modeled (sizes are data only)
 		SSTableBuildThroughputMBps:       75,                       // 75 MB/s SSTable build (includes compression, bloom, index)
 		MaxBackgroundJobs:                2,                        // 2 parallel compactions (RocksDB default)
+		MaxBackgroundFlushes:             0,                        // Flushes share maxBackgroundJobs
 		MaxSubcompactions:                1,                        // No intra-compaction parallelism (RocksDB default)
+		UrgentL0CompactionTrigger:        0,                        // L0 compactions wait for a regular scheduling pass
+		MaxActiveCompactionBytesMB:       0,                        // Only the job-count limit applies
 		MaxCompactionBytesMB:             1600,                     // 25x target_file_size_base (RocksDB typical default)
+		CompactionSetupLatencyMs:         0,                        // No fixed per-compaction overhead
+		CompactionMBPerCPUSec:            0,                        // Merge is never CPU-bound
+		NumCompactionThreads:             0,                        // One CPU thread per background job
 		IOLatencyMs:                      1.0,                      // 1ms latency (EBS gp3 baseline)
 		IOThroughputMBps:                 125.0,                    // 125 MB/s throughput (EBS gp3 baseline)
+		IOReadThroughputMBps:             0,                        // Reads share the 125 MB/s figure (gp3 throughput is symmetric)
+		TierBoundaryLevel:                0,                        // Single tier: every level on the same disk
+		ColdIOThroughputMBps:             0,                        // No cold tier
+		DiskIOPS:                         0,                        // Bandwidth-limited only (EBS gp3 baseline would be 3000)
+		CompactionRateLimitMBps:          0,                        // No rate_limiter (RocksDB default)
+		WarmCompactionReads:              false,                    // Compaction always reads input from disk
+		BackupBandwidthMBps:              0,                        // No backup shipping
+		BottommostCompactionIOPriority:   0,                        // Bottommost compactions use full bandwidth
 		NumLevels:                        7,                        // 7 levels (RocksDB default)
 		LevelCompactionDynamicLevelBytes: true,                     // true matches RocksDB default (v8.2+)
 		CompactionStyle:                  CompactionStyleUniversal, // Universal compaction (default as per user request)
 		MaxSizeAmplificationPercent:      200,                      // 200% max size amplification (RocksDB default)
+		UniversalIncrementalMode:         false,                    // Compact whole sorted runs (RocksDB default)
+		UniversalAgeBasedTrigger:         false,                    // Pure size-ratio amplification trigger (RocksDB behavior)
+		UniversalAgeThresholdSeconds:     3600,                     // 1 hour when the age-based trigger is enabled
+		RepickBusyTargetFiles:            false,                    // Defer compactions whose target files are busy (RocksDB behavior)
+		CompactionObjective:              CompactionObjectiveScore, // Score-driven picks (RocksDB behavior)
+		CompactionFailureRate:            0,                        // Compactions never fail
+		RecompactionWindowSeconds:        0,                        // Recompaction tracking disabled
+		CachePersistedFraction:           0,                        // Restart empties the block cache (RocksDB default: no persistent cache)
+		CacheWarmupSeconds:               300,                      // Cold cache mostly re-warmed within ~15 minutes
 		FIFOMaxTableFilesSizeMB:          1024,                     // 1024 MB = 1 GB (RocksDB default)
 		FIFOAllowCompaction:              false,                    // false = no intra-L0 compaction (RocksDB default)
+		FIFOTTLSeconds:                   0,                        // 0 = no TTL expiry (only size-based deletion)
+		TieredMinThreshold:               4,                        // Cassandra STCS default
+		TieredBucketLow:                  0.5,                      // Cassandra STCS default
+		TieredBucketHigh:                 1.5,                      // Cassandra STCS default
 		InitialLSMSizeMB:                 0,                        // 0 = start empty
 		SimulationSpeedMultiplier:        1,                        // 1 = process 1 event per step (real-time feel)
+		BaseStepSeconds:                  1.0,                      // 1 second of virtual time per Step iteration
+		RecentWritesWindowSeconds:        5.0,                      // Throughput averaged over ~5 seconds
+		MaxHistorySamples:                600,                      // 10 minutes of level sizes at 1s steps
 		RandomSeed:                       0,                        // 0 = use time-based seed
+		OverlapSeed:                      0,                        // 0 = overlap sampling follows randomSeed
 		MaxStalledWriteMemoryMB:          4096,                     // 4GB OOM threshold (reasonable default for simulator)
+		OOMWarningSeconds:                30,                       // Heads-up 30s before a projected OOM kill
+		AdaptiveStepMaxSeconds:           0,                        // Fixed baseStepSeconds iterations
 		EnableWAL:                        true,                     // WAL enabled (RocksDB default)
 		WALSync:                          false,                    // Sync after each write (RocksDB WriteOptions::sync default: false)
 		WALSyncLatencyMs:                 1.5,                      // 1.5ms fsync latency (typical NVMe/SSD)
+		WALGroupCommitIntervalMs:         0,                        // Each write synced on its own
+		IngestionMode:                    false,                    // Normal write path (WAL + memtable)
+		IngestFileSizeMB:                 0,                        // 0 = use targetFileSizeMB
+		IngestBehind:                     false,                    // Ingested files land in L0
+		CompactionRetryBackoff: RetryBackoffConfig{
+			Type:        BackoffExponential,
+			BaseSeconds: 1,  // 1s before the first retry
+			MaxSeconds:  60, // Never wait more than a minute
+		},
 		TrafficDistribution: TrafficDistributionConfig{
 			Model:         TrafficModelConstant,
 			WriteRateMBps: 10.0,
@@ -343,7 +678,11 @@ func DefaultReadWorkload() ReadWorkloadConfig {
 			Distribution: LatencyDistLognormal,
 			Mean:         10.0, // 10ms mean for scans
 		},
-		AvgScanSizeKB: 16.0, // 16 KB average scan size
+		AvgScanSizeKB:     16.0, // 16 KB average scan size
+		AvgScanLengthKeys: 0,    // Fixed-cost scans (multi-level scan model off)
+
+		ReadKeyDistribution: ReadKeyDistributionUniform, // No key skew
+		ZipfianTheta:        defaultZipfianTheta,        // YCSB default skew (used when zipfian)
 	}
 }
 
@@ -354,31 +693,90 @@ func ThreeLevelConfig() SimConfig {
 		WriteRateMBps:                    10.0,                     // 10 MB/s write rate (deprecated, use TrafficDistribution)
 		MemtableFlushSizeMB:              64,                       // 64MB memtable
 		MaxWriteBufferNumber:             2,                        // 2 memtables max
+		ImmutableMemtableSlowdownNumber:  0,                        // No slowdown before the stall
 		L0CompactionTrigger:              4,                        // 4 L0 files trigger compaction
+		EnableL0SubLevels:                false,                    // Every L0 file is checked on reads and scored
+		DetailedL0State:                  false,                    // Standard per-level State() payload only
+		CreatedAtJitterSeconds:           0,                        // Files are stamped with their actual creation time
+		SmallFileMergeThresholdMB:        0,                        // No proactive small-file merging
 		MaxBytesForLevelBaseMB:           256,                      // 256MB L1 target
 		LevelMultiplier:                  10,                       // 10x multiplier (but only 3 levels total)
+		EqualScoreTieBreak:               TieBreakShallow,          // Equal scores resolve toward L0
+		CompactionPriority:               CompactionPriorityLevel,  // Files in level order
 		TargetFileSizeMB:                 64,                       // 64MB SST files
 		TargetFileSizeMultiplier:         2,                        // 2x multiplier per level
 		DeduplicationFactor:              0.9,                      // 10% logical reduction
 		CompressionFactor:                0.85,                     // 15% physical reduction with 4KB blocks (LZ4/Snappy)
+		AvgKeyValueSizeBytes:             1024,                     // 1 KB average key-value pair
+		MinOutputFileSizeMB:              0,                        // Even split across output files
+		SplitOutputAtTargetBoundaries:    false,                    // Output split by size only
+		UseKeyRangeOverlap:               false,                    // Overlaps sampled from overlapDistribution
+		CompactionGarbageFraction:        0,                        // No garbage beyond deduplicationFactor
+		L0ReductionFactor:                0,                        // deduplicationFactor out of L0
+		DeepReductionFactor:              0,                        // 0.99 out of L1+
+		CompressionModel:                 CompressionModelUniform,  // Same compressionFactor at every level
+		CompressionAgeDecay:              0.95,                     // age_based: each level 5% denser than the one above
 		CompressionThroughputMBps:        750,                      // LZ4 compression speed
 		DecompressionThroughputMBps:      3700,                     // LZ4 decompression speed
 		BlockSizeKB:                      4,                        // 4 KB block size (RocksDB default)
+		BloomFilterBitsPerKey:            0,                        // No filter_policy (RocksDB default)
+		MetadataOverheadPercent:          0,                        // Metadata blocks not modeled (sizes are data only)
 		MaxBackgroundJobs:                2,                        // 2 parallel compactions
+		MaxBackgroundFlushes:             0,                        // Flushes share maxBackgroundJobs
 		MaxSubcompactions:                1,                        // No intra-compaction parallelism
+		UrgentL0CompactionTrigger:        0,                        // L0 compactions wait for a regular scheduling pass
+		MaxActiveCompactionBytesMB:       0,                        // Only the job-count limit applies
+		CompactionSetupLatencyMs:         0,                        // No fixed per-compaction overhead
+		CompactionMBPerCPUSec:            0,                        // Merge is never CPU-bound
+		NumCompactionThreads:             0,                        // One CPU thread per background job
 		IOLatencyMs:                      5.0,                      // 5ms seek time
 		IOThroughputMBps:                 500.0,                    // 500 MB/s throughput
+		IOReadThroughputMBps:             0,                        // Same for reads
+		TierBoundaryLevel:                0,                        // Single tier
+		ColdIOThroughputMBps:             0,                        // No cold tier
+		DiskIOPS:                         0,                        // Bandwidth-limited only
+		CompactionRateLimitMBps:          0,                        // No rate_limiter (RocksDB default)
+		WarmCompactionReads:              false,                    // Compaction always reads input from disk
+		BackupBandwidthMBps:              0,                        // No backup shipping
+		BottommostCompactionIOPriority:   0,                        // Bottommost compactions use full bandwidth
 		NumLevels:                        3,                        // Only 3 levels: Memtable, L0, L1
 		LevelCompactionDynamicLevelBytes: true,                     // true matches RocksDB default (v8.2+)
 		CompactionStyle:                  CompactionStyleUniversal, // Default to universal
 		MaxSizeAmplificationPercent:      200,                      // 200% max size amplification (RocksDB default)
+		UniversalIncrementalMode:         false,                    // Compact whole sorted runs (RocksDB default)
+		UniversalAgeBasedTrigger:         false,                    // Pure size-ratio amplification trigger (RocksDB behavior)
+		UniversalAgeThresholdSeconds:     3600,                     // 1 hour when the age-based trigger is enabled
+		RepickBusyTargetFiles:            false,                    // Defer compactions whose target files are busy (RocksDB behavior)
+		CompactionObjective:              CompactionObjectiveScore, // Score-driven picks (RocksDB behavior)
+		CompactionFailureRate:            0,                        // Compactions never fail
+		RecompactionWindowSeconds:        0,                        // Recompaction tracking disabled
+		CachePersistedFraction:           0,                        // Restart empties the block cache (RocksDB default: no persistent cache)
+		CacheWarmupSeconds:               300,                      // Cold cache mostly re-warmed within ~15 minutes
+		TieredMinThreshold:               4,                        // Cassandra STCS default
+		TieredBucketLow:                  0.5,                      // Cassandra STCS default
+		TieredBucketHigh:                 1.5,                      // Cassandra STCS default
 		InitialLSMSizeMB:                 0,                        // 0 = start empty
 		SimulationSpeedMultiplier:        1,                        // 1 = process 1 event per step
+		BaseStepSeconds:                  1.0,                      // 1 second of virtual time per Step iteration
+		RecentWritesWindowSeconds:        5.0,                      // Throughput averaged over ~5 seconds
+		MaxHistorySamples:                600,                      // 10 minutes of level sizes at 1s steps
 		RandomSeed:                       0,                        // 0 = use time-based seed
+		OverlapSeed:                      0,                        // 0 = overlap sampling follows randomSeed
 		MaxStalledWriteMemoryMB:          4096,                     // 4GB OOM threshold (reasonable default for simulator)
+		OOMWarningSeconds:                30,                       // Heads-up 30s before a projected OOM kill
+		AdaptiveStepMaxSeconds:           0,                        // Fixed baseStepSeconds iterations
 		EnableWAL:                        true,                     // WAL enabled (RocksDB default)
 		WALSync:                          false,                    // Sync after each write (RocksDB WriteOptions::sync default: false)
 		WALSyncLatencyMs:                 1.5,                      // 1.5ms fsync latency (typical NVMe/SSD)
+		WALGroupCommitIntervalMs:         0,                        // Each write synced on its own
+		IngestionMode:                    false,                    // Normal write path (WAL + memtable)
+		IngestFileSizeMB:                 0,                        // 0 = use targetFileSizeMB
+		IngestBehind:                     false,                    // Ingested files land in L0
+		CompactionRetryBackoff: RetryBackoffConfig{
+			Type:        BackoffExponential,
+			BaseSeconds: 1,  // 1s before the first retry
+			MaxSeconds:  60, // Never wait more than a minute
+		},
 		TrafficDistribution: TrafficDistributionConfig{
 			Model:         TrafficModelConstant,
 			WriteRateMBps: 10.0,
@@ -396,12 +794,26 @@ func (c *SimConfig) Validate() error {
 	if c.WriteRateMBps < 0 {
 		return ErrInvalidConfig("writeRateMBps must be >= 0")
 	}
+	if traffic := c.TrafficDistribution; traffic.Model == TrafficModelBurst {
+		if traffic.BurstRateMBps < 0 {
+			return ErrInvalidConfig("trafficDistribution.burstRateMBps must be >= 0")
+		}
+		if traffic.BurstDurationSec <= 0 {
+			return ErrInvalidConfig("trafficDistribution.burstDurationSec must be > 0")
+		}
+		if traffic.QuietDurationSec < 0 {
+			return ErrInvalidConfig("trafficDistribution.quietDurationSec must be >= 0")
+		}
+	}
 	if c.MemtableFlushSizeMB <= 0 {
 		return ErrInvalidConfig("memtableFlushSizeMB must be > 0")
 	}
 	if c.MaxWriteBufferNumber < 1 {
 		return ErrInvalidConfig("maxWriteBufferNumber must be >= 1")
 	}
+	if c.ImmutableMemtableSlowdownNumber < 0 || (c.ImmutableMemtableSlowdownNumber > 0 && c.ImmutableMemtableSlowdownNumber >= c.MaxWriteBufferNumber) {
+		return ErrInvalidConfig("immutableMemtableSlowdownNumber must be >= 0 and < maxWriteBufferNumber (0 = disabled)")
+	}
 	if c.L0CompactionTrigger < 2 {
 		return ErrInvalidConfig("l0CompactionTrigger must be >= 2")
 	}
@@ -411,6 +823,66 @@ func (c *SimConfig) Validate() error {
 	if c.CompressionFactor < 0.1 || c.CompressionFactor > 1.0 {
 		return ErrInvalidConfig("compressionFactor must be between 0.1 and 1.0")
 	}
+	if c.CompactionGarbageFraction < 0 || c.CompactionGarbageFraction >= 1.0 {
+		return ErrInvalidConfig("compactionGarbageFraction must be >= 0 and < 1.0")
+	}
+	switch c.EqualScoreTieBreak {
+	case "", TieBreakShallow, TieBreakDeep:
+	default:
+		return ErrInvalidConfig("equalScoreTieBreak must be \"shallow\" or \"deep\"")
+	}
+	switch c.CompactionPriority {
+	case CompactionPriorityLevel, CompactionPriorityBySize, CompactionPriorityOldestLargestSeq,
+		CompactionPriorityOldestSmallestSeq, CompactionPriorityRoundRobin:
+	default:
+		return ErrInvalidConfig("compactionPriority must be \"\", \"by_size\", \"oldest_largest_seq\", \"oldest_smallest_seq\" or \"round_robin\"")
+	}
+	switch c.CompactionObjective {
+	case CompactionObjectiveScore, CompactionObjectiveMinReadAmp:
+	default:
+		return ErrInvalidConfig("compactionObjective must be \"\" or \"min_read_amp\"")
+	}
+	if c.ReadWorkload != nil {
+		switch c.ReadWorkload.ReadKeyDistribution {
+		case "", ReadKeyDistributionUniform, ReadKeyDistributionZipfian:
+		default:
+			return ErrInvalidConfig("readWorkload.readKeyDistribution must be \"uniform\" or \"zipfian\"")
+		}
+		if c.ReadWorkload.ZipfianTheta < 0 || c.ReadWorkload.ZipfianTheta >= 1 {
+			return ErrInvalidConfig("readWorkload.zipfianTheta must be >= 0 and < 1 (0 = 0.99)")
+		}
+	}
+	switch c.CompressionModel {
+	case CompressionModelUniform, CompressionModelFixed:
+	case CompressionModelAgeBased:
+		if c.CompressionAgeDecay <= 0 || c.CompressionAgeDecay > 1.0 {
+			return ErrInvalidConfig("compressionAgeDecay must be > 0 and <= 1.0 for the age_based compression model")
+		}
+	default:
+		return ErrInvalidConfig("compressionModel must be \"\", \"fixed\" or \"age_based\"")
+	}
+	for _, factor := range c.CompressionFactorPerLevel {
+		if factor != 0 && (factor < 0.1 || factor > 1.0) {
+			return ErrInvalidConfig("compressionFactorPerLevel entries must be 0 (use compressionFactor) or between 0.1 and 1.0")
+		}
+	}
+	if c.L0ReductionFactor != 0 && (c.L0ReductionFactor < 0.1 || c.L0ReductionFactor > 1.0) {
+		return ErrInvalidConfig("l0ReductionFactor must be 0 (default 0.9) or between 0.1 and 1.0")
+	}
+	if c.DeepReductionFactor != 0 && (c.DeepReductionFactor < 0.1 || c.DeepReductionFactor > 1.0) {
+		return ErrInvalidConfig("deepReductionFactor must be 0 (default 0.99) or between 0.1 and 1.0")
+	}
+	for _, factor := range c.LevelReductionFactors {
+		if factor != 0 && (factor < 0.1 || factor > 1.0) {
+			return ErrInvalidConfig("levelReductionFactors entries must be 0 (use l0/deepReductionFactor) or between 0.1 and 1.0")
+		}
+	}
+	if c.AvgKeyValueSizeBytes < 0 {
+		return ErrInvalidConfig("avgKeyValueSizeBytes must be >= 0 (0 = key counts not modeled)")
+	}
+	if c.MinOutputFileSizeMB < 0 {
+		return ErrInvalidConfig("minOutputFileSizeMB must be >= 0 (0 = keep runt files)")
+	}
 	if c.CompressionThroughputMBps < 0 {
 		return ErrInvalidConfig("compressionThroughputMBps must be >= 0 (0 = infinite/no CPU cost)")
 	}
@@ -420,17 +892,91 @@ func (c *SimConfig) Validate() error {
 	if c.BlockSizeKB < 1 || c.BlockSizeKB > 1024 {
 		return ErrInvalidConfig("blockSizeKB must be between 1 and 1024")
 	}
+	if c.BloomFilterBitsPerKey < 0 {
+		return ErrInvalidConfig("bloomFilterBitsPerKey must be >= 0 (0 = no bloom filters)")
+	}
+	if c.MetadataOverheadPercent < 0 || c.MetadataOverheadPercent > 100 {
+		return ErrInvalidConfig("metadataOverheadPercent must be between 0 and 100 (0 = not modeled)")
+	}
 	if c.MaxBackgroundJobs < 1 {
 		return ErrInvalidConfig("maxBackgroundJobs must be >= 1")
 	}
+	if c.MaxBackgroundFlushes < 0 {
+		return ErrInvalidConfig("maxBackgroundFlushes must be >= 0 (0 = flushes share maxBackgroundJobs)")
+	}
 	if c.MaxSubcompactions < 1 {
 		return ErrInvalidConfig("maxSubcompactions must be >= 1")
 	}
+	if c.MaxActiveCompactionBytesMB < 0 {
+		return ErrInvalidConfig("maxActiveCompactionBytesMB must be >= 0 (0 = unlimited)")
+	}
+	if c.UrgentL0CompactionTrigger < 0 {
+		return ErrInvalidConfig("urgentL0CompactionTrigger must be >= 0 (0 = disabled)")
+	}
+	if c.UrgentL0CompactionTrigger > 0 && c.UrgentL0CompactionTrigger < c.L0CompactionTrigger {
+		return ErrInvalidConfig("urgentL0CompactionTrigger must be >= l0CompactionTrigger")
+	}
+	if c.CreatedAtJitterSeconds < 0 {
+		return ErrInvalidConfig("createdAtJitterSeconds must be >= 0 (0 = no jitter)")
+	}
+	if c.CompactionSetupLatencyMs < 0 {
+		return ErrInvalidConfig("compactionSetupLatencyMs must be >= 0")
+	}
+	if c.CompactionMBPerCPUSec < 0 {
+		return ErrInvalidConfig("compactionMBPerCPUSec must be >= 0 (0 = no CPU ceiling)")
+	}
+	if c.NumCompactionThreads < 0 {
+		return ErrInvalidConfig("numCompactionThreads must be >= 0 (0 = maxBackgroundJobs)")
+	}
+	if c.BackupBandwidthMBps < 0 {
+		return ErrInvalidConfig("backupBandwidthMBps must be >= 0 (0 = no backup)")
+	}
+	if c.CompactionRateLimitMBps < 0 {
+		return ErrInvalidConfig("compactionRateLimitMBps must be >= 0 (0 = unlimited)")
+	}
+	if c.DiskIOPS < 0 {
+		return ErrInvalidConfig("diskIOPS must be >= 0 (0 = bandwidth-limited only)")
+	}
 	if c.IOThroughputMBps <= 0 {
 		return ErrInvalidConfig("ioThroughputMBps must be > 0")
 	}
-	if c.NumLevels < 2 || c.NumLevels > 10 {
-		return ErrInvalidConfig("numLevels must be between 2 and 10")
+	if c.IOReadThroughputMBps < 0 {
+		return ErrInvalidConfig("ioReadThroughputMBps must be >= 0 (0 = same as ioThroughputMBps)")
+	}
+	if c.TierBoundaryLevel < 0 {
+		return ErrInvalidConfig("tierBoundaryLevel must be >= 0 (0 = single tier)")
+	}
+	if c.ColdIOThroughputMBps < 0 {
+		return ErrInvalidConfig("coldIOThroughputMBps must be >= 0 (0 = same as ioThroughputMBps)")
+	}
+	if c.MaxHistorySamples < 0 {
+		return ErrInvalidConfig("maxHistorySamples must be >= 0 (0 = no history)")
+	}
+	if c.RecentWritesWindowSeconds < 0 {
+		return ErrInvalidConfig("recentWritesWindowSeconds must be >= 0 (0 = default 5s)")
+	}
+	if c.BaseStepSeconds < 0 {
+		return ErrInvalidConfig("baseStepSeconds must be >= 0 (0 = default 1s)")
+	}
+	if c.AdaptiveStepMaxSeconds != 0 && c.AdaptiveStepMaxSeconds < c.BaseStep() {
+		return ErrInvalidConfig("adaptiveStepMaxSeconds must be 0 (disabled) or >= baseStepSeconds")
+	}
+	if c.SmallFileMergeThresholdMB < 0 {
+		return ErrInvalidConfig("smallFileMergeThresholdMB must be >= 0 (0 = disabled)")
+	}
+	if c.WALGroupCommitIntervalMs < 0 {
+		return ErrInvalidConfig("walGroupCommitIntervalMs must be >= 0 (0 = no group commit)")
+	}
+	if c.IngestFileSizeMB < 0 {
+		return ErrInvalidConfig("ingestFileSizeMB must be >= 0 (0 = use targetFileSizeMB)")
+	}
+	if c.NumLevels < 1 || c.NumLevels > maxNumLevels {
+		return ErrInvalidConfig("numLevels must be between 1 and 10")
+	}
+	// A single level holds everything in L0: universal and FIFO compact within it, but leveled
+	// compaction has no level to compact into (RocksDB sanitizes num_levels to 2 for leveled)
+	if c.NumLevels == 1 && c.CompactionStyle == CompactionStyleLeveled {
+		return ErrInvalidConfig("numLevels must be at least 2 for leveled compaction")
 	}
 
 	// RocksDB allows max_size_amplification_percent to be any unsigned int (0 to UINT_MAX)
@@ -438,13 +984,209 @@ func (c *SimConfig) Validate() error {
 	// - 0: triggers compaction on any positive amplification (aggressive compaction)
 	// - Very high values (e.g., 9000): allows extreme space amplification before triggering
 	// We validate it's non-negative to match RocksDB's unsigned int constraint
+	if c.UniversalAgeBasedTrigger && c.UniversalAgeThresholdSeconds <= 0 {
+		return ErrInvalidConfig("universalAgeThresholdSeconds must be > 0 when universalAgeBasedTrigger is set")
+	}
 	if c.MaxSizeAmplificationPercent < 0 {
 		return ErrInvalidConfig("maxSizeAmplificationPercent must be >= 0")
 	}
+	if c.FIFOTTLSeconds < 0 {
+		return ErrInvalidConfig("fifoTTLSeconds must be >= 0")
+	}
+	if c.CompactionFailureRate < 0 || c.CompactionFailureRate >= 1.0 {
+		return ErrInvalidConfig("compactionFailureRate must be >= 0 and < 1.0")
+	}
+	if c.CachePersistedFraction < 0 || c.CachePersistedFraction > 1 {
+		return ErrInvalidConfig("cachePersistedFraction must be between 0 and 1")
+	}
+	if c.CacheWarmupSeconds < 0 {
+		return ErrInvalidConfig("cacheWarmupSeconds must be >= 0 (0 = default 300s)")
+	}
+	if c.RecompactionWindowSeconds < 0 {
+		return ErrInvalidConfig("recompactionWindowSeconds must be >= 0 (0 = disabled)")
+	}
+	if c.OOMWarningSeconds < 0 {
+		return ErrInvalidConfig("oomWarningSeconds must be >= 0 (0 = disabled)")
+	}
+	if c.CompactionStyle == CompactionStyleTiered {
+		if c.TieredMinThreshold < 2 {
+			return ErrInvalidConfig("tieredMinThreshold must be >= 2")
+		}
+		if c.TieredBucketLow <= 0 || c.TieredBucketLow > 1 || c.TieredBucketHigh < 1 {
+			return ErrInvalidConfig("tieredBucketLow must be in (0, 1] and tieredBucketHigh must be >= 1")
+		}
+	}
+	if c.BottommostCompactionIOPriority < 0 || c.BottommostCompactionIOPriority > 1 {
+		return ErrInvalidConfig("bottommostCompactionIOPriority must be between 0 and 1 (0 = full bandwidth)")
+	}
+	switch c.CompactionRetryBackoff.Type {
+	case "", BackoffFixed, BackoffExponential: // Empty = fixed (zero-value configs retry immediately)
+	default:
+		return ErrInvalidConfig("compactionRetryBackoff.type must be 'fixed' or 'exponential'")
+	}
+	if c.CompactionRetryBackoff.BaseSeconds < 0 {
+		return ErrInvalidConfig("compactionRetryBackoff.baseSeconds must be >= 0")
+	}
+	if c.CompactionRetryBackoff.Type == BackoffExponential && c.CompactionRetryBackoff.MaxSeconds < c.CompactionRetryBackoff.BaseSeconds {
+		return ErrInvalidConfig("compactionRetryBackoff.maxSeconds must be >= baseSeconds")
+	}
+	names := map[string]bool{"default": true}
+	for i, cf := range c.columnFamilyConfigs() {
+		if names[cf.Name] {
+			return ErrInvalidConfig(fmt.Sprintf("columnFamilies: duplicate or reserved name %q", cf.Name))
+		}
+		names[cf.Name] = true
+		if cf.WriteRateMBps < 0 || cf.MemtableFlushSizeMB < 0 || cf.L0CompactionTrigger < 0 || cf.MaxBytesForLevelBaseMB < 0 ||
+			cf.LevelMultiplier < 0 || cf.TargetFileSizeMB < 0 {
+			return ErrInvalidConfig(fmt.Sprintf("columnFamilies[%s]: settings must be >= 0 (0 = inherit)", cf.Name))
+		}
+		cfConfig, err := cf.apply(*c, i)
+		if err != nil {
+			return ErrInvalidConfig(fmt.Sprintf("columnFamilies[%s]: %v", cf.Name, err))
+		}
+		if err := cfConfig.Validate(); err != nil {
+			return fmt.Errorf("columnFamilies[%s]: %w", cf.Name, err)
+		}
+	}
 	// CompactionStyle validation: type-safe enum, no additional validation needed
 	return nil
 }
 
+// ValidateWithWarnings is Validate plus non-fatal warnings about settings that are valid but
+// likely unintended. Warnings are heuristics, not limits: the config is used as given. A config
+// that fails Validate returns its error and no warnings.
+func (c *SimConfig) ValidateWithWarnings() (warnings []string, err error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	leveled := c.CompactionStyle == CompactionStyleLeveled
+	if leveled && c.LevelMultiplier < 2 {
+		warnings = append(warnings, fmt.Sprintf("levelMultiplier %d < 2: levels don't grow, so the tree needs more levels and every level adds a rewrite of the data", c.LevelMultiplier))
+	}
+	if c.MaxBackgroundJobs == 1 && c.MaxBackgroundFlushes == 0 && c.WriteRateMBps > c.IOThroughputMBps/4 {
+		warnings = append(warnings, fmt.Sprintf("maxBackgroundJobs=1 with writeRateMBps %.0f (over a quarter of ioThroughputMBps %.0f): flushes queue behind compactions in the single slot, expect write stalls", c.WriteRateMBps, c.IOThroughputMBps))
+	}
+	if l0SizeMB := c.L0CompactionTrigger * c.MemtableFlushSizeMB; leveled && c.MaxBytesForLevelBaseMB < l0SizeMB {
+		warnings = append(warnings, fmt.Sprintf("maxBytesForLevelBaseMB %d is below L0's size at its compaction trigger (%dMB): every L0 compaction overfills the base level (RocksDB suggests sizing them alike)", c.MaxBytesForLevelBaseMB, l0SizeMB))
+	}
+	return warnings, nil
+}
+
+// ReadThroughputMBps returns the disk's sequential read throughput: IOReadThroughputMBps, or
+// IOThroughputMBps when that is unset
+func (c *SimConfig) ReadThroughputMBps() float64 {
+	if c.IOReadThroughputMBps > 0 {
+		return c.IOReadThroughputMBps
+	}
+	return c.IOThroughputMBps
+}
+
+// Tiered reports whether some levels live on the cold tier (TierBoundaryLevel within [1, NumLevels))
+func (c *SimConfig) Tiered() bool {
+	return c.TierBoundaryLevel > 0 && c.TierBoundaryLevel < c.NumLevels
+}
+
+// IsColdLevel reports whether level is stored on the cold tier
+func (c *SimConfig) IsColdLevel(level int) bool {
+	return c.Tiered() && level >= c.TierBoundaryLevel
+}
+
+// ColdThroughputMBps returns the cold tier's throughput: ColdIOThroughputMBps, or
+// IOThroughputMBps when that is unset
+func (c *SimConfig) ColdThroughputMBps() float64 {
+	if c.ColdIOThroughputMBps > 0 {
+		return float64(c.ColdIOThroughputMBps)
+	}
+	return c.IOThroughputMBps
+}
+
+// CompressionFactorForLevel returns the compression factor (physical/logical size) of SSTs written to level
+func (c *SimConfig) CompressionFactorForLevel(level int) float64 {
+	switch c.CompressionModel {
+	case CompressionModelFixed:
+		if level >= 0 && level < len(c.CompressionFactorPerLevel) && c.CompressionFactorPerLevel[level] > 0 {
+			return c.CompressionFactorPerLevel[level]
+		}
+	case CompressionModelAgeBased:
+		return math.Max(0.1, c.CompressionFactor*math.Pow(c.CompressionAgeDecay, float64(max(0, level))))
+	}
+	return c.CompressionFactor
+}
+
+// Default compaction output/input ratios when L0ReductionFactor (and DeduplicationFactor) or
+// DeepReductionFactor are unset
+const (
+	defaultL0ReductionFactor   = 0.9  // L0→base level: multiple versions of the same key across L0 files get merged
+	defaultDeepReductionFactor = 0.99 // L1+: leveled structure means little key overlap
+)
+
+// CompactionReductionFactor returns the output/input size ratio (deduplication and overwrites
+// merged away) of a compaction out of fromLevel: LevelReductionFactors[fromLevel] when set,
+// otherwise L0ReductionFactor or DeepReductionFactor. Out of L0 it falls back to
+// DeduplicationFactor, then 0.9; deeper it falls back to 0.99.
+func (c *SimConfig) CompactionReductionFactor(fromLevel int) float64 {
+	if fromLevel >= 0 && fromLevel < len(c.LevelReductionFactors) && c.LevelReductionFactors[fromLevel] > 0 {
+		return c.LevelReductionFactors[fromLevel]
+	}
+	if fromLevel == 0 {
+		if c.L0ReductionFactor > 0 {
+			return c.L0ReductionFactor
+		}
+		if c.DeduplicationFactor > 0 {
+			return c.DeduplicationFactor
+		}
+		return defaultL0ReductionFactor
+	}
+	if c.DeepReductionFactor > 0 {
+		return c.DeepReductionFactor
+	}
+	return defaultDeepReductionFactor
+}
+
+// defaultBaseStepSeconds is the Step iteration length when BaseStepSeconds is unset
+const defaultBaseStepSeconds = 1.0
+
+// BaseStep returns the virtual seconds per Step iteration (BaseStepSeconds, defaulting to 1s)
+func (c *SimConfig) BaseStep() float64 {
+	if c.BaseStepSeconds > 0 {
+		return c.BaseStepSeconds
+	}
+	return defaultBaseStepSeconds
+}
+
+// CompactionThreads returns the CPU threads compactions share (NumCompactionThreads, defaulting to
+// one per background job)
+func (c *SimConfig) CompactionThreads() int {
+	if c.NumCompactionThreads > 0 {
+		return c.NumCompactionThreads
+	}
+	return c.MaxBackgroundJobs
+}
+
+// MetadataOverheadFactor returns the size of a flushed SST relative to its data once index and
+// filter blocks are added (1.0 when MetadataOverheadPercent is 0 and filter blocks aren't modeled)
+func (c *SimConfig) MetadataOverheadFactor() float64 {
+	return 1 + c.MetadataOverheadPercent/100 + c.FilterBlockFraction()
+}
+
+// FilterBlockFraction returns the bloom filter bytes an SST carries per byte of data:
+// BloomFilterBitsPerKey bits for each of the keys in it. Small key-value pairs pack more keys
+// into each MB and so carry larger filters. 0 unless BloomFilterBitsPerKey and
+// AvgKeyValueSizeBytes are both set.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Uniform key-value size (see keysForSizeMB); RocksDB sizes each
+// filter from the file's exact num_entries
+func (c *SimConfig) FilterBlockFraction() float64 {
+	keysPerMB := keysForSizeMB(1, c.AvgKeyValueSizeBytes)
+	return float64(keysPerMB) * float64(c.BloomFilterBitsPerKey) / 8 / (1024 * 1024)
+}
+
+// RecompressionRatio returns the size change when data stored at fromLevel is rewritten to toLevel
+// (1.0 under the uniform model)
+func (c *SimConfig) RecompressionRatio(fromLevel, toLevel int) float64 {
+	return c.CompressionFactorForLevel(toLevel) / c.CompressionFactorForLevel(fromLevel)
+}
+
 // ================================
 // Compression Presets
 // ================================
@@ -96,3 +96,99 @@ func TestDiskSaturation_ShouldStallAndOOM(t *testing.T) {
 		"numImmutable=%d, diskBusyUntil=%.2fs",
 		sim.numImmutableMemtables, sim.GetDiskBusyUntil())
 }
+
+// TestAsymmetricDiskBandwidth tests that compaction input is read at IOReadThroughputMBps while
+// its output is written at IOThroughputMBps, and that each direction's utilization is measured
+// against its own budget
+func TestAsymmetricDiskBandwidth(t *testing.T) {
+	// compaction schedules one L0→L1 compaction of four 64 MB files and returns its completion time
+	compaction := func(ioReadThroughputMBps float64) (float64, *Simulator) {
+		config := DefaultConfig()
+		config.IOThroughputMBps = 100
+		config.IOReadThroughputMBps = ioReadThroughputMBps
+		config.IOLatencyMs = 0
+		config.DecompressionThroughputMBps = 0
+		config.SSTableBuildThroughputMBps = 0
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.placeFiles(0, 4, 64)
+
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		completion := 0.0
+		for _, busyUntil := range sim.backgroundJobSlots {
+			completion = max(completion, busyUntil)
+		}
+		return completion, sim
+	}
+
+	// Unset, reads share the write budget
+	symmetric, _ := compaction(0)
+	fastReads, sim := compaction(400)
+	require.InDelta(t, 256.0/100-256.0/400, symmetric-fastReads, 1e-9, "only the 256 MB of input reads faster")
+
+	// The job keeps the disk busy throughout: its reads against the read budget, then its writes
+	// against the write budget
+	sim.metrics.updateDiskDirectionUtilization(fastReads, 400, 100)
+	require.InDelta(t, 256/(400*fastReads)*100, sim.metrics.DiskReadUtilizationPercent, 1e-6)
+	require.InDelta(t, 100-256.0/400/fastReads*100, sim.metrics.DiskWriteUtilizationPercent, 1e-6)
+
+	config := DefaultConfig()
+	config.IOReadThroughputMBps = -1
+	require.Error(t, config.Validate())
+}
+
+// TestTieredStorage tests that I/O on levels at or below TierBoundaryLevel moves at the cold
+// tier's bandwidth, that reads split between tiers by data share, and that each tier's disk
+// time is measured
+func TestTieredStorage(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.IOThroughputMBps = 100
+	config.TierBoundaryLevel = 6
+	config.ColdIOThroughputMBps = 25
+	config.RandomSeed = 42
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+
+	// Both ends hot: everything at IOThroughputMBps
+	readSec, writeSec, coldSec := sim.compactionIOTime(&CompactionJob{FromLevel: 4, ToLevel: 5}, 0.5, 100, 0, 100, 0)
+	require.InDelta(t, 1.0, readSec, 1e-9)
+	require.InDelta(t, 1.0, writeSec, 1e-9)
+	require.Zero(t, coldSec)
+
+	// Into the cold tier: source files read hot, target files read cold, output written cold
+	readSec, writeSec, coldSec = sim.compactionIOTime(&CompactionJob{FromLevel: 5, ToLevel: 6}, 0.5, 100, 0, 100, 0)
+	require.InDelta(t, 50.0/100+50.0/25, readSec, 1e-9)
+	require.InDelta(t, 100.0/25, writeSec, 1e-9)
+	require.InDelta(t, 50.0/25+100.0/25, coldSec, 1e-9)
+
+	// Reads come from each tier in proportion to its data
+	sim.placeFiles(1, 1, 100)
+	sim.placeFiles(6, 3, 100)
+	hotSec, coldReadSec := sim.readWorkloadTransferTime(100)
+	require.InDelta(t, 25.0/100, hotSec, 1e-9)
+	require.InDelta(t, 75.0/25, coldReadSec, 1e-9)
+
+	// A running tiered simulation spends disk time on both tiers
+	readWorkload := DefaultReadWorkload()
+	config.ReadWorkload = &readWorkload
+	config.SimulationSpeedMultiplier = 1
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	sim.placeFiles(6, 20, 64)
+	for i := 0; i < 30; i++ {
+		sim.Step()
+	}
+	require.Greater(t, sim.Metrics().HotTierUtilizationPercent, 0.0)
+	require.Greater(t, sim.Metrics().ColdTierUtilizationPercent, 0.0)
+
+	// A boundary at or past the last level is the single-tier default
+	config.TierBoundaryLevel = config.NumLevels
+	require.False(t, config.Tiered())
+	require.False(t, config.IsColdLevel(6))
+	config.TierBoundaryLevel = -1
+	require.Error(t, config.Validate())
+}
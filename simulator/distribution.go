@@ -218,6 +218,28 @@ func NewDistribution(distType DistributionType) Distribution {
 	}
 }
 
+// newOverlapDistribution creates the distribution described by an OverlapDistributionConfig
+func newOverlapDistribution(overlapConfig OverlapDistributionConfig) Distribution {
+	switch overlapConfig.Type {
+	case DistExponential:
+		return &ExponentialDistribution{Lambda: overlapConfig.ExponentialLambda}
+	case DistGeometric:
+		return &GeometricDistribution{P: overlapConfig.GeometricP}
+	case DistFixed:
+		percentage := overlapConfig.FixedPercentage
+		// Clamp to [0.0, 1.0] - allow 0.0 and 1.0 as valid extremes
+		if percentage < 0.0 {
+			percentage = 0.0
+		}
+		if percentage > 1.0 {
+			percentage = 1.0
+		}
+		return &FixedDistribution{Percentage: percentage}
+	default: // DistUniform
+		return &UniformDistribution{}
+	}
+}
+
 // filePicker interface for selecting files (internal to compactor)
 type filePicker interface {
 	Pick(min, max int) int
@@ -243,20 +265,29 @@ func newDistributionAdapter(distType DistributionType) filePicker {
 	}
 }
 
-// newDistributionAdapterWithSeed creates a distribution adapter with a specific seed
-func newDistributionAdapterWithSeed(distType DistributionType, seed int64) filePicker {
-	var rng *rand.Rand
+// newDistributionAdapterWithSeed creates a distribution adapter with a specific seed,
+// tracking its RNG in rngs
+func newDistributionAdapterWithSeed(distType DistributionType, seed int64, rngs *rngSet) filePicker {
 	if seed == 0 {
-		rng = rand.New(rand.NewSource(rand.Int63())) // Use random seed if 0
-	} else {
-		rng = rand.New(rand.NewSource(seed))
+		seed = rand.Int63() // Use random seed if 0
 	}
 	return &distributionAdapter{
 		dist: NewDistribution(distType),
-		rng:  rng,
+		rng:  rngs.newRand(seed),
 	}
 }
 
+// newOverlapRNG returns the RNG used for overlap sampling. With overlapSeed == 0 overlap sampling
+// shares the compactor's RNG (the historical behavior); otherwise it gets its own source so the
+// overlap realization can vary while file selection stays fixed, or vice versa. A separate
+// source is tracked in rngs.
+func newOverlapRNG(compactorRNG *rand.Rand, overlapSeed int64, rngs *rngSet) *rand.Rand {
+	if overlapSeed == 0 {
+		return compactorRNG
+	}
+	return rngs.newRand(overlapSeed)
+}
+
 // ================================
 // Latency Distribution Sampling
 // ================================
@@ -302,3 +333,55 @@ func SampleLatency(spec LatencySpec, rng *rand.Rand) float64 {
 		return spec.Mean
 	}
 }
+
+// ================================
+// Key Access Distribution
+// ================================
+
+// zipfianKeyRanks is how many popularity ranks the zipfian generator draws from. Rank r stands for
+// the (r+1)-th hundredth of a percent of the data, so the hottest rank is the newest 0.01%.
+const zipfianKeyRanks = 10000
+
+// zipfianGenerator draws key ranks (0 = hottest) following a zipfian distribution with constant
+// theta in (0, 1), using the method of Gray et al., "Quickly Generating Billion-Record Synthetic
+// Databases" (SIGMOD '94), as YCSB's ZipfianGenerator does. math/rand's Zipf needs s > 1, which
+// rules out the usual YCSB skews (theta = 0.99).
+type zipfianGenerator struct {
+	n     int
+	theta float64
+	alpha float64
+	zetaN float64
+	eta   float64
+}
+
+// newZipfianGenerator precomputes the constants for n ranks; O(n), so callers keep the generator
+func newZipfianGenerator(n int, theta float64) *zipfianGenerator {
+	zeta := func(n int) float64 {
+		sum := 0.0
+		for i := 1; i <= n; i++ {
+			sum += 1.0 / math.Pow(float64(i), theta)
+		}
+		return sum
+	}
+	zetaN := zeta(n)
+	return &zipfianGenerator{
+		n:     n,
+		theta: theta,
+		alpha: 1.0 / (1.0 - theta),
+		zetaN: zetaN,
+		eta:   (1 - math.Pow(2.0/float64(n), 1-theta)) / (1 - zeta(2)/zetaN),
+	}
+}
+
+// Sample returns a rank in [0, n)
+func (z *zipfianGenerator) Sample(rng *rand.Rand) int {
+	u := rng.Float64()
+	uz := u * z.zetaN
+	if uz < 1.0 {
+		return 0
+	}
+	if uz < 1.0+math.Pow(0.5, z.theta) {
+		return 1
+	}
+	return min(z.n-1, int(float64(z.n)*math.Pow(z.eta*u-z.eta+1, z.alpha)))
+}
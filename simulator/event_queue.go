@@ -64,12 +64,13 @@ func (eq *EventQueue) CountWriteEvents() int {
 	return count
 }
 
-// FindNextFlushEvent finds the earliest FlushEvent in the queue
+// FindNextFlushEvent finds the earliest FlushEvent of column family cf in the queue
+// (0 = default column family)
 // Returns nil if no flush event is found
-func (eq *EventQueue) FindNextFlushEvent() *FlushEvent {
+func (eq *EventQueue) FindNextFlushEvent(cf int) *FlushEvent {
 	var earliestFlush *FlushEvent
 	for _, event := range eq.events {
-		if flushEvent, ok := event.(*FlushEvent); ok {
+		if flushEvent, ok := event.(*FlushEvent); ok && flushEvent.ColumnFamily() == cf {
 			if earliestFlush == nil || flushEvent.Timestamp() < earliestFlush.Timestamp() {
 				earliestFlush = flushEvent
 			}
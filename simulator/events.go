@@ -14,6 +14,8 @@ const (
 	EventTypeWALWrite
 	EventTypeScheduleRead
 	EventTypeReadBatch
+	EventTypeColumnFamilyWrite
+	EventTypeWALGroupCommit
 )
 
 func (et EventType) String() string {
@@ -34,6 +36,10 @@ func (et EventType) String() string {
 		return "schedule_read"
 	case EventTypeReadBatch:
 		return "read_batch"
+	case EventTypeColumnFamilyWrite:
+		return "column_family_write"
+	case EventTypeWALGroupCommit:
+		return "wal_group_commit"
 	default:
 		return "unknown"
 	}
@@ -83,6 +89,7 @@ type FlushEvent struct {
 	startTime     float64 // When the flush started
 	sizeMB        float64
 	bandwidthMBps float64 // Disk bandwidth reserved for this flush
+	columnFamily  int     // 0 = default column family, i = ColumnFamilies entry i-1
 }
 
 func NewFlushEvent(timestamp, startTime, sizeMB float64) *FlushEvent {
@@ -94,13 +101,24 @@ func NewFlushEvent(timestamp, startTime, sizeMB float64) *FlushEvent {
 	}
 }
 
+// NewColumnFamilyFlushEvent creates a flush of a non-default column family's memtable
+func NewColumnFamilyFlushEvent(timestamp, startTime, sizeMB float64, columnFamily int) *FlushEvent {
+	event := NewFlushEvent(timestamp, startTime, sizeMB)
+	event.columnFamily = columnFamily
+	return event
+}
+
 func (e *FlushEvent) Timestamp() float64          { return e.timestamp }
 func (e *FlushEvent) StartTime() float64          { return e.startTime }
 func (e *FlushEvent) Type() EventType             { return EventTypeFlush }
 func (e *FlushEvent) SizeMB() float64             { return e.sizeMB }
 func (e *FlushEvent) BandwidthMBps() float64      { return e.bandwidthMBps }
 func (e *FlushEvent) SetBandwidthMBps(bw float64) { e.bandwidthMBps = bw }
+func (e *FlushEvent) ColumnFamily() int           { return e.columnFamily }
 func (e *FlushEvent) String() string {
+	if e.columnFamily > 0 {
+		return fmt.Sprintf("Flush(t=%.3fs, size=%.2fMB, cf=%d)", e.timestamp, e.sizeMB, e.columnFamily)
+	}
 	return fmt.Sprintf("Flush(t=%.3fs, size=%.2fMB)", e.timestamp, e.sizeMB)
 }
 
@@ -260,3 +278,38 @@ func (e *ReadBatchEvent) SetBandwidthMBps(bw float64) { e.bandwidthMBps = bw }
 func (e *ReadBatchEvent) String() string {
 	return fmt.Sprintf("ReadBatch(t=%.3fs, requests=%d)", e.timestamp, e.totalRequests)
 }
+
+// ColumnFamilyWriteEvent delivers one interval's worth of writes to a non-default column family
+type ColumnFamilyWriteEvent struct {
+	timestamp    float64
+	columnFamily int // ColumnFamilies entry columnFamily-1
+}
+
+func NewColumnFamilyWriteEvent(timestamp float64, columnFamily int) *ColumnFamilyWriteEvent {
+	return &ColumnFamilyWriteEvent{
+		timestamp:    timestamp,
+		columnFamily: columnFamily,
+	}
+}
+
+func (e *ColumnFamilyWriteEvent) Timestamp() float64 { return e.timestamp }
+func (e *ColumnFamilyWriteEvent) Type() EventType    { return EventTypeColumnFamilyWrite }
+func (e *ColumnFamilyWriteEvent) ColumnFamily() int  { return e.columnFamily }
+func (e *ColumnFamilyWriteEvent) String() string {
+	return fmt.Sprintf("ColumnFamilyWrite(t=%.3fs, cf=%d)", e.timestamp, e.columnFamily)
+}
+
+// WALGroupCommitEvent closes the open WAL group commit, writing and syncing the writes it gathered
+type WALGroupCommitEvent struct {
+	timestamp float64
+}
+
+func NewWALGroupCommitEvent(timestamp float64) *WALGroupCommitEvent {
+	return &WALGroupCommitEvent{timestamp: timestamp}
+}
+
+func (e *WALGroupCommitEvent) Timestamp() float64 { return e.timestamp }
+func (e *WALGroupCommitEvent) Type() EventType    { return EventTypeWALGroupCommit }
+func (e *WALGroupCommitEvent) String() string {
+	return fmt.Sprintf("WALGroupCommit(t=%.3fs)", e.timestamp)
+}
@@ -2,6 +2,7 @@ package simulator
 
 import (
 	"fmt"
+	"maps"
 	"math"
 	"math/rand"
 )
@@ -12,30 +13,49 @@ import (
 // FIDELITY: RocksDB Reference - FIFO Compaction Overview
 // https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker_fifo.cc
 //
-// FIFO compaction executes in two phases (in order):
-// 1. Size-based deletion: Delete oldest files when total size exceeds threshold
-// 2. Intra-L0 compaction: Merge small files to reduce file count (if allow_compaction=true)
-//
-// FIDELITY: ⚠️ INTENTIONALLY OMITTED - TTL-based deletion
-//   - RocksDB supports TTL-based deletion (files older than threshold)
-//   - Not implemented here: requires virtualTime in PickCompaction interface
-//   - For simulator use: Size-based deletion provides equivalent behavior for time-series workloads
-//   - TTL adds complexity without clear benefit for simulation purposes
+// FIFO compaction executes in three phases (in order):
+// 1. TTL-based deletion: Delete files older than ttl (if ttl > 0)
+// 2. Size-based deletion: Delete oldest files when total size exceeds threshold
+// 3. Intra-L0 compaction: Merge small files to reduce file count (if allow_compaction=true)
 //
 // FIDELITY: ⚠️ SIMPLIFIED - Temperature-based migration not implemented (EXPERIMENTAL feature)
 type FIFOCompactor struct {
 	rng               *rand.Rand
+	rngs              rngSet       // rng, for RNGState
 	activeCompactions map[int]bool // Track levels currently being compacted
+	virtualTime       float64      // Time file ages are measured against (set before each PickCompaction)
+	pickLogger
+}
+
+// setVirtualTime records the current time for TTL-based deletion
+func (f *FIFOCompactor) setVirtualTime(virtualTime float64) {
+	f.virtualTime = virtualTime
 }
 
 // NewFIFOCompactor creates a new FIFO compaction strategy.
 func NewFIFOCompactor(seed int64) *FIFOCompactor {
+	var rngs rngSet
+	rng := rngs.newRand(seed)
 	return &FIFOCompactor{
-		rng:               rand.New(rand.NewSource(seed)),
+		rng:               rng,
+		rngs:              rngs,
 		activeCompactions: make(map[int]bool),
 	}
 }
 
+// randomSources returns the compactor's RNG
+func (c *FIFOCompactor) randomSources() rngSet {
+	return c.rngs
+}
+
+// checkpoint saves the claimed levels
+func (f *FIFOCompactor) checkpoint() (restore func()) {
+	active := maps.Clone(f.activeCompactions)
+	return func() {
+		f.activeCompactions = active
+	}
+}
+
 // NeedsCompaction checks if compaction is needed for FIFO.
 //
 // FIDELITY: RocksDB Reference - FIFO NeedsCompaction
@@ -99,7 +119,8 @@ func (f *FIFOCompactor) NeedsCompaction(level int, lsm *LSMTree, config SimConfi
 		return true
 	}
 
-	return false
+	// Check TTL (oldest file expired)
+	return len(f.expiredFiles(lsm, config)) > 0
 }
 
 // PickCompaction selects files for compaction using FIFO strategy.
@@ -126,31 +147,99 @@ func (f *FIFOCompactor) NeedsCompaction(level int, lsm *LSMTree, config SimConfi
 //	}
 //	```
 //
-// FIDELITY: ⚠️ SIMPLIFIED - TTL phase skipped (not implemented)
-// FIDELITY: ✓ Exact match - Size-based compaction phase matches RocksDB
+// FIDELITY: ✓ Exact match - TTL phase runs first, then size-based compaction
 func (f *FIFOCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
 	// FIFO only uses L0
 	if len(lsm.Levels) == 0 || lsm.Levels[0].FileCount == 0 {
 		return nil
 	}
 
-	// Don't pick if L0 already compacting
-	if f.activeCompactions[0] {
+	// Don't pick if L0 already compacting or paused
+	if f.activeCompactions[0] || lsm.Levels[0].CompactionPaused {
 		return nil
 	}
 
-	// Phase 1: Size-based compaction or intra-L0
+	// Phase 1: TTL-based deletion
+	if job := f.pickTTLCompaction(lsm, config); job != nil {
+		f.activeCompactions[0] = true
+		return job
+	}
+
+	// Phase 2: Size-based compaction or intra-L0
 	if job := f.pickSizeCompaction(lsm, config); job != nil {
 		f.activeCompactions[0] = true
 		return job
 	}
 
-	// Phase 2: Temperature change compaction
+	// Phase 3: Temperature change compaction
 	// FIDELITY: ✗ NOT IMPLEMENTED - Temperature-based tiering (EXPERIMENTAL)
 
 	return nil
 }
 
+// pickTTLCompaction creates a job to delete every file older than ttl.
+//
+// FIDELITY: RocksDB Reference - FIFO TTL-Based Deletion
+// https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker_fifo.cc#L78-L100
+//
+// C++ snippet from PickTTLCompaction():
+//
+//	```cpp
+//	// avoid underflow
+//	if (current_time > mutable_cf_options.ttl) {
+//	  for (auto ritr = level_files.rbegin(); ritr != level_files.rend(); ++ritr) {
+//	    FileMetaData* f = *ritr;
+//	    uint64_t creation_time = f->TryGetFileCreationTime();
+//	    if (creation_time == kUnknownFileCreationTime ||
+//	        creation_time >= (current_time - mutable_cf_options.ttl)) {
+//	      break;
+//	    }
+//	    total_size -= f->fd.file_size;
+//	    inputs[0].files.push_back(f);
+//	  }
+//	}
+//	```
+//
+// FIDELITY: ✓ Exact match - Walks from the oldest file and stops at the first one within ttl
+// FIDELITY: ⚠️ SIMPLIFIED - Every file has a known creation time (CreatedAt), so none stops the walk
+func (f *FIFOCompactor) pickTTLCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
+	expired := f.expiredFiles(lsm, config)
+	if len(expired) == 0 {
+		return nil
+	}
+
+	f.logf("[FIFO-TTL] Deleting %d files older than %ds at t=%.1f\n",
+		len(expired), config.FIFOTTLSeconds, f.virtualTime)
+
+	return &CompactionJob{
+		FromLevel:   0,
+		ToLevel:     0,
+		SourceFiles: expired,
+		TargetFiles: nil,
+		IsIntraL0:   false, // This is deletion, not merge
+		Reason:      "ttl",
+	}
+}
+
+// expiredFiles returns the L0 files older than ttl, oldest first (nil when ttl is disabled)
+func (f *FIFOCompactor) expiredFiles(lsm *LSMTree, config SimConfig) []*SSTFile {
+	if config.FIFOTTLSeconds <= 0 || len(lsm.Levels) == 0 {
+		return nil
+	}
+	ttl := float64(config.FIFOTTLSeconds)
+	l0 := lsm.Levels[0]
+
+	// Rightmost files are oldest
+	var expired []*SSTFile
+	for i := len(l0.Files) - 1; i >= 0; i-- {
+		if f.virtualTime-l0.Files[i].CreatedAt <= ttl {
+			break
+		}
+		expired = append(expired, l0.Files[i])
+	}
+	return expired
+}
+
 // pickSizeCompaction implements size-based deletion and intra-L0 compaction.
 func (f *FIFOCompactor) pickSizeCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
 	l0 := lsm.Levels[0]
@@ -206,7 +295,7 @@ func (f *FIFOCompactor) pickDeletionCompaction(lsm *LSMTree, config SimConfig) *
 	totalSizeMB := l0.TotalSize
 	maxSizeMB := float64(config.FIFOMaxTableFilesSizeMB)
 
-	fmt.Printf("[FIFO-DEL] Starting deletion: totalSize=%.1f MB, maxSize=%.1f MB, fileCount=%d\n",
+	f.logf("[FIFO-DEL] Starting deletion: totalSize=%.1f MB, maxSize=%.1f MB, fileCount=%d\n",
 		totalSizeMB, maxSizeMB, len(l0.Files))
 
 	// Select oldest files (rightmost in L0) until size drops below threshold
@@ -214,7 +303,7 @@ func (f *FIFOCompactor) pickDeletionCompaction(lsm *LSMTree, config SimConfig) *
 	var filesToDelete []*SSTFile
 	for i := len(l0.Files) - 1; i >= 0 && totalSizeMB >= maxSizeMB; i-- {
 		file := l0.Files[i]
-		fmt.Printf("[FIFO-DEL] Considering file at index %d: ID=%s, size=%.1f MB, createdAt=%.1f\n",
+		f.logf("[FIFO-DEL] Considering file at index %d: ID=%s, size=%.1f MB, createdAt=%.1f\n",
 			i, file.ID, file.SizeMB, file.CreatedAt)
 		totalSizeMB -= file.SizeMB
 		filesToDelete = append(filesToDelete, file)
@@ -259,7 +348,7 @@ func (f *FIFOCompactor) pickDeletionCompaction(lsm *LSMTree, config SimConfig) *
 func (f *FIFOCompactor) pickIntraL0Compaction(lsm *LSMTree, config SimConfig) *CompactionJob {
 	l0 := lsm.Levels[0]
 	if len(l0.Files) < config.L0CompactionTrigger {
-		fmt.Printf("[FIFO-INTRA] File count check failed: %d < %d (trigger)\n", len(l0.Files), config.L0CompactionTrigger)
+		f.logf("[FIFO-INTRA] File count check failed: %d < %d (trigger)\n", len(l0.Files), config.L0CompactionTrigger)
 		return nil
 	}
 
@@ -272,7 +361,7 @@ func (f *FIFOCompactor) pickIntraL0Compaction(lsm *LSMTree, config SimConfig) *C
 	maxCompactBytesPerDelFile := writeBufferSizeMB * 1.1
 	maxCompactionBytesMB := float64(config.MaxCompactionBytesMB)
 
-	fmt.Printf("[FIFO-INTRA] Starting pick: fileCount=%d, maxCompactBytesPerDelFile=%.1f MB, maxCompactionBytes=%.1f MB\n",
+	f.logf("[FIFO-INTRA] Starting pick: fileCount=%d, maxCompactBytesPerDelFile=%.1f MB, maxCompactionBytes=%.1f MB\n",
 		len(l0.Files), maxCompactBytesPerDelFile, maxCompactionBytesMB)
 
 	// FIDELITY: L0 File Ordering in RocksDB
@@ -294,7 +383,7 @@ func (f *FIFOCompactor) pickIntraL0Compaction(lsm *LSMTree, config SimConfig) *C
 
 		// Stop if work per deleted file increases OR exceeds max size
 		if newCompactBytesPerDelFile > compactBytesPerDelFile || compactBytesMB > maxCompactionBytesMB {
-			fmt.Printf("[FIFO-INTRA] Stopping at file %d: newBytesPerDel=%.1f > prevBytesPerDel=%.1f OR compactBytes=%.1f > maxBytes=%.1f\n",
+			f.logf("[FIFO-INTRA] Stopping at file %d: newBytesPerDel=%.1f > prevBytesPerDel=%.1f OR compactBytes=%.1f > maxBytes=%.1f\n",
 				limit, newCompactBytesPerDelFile, compactBytesPerDelFile, compactBytesMB, maxCompactionBytesMB)
 			break
 		}
@@ -319,14 +408,14 @@ func (f *FIFOCompactor) pickIntraL0Compaction(lsm *LSMTree, config SimConfig) *C
 	//   ```
 	//
 	numFiles := limit - start
-	fmt.Printf("[FIFO-INTRA] Final check: numFiles=%d (trigger=%d), compactBytesPerDelFile=%.1f (max=%.1f)\n",
+	f.logf("[FIFO-INTRA] Final check: numFiles=%d (trigger=%d), compactBytesPerDelFile=%.1f (max=%.1f)\n",
 		numFiles, config.L0CompactionTrigger, compactBytesPerDelFile, maxCompactBytesPerDelFile)
 
 	// Accept only if BOTH conditions are true (matches RocksDB AND logic)
 	if numFiles >= config.L0CompactionTrigger && compactBytesPerDelFile < maxCompactBytesPerDelFile {
 		// Continue to file selection below
 	} else {
-		fmt.Printf("[FIFO-INTRA] REJECTED: numFiles=%d < %d OR bytesPerDel=%.1f >= %.1f\n",
+		f.logf("[FIFO-INTRA] REJECTED: numFiles=%d < %d OR bytesPerDel=%.1f >= %.1f\n",
 			numFiles, config.L0CompactionTrigger, compactBytesPerDelFile, maxCompactBytesPerDelFile)
 		return nil
 	}
@@ -334,9 +423,9 @@ func (f *FIFOCompactor) pickIntraL0Compaction(lsm *LSMTree, config SimConfig) *C
 	// Select files [start, limit)
 	sourceFiles := l0.Files[start:limit]
 
-	fmt.Printf("[FIFO-INTRA] SELECTED %d files for intra-L0:\n", len(sourceFiles))
-	for i, f := range sourceFiles {
-		fmt.Printf("  [%d] ID=%s, size=%.1f MB, createdAt=%.1f\n", i, f.ID, f.SizeMB, f.CreatedAt)
+	f.logf("[FIFO-INTRA] SELECTED %d files for intra-L0:\n", len(sourceFiles))
+	for i, file := range sourceFiles {
+		f.logf("  [%d] ID=%s, size=%.1f MB, createdAt=%.1f\n", i, file.ID, file.SizeMB, file.CreatedAt)
 	}
 
 	return &CompactionJob{
@@ -460,10 +549,11 @@ func (f *FIFOCompactor) executeIntraL0Compaction(job *CompactionJob, lsm *LSMTre
 	}
 
 	// Apply reduction factor for deduplication
-	outputSize = inputSize * config.DeduplicationFactor
+	reductionFactor := config.CompactionReductionFactor(0)
+	outputSize = inputSize * reductionFactor * (1 - config.CompactionGarbageFraction)
 
 	fmt.Printf("[FIFO-INTRA] Deduplication: inputSize=%.1f MB * factor=%.3f = outputSize=%.1f MB\n",
-		inputSize, config.DeduplicationFactor, outputSize)
+		inputSize, reductionFactor, outputSize)
 
 	// PRECONDITION: Calculate size BEFORE compaction
 	sizeBefore := l0.TotalSize
@@ -1,6 +1,8 @@
 package simulator
 
 import (
+	"io"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,15 +57,11 @@ func TestFIFOSizeBasedDeletion(t *testing.T) {
 
 // TestFIFOTTLDeletion tests that FIFO deletes files older than TTL threshold
 func TestFIFOTTLDeletion(t *testing.T) {
-	// NOTE: TTL implementation is currently a stub (returns nil)
-	// This test is a placeholder for when TTL is fully implemented
-	t.Skip("TTL compaction not yet fully implemented")
-
 	config := DefaultConfig()
 	config.CompactionStyle = CompactionStyleFIFO
 	config.FIFOMaxTableFilesSizeMB = 1000 // Large threshold (won't trigger size-based)
 	config.FIFOAllowCompaction = false
-	// 60 seconds TTL
+	config.FIFOTTLSeconds = 60 // 60 seconds TTL
 	config.NumLevels = 1
 
 	tree := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
@@ -80,23 +78,48 @@ func TestFIFOTTLDeletion(t *testing.T) {
 	tree.Levels[0].TotalSize = 5 * 50
 	tree.Levels[0].FileCount = 5
 
+	// At 75s the oldest file is only 55s old
+	compactor.setVirtualTime(75)
+	assert.Empty(t, compactor.expiredFiles(tree, config), "No file is older than TTL at 75s")
+	assert.Nil(t, compactor.PickCompaction(tree, config))
+
 	// Current virtual time = 150s
+	compactor.setVirtualTime(150)
 	compaction := compactor.PickCompaction(tree, config)
 
 	assert.NotNil(t, compaction, "TTL compaction should be triggered")
-	// When TTL is implemented, verify correct files are selected
+	assert.Equal(t, "ttl", compaction.Reason)
+	assert.False(t, compaction.IsIntraL0, "TTL expiry is a deletion")
+	// Oldest first; the file exactly TTL old and the young one survive
+	var createdAt []float64
+	for _, file := range compaction.SourceFiles {
+		createdAt = append(createdAt, file.CreatedAt)
+	}
+	assert.Equal(t, []float64{20, 50, 80}, createdAt)
+
+	inputSize, outputSize, outputFiles := compactor.ExecuteCompaction(compaction, tree, config, 150)
+	assert.Equal(t, 150.0, inputSize)
+	assert.Equal(t, 0.0, outputSize, "Deletion writes nothing")
+	assert.Equal(t, 0, outputFiles)
+	assert.Equal(t, 2, tree.Levels[0].FileCount)
+	assert.Equal(t, 100.0, tree.Levels[0].TotalSize)
+	assert.Equal(t, 90.0, tree.Levels[0].Files[1].CreatedAt)
+
+	// TTL disabled: the same ages never expire
+	config.FIFOTTLSeconds = 0
+	compactor.setVirtualTime(10000)
+	assert.Nil(t, compactor.PickCompaction(tree, config))
+
+	config.FIFOTTLSeconds = -1
+	assert.Error(t, config.Validate())
 }
 
 // TestFIFOCompactionOrder tests that TTL runs before size-based compaction
 func TestFIFOCompactionOrder(t *testing.T) {
-	// NOTE: TTL implementation is currently a stub
-	// This test verifies the ordering logic is in place
-	t.Skip("TTL compaction not yet fully implemented")
-
 	config := DefaultConfig()
 	config.CompactionStyle = CompactionStyleFIFO
 	config.FIFOMaxTableFilesSizeMB = 200 // Will trigger size-based
-	// TTL enabled
+	config.FIFOTTLSeconds = 70           // TTL enabled
 	config.NumLevels = 1
 
 	tree := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
@@ -111,10 +134,21 @@ func TestFIFOCompactionOrder(t *testing.T) {
 	tree.Levels[0].TotalSize = 3 * 100
 	tree.Levels[0].FileCount = 3
 
+	// Current virtual time = 100s: only the 80s-old file is past TTL
+	compactor.setVirtualTime(100)
 	compaction := compactor.PickCompaction(tree, config)
 
 	assert.NotNil(t, compaction)
-	// When TTL is implemented, verify TTL runs first
+	assert.Equal(t, "ttl", compaction.Reason, "TTL runs before size-based deletion")
+	assert.Len(t, compaction.SourceFiles, 1)
+	assert.Equal(t, 20.0, compaction.SourceFiles[0].CreatedAt)
+	compactor.ExecuteCompaction(compaction, tree, config, 100)
+
+	// Nothing else expired, but 200 MB still reaches the size threshold
+	compaction = compactor.PickCompaction(tree, config)
+	assert.NotNil(t, compaction)
+	assert.Empty(t, compaction.Reason, "Size-based deletion")
+	assert.Equal(t, 40.0, compaction.SourceFiles[0].CreatedAt)
 }
 
 // TestFIFOIntraL0Compaction tests intra-L0 compaction to merge small files
@@ -307,3 +341,92 @@ func TestFIFODiminishingReturns(t *testing.T) {
 		assert.LessOrEqual(t, len(compaction.SourceFiles), 4, "Should stop before including largest file")
 	}
 }
+
+// TestFIFOTTLSimulation tests that a running FIFO simulation expires files past TTL and counts them
+func TestFIFOTTLSimulation(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleFIFO
+	config.NumLevels = 1
+	config.RandomSeed = 42
+	config.WriteRateMBps = 20
+	config.SimulationSpeedMultiplier = 1
+	config.FIFOMaxTableFilesSizeMB = 100000 // Never reached: only TTL deletes
+	config.FIFOTTLSeconds = 60
+
+	sim, err := NewSimulator(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sim.Reset())
+	for i := 0; i < 300; i++ {
+		sim.Step()
+	}
+
+	assert.Greater(t, sim.metrics.FIFOTTLDroppedMB, 0.0)
+	assert.Equal(t, sim.metrics.FIFOTTLDroppedMB, sim.metrics.FIFODroppedMB, "Size cap never reached")
+	// Expiry is checked on compaction scheduling, so allow some slack past the TTL
+	for _, file := range sim.lsm.Levels[0].Files {
+		assert.Less(t, sim.VirtualTime()-file.CreatedAt, 120.0, "file %s outlived TTL", file.ID)
+	}
+}
+
+// TestIntraL0CompactionMetrics tests that intra-L0 merges are counted and measured apart from
+// other compactions, and that they are cumulative across ResetAggregateStats
+func TestIntraL0CompactionMetrics(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleFIFO
+	config.NumLevels = 1
+	config.RandomSeed = 42
+	config.WriteRateMBps = 20
+	config.SimulationSpeedMultiplier = 1
+	config.FIFOMaxTableFilesSizeMB = 100000 // Never reached: only intra-L0 merges run
+	config.FIFOAllowCompaction = true
+
+	sim, err := NewSimulator(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sim.Reset())
+	for i := 0; i < 100; i++ {
+		sim.Step()
+	}
+
+	metrics := sim.Metrics()
+	assert.Greater(t, metrics.IntraL0Compactions, 0)
+	assert.Equal(t, metrics.CompactionsSinceUpdate[0].Count, metrics.IntraL0Compactions, "every FIFO compaction here is a merge")
+	assert.InDelta(t, metrics.CompactionsSinceUpdate[0].TotalInputMB, metrics.IntraL0BytesMB, 1e-9)
+	assert.Zero(t, metrics.FIFODroppedMB)
+
+	count, bytes := metrics.IntraL0Compactions, metrics.IntraL0BytesMB
+	metrics.ResetAggregateStats()
+	assert.Equal(t, count, metrics.IntraL0Compactions)
+	assert.Equal(t, bytes, metrics.IntraL0BytesMB)
+}
+
+// TestFIFOPeekIsQuiet tests that previewing a FIFO deletion prints none of the deletion's
+// debug output, while the real pick still does
+func TestFIFOPeekIsQuiet(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleFIFO
+	config.FIFOMaxTableFilesSizeMB = 500
+	config.NumLevels = 1
+	config.RandomSeed = 42
+	sim, err := NewSimulator(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sim.Reset())
+	sim.placeFiles(0, 8, 100)
+
+	// stdout returns what fn printed
+	stdout := func(fn func()) string {
+		r, w, err := os.Pipe()
+		assert.NoError(t, err)
+		saved := os.Stdout
+		os.Stdout = w
+		fn()
+		os.Stdout = saved
+		w.Close()
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+
+	var preview *CompactionPreview
+	assert.NotContains(t, stdout(func() { preview = sim.PeekCompaction() }), "[FIFO-DEL]")
+	assert.NotNil(t, preview)
+	assert.Contains(t, stdout(func() { sim.tryScheduleCompaction(sim.columnFamily(0)) }), "[FIFO-DEL] Starting deletion")
+}
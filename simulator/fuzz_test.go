@@ -0,0 +1,120 @@
+package simulator
+
+import (
+	"math"
+	"testing"
+)
+
+// FuzzStep drives the event loop with random valid configurations and step counts,
+// checking the invariants behind past regressions (time going backwards, stuck
+// simulations, duplicated files, data lost from or added to the tree, NaN timestamps).
+//
+// Seed corpus runs as part of `go test`; explore further with:
+//
+//	go test ./simulator -run '^$' -fuzz FuzzStep -fuzztime 60s
+func FuzzStep(f *testing.F) {
+	// seed, writeRate, style, numLevels, jobs, speed, steps
+	f.Add(int64(1), uint8(10), uint8(0), uint8(7), uint8(2), uint8(10), uint8(20))
+	f.Add(int64(42), uint8(100), uint8(1), uint8(7), uint8(6), uint8(50), uint8(30))
+	f.Add(int64(7), uint8(30), uint8(2), uint8(3), uint8(1), uint8(5), uint8(40))
+	f.Add(int64(99), uint8(0), uint8(0), uint8(2), uint8(1), uint8(1), uint8(10))
+	f.Add(int64(1234), uint8(255), uint8(1), uint8(4), uint8(3), uint8(100), uint8(25))
+	f.Add(int64(5), uint8(60), uint8(3), uint8(5), uint8(2), uint8(20), uint8(30))
+
+	f.Fuzz(func(t *testing.T, seed int64, writeRate, style, numLevels, jobs, speed, steps uint8) {
+		config := DefaultConfig()
+		config.RandomSeed = seed
+		config.WriteRateMBps = float64(writeRate)
+		config.CompactionStyle = CompactionStyle(int(style) % (int(CompactionStyleTiered) + 1)) // Every style, the last being tiered
+		config.NumLevels = 2 + int(numLevels)%6
+		config.MaxBackgroundJobs = 1 + int(jobs)%8
+		config.SimulationSpeedMultiplier = 1 + int(speed)%100
+		if err := config.Validate(); err != nil {
+			t.Skip("invalid config:", err)
+		}
+
+		sim, err := NewSimulator(config)
+		if err != nil {
+			t.Skip("invalid config:", err)
+		}
+		if err := sim.Reset(); err != nil {
+			t.Fatalf("reset failed: %v", err)
+		}
+
+		lastTime := sim.VirtualTime()
+		for i := 0; i < 1+int(steps)%50; i++ {
+			sim.Step()
+
+			now := sim.VirtualTime()
+			if math.IsNaN(now) || math.IsInf(now, 0) {
+				t.Fatalf("step %d: virtual time is %v", i, now)
+			}
+			if now < lastTime {
+				t.Fatalf("step %d: virtual time went backwards: %.6f -> %.6f", i, lastTime, now)
+			}
+			lastTime = now
+
+			for _, event := range sim.queue.Events() {
+				ts := event.Timestamp()
+				if math.IsNaN(ts) || math.IsInf(ts, 0) {
+					t.Fatalf("step %d: event %s has timestamp %v", i, event.String(), ts)
+				}
+			}
+
+			if config.WriteRateMBps > 0 && !sim.metrics.IsOOMKilled && sim.IsQueueEmpty() {
+				t.Fatalf("step %d: queue drained at t=%.3f with write rate %.0f MB/s", i, now, config.WriteRateMBps)
+			}
+
+			checkFileInvariants(t, sim.lsm)
+			checkConservation(t, sim)
+		}
+	})
+}
+
+// checkConservation verifies no data vanishes from (or appears in) the tree: the files hold what
+// flushes, ingestion and initial placement wrote, less what compactions reduced away or FIFO
+// dropped (each compaction's input minus its output)
+func checkConservation(t *testing.T, sim *Simulator) {
+	t.Helper()
+	var filesMB float64
+	for _, level := range sim.lsm.Levels {
+		for _, file := range level.Files {
+			filesMB += file.SizeMB
+		}
+	}
+	m := sim.metrics
+	expectedMB := m.totalFlushWrittenMB + m.totalIngestedMB + m.placedDataSizeMB - m.compactionDroppedMB
+	if math.Abs(filesMB-expectedMB) > 1e-6*math.Max(1, expectedMB) {
+		t.Fatalf("t=%.3f: files hold %.6f MB but flushed %.6f + ingested %.6f + placed %.6f - compacted away %.6f = %.6f MB",
+			sim.VirtualTime(), filesMB, m.totalFlushWrittenMB, m.totalIngestedMB, m.placedDataSizeMB, m.compactionDroppedMB, expectedMB)
+	}
+}
+
+// checkFileInvariants verifies no file appears twice in the tree and that each
+// level's bookkeeping matches its file list
+func checkFileInvariants(t *testing.T, lsm *LSMTree) {
+	t.Helper()
+	seen := make(map[*SSTFile]int)
+	for levelNum, level := range lsm.Levels {
+		if level.FileCount != len(level.Files) {
+			t.Fatalf("L%d: FileCount=%d but %d files listed", levelNum, level.FileCount, len(level.Files))
+		}
+		var totalSize float64
+		for _, file := range level.Files {
+			if prev, ok := seen[file]; ok {
+				t.Fatalf("file %s appears in both L%d and L%d", file.ID, prev, levelNum)
+			}
+			seen[file] = levelNum
+			if math.IsNaN(file.SizeMB) || file.SizeMB < 0 {
+				t.Fatalf("L%d: file %s has size %v", levelNum, file.ID, file.SizeMB)
+			}
+			if math.IsNaN(file.CreatedAt) || math.IsInf(file.CreatedAt, 0) {
+				t.Fatalf("L%d: file %s has creation time %v", levelNum, file.ID, file.CreatedAt)
+			}
+			totalSize += file.SizeMB
+		}
+		if math.Abs(totalSize-level.TotalSize) > 1e-6*math.Max(1, totalSize) {
+			t.Fatalf("L%d: TotalSize=%.6f but files sum to %.6f", levelNum, level.TotalSize, totalSize)
+		}
+	}
+}
@@ -609,3 +609,79 @@ func TestWALWriteAmplification(t *testing.T) {
 	t.Logf("  Without WAL: WA=%.2fx, WAL bytes=%.2f MB, user writes=%.2f MB",
 		metricsNoWAL.WriteAmplification, metricsNoWAL.WALBytesWritten, metricsNoWAL.TotalDataWrittenMB)
 }
+
+// TestWALWriteAmplificationSplit verifies that the per-user-byte write amplification separates WAL
+// bytes from SST bytes, so toggling the WAL moves only the WAL component
+func TestWALWriteAmplificationSplit(t *testing.T) {
+	run := func(enableWAL bool) *Metrics {
+		config := DefaultConfig()
+		config.EnableWAL = enableWAL
+		config.WriteRateMBps = 10.0
+		config.SimulationSpeedMultiplier = 10
+		config.MemtableFlushSizeMB = 64
+		config.L0CompactionTrigger = 4
+		config.IOThroughputMBps = 125.0
+		config.RandomSeed = 7
+
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		for sim.VirtualTime() < 60.0 {
+			sim.Step()
+		}
+		return sim.Metrics()
+	}
+
+	withWAL := run(true)
+	noWAL := run(false)
+
+	// Every user byte goes to the WAL once
+	require.InDelta(t, 1.0, withWAL.WriteAmplificationWAL, 0.1)
+	require.Equal(t, 0.0, noWAL.WriteAmplificationWAL)
+
+	// Flushes and compactions are counted in the SST component either way
+	require.Greater(t, withWAL.WriteAmplificationCompaction, 0.0)
+	require.Greater(t, noWAL.WriteAmplificationCompaction, 0.0)
+	for _, m := range []*Metrics{withWAL, noWAL} {
+		require.InDelta(t, m.WriteAmplificationWAL+m.WriteAmplificationCompaction, m.WriteAmplificationTotal, 1e-9)
+	}
+
+	// WAL bytes add to SST bytes rather than being hidden in them
+	require.Greater(t, withWAL.WriteAmplificationTotal, withWAL.WriteAmplificationCompaction)
+}
+
+// TestWALGroupCommit verifies that group commit writes the same WAL bytes with one sync per
+// interval instead of one per write, cutting the disk time the WAL takes
+func TestWALGroupCommit(t *testing.T) {
+	run := func(groupCommitMs int) *Simulator {
+		config := DefaultConfig()
+		config.EnableWAL = true
+		config.WALSync = true
+		config.WALSyncLatencyMs = 10
+		config.WALGroupCommitIntervalMs = groupCommitMs
+		config.WriteRateMBps = 40.0
+		config.TrafficDistribution.WriteRateMBps = 40.0
+		config.IOThroughputMBps = 500.0
+		config.RandomSeed = 7
+
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(30.0)
+		return sim
+	}
+
+	perWrite := run(0)
+	grouped := run(200)
+
+	// Every user byte still reaches the WAL (less whatever the open group holds)
+	require.InDelta(t, grouped.metrics.TotalDataWrittenMB, grouped.metrics.WALBytesWritten+grouped.walGroupMB, 1e-9)
+	require.InDelta(t, perWrite.metrics.WALBytesWritten, grouped.metrics.WALBytesWritten, 10.0)
+
+	// Several writes share each sync, so the WAL holds the disk for far less time
+	require.Less(t, grouped.diskTimeByCategory["wal"], perWrite.diskTimeByCategory["wal"]/2)
+
+	config := DefaultConfig()
+	config.WALGroupCommitIntervalMs = -1
+	require.Error(t, config.Validate())
+}
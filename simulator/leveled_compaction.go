@@ -2,8 +2,10 @@ package simulator
 
 import (
 	"fmt"
+	"maps"
 	"math"
 	"math/rand"
+	"slices"
 	"sort"
 	"time"
 )
@@ -26,15 +28,27 @@ import (
 //  1. Statistical file selection: Uses Geometric/Exponential distributions instead of
 //     tracking actual SSTable key ranges. This models workload characteristics
 //     statistically (uniform writes = many overlaps, skewed = few overlaps).
+//     UseKeyRangeOverlap switches to exact interval intersection of per-file key ranges,
+//     making write amplification emergent rather than configured.
 //  2. Simplified intra-L0 logic: Respects max_compaction_bytes but doesn't implement
 //     RocksDB's "diminishing returns" check (compact_bytes_per_del_file increasing).
 //
 // See FIDELITY_REPORT.md for comprehensive audit results and test coverage.
 type LeveledCompactor struct {
-	fileSelectDist    filePicker   // For picking files from source level
-	overlapSelectDist filePicker   // For estimating overlaps in target level
-	rng               *rand.Rand   // Random number generator for file selection
-	activeCompactions map[int]bool // Track levels currently being compacted
+	fileSelectDist    filePicker         // For picking files from source level
+	overlapSelectDist filePicker         // For estimating overlaps in target level
+	rng               *rand.Rand         // Random number generator for file selection
+	rngs              rngSet             // Every RNG above, for RNGState
+	activeCompactions map[int]bool       // Track levels currently being compacted
+	followUpJobs      []*CompactionJob   // Remaining pieces of split over-large compactions (FIFO order)
+	compactCursors    map[int]uint64     // Per-level key the next round_robin pick starts from (absent = start of the key space)
+	pickCursors       map[int]pickCursor // Claimed levels' cursors from before their job was picked, for releaseJob
+}
+
+// pickCursor is a level's round_robin cursor as it was before a pick advanced it
+type pickCursor struct {
+	key uint64
+	set bool // False: the level had no cursor (start of the key space)
 }
 
 // NewLeveledCompactor creates a compactor with default distributions
@@ -49,47 +63,39 @@ func NewLeveledCompactor(seed int64) *LeveledCompactor {
 		GeometricP:        0.3,
 		ExponentialLambda: 0.5,
 	}
-	return NewLeveledCompactorWithOverlapDist(seed, defaultOverlap)
+	return NewLeveledCompactorWithOverlapDist(seed, 0, defaultOverlap)
 }
 
-// NewLeveledCompactorWithOverlapDist creates a compactor with specified overlap distribution
-func NewLeveledCompactorWithOverlapDist(seed int64, overlapConfig OverlapDistributionConfig) *LeveledCompactor {
-	var rng *rand.Rand
-	if seed == 0 {
-		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
-	} else {
-		rng = rand.New(rand.NewSource(seed))
+// NewLeveledCompactorWithOverlapDist creates a compactor with specified overlap distribution.
+// A non-zero overlapSeed gives overlap sampling its own RNG, independent of file selection.
+func NewLeveledCompactorWithOverlapDist(seed, overlapSeed int64, overlapConfig OverlapDistributionConfig) *LeveledCompactor {
+	var rngs rngSet
+	rngSeed := seed
+	if rngSeed == 0 {
+		rngSeed = time.Now().UnixNano()
 	}
+	rng := rngs.newRand(rngSeed)
 
 	// Create overlap distribution based on config
-	var overlapDist Distribution
-	switch overlapConfig.Type {
-	case DistExponential:
-		overlapDist = &ExponentialDistribution{Lambda: overlapConfig.ExponentialLambda}
-	case DistGeometric:
-		overlapDist = &GeometricDistribution{P: overlapConfig.GeometricP}
-	case DistFixed:
-		percentage := overlapConfig.FixedPercentage
-		// Clamp to [0.0, 1.0] - allow 0.0 and 1.0 as valid extremes
-		if percentage < 0.0 {
-			percentage = 0.0
-		}
-		if percentage > 1.0 {
-			percentage = 1.0
-		}
-		overlapDist = &FixedDistribution{Percentage: percentage}
-	default: // DistUniform
-		overlapDist = &UniformDistribution{}
-	}
+	overlapPicker := &distributionAdapter{dist: newOverlapDistribution(overlapConfig), rng: newOverlapRNG(rng, overlapSeed, &rngs)}
 
 	// Use different seeds for each distribution to avoid correlation
 	// Derive seeds from base seed: fileSelect uses seed+1, overlap uses seed+0
-	return &LeveledCompactor{
-		fileSelectDist:    newDistributionAdapterWithSeed(DistGeometric, seed+1), // Favor picking fewer files, use seed+1 for reproducibility
-		overlapSelectDist: &distributionAdapter{dist: overlapDist, rng: rng},     // Uses seed (seed+0)
+	c := &LeveledCompactor{
+		fileSelectDist:    newDistributionAdapterWithSeed(DistGeometric, seed+1, &rngs), // Favor picking fewer files, use seed+1 for reproducibility
+		overlapSelectDist: overlapPicker,                                                // Uses overlapSeed, or shares seed (seed+0) when 0
 		rng:               rng,
 		activeCompactions: make(map[int]bool),
+		compactCursors:    make(map[int]uint64),
+		pickCursors:       make(map[int]pickCursor),
 	}
+	c.rngs = rngs // After the adapters above have added their sources
+	return c
+}
+
+// randomSources returns the compactor's RNGs: file selection, overlap sampling and distributions
+func (c *LeveledCompactor) randomSources() rngSet {
+	return c.rngs
 }
 
 // calculateTotalDowncompactBytes calculates the total bytes being compacted
@@ -144,9 +150,15 @@ func (c *LeveledCompactor) NeedsCompaction(level int, lsm *LSMTree, config SimCo
 // Key difference from RocksDB:
 // - RocksDB tracks actual key ranges and computes exact overlaps
 // - Simulator uses distributions to model overlap probability (workload characteristic)
+// - UseKeyRangeOverlap opts into exact overlaps from per-file key ranges
 //
 // This method does fast checks first (level selection, thresholds) then picks files
 func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
+	// Follow-up pieces of a split compaction run before picking new work
+	if job := c.popFollowUpJob(lsm); job != nil {
+		return job
+	}
+
 	// Fast path: Find best level to compact (moved from FindLevelToCompact)
 	// Calculate total_downcompact_bytes for accurate scoring
 	totalDowncompactBytes := calculateTotalDowncompactBytes(lsm, config)
@@ -179,15 +191,27 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 	}
 
 	// Sort by score descending (highest score first)
+	// Equal scores are ordered by level so picks are reproducible across runs; sort.Slice
+	// is not stable, so without the explicit tie-breaker the order would be unspecified
+	//
+	// FIDELITY: ⚠️ RocksDB's ComputeCompactionScore() only swaps levels on a strictly higher
+	// score, so equal-score levels generally stay in level order (the "shallow" tie-break)
+	preferDeep := config.EqualScoreTieBreak == TieBreakDeep
 	sort.Slice(scores, func(i, j int) bool {
-		return scores[j].score < scores[i].score // Descending order
+		if scores[i].score != scores[j].score {
+			return scores[j].score < scores[i].score // Descending order
+		}
+		if preferDeep {
+			return scores[i].level > scores[j].level
+		}
+		return scores[i].level < scores[j].level
 	})
 
 	// Find first eligible level (not already compacting, target not too busy, score > threshold)
 	bestLevel := -1
 	for _, ls := range scores {
-		// Skip if source level is already compacting
-		if c.activeCompactions[ls.level] {
+		// Skip if source level is already compacting or paused
+		if c.activeCompactions[ls.level] || lsm.Levels[ls.level].CompactionPaused {
 			continue
 		}
 
@@ -262,9 +286,9 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 		}
 	}
 
-	// Fast path: No level needs compaction
+	// Fast path: No level needs compaction - consolidate small files if configured
 	if bestLevel < 0 {
-		return nil
+		return c.pickSmallFileMerge(lsm, config)
 	}
 
 	// Now pick files for the selected level
@@ -337,8 +361,15 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 
 		// Estimate overlap - L0 files typically overlap many L1 files
 		// Distribution models workload: uniform writes = many overlaps, skewed = few
-		numOverlaps := pickOverlapCount(targetLevel.FileCount, c.overlapSelectDist)
-		targetFiles := selectFiles(targetLevel.Files, numOverlaps)
+		// With key ranges, the overlap is exact: every base-level file the L0 files intersect
+		var targetFiles []*SSTFile
+		if config.UseKeyRangeOverlap {
+			minKey, maxKey := keyRangeOf(l0SourceFiles)
+			targetFiles = overlappingFiles(targetLevel.Files, minKey, maxKey)
+		} else {
+			numOverlaps := pickOverlapCount(targetLevel.FileCount, c.overlapSelectDist)
+			targetFiles = selectFiles(targetLevel.Files, numOverlaps)
+		}
 
 		// Calculate target file size
 		var targetTotalSize float64
@@ -590,9 +621,22 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 			maxCompactionMB = float64(config.TargetFileSizeMB * kDefaultMaxCompactionBytesMultiplier)
 		}
 
-		// Pick small number of files from source level
+		// Pick small number of files from source level, in compaction_pri order
 		numSourceFiles := pickFileCount(sourceLevel.FileCount, 1, c.fileSelectDist)
-		sourceFiles := selectFiles(sourceLevel.Files, numSourceFiles)
+		candidates := c.orderSourceFiles(sourceLevel, config.CompactionPriority)
+		if config.UseKeyRangeOverlap {
+			sourceFiles, targetFiles := pickKeyRangeInputs(candidates, targetLevel.Files, numSourceFiles, maxCompactionMB)
+			c.advanceCompactCursor(level, sourceFiles, config.CompactionPriority)
+			return &CompactionJob{
+				FromLevel:   level,
+				ToLevel:     level + 1,
+				SourceFiles: sourceFiles,
+				TargetFiles: targetFiles,
+				IsIntraL0:   false,
+			}
+		}
+		sourceFiles := selectFiles(candidates, numSourceFiles)
+		c.advanceCompactCursor(level, sourceFiles, config.CompactionPriority)
 
 		// Calculate source size
 		var sourceSize float64
@@ -604,22 +648,23 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 		numOverlaps := pickOverlapCount(targetLevel.FileCount, c.overlapSelectDist)
 		targetFiles := selectFiles(targetLevel.Files, numOverlaps)
 
-		// Limit target files to respect max_compaction_bytes
+		// Over-large candidate with several source files: split into sequential jobs
+		// covering disjoint key ranges instead of truncating the overlap
 		var targetSize float64
-		limitedTargetFiles := make([]*SSTFile, 0, len(targetFiles))
 		for _, f := range targetFiles {
-			if sourceSize+targetSize+f.SizeMB > maxCompactionMB {
-				break // Hit limit
-			}
-			limitedTargetFiles = append(limitedTargetFiles, f)
 			targetSize += f.SizeMB
 		}
+		if sourceSize+targetSize > maxCompactionMB && len(sourceFiles) > 1 {
+			jobs := splitCompaction(level, sourceFiles, targetFiles, maxCompactionMB)
+			c.followUpJobs = append(c.followUpJobs, jobs[1:]...)
+			return jobs[0]
+		}
 
 		return &CompactionJob{
 			FromLevel:   level,
 			ToLevel:     level + 1,
 			SourceFiles: sourceFiles,
-			TargetFiles: limitedTargetFiles,
+			TargetFiles: limitTargetFiles(sourceSize, targetFiles, maxCompactionMB),
 			IsIntraL0:   false,
 		}
 	}
@@ -627,6 +672,355 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 	return nil
 }
 
+// orderSourceFiles returns the level's files in the order compaction_pri takes them. The picker
+// starts from the first file, so this decides which part of the level each compaction rewrites.
+//
+// RocksDB Reference: VersionStorageInfo::UpdateFilesByCompactionPri() (db/version_set.cc)
+//
+// FIDELITY: ⚠️ SIMPLIFIED - by_size uses raw file size (no deletion entries to compensate for);
+// the oldest_* priorities compare the write times of the files' oldest/newest data
+// (SSTFile.dataSpan) instead of sequence numbers; kMinOverlappingRatio is not modeled
+func (c *LeveledCompactor) orderSourceFiles(level *Level, priority CompactionPriority) []*SSTFile {
+	if priority == CompactionPriorityLevel {
+		return level.Files
+	}
+	files := append([]*SSTFile(nil), level.Files...)
+	switch priority {
+	case CompactionPriorityBySize:
+		sort.SliceStable(files, func(i, j int) bool { return files[i].SizeMB > files[j].SizeMB })
+	case CompactionPriorityOldestLargestSeq:
+		sort.SliceStable(files, func(i, j int) bool {
+			_, newestI := files[i].dataSpan()
+			_, newestJ := files[j].dataSpan()
+			return newestI < newestJ
+		})
+	case CompactionPriorityOldestSmallestSeq:
+		sort.SliceStable(files, func(i, j int) bool {
+			oldestI, _ := files[i].dataSpan()
+			oldestJ, _ := files[j].dataSpan()
+			return oldestI < oldestJ
+		})
+	case CompactionPriorityRoundRobin:
+		// Key order, from the first file at or after the level's cursor to the end of the level
+		// (a job doesn't wrap around; the pick after it starts over at the beginning)
+		sort.SliceStable(files, func(i, j int) bool {
+			minI, _ := files[i].keyRange()
+			minJ, _ := files[j].keyRange()
+			return minI < minJ
+		})
+		start := 0
+		cursor := c.compactCursors[level.Number]
+		for start < len(files) {
+			if minKey, _ := files[start].keyRange(); minKey >= cursor {
+				break
+			}
+			start++
+		}
+		if start == len(files) {
+			start = 0 // Past the last file: wrap around to the start of the key space
+		}
+		files = files[start:]
+	}
+	return files
+}
+
+// advanceCompactCursor moves a level's round_robin cursor past the files just picked from it,
+// remembering where it was so releaseJob can move it back if the job never runs
+//
+// FIDELITY: ⚠️ SIMPLIFIED - The cursor moves when the job is picked; RocksDB moves it when the
+// compaction's version edit is installed
+func (c *LeveledCompactor) advanceCompactCursor(level int, sourceFiles []*SSTFile, priority CompactionPriority) {
+	if priority != CompactionPriorityRoundRobin || len(sourceFiles) == 0 {
+		return
+	}
+	key, set := c.compactCursors[level]
+	c.pickCursors[level] = pickCursor{key: key, set: set}
+	_, maxKey := keyRangeOf(sourceFiles)
+	if maxKey >= keySpaceMax {
+		delete(c.compactCursors, level) // Reached the end of the key space: start over
+		return
+	}
+	c.compactCursors[level] = maxKey + 1
+}
+
+// kMinSmallFilesToMerge is the minimum run of adjacent small files worth a merge
+// (mirrors kMinFilesForIntraL0Compaction)
+const kMinSmallFilesToMerge = 4
+
+// pickSmallFileMerge is a secondary trigger: when no level's score calls for compaction,
+// find a run of adjacent files below SmallFileMergeThresholdMB and consolidate them.
+// L0 runs merge in place (intra-L0); Ln runs merge into Ln+1 with their overlapping files.
+// The bottommost level is skipped - its files have nowhere to go.
+//
+// FIDELITY: ⚠️ NOT IN ROCKSDB - Leveled compaction only picks by score (plus TTL/periodic/marked files);
+// this models operator-driven consolidation of tiny files (low write rate, frequent periodic flush)
+// FIDELITY: ⚠️ SIMPLIFIED - File order stands in for key order when choosing "adjacent" files
+func (c *LeveledCompactor) pickSmallFileMerge(lsm *LSMTree, config SimConfig) *CompactionJob {
+	if config.SmallFileMergeThresholdMB <= 0 {
+		return nil
+	}
+	threshold := float64(config.SmallFileMergeThresholdMB)
+
+	for level := 0; level < len(lsm.Levels)-1; level++ {
+		if c.activeCompactions[level] || lsm.Levels[level].CompactionPaused {
+			continue
+		}
+		sourceFiles := findSmallFileRun(lsm.Levels[level].Files, threshold)
+		if len(sourceFiles) < kMinSmallFilesToMerge {
+			continue
+		}
+
+		c.activeCompactions[level] = true
+		if level == 0 {
+			return &CompactionJob{
+				FromLevel:        0,
+				ToLevel:          0,
+				SourceFiles:      sourceFiles,
+				TargetFiles:      []*SSTFile{},
+				IsIntraL0:        true,
+				IsSmallFileMerge: true,
+			}
+		}
+
+		var sourceSize float64
+		for _, f := range sourceFiles {
+			sourceSize += f.SizeMB
+		}
+		targetLevel := lsm.Levels[level+1]
+		if config.UseKeyRangeOverlap {
+			minKey, maxKey := keyRangeOf(sourceFiles)
+			return &CompactionJob{
+				FromLevel:        level,
+				ToLevel:          level + 1,
+				SourceFiles:      sourceFiles,
+				TargetFiles:      overlappingFiles(targetLevel.Files, minKey, maxKey),
+				IsSmallFileMerge: true,
+			}
+		}
+		numOverlaps := pickOverlapCount(targetLevel.FileCount, c.overlapSelectDist)
+		targetFiles := selectFiles(targetLevel.Files, numOverlaps)
+		maxCompactionMB := float64(config.MaxCompactionBytesMB)
+		if maxCompactionMB <= 0 {
+			maxCompactionMB = float64(config.TargetFileSizeMB * 25)
+		}
+		return &CompactionJob{
+			FromLevel:        level,
+			ToLevel:          level + 1,
+			SourceFiles:      sourceFiles,
+			TargetFiles:      limitTargetFiles(sourceSize, targetFiles, maxCompactionMB),
+			IsSmallFileMerge: true,
+		}
+	}
+	return nil
+}
+
+// findSmallFileRun returns the first run of adjacent files smaller than thresholdMB,
+// or nil if no run reaches kMinSmallFilesToMerge files
+func findSmallFileRun(files []*SSTFile, thresholdMB float64) []*SSTFile {
+	start := 0
+	for i := 0; i <= len(files); i++ {
+		if i < len(files) && files[i].SizeMB < thresholdMB {
+			continue
+		}
+		if i-start >= kMinSmallFilesToMerge {
+			return append([]*SSTFile(nil), files[start:i]...)
+		}
+		start = i + 1
+	}
+	return nil
+}
+
+// pickKeyRangeInputs picks Ln→Ln+1 inputs by key range (UseKeyRangeOverlap): the level's first
+// file plus up to numSourceFiles-1 of its successors in key order, and every Ln+1 file their
+// range intersects. The run stops growing before source plus overlap would exceed
+// maxCompactionMB (the first file is always taken); overlapping target files are never dropped,
+// so the output can't overlap Ln+1 files left out of the compaction.
+//
+// FIDELITY: ✓ Target files are the exact overlap, as in RocksDB's SetupOtherInputs()
+// https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker.cc#L464-L588
+//
+// FIDELITY: ⚠️ SIMPLIFIED - No clean-cut expansion (a user key never spans two files here).
+// sourceLevelFiles comes in compaction_pri order; the job starts from its first file.
+func pickKeyRangeInputs(sourceLevelFiles, targetLevelFiles []*SSTFile, numSourceFiles int, maxCompactionMB float64) (sourceFiles, targetFiles []*SSTFile) {
+	if len(sourceLevelFiles) == 0 {
+		return nil, nil
+	}
+	byKey := make([]*SSTFile, len(sourceLevelFiles))
+	copy(byKey, sourceLevelFiles)
+	sort.SliceStable(byKey, func(i, j int) bool {
+		minI, _ := byKey[i].keyRange()
+		minJ, _ := byKey[j].keyRange()
+		return minI < minJ
+	})
+	start := 0
+	for i, f := range byKey {
+		if f == sourceLevelFiles[0] {
+			start = i
+			break
+		}
+	}
+
+	var sourceSize float64
+	for _, f := range byKey[start:] {
+		if len(sourceFiles) >= max(1, numSourceFiles) {
+			break
+		}
+		candidate := append(append([]*SSTFile(nil), sourceFiles...), f)
+		minKey, maxKey := keyRangeOf(candidate)
+		overlap := overlappingFiles(targetLevelFiles, minKey, maxKey)
+		totalSize := sourceSize + f.SizeMB
+		for _, t := range overlap {
+			totalSize += t.SizeMB
+		}
+		if len(sourceFiles) > 0 && totalSize > maxCompactionMB {
+			break
+		}
+		sourceFiles, targetFiles = candidate, overlap
+		sourceSize += f.SizeMB
+	}
+	return sourceFiles, targetFiles
+}
+
+// limitTargetFiles keeps target files until adding another would exceed max_compaction_bytes
+func limitTargetFiles(sourceSize float64, targetFiles []*SSTFile, maxCompactionMB float64) []*SSTFile {
+	var targetSize float64
+	limitedTargetFiles := make([]*SSTFile, 0, len(targetFiles))
+	for _, f := range targetFiles {
+		if sourceSize+targetSize+f.SizeMB > maxCompactionMB {
+			break // Hit limit
+		}
+		limitedTargetFiles = append(limitedTargetFiles, f)
+		targetSize += f.SizeMB
+	}
+	return limitedTargetFiles
+}
+
+// splitCompaction partitions an over-large Ln→Ln+1 candidate into jobs that each stay
+// within max_compaction_bytes. The first job is the primary; the rest are follow-ups.
+//
+// FIDELITY: RocksDB Reference - max_compaction_bytes bounds a single compaction
+// https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker.cc#L464-L588
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Only used when overlaps are sampled (UseKeyRangeOverlap off), where the
+// sampled target files don't follow the source files' key ranges, so file order stands in for
+// key order: target files are assigned to source files proportionally by position, and
+// consecutive source files (with their share of targets) are grouped until the limit is reached.
+// Each group therefore covers a disjoint slice of the level.
+func splitCompaction(level int, sourceFiles, targetFiles []*SSTFile, maxCompactionMB float64) []*CompactionJob {
+	jobs := make([]*CompactionJob, 0)
+	var current *CompactionJob
+	var currentSize float64
+
+	for i, src := range sourceFiles {
+		// Target files overlapping this source file's slice of the key space
+		lo := i * len(targetFiles) / len(sourceFiles)
+		hi := (i + 1) * len(targetFiles) / len(sourceFiles)
+		overlap := targetFiles[lo:hi]
+
+		pieceSize := src.SizeMB
+		for _, f := range overlap {
+			pieceSize += f.SizeMB
+		}
+
+		if current != nil && currentSize+pieceSize > maxCompactionMB {
+			jobs = append(jobs, current)
+			current = nil
+		}
+		if current == nil {
+			current = &CompactionJob{
+				FromLevel:  level,
+				ToLevel:    level + 1,
+				IsFollowUp: len(jobs) > 0,
+			}
+			currentSize = 0
+		}
+		current.SourceFiles = append(current.SourceFiles, src)
+		current.TargetFiles = append(current.TargetFiles, overlap...)
+		currentSize += pieceSize
+	}
+	jobs = append(jobs, current)
+
+	// A single source file with too much overlap can't be split further; truncate as before
+	for _, job := range jobs {
+		if len(job.SourceFiles) == 1 {
+			job.TargetFiles = limitTargetFiles(job.SourceFiles[0].SizeMB, job.TargetFiles, maxCompactionMB)
+		}
+	}
+	return jobs
+}
+
+// popFollowUpJob returns the oldest queued follow-up whose source level is free.
+// Files consumed by other compactions since the split are dropped from the job;
+// a follow-up with no remaining source files is discarded.
+func (c *LeveledCompactor) popFollowUpJob(lsm *LSMTree) *CompactionJob {
+	for i := 0; i < len(c.followUpJobs); i++ {
+		job := c.followUpJobs[i]
+		if c.activeCompactions[job.FromLevel] || lsm.Levels[job.FromLevel].CompactionPaused {
+			continue
+		}
+		c.followUpJobs = append(c.followUpJobs[:i], c.followUpJobs[i+1:]...)
+		i--
+
+		job.SourceFiles = filesStillInLevel(job.SourceFiles, lsm.Levels[job.FromLevel])
+		if len(job.SourceFiles) == 0 {
+			continue
+		}
+		job.TargetFiles = filesStillInLevel(job.TargetFiles, lsm.Levels[job.ToLevel])
+
+		c.activeCompactions[job.FromLevel] = true
+		return job
+	}
+	return nil
+}
+
+// releaseJob forgets a picked job the simulator won't run. A follow-up job goes back to the front
+// of the queue so the rest of the split compaction still runs, and the source level's round_robin
+// cursor goes back to where it was before the pick so the job's files aren't skipped.
+func (c *LeveledCompactor) releaseJob(job *CompactionJob) {
+	delete(c.activeCompactions, job.FromLevel)
+	if cursor, ok := c.pickCursors[job.FromLevel]; ok {
+		if cursor.set {
+			c.compactCursors[job.FromLevel] = cursor.key
+		} else {
+			delete(c.compactCursors, job.FromLevel)
+		}
+		delete(c.pickCursors, job.FromLevel)
+	}
+	if job.IsFollowUp {
+		c.followUpJobs = append([]*CompactionJob{job}, c.followUpJobs...)
+	}
+}
+
+// checkpoint saves the claimed levels, follow-up queue and round-robin cursors
+func (c *LeveledCompactor) checkpoint() (restore func()) {
+	active, followUps := maps.Clone(c.activeCompactions), slices.Clone(c.followUpJobs)
+	cursors, pickCursors := maps.Clone(c.compactCursors), maps.Clone(c.pickCursors)
+	return func() {
+		c.activeCompactions, c.followUpJobs = active, followUps
+		c.compactCursors, c.pickCursors = cursors, pickCursors
+	}
+}
+
+// PendingFollowUpJobs returns the number of queued follow-up compactions
+func (c *LeveledCompactor) PendingFollowUpJobs() int {
+	return len(c.followUpJobs)
+}
+
+// filesStillInLevel filters files to those still present in the level
+func filesStillInLevel(files []*SSTFile, level *Level) []*SSTFile {
+	present := make(map[*SSTFile]bool, len(level.Files))
+	for _, f := range level.Files {
+		present[f] = true
+	}
+	kept := make([]*SSTFile, 0, len(files))
+	for _, f := range files {
+		if present[f] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
 // ExecuteCompaction performs the compaction and returns input/output sizes
 //
 // RocksDB Reference: CompactionJob::Run() in db/compaction/compaction_job.cc
@@ -640,15 +1034,16 @@ func (c *LeveledCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Compa
 //
 // Simulation approximations:
 // - No actual data merging (uses reduction factor to model dedup/compression)
-// - File splitting based on size, not actual key ranges
+// - Output files are cut by size; their key ranges divide the input range in proportion to size
 func (c *LeveledCompactor) ExecuteCompaction(job *CompactionJob, lsm *LSMTree, config SimConfig, virtualTime float64) (inputSize, outputSize float64, outputFileCount int) {
 	if job == nil {
 		return 0, 0, 0
 	}
 
-	// Clear active compaction tracking when compaction completes
+	// Clear active compaction tracking when compaction completes (the cursor move is now final)
 	defer func() {
 		delete(c.activeCompactions, job.FromLevel)
+		delete(c.pickCursors, job.FromLevel)
 	}()
 
 	// Handle subcompactions: execute each subcompaction in parallel
@@ -677,9 +1072,9 @@ func (c *LeveledCompactor) ExecuteCompaction(job *CompactionJob, lsm *LSMTree, c
 	//	  }
 	//	}
 	//
-	// FIDELITY: ✓ Matches RocksDB's parallel execution model
-	// ⚠️ SIMPLIFIED: Subcompactions execute sequentially in simulation (modeled as parallel)
-	// All compactions are single-job (subcompactions removed for simplicity)
+	// FIDELITY: ⚠️ SIMPLIFIED - The LSM update is the same with or without subcompactions, so the
+	// job executes as one piece; their parallelism only shortens the job's duration, which the
+	// simulator models when scheduling it (see Simulator.subcompactionCount)
 	return c.executeCompactionSingle(job, lsm, config, virtualTime)
 }
 
@@ -703,7 +1098,11 @@ func (c *LeveledCompactor) executeCompactionSingle(job *CompactionJob, lsm *LSMT
 	//
 	// FIDELITY: ✓ Optimization implemented correctly
 	// Test: compactor_test.go:TestTrivialMove
-	if len(job.TargetFiles) == 0 && !job.IsIntraL0 {
+	//
+	// With key ranges, source files that overlap each other (e.g. several L0 files) must be
+	// merged: moving them as-is would leave overlapping files in an L1+ level
+	// (RocksDB's IsTrivialMove() likewise requires non-overlapping L0 inputs)
+	if len(job.TargetFiles) == 0 && !job.IsIntraL0 && !(config.UseKeyRangeOverlap && filesOverlapEachOther(job.SourceFiles)) {
 		// Check if any source files are already in target level
 		// If so, this is NOT a trivial move (it's merging files from same level)
 		targetLevel := lsm.Levels[job.ToLevel]
@@ -720,57 +1119,49 @@ func (c *LeveledCompactor) executeCompactionSingle(job *CompactionJob, lsm *LSMT
 			}
 		}
 
-		// Only do trivial move if no source files are in target level
-		if !hasFilesFromTargetLevel {
-			// Calculate input size for metrics
-			for _, f := range job.SourceFiles {
-				inputSize += f.SizeMB
-			}
-
-			fmt.Printf("[TRIVIAL MOVE] L%d→L%d: Moving %d files (%.1f MB) without rewriting\n",
-				job.FromLevel, job.ToLevel, len(job.SourceFiles), inputSize)
-
-			// Trivial move: output = input (no reduction)
-			outputSize = inputSize
-			outputFileCount = len(job.SourceFiles) // Just moving existing files
+		// Sources spread over several levels (a manual full-tree compaction) are merged too:
+		// a trivial move only lifts files out of FromLevel
+		multiLevel := slices.ContainsFunc(job.SourceFiles, func(f *SSTFile) bool {
+			return !slices.Contains(lsm.Levels[job.FromLevel].Files, f)
+		})
 
-			// Remove from source level (single level for trivial move)
-			lsm.Levels[job.FromLevel].removeFiles(job.SourceFiles)
-
-			// Add all files to target level (trivial move: just move files)
-			for _, f := range job.SourceFiles {
-				lsm.Levels[job.ToLevel].AddFile(f)
-			}
-
-			return inputSize, outputSize, outputFileCount
+		// Only do trivial move if no source files are in target level
+		if !hasFilesFromTargetLevel && !multiLevel {
+			return trivialMoveFiles(job, lsm)
 		}
 		// Fall through to normal compaction if source files include target level files
 	}
 
 	// Normal compaction: read all input files
+	var sourceSize float64
 	for _, f := range job.SourceFiles {
-		inputSize += f.SizeMB
+		sourceSize += f.SizeMB
 	}
+	inputSize = sourceSize
 	for _, f := range job.TargetFiles {
 		inputSize += f.SizeMB
 	}
 
 	// Calculate output size based on reduction factor
 	// Models RocksDB's merge operator, deduplication, and compression
-	var reductionFactor float64
-	if job.FromLevel == 0 {
-		// L0→base_level: significant deduplication (10% reduction)
-		// Multiple versions of same key across L0 files get merged
-		// FIDELITY: ⚠️ SIMPLIFIED - Uses same reduction factor for L0→any level
-		// In practice, L0→L1 has more dedup than L0→L5, but we approximate with single factor
-		reductionFactor = 0.9
-	} else {
-		// Deeper levels: minimal deduplication (1% reduction)
-		// Leveled structure means less key overlap
-		reductionFactor = 0.99
-	}
+	// (by default 10% out of L0, where multiple versions of the same key across L0 files get
+	// merged, and 1% deeper, where the leveled structure means less key overlap)
+	// FIDELITY: ⚠️ SIMPLIFIED - Factor depends only on the source level
+	// In practice dedup depends on the workload's overwrite rate and key overlap, which
+	// l0ReductionFactor/deepReductionFactor/levelReductionFactors let the user approximate
+	reductionFactor := config.CompactionReductionFactor(job.FromLevel)
+
+	// Source data is recompressed at the target level's ratio; target files are already stored at it
+	// (ratio is 1.0 unless a per-level compression model is configured)
+	storedSize := inputSize - sourceSize + sourceSize*config.RecompressionRatio(job.FromLevel, job.ToLevel)
+
+	// Deleted/expired entries are read and merged, then dropped rather than written
+	outputSize = storedSize * reductionFactor * (1 - config.CompactionGarbageFraction)
 
-	outputSize = inputSize * reductionFactor
+	// Output files split the inputs' combined key range between them and carry their data
+	inputFiles := append(append([]*SSTFile(nil), job.SourceFiles...), job.TargetFiles...)
+	minKey, maxKey := keyRangeOf(inputFiles)
+	oldestData, newestData := dataSpanOf(inputFiles)
 
 	// Handle intra-L0 compaction
 	if job.IsIntraL0 {
@@ -778,8 +1169,14 @@ func (c *LeveledCompactor) executeCompactionSingle(job *CompactionJob, lsm *LSMT
 		lsm.Levels[0].removeFiles(job.SourceFiles)
 		numOutputFiles := max(1, len(job.SourceFiles)/2) // Merge into fewer files (int)
 		avgFileSize := outputSize / float64(numOutputFiles)
+		outputFiles := make([]*SSTFile, 0, numOutputFiles)
 		for i := 0; i < numOutputFiles; i++ {
-			lsm.Levels[0].AddSize(avgFileSize, virtualTime)
+			outputFiles = append(outputFiles, lsm.Levels[0].AddSize(avgFileSize, virtualTime))
+		}
+		// Disjoint slices of the inputs' key range, so the outputs share one L0 sub-level
+		assignKeyRanges(outputFiles, minKey, maxKey)
+		for _, f := range outputFiles {
+			f.setDataSpan(oldestData, newestData)
 		}
 		// DEBUG
 		fmt.Printf("[COMPACTION] Intra-L0: removed %d files, added %d files, L0 now has %d files\n",
@@ -880,27 +1277,27 @@ func (c *LeveledCompactor) executeCompactionSingle(job *CompactionJob, lsm *LSMT
 	// - L4: 64MB * 2^3 = 512MB
 	// - L5: 64MB * 2^4 = 1024MB
 	// - L6: 64MB * 2^5 = 2048MB (2GB, capped)
-	targetFileSizeMB := float64(config.TargetFileSizeMB)
-	if job.ToLevel > 0 {
-		// Apply multiplier: level 1 uses base, level 2 uses base*mult, etc.
-		multiplier := float64(config.TargetFileSizeMultiplier)
-		for i := 1; i < job.ToLevel; i++ {
-			targetFileSizeMB *= multiplier
-		}
-		// Cap at 2GB per file (reasonable maximum for manageable compactions)
-		if targetFileSizeMB > 2048.0 {
-			targetFileSizeMB = 2048.0
-		}
+	targetFileSizeMB := targetFileSizeForLevel(job.ToLevel, config)
+
+	fileSizes := splitOutputFiles(outputSize, targetFileSizeMB, float64(config.MinOutputFileSizeMB))
+	if job.ToLevel == 0 && config.CompactionStyle == CompactionStyleUniversal {
+		// Universal L0 output is a single sorted run (max_file_size[0] = ULLONG_MAX above); splitting
+		// it would turn one run into several, each counting toward the sorted-run trigger.
+		// This is every compaction's output when numLevels == 1.
+		fileSizes = []float64{outputSize}
+	} else if config.SplitOutputAtTargetBoundaries && len(job.TargetFiles) > 1 {
+		sizeOnlyCount := len(fileSizes)
+		fileSizes = splitOutputAtBoundaries(outputSize, job.TargetFiles, targetFileSizeMB, float64(config.MinOutputFileSizeMB))
+		job.ExtraOutputFiles = max(0, len(fileSizes)-sizeOnlyCount)
 	}
-
-	numOutputFiles := int(math.Ceil(outputSize / targetFileSizeMB))
-	if numOutputFiles < 1 {
-		numOutputFiles = 1
+	numOutputFiles := len(fileSizes)
+	outputFiles := make([]*SSTFile, 0, numOutputFiles)
+	for _, sizeMB := range fileSizes {
+		outputFiles = append(outputFiles, lsm.Levels[job.ToLevel].AddSize(sizeMB, virtualTime))
 	}
-
-	avgFileSize := outputSize / float64(numOutputFiles)
-	for i := 0; i < numOutputFiles; i++ {
-		lsm.Levels[job.ToLevel].AddSize(avgFileSize, virtualTime)
+	assignKeyRanges(outputFiles, minKey, maxKey)
+	for _, f := range outputFiles {
+		f.setDataSpan(oldestData, newestData)
 	}
 
 	// DEBUG: After compaction
@@ -913,6 +1310,108 @@ func (c *LeveledCompactor) executeCompactionSingle(job *CompactionJob, lsm *LSMT
 	return inputSize, outputSize, numOutputFiles
 }
 
+// trivialMoveFiles moves a job's source files to its target level as they are, without reading or
+// rewriting them: output = input, and the files keep their identity
+func trivialMoveFiles(job *CompactionJob, lsm *LSMTree) (inputSize, outputSize float64, outputFileCount int) {
+	// Calculate input size for metrics
+	for _, f := range job.SourceFiles {
+		inputSize += f.SizeMB
+	}
+
+	fmt.Printf("[TRIVIAL MOVE] L%d→L%d: Moving %d files (%.1f MB) without rewriting\n",
+		job.FromLevel, job.ToLevel, len(job.SourceFiles), inputSize)
+
+	// Trivial move: output = input (no reduction)
+	outputSize = inputSize
+	outputFileCount = len(job.SourceFiles) // Just moving existing files
+
+	// Remove from source level (single level for trivial move)
+	lsm.Levels[job.FromLevel].removeFiles(job.SourceFiles)
+
+	// Add all files to target level (trivial move: just move files)
+	for _, f := range job.SourceFiles {
+		lsm.Levels[job.ToLevel].AddFile(f)
+	}
+
+	return inputSize, outputSize, outputFileCount
+}
+
+// splitOutputFiles divides compaction output into files for a level whose target file size is
+// targetFileSizeMB. By default (minFileSizeMB = 0) the output is spread evenly over
+// ceil(output / target) files. With minFileSizeMB set, files are cut at the target size with the
+// remainder in a trailing file, and a trailing file smaller than minFileSizeMB is merged into the
+// previous file instead of being emitted as a runt.
+//
+// FIDELITY: RocksDB Reference - CompactionOutputs::ShouldStopBefore() cuts the output
+// file once it reaches max_output_file_size (the level's target file size)
+// https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_outputs.cc
+//
+// FIDELITY: ⚠️ SIMPLIFIED - The even split stands in for RocksDB's cut-at-target files plus
+// remainder; RocksDB has no runt-merge option; files are also cut at grandparent-overlap and
+// key boundaries, which we don't model
+func splitOutputFiles(outputSize, targetFileSizeMB, minFileSizeMB float64) []float64 {
+	if outputSize <= targetFileSizeMB {
+		return []float64{outputSize}
+	}
+
+	if minFileSizeMB <= 0 {
+		numFiles := int(math.Ceil(outputSize / targetFileSizeMB))
+		fileSizes := make([]float64, numFiles)
+		for i := range fileSizes {
+			fileSizes[i] = outputSize / float64(numFiles)
+		}
+		return fileSizes
+	}
+
+	numFullFiles := int(outputSize / targetFileSizeMB)
+	fileSizes := make([]float64, numFullFiles, numFullFiles+1)
+	for i := range fileSizes {
+		fileSizes[i] = targetFileSizeMB
+	}
+
+	remainder := outputSize - float64(numFullFiles)*targetFileSizeMB
+	if remainder <= 1e-9 {
+		return fileSizes
+	}
+	if remainder < minFileSizeMB {
+		fileSizes[numFullFiles-1] += remainder // Merge runt into previous file
+		return fileSizes
+	}
+	return append(fileSizes, remainder)
+}
+
+// splitOutputAtBoundaries cuts compaction output at the key boundaries of the overlapped target
+// files as well as at targetFileSizeMB. Each target file's key range becomes a segment receiving
+// a share of the output proportional to that file's size (source data lands between the same
+// boundaries), and each segment is split by size on its own, so the file count is at least the
+// number of target files even when the size formula alone would produce fewer.
+//
+// FIDELITY: RocksDB Reference - CompactionOutputs::ShouldStopBefore() also cuts at grandparent
+// boundaries; here the boundaries come from the level being merged into
+// https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_outputs.cc
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Source data is spread over the target segments by the target files'
+// sizes rather than by how its key range intersects theirs
+func splitOutputAtBoundaries(outputSize float64, targets []*SSTFile, targetFileSizeMB, minFileSizeMB float64) []float64 {
+	var targetTotal float64
+	for _, f := range targets {
+		targetTotal += f.SizeMB
+	}
+	if targetTotal <= 0 {
+		return splitOutputFiles(outputSize, targetFileSizeMB, minFileSizeMB)
+	}
+
+	fileSizes := make([]float64, 0, len(targets))
+	for _, f := range targets {
+		segment := outputSize * f.SizeMB / targetTotal
+		if segment <= 0 {
+			continue
+		}
+		fileSizes = append(fileSizes, splitOutputFiles(segment, targetFileSizeMB, minFileSizeMB)...)
+	}
+	return fileSizes
+}
+
 // removeFiles removes specified files from a level
 func (l *Level) removeFiles(filesToRemove []*SSTFile) {
 	if len(filesToRemove) == 0 {
@@ -1,8 +1,10 @@
 package simulator
 
 import (
+	"cmp"
 	"fmt"
 	"math"
+	"slices"
 )
 
 // SSTFile represents a single SST file
@@ -10,6 +12,154 @@ type SSTFile struct {
 	ID        string  `json:"id"`
 	SizeMB    float64 `json:"sizeMB"`
 	CreatedAt float64 `json:"createdAt"` // Virtual time when created
+
+	CompactedAt float64 `json:"compactedAt,omitempty"` // Virtual time a compaction wrote this file (0 = flushed/ingested, or recompactionWindowSeconds disabled)
+
+	// How long before CreatedAt the file's oldest and newest data was written, standing in for its
+	// smallest and largest sequence numbers. Compaction outputs carry their inputs' data, so they
+	// set these; 0 = written at CreatedAt (flushed, ingested and placed files).
+	OldestDataAge float64 `json:"oldestDataAge,omitempty"`
+	NewestDataAge float64 `json:"newestDataAge,omitempty"`
+
+	// Key range (inclusive); MaxKey 0 means unknown, treated as spanning the whole key space
+	MinKey uint64 `json:"minKey,omitempty"`
+	MaxKey uint64 `json:"maxKey,omitempty"`
+}
+
+// dataSpan returns the virtual times the file's oldest and newest data was written
+func (f *SSTFile) dataSpan() (oldest, newest float64) {
+	return f.CreatedAt - f.OldestDataAge, f.CreatedAt - f.NewestDataAge
+}
+
+// setDataSpan records that the file holds data written between oldest and newest
+func (f *SSTFile) setDataSpan(oldest, newest float64) {
+	f.OldestDataAge = max(0, f.CreatedAt-oldest)
+	f.NewestDataAge = max(0, f.CreatedAt-newest)
+}
+
+// dataSpanOf returns the combined data span of files (a compaction output inherits its inputs')
+func dataSpanOf(files []*SSTFile) (oldest, newest float64) {
+	for i, f := range files {
+		fOldest, fNewest := f.dataSpan()
+		if i == 0 {
+			oldest, newest = fOldest, fNewest
+			continue
+		}
+		oldest = min(oldest, fOldest)
+		newest = max(newest, fNewest)
+	}
+	return oldest, newest
+}
+
+// keySpaceMax is the largest key in the simulated key space
+const keySpaceMax uint64 = 1 << 48
+
+// keyRange returns the file's inclusive key range (the whole key space if unknown)
+func (f *SSTFile) keyRange() (minKey, maxKey uint64) {
+	if f.MaxKey == 0 {
+		return 0, keySpaceMax
+	}
+	return f.MinKey, f.MaxKey
+}
+
+// overlapsKeyRange reports whether the file's keys intersect [minKey, maxKey]
+func (f *SSTFile) overlapsKeyRange(minKey, maxKey uint64) bool {
+	fMin, fMax := f.keyRange()
+	return fMin <= maxKey && minKey <= fMax
+}
+
+// keyRangeOf returns the smallest range covering every file's keys
+func keyRangeOf(files []*SSTFile) (minKey, maxKey uint64) {
+	minKey = keySpaceMax
+	for _, f := range files {
+		fMin, fMax := f.keyRange()
+		minKey = min(minKey, fMin)
+		maxKey = max(maxKey, fMax)
+	}
+	return minKey, maxKey
+}
+
+// overlappingFiles returns the files whose key ranges intersect [minKey, maxKey], in their original order
+//
+// FIDELITY: ✓ Same interval test as RocksDB's VersionStorageInfo::GetOverlappingInputs()
+// https://github.com/facebook/rocksdb/blob/main/db/version_set.cc
+func overlappingFiles(files []*SSTFile, minKey, maxKey uint64) []*SSTFile {
+	var overlapping []*SSTFile
+	for _, f := range files {
+		if f.overlapsKeyRange(minKey, maxKey) {
+			overlapping = append(overlapping, f)
+		}
+	}
+	return overlapping
+}
+
+// filesOverlapEachOther reports whether any two of the files share a key
+func filesOverlapEachOther(files []*SSTFile) bool {
+	for i, f := range files {
+		fMin, fMax := f.keyRange()
+		for _, other := range files[i+1:] {
+			if other.overlapsKeyRange(fMin, fMax) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// assignKeyRanges splits [minKey, maxKey] into consecutive, non-overlapping slices, one per file
+// in order, each proportional to the file's size
+func assignKeyRanges(files []*SSTFile, minKey, maxKey uint64) {
+	var total float64
+	for _, f := range files {
+		total += f.SizeMB
+	}
+	if len(files) == 0 || total <= 0 {
+		return
+	}
+	span := float64(maxKey - minKey)
+	next := minKey
+	var cumulative float64
+	for i, f := range files {
+		cumulative += f.SizeMB
+		end := minKey + uint64(span*cumulative/total)
+		if i == len(files)-1 {
+			end = maxKey
+		}
+		f.MinKey = min(next, maxKey)
+		f.MaxKey = max(end, f.MinKey)
+		next = f.MaxKey + 1
+	}
+}
+
+// keysForSizeMB estimates how many key-value pairs fit in sizeMB of data.
+// Returns 0 when avgKeyValueSizeBytes is 0 (key counts not modeled).
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Uniform key-value size; RocksDB tracks exact num_entries per file
+func keysForSizeMB(sizeMB float64, avgKeyValueSizeBytes int) int64 {
+	if avgKeyValueSizeBytes <= 0 || sizeMB <= 0 {
+		return 0
+	}
+	return int64(sizeMB * 1024 * 1024 / float64(avgKeyValueSizeBytes))
+}
+
+// keysInSSTs estimates how many key-value pairs sizeMB of SST files hold, leaving out their
+// index and filter blocks
+func keysInSSTs(sizeMB float64, config SimConfig) int64 {
+	return keysForSizeMB(sizeMB/config.MetadataOverheadFactor(), config.AvgKeyValueSizeBytes)
+}
+
+// targetFileSizeForLevel returns the target SST file size for a level
+// L0 and L1 use target_file_size_base; each deeper level multiplies by target_file_size_multiplier.
+// Capped at 2GB, matching compaction output splitting.
+func targetFileSizeForLevel(level int, config SimConfig) float64 {
+	targetFileSizeMB := float64(config.TargetFileSizeMB)
+	for i := 1; i < level; i++ {
+		targetFileSizeMB *= float64(config.TargetFileSizeMultiplier)
+	}
+	if targetFileSizeMB > 2048.0 {
+		targetFileSizeMB = 2048.0
+	}
+	return targetFileSizeMB
 }
 
 // AgeSeconds returns the age of the file at given virtual time
@@ -26,6 +176,7 @@ type Level struct {
 	CompactingSize        float64    `json:"compactingSizeMB"`      // Size of files currently being compacted FROM this level
 	CompactingFileCount   int        `json:"compactingFileCount"`   // Number of files currently being compacted FROM this level
 	TargetCompactingFiles int        `json:"targetCompactingFiles"` // Number of files at this level being used as TARGET in compactions
+	CompactionPaused      bool       `json:"compactionPaused"`      // No new compactions take input from this level (see Simulator.PauseLevelCompaction)
 }
 
 // NewLevel creates a new level
@@ -58,9 +209,9 @@ func (l *Level) AddFile(file *SSTFile) {
 	l.FileCount++
 }
 
-// AddSize adds data of given size to the level (creates a virtual file)
+// AddSize adds data of given size to the level (creates a virtual file) and returns the file
 // Used by compaction when we don't need to track individual file details
-func (l *Level) AddSize(sizeMB float64, virtualTime float64) {
+func (l *Level) AddSize(sizeMB float64, virtualTime float64) *SSTFile {
 	// Create a single virtual file representing the compacted data
 	file := &SSTFile{
 		ID:        fmt.Sprintf("sst-%d-%d", l.Number, len(l.Files)),
@@ -68,6 +219,49 @@ func (l *Level) AddSize(sizeMB float64, virtualTime float64) {
 		CreatedAt: virtualTime,
 	}
 	l.AddFile(file)
+	return file
+}
+
+// SubLevelCount returns the number of L0 sub-levels in this level (see subLevels)
+func (l *Level) SubLevelCount() int {
+	return len(l.subLevels())
+}
+
+// subLevels organizes the level's files into L0 sub-levels from their ages and key ranges, oldest
+// sub-level first: each file, taken oldest data first, goes one sub-level above the highest
+// sub-level holding an older file its keys overlap. Files within a sub-level never overlap, so a
+// point lookup checks at most one file per sub-level. Flushed files span the whole key space and
+// stack one per sub-level; the disjoint outputs of an intra-L0 compaction, and disjoint flushed or
+// ingested files, share one.
+//
+// FIDELITY: RocksDB Reference - None; sub-levels come from Pebble (manifest/l0_sublevels.go)
+// FIDELITY: ⚠️ SIMPLIFIED - A file's newest data time (dataSpan) stands in for its largest
+// sequence number (CreatedAtJitterSeconds can reorder flushes)
+func (l *Level) subLevels() [][]*SSTFile {
+	files := slices.Clone(l.Files)
+	slices.Reverse(files) // Newest files are prepended, so this is (mostly) oldest first
+	slices.SortStableFunc(files, func(a, b *SSTFile) int {
+		_, aNewest := a.dataSpan()
+		_, bNewest := b.dataSpan()
+		return cmp.Compare(aNewest, bNewest)
+	})
+
+	var subLevels [][]*SSTFile
+	for _, f := range files {
+		minKey, maxKey := f.keyRange()
+		subLevel := 0
+		for i := len(subLevels) - 1; i >= 0; i-- {
+			if len(overlappingFiles(subLevels[i], minKey, maxKey)) > 0 {
+				subLevel = i + 1
+				break
+			}
+		}
+		if subLevel == len(subLevels) {
+			subLevels = append(subLevels, nil)
+		}
+		subLevels[subLevel] = append(subLevels[subLevel], f)
+	}
+	return subLevels
 }
 
 // RemoveFiles removes files from the level
@@ -168,6 +362,7 @@ func (t *LSMTree) FlushMemtable(virtualTime float64) *SSTFile {
 		ID:        fmt.Sprintf("sst-%d", t.nextFileID),
 		SizeMB:    t.MemtableCurrentSize,
 		CreatedAt: virtualTime,
+		MaxKey:    keySpaceMax, // Uniform writes: a memtable's keys span the whole key space
 	}
 	t.nextFileID++
 
@@ -182,7 +377,13 @@ func (t *LSMTree) FlushMemtable(virtualTime float64) *SSTFile {
 	return file
 }
 
-// CreateSSTFile creates an SST file at the specified level with given size
+// CreateSSTFile creates an SST file at the specified level with given size.
+// Key ranges follow the traffic: writes are uniform over the key space, so an L0 file spans all
+// of it, while a file placed directly in L1+ (initial population, ingestion) takes its share of
+// the level's non-overlapping key space.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Uniform keys only (no skewed or sequential inserts); adding a file
+// below L0 re-partitions that level's key space among its files by size
 // This is used when flushing a frozen (immutable) memtable
 func (t *LSMTree) CreateSSTFile(level int, sizeMB float64, virtualTime float64) *SSTFile {
 	if level < 0 || level >= len(t.Levels) || sizeMB <= 0 {
@@ -199,6 +400,11 @@ func (t *LSMTree) CreateSSTFile(level int, sizeMB float64, virtualTime float64)
 	// Add to specified level
 	t.Levels[level].AddFile(file)
 	t.TotalSizeMB += file.SizeMB
+	if level == 0 {
+		file.MaxKey = keySpaceMax
+	} else {
+		assignKeyRanges(t.Levels[level].Files, 0, keySpaceMax)
+	}
 
 	return file
 }
@@ -363,6 +569,10 @@ func (t *LSMTree) calculateCompactionScore(level int, config SimConfig, totalDow
 		// RocksDB scores L0 by file count because each file must be checked during reads
 		// This matches RocksDB's level0_file_num_compaction_trigger behavior
 		fileScore := float64(levelState.FileCount) / float64(config.L0CompactionTrigger)
+		if config.EnableL0SubLevels && config.CompactionStyle == CompactionStyleLeveled {
+			// Reads check one file per sub-level, so sub-levels are what L0 compaction has to bring down
+			fileScore = float64(levelState.SubLevelCount()) / float64(config.L0CompactionTrigger)
+		}
 		sizeScore := levelState.TotalSize / float64(config.MaxBytesForLevelBaseMB)
 		if fileScore > sizeScore {
 			return fileScore
@@ -802,13 +1012,16 @@ func (t *LSMTree) State(virtualTime float64, config SimConfig) map[string]interf
 			"totalSizeMB":  level.TotalSize,
 			"targetSizeMB": targets[i],
 			"fileCount":    level.FileCount,
+			"keyCount":     keysInSSTs(level.TotalSize, config),
 			"files":        files,
+			"paused":       level.CompactionPaused,
 		}
 	}
 
 	return map[string]interface{}{
 		"levels":                levels,
 		"memtableCurrentSizeMB": t.MemtableCurrentSize,
+		"memtableKeyCount":      keysForSizeMB(t.MemtableCurrentSize, config.AvgKeyValueSizeBytes),
 		"totalSizeMB":           t.TotalSizeMB,
 	}
 }
@@ -165,3 +165,58 @@ func TestDynamicBaseLevel_MatchesCalculateLevelTargets(t *testing.T) {
 		})
 	}
 }
+
+// TestDynamicBaseLevel_NewLevelActivatesThroughGrowth drives a leveled simulation with dynamic
+// level bytes until the base level moves up, checking end to end that flushes first land in the
+// deepest level, that compaction output then establishes the previously empty level above it, and
+// that State() reports the newly active level with a target size
+func TestDynamicBaseLevel_NewLevelActivatesThroughGrowth(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = true
+	config.NumLevels = 4
+	config.MemtableFlushSizeMB = 16
+	config.TargetFileSizeMB = 16
+	config.MaxBytesForLevelBaseMB = 64
+	config.LevelMultiplier = 4
+	config.L0CompactionTrigger = 2
+	config.WriteRateMBps = 10
+	config.TrafficDistribution.WriteRateMBps = 10
+	config.MaxBackgroundJobs = 4
+	config.RandomSeed = 7
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+
+	deepest := config.NumLevels - 1
+	require.Equal(t, deepest, sim.lsm.calculateDynamicBaseLevel(config), "empty tree compacts L0 straight to the last level")
+
+	// Run until some level above the deepest one holds data
+	activated := -1
+	for sim.VirtualTime() < 3600 && activated < 0 {
+		sim.Step()
+		require.False(t, sim.metrics.IsOOMKilled)
+		checkFileInvariants(t, sim.lsm)
+		for level := 1; level < deepest; level++ {
+			if sim.lsm.Levels[level].FileCount > 0 {
+				activated = level
+				break
+			}
+		}
+	}
+	require.GreaterOrEqual(t, activated, 1, "growth should activate a level above L%d", deepest)
+	require.Greater(t, sim.lsm.Levels[deepest].TotalSize, 0.0, "the deepest level fills first")
+
+	state := sim.State()
+	baseLevel := state["baseLevel"].(int)
+	require.Less(t, baseLevel, deepest, "base level moved up as data grew")
+	require.LessOrEqual(t, baseLevel, activated, "data only lands at or below the base level")
+
+	levels := state["levels"].([]map[string]interface{})
+	require.Greater(t, levels[activated]["fileCount"].(int), 0)
+	require.Greater(t, levels[activated]["targetSizeMB"].(float64), 0.0, "the active level gets a target size")
+	for level := 1; level < baseLevel; level++ {
+		require.Equal(t, 0, levels[level]["fileCount"].(int), "L%d is above the base level and stays empty", level)
+	}
+}
@@ -0,0 +1,102 @@
+package simulator
+
+import "fmt"
+
+// manualCompactionReason is the Reason of jobs started by RequestManualCompaction
+const manualCompactionReason = "manual"
+
+// RequestManualCompaction queues a full compaction of one level into the next (L0 into the base
+// level under dynamic level bytes), or of the whole tree into the last level when level < 0. It
+// starts at the next compaction check, whether or not the level's score calls for it.
+//
+// Manual compactions run exclusively: once one is requested, no new automatic compactions start in
+// the default column family; the manual compaction waits for the running ones to finish, then
+// runs alone. It still takes a background job slot and pays for its CPU and disk time like any
+// other compaction. A request with nothing to compact is dropped.
+//
+// FIDELITY: RocksDB Reference - DBImpl::CompactRange() (db/db_impl/db_impl_compaction_flush.cc)
+// with CompactRangeOptions::exclusive_manual_compaction = true
+//
+// FIDELITY: ⚠️ SIMPLIFIED - A full-tree compaction is one job into the last level; RocksDB's
+// CompactRange() compacts level by level, running one compaction per level
+// FIDELITY: ⚠️ SIMPLIFIED - Only the default column family; leveled and universal styles only
+func (s *Simulator) RequestManualCompaction(level int) error {
+	if s.config.CompactionStyle != CompactionStyleLeveled && s.config.CompactionStyle != CompactionStyleUniversal {
+		return fmt.Errorf("manual compaction requires leveled or universal compaction, not %s", s.config.CompactionStyle)
+	}
+	if level >= len(s.lsm.Levels)-1 {
+		return fmt.Errorf("manual compaction level %d out of range [0, %d) (or < 0 for the whole tree)", level, len(s.lsm.Levels)-1)
+	}
+	if level < 0 {
+		level = -1
+		s.logEvent("[t=%.1fs] MANUAL COMPACTION requested: full tree", s.virtualTime)
+	} else {
+		s.logEvent("[t=%.1fs] MANUAL COMPACTION requested: L%d", s.virtualTime, level)
+	}
+	s.manualCompactions = append(s.manualCompactions, level)
+	s.record(journalEntry{Op: "manual_compaction", Level: level})
+	return nil
+}
+
+// pickManualCompaction returns the next requested manual compaction once the default column
+// family's running compactions have drained. blocked reports that family's automatic compactions
+// must wait because a manual compaction is queued or running. Manual compactions only target the
+// default column family, so other column families are never blocked.
+func (s *Simulator) pickManualCompaction(family *columnFamily) (job *CompactionJob, blocked bool) {
+	if family.id != 0 {
+		return nil, false
+	}
+	draining := false
+	for _, pending := range s.pendingCompactions {
+		if pending.ColumnFamily != 0 {
+			continue
+		}
+		if pending.Reason == manualCompactionReason {
+			return nil, true
+		}
+		draining = true
+	}
+
+	for len(s.manualCompactions) > 0 {
+		if draining {
+			return nil, true
+		}
+		level := s.manualCompactions[0]
+		s.manualCompactions = s.manualCompactions[1:]
+		if job := s.manualCompactionJob(level); job != nil {
+			return job, true
+		}
+	}
+	return nil, false
+}
+
+// manualCompactionJob builds the job for a manual compaction of level (< 0 = whole tree), or nil
+// when there is nothing to compact
+func (s *Simulator) manualCompactionJob(level int) *CompactionJob {
+	last := len(s.lsm.Levels) - 1
+	job := &CompactionJob{FromLevel: level, ToLevel: level + 1, Reason: manualCompactionReason}
+	if level < 0 {
+		// Every file above the last level merges into it
+		job.FromLevel, job.ToLevel = -1, last
+		for l := 0; l < last; l++ {
+			if len(s.lsm.Levels[l].Files) == 0 {
+				continue
+			}
+			if job.FromLevel < 0 {
+				job.FromLevel = l
+			}
+			job.SourceFiles = append(job.SourceFiles, s.lsm.Levels[l].Files...)
+		}
+	} else {
+		job.SourceFiles = append([]*SSTFile(nil), s.lsm.Levels[level].Files...)
+		if level == 0 && s.config.CompactionStyle == CompactionStyleLeveled && s.config.LevelCompactionDynamicLevelBytes {
+			job.ToLevel = s.lsm.calculateDynamicBaseLevel(s.config)
+		}
+	}
+	if len(job.SourceFiles) == 0 {
+		return nil
+	}
+	// A full compaction rewrites the whole output level
+	job.TargetFiles = append([]*SSTFile(nil), s.lsm.Levels[job.ToLevel].Files...)
+	return job
+}
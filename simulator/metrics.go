@@ -2,7 +2,9 @@ package simulator
 
 import (
 	"log"
+	"math"
 	"math/rand"
+	"sort"
 )
 
 // WriteActivity tracks a write event for throughput calculation
@@ -11,8 +13,58 @@ type WriteActivity struct {
 	EndTime   float64 // Virtual time when write completed
 	SizeMB    float64 // Output size in MB
 	InputMB   float64 // Input size in MB (for compactions)
-	Level     int     // Source level (-1 = flush to L0, 0+ = compaction from level N)
+	Level     int     // Source level (-3 = ingestion, -2 = WAL, -1 = flush to L0, 0+ = compaction from level N)
 	ToLevel   int     // Target level (for compactions)
+	// Column family written (0 = default). Per-level stats describe the default column family's
+	// tree, so they skip the other column families' compactions.
+	ColumnFamily int
+}
+
+// SlotOccupancy records the interval during which a background job slot was reserved
+type SlotOccupancy struct {
+	Slot      int     // Background job slot index
+	StartTime float64 // Virtual time when the job started running in the slot
+	EndTime   float64 // Virtual time when the slot became free again
+}
+
+// spreadActivity is an amount of work (disk operations, rate-limited MB) spread evenly over
+// [StartTime, EndTime), for utilization over the throughput window
+type spreadActivity struct {
+	StartTime float64
+	EndTime   float64
+	Amount    float64
+}
+
+// sumOverWindow returns how much of the activities' amount falls within [windowStart, virtualTime],
+// along with the activities that haven't ended before the window (the rest can be dropped)
+func sumOverWindow(activities []spreadActivity, windowStart, virtualTime float64) (float64, []spreadActivity) {
+	var total float64
+	kept := activities[:0]
+	for _, a := range activities {
+		if a.EndTime < windowStart {
+			continue
+		}
+		kept = append(kept, a)
+
+		if a.EndTime <= a.StartTime {
+			if a.StartTime <= virtualTime {
+				total += a.Amount
+			}
+			continue
+		}
+		overlap := min(a.EndTime, virtualTime) - max(a.StartTime, windowStart)
+		if overlap > 0 {
+			total += a.Amount * overlap / (a.EndTime - a.StartTime)
+		}
+	}
+	return total, kept
+}
+
+// rateSample records cumulative byte counters at one metrics update, for windowed rates
+type rateSample struct {
+	time      float64 // Virtual time of the update
+	flushedMB float64 // Cumulative MB flushed to L0
+	writtenMB float64 // Cumulative MB of user writes accepted
 }
 
 // CompactionStats tracks aggregate compaction activity since last UI update
@@ -32,17 +84,76 @@ type Metrics struct {
 	// Amplification factors
 	WriteAmplification float64 `json:"writeAmplification"` // bytes written to disk / bytes written by flush (RocksDB-style)
 	ReadAmplification  float64 `json:"readAmplification"`  // number of files checked during point lookup (RocksDB-style approximation)
-	SpaceAmplification float64 `json:"spaceAmplification"` // disk space used / logical data size
+	SpaceAmplification float64 `json:"spaceAmplification"` // disk space used / live data size (see UpdateSpaceAmplification)
+	LiveDataSizeMB     float64 `json:"liveDataSizeMB"`     // estimated live bytes: user writes minus the overwrites compaction merges away
+	L0SubLevelCount    int     `json:"l0SubLevelCount"`    // number of L0 sub-levels (equals L0 file count unless some L0 files don't overlap)
+
+	// Write amplification per user byte, split by where the bytes go, so changing the WAL settings
+	// shows up in one component only. Unlike WriteAmplification (flush-denominated, RocksDB-style),
+	// the denominator is user bytes (writes plus ingested files).
+	WriteAmplificationWAL        float64 `json:"writeAmplificationWAL"`        // WAL bytes / user bytes (0 with the WAL disabled)
+	WriteAmplificationCompaction float64 `json:"writeAmplificationCompaction"` // SST bytes (flush + compaction output + ingested files) / user bytes
+	WriteAmplificationTotal      float64 `json:"writeAmplificationTotal"`      // WriteAmplificationWAL + WriteAmplificationCompaction
+
+	// ReadAmplification sampled from the tree as each read batch is scheduled, smoothed like the throughput
+	// metrics (0 until reads run; ResetMetrics re-seeds it from the next batch)
+	ReadAmplificationEMA float64 `json:"readAmplificationEMA"`
+
+	// Read amplification achieved over the run (compare compactionObjective settings)
+	AvgReadAmplification      float64 `json:"avgReadAmplification"`      // Time-weighted mean of ReadAmplification since the start (or ResetMetrics)
+	PeakReadAmplification     float64 `json:"peakReadAmplification"`     // Highest ReadAmplification seen since the start (or ResetMetrics)
+	ReadAmpObjectiveOverrides int     `json:"readAmpObjectiveOverrides"` // L0 compactions the min_read_amp objective started while a deeper level was also due
+
+	// Queued L0 work: ceil(L0 bytes / typical L0 compaction output), 0 when L0 is empty
+	// Typical output is the mean of completed L0 compactions, or one trigger's worth of memtables before any ran
+	EstimatedCompactionsToClearL0 int `json:"estimatedCompactionsToClearL0"`
+
+	// Bytes above level targets (the same targets leveled compaction scores against, dynamic or static)
+	// Debt growing over time means compaction isn't keeping up with the write rate
+	CompactionDebtMB    float64 `json:"compactionDebtMB"`    // Sum over levels of max(0, level size - level target), excluding the last level (L0's target is max_bytes_for_level_base)
+	CompactionDebtRatio float64 `json:"compactionDebtRatio"` // CompactionDebtMB / total tree size (0 when the tree is empty)
 
 	// Latencies
 	WriteLatencyMs float64 `json:"writeLatencyMs"`
-	ReadLatencyMs  float64 `json:"readLatencyMs"`
+	ReadLatencyMs  float64 `json:"readLatencyMs"` // Mean latency of disk reads over the throughput window, queueing included (see RecordReadLatency)
+
+	// Tail of the queued disk read latency above: spikes while large flushes/compactions hold the disk
+	ReadLatencyMsP99 float64 `json:"readLatencyMsP99"`
 
 	// Cumulative counters
 	TotalDataWrittenMB float64 `json:"totalDataWrittenMB"` // User writes
 	TotalDataReadMB    float64 `json:"totalDataReadMB"`    // User reads (future)
 	WALBytesWritten    float64 `json:"walBytesWritten"`    // Total bytes written to WAL
 
+	// Fraction of files per level within ±20% of that level's target file size (1.0 for empty levels)
+	// Low values mean output splitting is producing badly sized files (e.g., many tiny L0 files)
+	FileSizeCompliancePerLevel []float64 `json:"fileSizeCompliancePerLevel"`
+
+	// Stored vs logical bytes per level under the per-level compression model
+	// Logical size is expressed at L0's compression factor (the units flushes produce), so the two
+	// only diverge in levels that compress better or worse than L0
+	PhysicalSizeMBPerLevel []float64 `json:"physicalSizeMBPerLevel"`
+	LogicalSizeMBPerLevel  []float64 `json:"logicalSizeMBPerLevel"`
+
+	// Level sizes over time for charting how levels fill and drain (compaction waves)
+	// One sample per Step, oldest first: [virtualTime, L0 size MB, L1 size MB, ...]
+	// Capped at MaxHistorySamples; cleared by ResetMetrics
+	LevelSizeHistory [][]float64 `json:"levelSizeHistory"`
+
+	// Ingestion counters (external SST files added without going through the memtable)
+	IngestedFiles         int         `json:"ingestedFiles"`         // Total SST files ingested
+	IngestedBytes         float64     `json:"ingestedBytes"`         // Total MB ingested
+	IngestedFilesPerLevel map[int]int `json:"ingestedFilesPerLevel"` // Where ingested files landed (level → file count)
+
+	// Refit counters (files re-fitted to a lower level by RefitLevels, without rewriting data)
+	RefitFilesMoved         int         `json:"refitFilesMoved"`         // Total files moved by refits
+	RefitBytesMoved         float64     `json:"refitBytesMoved"`         // Total MB moved by refits
+	RefitFilesMovedPerLevel map[int]int `json:"refitFilesMovedPerLevel"` // Where refitted files landed (level → file count)
+
+	// Key counts (derived from AvgKeyValueSizeBytes, 0 when key counts are not modeled)
+	TotalKeys    int64         `json:"totalKeys"`    // Total keys written by the user
+	PerLevelKeys map[int]int64 `json:"perLevelKeys"` // Estimated keys stored per level
+
 	// Throughput tracking (MB/s) - smoothed via exponential moving average
 	FlushThroughputMBps         float64         `json:"flushThroughputMBps"`         // Memtable flush rate (smoothed)
 	CompactionThroughputMBps    float64         `json:"compactionThroughputMBps"`    // Total compaction write rate (smoothed)
@@ -51,6 +162,11 @@ type Metrics struct {
 	MaxSustainableWriteRateMBps float64         `json:"maxSustainableWriteRateMBps"` // Maximum sustainable write rate (conservative estimate based on average overhead)
 	MinSustainableWriteRateMBps float64         `json:"minSustainableWriteRateMBps"` // Minimum sustainable write rate (worst-case based on buffer capacity)
 
+	// Is flush keeping up? Both averaged over the throughput window from cumulative byte counters.
+	// Flush rate persistently below the achieved write rate means immutable memtables are piling up toward a stall.
+	FlushRateMBps         float64 `json:"flushRateMBps"`         // MB flushed to L0 per second
+	AchievedWriteRateMBps float64 `json:"achievedWriteRateMBps"` // MB of user writes accepted into the memtable per second
+
 	// Last compaction performance (for observing WAL/disk contention impact)
 	LastCompactionDurationSec    float64 `json:"lastCompactionDurationSec"`    // Duration of most recent compaction in seconds
 	LastCompactionThroughputMBps float64 `json:"lastCompactionThroughputMBps"` // Throughput of most recent compaction (input MB / duration)
@@ -58,32 +174,181 @@ type Metrics struct {
 	// Disk utilization (for observing WAL baseline overhead)
 	DiskUtilizationPercent float64 `json:"diskUtilizationPercent"` // Percentage of disk bandwidth used (0-100%)
 
+	// Disk time split by direction: bytes moved over the throughput window against each budget
+	// (IOReadThroughputMBps for reads, IOThroughputMBps for writes). The two sum to at most 100%
+	// since reads and writes share one disk queue.
+	DiskReadUtilizationPercent  float64 `json:"diskReadUtilizationPercent"`  // Compaction input and read workload MB over the window as a percentage of the read budget (0-100%)
+	DiskWriteUtilizationPercent float64 `json:"diskWriteUtilizationPercent"` // Flush, compaction output, WAL and ingest MB over the window as a percentage of the write budget (0-100%)
+
+	// Hot/cold tiered storage (TierBoundaryLevel; 0 when single-tier): the share of the throughput
+	// window the disk spent moving each tier's data. Tiers share one I/O queue, so they sum to at
+	// most 100%.
+	HotTierUtilizationPercent  float64 `json:"hotTierUtilizationPercent"`  // WAL, flush and I/O on levels above tierBoundaryLevel (0-100%)
+	ColdTierUtilizationPercent float64 `json:"coldTierUtilizationPercent"` // I/O on levels at or below tierBoundaryLevel, at coldIOThroughputMBps (0-100%)
+
+	// IOPS-limited disk model (DiskIOPS; 0 when disabled)
+	IOPSUtilizationPercent float64 `json:"iopsUtilizationPercent"` // Disk operations issued over the throughput window as a percentage of DiskIOPS (0-100%)
+
+	// Compaction CPU ceiling (CompactionMBPerCPUSec; 0 when disabled)
+	CPUUtilizationPercent float64 `json:"cpuUtilizationPercent"` // Compaction merge work over the throughput window as a percentage of the numCompactionThreads pool (0-100%)
+
+	// Compaction rate limiter (CompactionRateLimitMBps; 0 when disabled)
+	CompactionRateLimitUtilizationPercent float64 `json:"compactionRateLimitUtilizationPercent"` // Compaction I/O over the throughput window as a percentage of the limiter's budget (0-100%)
+	CompactionRateLimitDelaySeconds       float64 `json:"compactionRateLimitDelaySeconds"`       // Cumulative time compactions waited for the limiter's budget before starting
+
 	// In-progress activities (for UI display)
-	InProgressCount        int                      `json:"inProgressCount"`        // Number of ongoing writes
-	InProgressDetails      []map[string]interface{} `json:"inProgressDetails"`      // Details of ongoing writes
-	ActiveBackgroundJobs   int                      `json:"activeBackgroundJobs"`   // Number of background job slots currently busy
-	MaxBackgroundJobs      int                      `json:"maxBackgroundJobs"`      // Total number of background job slots available
+	InProgressCount      int                      `json:"inProgressCount"`      // Number of ongoing writes
+	InProgressDetails    []map[string]interface{} `json:"inProgressDetails"`    // Details of ongoing writes
+	ActiveBackgroundJobs int                      `json:"activeBackgroundJobs"` // Number of background job slots currently busy
+	MaxBackgroundJobs    int                      `json:"maxBackgroundJobs"`    // Total number of background job slots available (including dedicated flush slots)
+
+	// Per-slot busy fraction over the throughput window (0.0-1.0, len = max_background_jobs + max_background_flushes, flush slots last)
+	// All slots near 1.0 means background work is the bottleneck; idle slots mean more jobs won't help
+	BackgroundSlotUtilization []float64 `json:"backgroundSlotUtilization"`
+
+	// Background pool queueing (flushes and compactions share MaxBackgroundJobs workers unless MaxBackgroundFlushes is set)
+	BackgroundQueueDepth          int     `json:"backgroundQueueDepth"`          // Tasks submitted but not yet started on a worker
+	AvgFlushQueueWaitSeconds      float64 `json:"avgFlushQueueWaitSeconds"`      // Mean time flushes waited for a free worker
+	AvgCompactionQueueWaitSeconds float64 `json:"avgCompactionQueueWaitSeconds"` // Mean time compactions waited for a free worker
+
+	// Compaction backlog: levels that need compaction but have none scheduled. A deep queue with
+	// long waits and busy slots means too few background jobs; a short queue with a busy disk means
+	// the disk is the bottleneck.
+	CompactionQueueDepth int     `json:"compactionQueueDepth"` // Levels needing compaction with none scheduled, at the last compaction check
+	AvgCompactionWaitSec float64 `json:"avgCompactionWaitSec"` // Mean time from a level first needing compaction until one is scheduled for it
 
 	// Aggregate stats since last UI update (for fast simulations)
 	// Map of fromLevel -> stats for compactions that completed between UI updates
 	CompactionsSinceUpdate map[int]CompactionStats `json:"compactionsSinceUpdate"` // Per-level aggregate compaction activity
 
+	// Intra-L0 compactions: L0 files merged back into L0. They cut the L0 file count without moving
+	// data toward the base level, so a high share of the L0 compaction work here means the
+	// compactor is churning rather than draining L0.
+	IntraL0Compactions int     `json:"intraL0Compactions"` // Intra-L0 compactions completed since start (or ResetMetrics)
+	IntraL0BytesMB     float64 `json:"intraL0BytesMB"`     // Input MB they rewrote
+
 	// Monotonic compaction counter (never reset, for rate calculation in UI)
 	TotalCompactionsCompleted int `json:"totalCompactionsCompleted"` // Total number of compactions completed since simulation start
 
+	// Compaction input served from the block cache instead of disk (WarmCompactionReads)
+	WarmCompactionBytes float64 `json:"warmCompactionBytes"` // Total compaction input MB read from cache
+
+	// Compaction output shipped to the backup target (BackupBandwidthMBps)
+	BackupBytesShipped float64 `json:"backupBytesShipped"` // Total MB shipped for completed compactions
+	BackupLagSeconds   float64 `json:"backupLagSeconds"`   // Time until the backup link drains everything queued so far (0 = caught up)
+
+	// Output files cut at target-file boundaries (SplitOutputAtTargetBoundaries)
+	BoundarySplitCompactions int `json:"boundarySplitCompactions"` // Compactions whose output needed more files than the size formula predicts
+	BoundarySplitExtraFiles  int `json:"boundarySplitExtraFiles"`  // Total extra output files created by boundary splitting
+
+	// Fixed per-compaction overhead (CompactionSetupLatencyMs)
+	CompactionSetupSeconds float64 `json:"compactionSetupSeconds"` // Total compaction time spent on setup rather than data
+
+	// Compactions split into parallel subcompactions (MaxSubcompactions)
+	SubcompactionJobs         int     `json:"subcompactionJobs"`         // Compactions that ran as more than one subcompaction
+	SubcompactionSecondsSaved float64 `json:"subcompactionSecondsSaved"` // CPU-phase time the parallel pieces saved over running each job on one thread
+
+	// Follow-up compactions scheduled from split over-large candidates (max_compaction_bytes)
+	FollowUpCompactions int `json:"followUpCompactions"` // Total follow-up jobs scheduled since simulation start
+
+	// Compactions requested through RequestManualCompaction
+	ManualCompactions int `json:"manualCompactions"` // Manual compactions scheduled since simulation start
+
+	// Urgent L0 compactions queued behind busy slots (UrgentL0CompactionTrigger)
+	UrgentCompactionWaitSeconds float64 `json:"urgentCompactionWaitSeconds"` // Cumulative time urgent compactions waited for a free slot
+
+	// Failed compactions re-run after a backoff (CompactionFailureRate, CompactionRetryBackoff)
+	CompactionRetries        int     `json:"compactionRetries"`        // Total compaction retries since simulation start
+	CompactionBackoffSeconds float64 `json:"compactionBackoffSeconds"` // Cumulative time failed compactions waited before re-running
+
+	// Byte-based compaction concurrency limit (MaxActiveCompactionBytesMB)
+	ActiveCompactionBytesMB         float64 `json:"activeCompactionBytesMB"`         // Input MB across currently running compactions
+	CompactionsBlockedByActiveBytes int     `json:"compactionsBlockedByActiveBytes"` // Scheduling attempts blocked by the limit since simulation start
+
+	// Bytes removed from the tree per byte rewritten by compactions over the throughput window
+	// (input − output) / output: high values mean compactions drop lots of garbage, near 0 means pure rewriting
+	CompactionEfficiency float64 `json:"compactionEfficiency"`
+
+	// Compaction I/O asymmetry: garbage (tombstones, expired entries) is read but not written back
+	CompactionReadMB         float64 `json:"compactionReadMB"`         // Total compaction input read since simulation start (excludes trivial moves)
+	CompactionWrittenMB      float64 `json:"compactionWrittenMB"`      // Total compaction output written since simulation start
+	CompactionReadWriteRatio float64 `json:"compactionReadWriteRatio"` // CompactionReadMB / CompactionWrittenMB (1.0 before any output; > 1 means read-IO-bound)
+
+	// Universal compaction job coverage (UniversalIncrementalMode)
+	AvgUniversalJobCoverage float64 `json:"avgUniversalJobCoverage"` // Mean fraction of the picked sorted runs' bytes compacted per job (1.0 = whole runs)
+
+	// Compaction input that an earlier compaction wrote shortly before (RecompactionWindowSeconds)
+	RecompactionBytes float64 `json:"recompactionBytes"` // Total MB compacted again within the window of being compacted
+
+	// Compaction throughput split by output depth (BottommostCompactionIOPriority throttles the deepest level)
+	// Input MB per second of job time, over all non-trivial compactions since simulation start
+	BottommostCompactionThroughputMBps float64 `json:"bottommostCompactionThroughputMBps"` // Compactions into the deepest level
+	UpperCompactionThroughputMBps      float64 `json:"upperCompactionThroughputMBps"`      // All other compactions
+
+	// SST metadata rewritten by flushes and compactions (MetadataOverheadPercent and key-count-sized filter blocks)
+	MetadataBytes float64 `json:"metadataBytes"` // Total MB of index/filter blocks written since simulation start
+
+	// Compactions whose picked target files were already inputs of a running compaction (RepickBusyTargetFiles)
+	CompactionsDeferredForBusyTargets int `json:"compactionsDeferredForBusyTargets"` // Picks abandoned until the running compaction finished
+	RepickedBusyTargetFiles           int `json:"repickedBusyTargetFiles"`           // Busy target files swapped for free files of the same level
+
+	// Universal size-amplification compactions the age-based trigger fired (UniversalAgeBasedTrigger)
+	AgeTriggeredSizeAmpCompactions int `json:"ageTriggeredSizeAmpCompactions"` // Picked only because the base run's age lowered the threshold
+
+	// FIFO deletion compactions (fifoMaxTableFilesSizeMB, fifoTTLSeconds)
+	FIFODroppedMB    float64 `json:"fifoDroppedMB"`    // Total MB of files FIFO deleted, by size or TTL
+	FIFOTTLDroppedMB float64 `json:"fifoTTLDroppedMB"` // Share of FIFODroppedMB deleted because files outlived fifoTTLSeconds
+
+	// Proactive small-file consolidation (SmallFileMergeThresholdMB)
+	SmallFileMerges int `json:"smallFileMerges"` // Total small-file merge compactions scheduled since simulation start
+
 	// Write stall metrics
 	StalledWriteCount    int     `json:"stalledWriteCount"`    // Current number of WriteEvents queued during stall
 	MaxStalledWriteCount int     `json:"maxStalledWriteCount"` // Peak stalled write count seen
 	StallDurationSeconds float64 `json:"stallDurationSeconds"` // Cumulative time spent in stall state
+	SlowdownSeconds      float64 `json:"slowdownSeconds"`      // Cumulative time writes were slowed (immutableMemtableSlowdownNumber reached, not yet stalled)
+	StallCount           int     `json:"stallCount"`           // Completed stalls since start (or ResetMetrics)
+	StallsSinceUpdate    int     `json:"stallsSinceUpdate"`    // Completed stalls since the last UI update (cleared by ResetAggregateStats)
+	StallDurationP50     float64 `json:"stallDurationP50"`     // Median duration of completed stalls (seconds)
+	StallDurationP95     float64 `json:"stallDurationP95"`     // P95 duration of completed stalls (seconds)
+	StallDurationP99     float64 `json:"stallDurationP99"`     // P99 duration of completed stalls (seconds)
 	IsStalled            bool    `json:"isStalled"`            // Whether currently in write stall state
 	IsOOMKilled          bool    `json:"isOOMKilled"`          // Whether simulation was killed due to OOM
 
+	// Stalled write backlog growing fast enough to reach MaxStalledWriteMemoryMB within
+	// OOMWarningSeconds (see UpdateOOMRisk): the approach to an OOM kill, while it can still be seen
+	OOMRisk bool `json:"oomRisk"`
+
+	// Adaptive stepping (AdaptiveStepMaxSeconds)
+	StepSeconds             float64 `json:"stepSeconds"`             // Length of the latest Step iteration (baseStepSeconds unless adaptive stepping coarsened it)
+	AdaptiveStepRefinements int     `json:"adaptiveStepRefinements"` // Times a coarse stretch dropped back to baseStepSeconds because a stall, OOM risk or compaction burst approached
+
 	// Read path metrics (statistical model - no discrete read events)
 	AvgReadLatencyMs      float64 `json:"avgReadLatencyMs"`      // Average read latency across all request types
 	P50ReadLatencyMs      float64 `json:"p50ReadLatencyMs"`      // P50 (median) read latency
 	P99ReadLatencyMs      float64 `json:"p99ReadLatencyMs"`      // P99 read latency
 	ReadBandwidthMBps     float64 `json:"readBandwidthMBps"`     // Disk bandwidth consumed by reads
 	CurrentReadReqsPerSec float64 `json:"currentReadReqsPerSec"` // Current actual read requests/sec (with variability applied)
+	PointReadLatencyMs    float64 `json:"pointReadLatencyMs"`    // Average latency of point lookups that miss the cache
+	ScanLatencyMs         float64 `json:"scanLatencyMs"`         // Average range scan latency
+
+	// Block cache after a restart (Simulator.Restart, CachePersistedFraction)
+	CacheWarmth                  float64 `json:"cacheWarmth"`                  // Fraction of the block cache that is warm (1.0 except while re-warming after a restart)
+	Restarts                     int     `json:"restarts"`                     // Restarts since simulation start
+	PostRestartPeakReadLatencyMs float64 `json:"postRestartPeakReadLatencyMs"` // Highest average read latency since the last restart
+	ReadLatencyRecoverySeconds   float64 `json:"readLatencyRecoverySeconds"`   // Time after the last restart until read latency returned within 10% of its prior level (0 until it has)
+
+	// Read amplification in "levels touched" units: sorted runs (memtable, L0 files or sub-levels,
+	// non-empty L1+ levels) a read probes before it resolves, averaged across request types
+	AvgLevelsTouchedPerRead float64 `json:"avgLevelsTouchedPerRead"`
+
+	// Bloom filter modeling (BloomFilterBitsPerKey; both 0 when disabled)
+	BloomFilterFPR       float64 `json:"bloomFilterFPR"`       // Chance a run without the key is still probed
+	BloomAdmittedReadAmp float64 `json:"bloomAdmittedReadAmp"` // Files the last read batch's point lookups actually probed, on average
+
+	// Key access skew (ReadKeyDistribution), from the last read batch with point lookups
+	ReadMemtableHitRatio float64 `json:"readMemtableHitRatio"` // Fraction of point lookups whose key is still in the memtable (served from memory, like a cache hit)
+	ReadKeySkewFactor    float64 `json:"readKeySkewFactor"`    // Sorted runs to a lookup's key relative to uniform access (1 = uniform; < 1 when hot keys resolve in upper levels)
 
 	// Read request type breakdown (requests per second)
 	CacheHitsPerSec      float64 `json:"cacheHitsPerSec"`      // Cache hits per second
@@ -94,12 +359,54 @@ type Metrics struct {
 	// Internal tracking
 	totalDiskWrittenMB     float64         // Total bytes written to disk (including compaction)
 	totalFlushWrittenMB    float64         // Total bytes written by flushes (RocksDB-style WA denominator)
+	totalIngestedMB        float64         // Total bytes ingested as external files (added to WA denominator)
 	totalCompactionInputMB float64         // Total compaction input (read) size for overhead calculation
-	logicalDataSizeMB      float64         // Estimated logical data size
+	compactionMBByDepth    [2]float64      // Input MB of non-trivial compactions: [upper, bottommost]
+	compactionSecByDepth   [2]float64      // Job time of non-trivial compactions: [upper, bottommost]
+	logicalDataSizeMB      float64         // Cumulative bytes users wrote or ingested into the default column family's tree
+	unflushedDataSizeMB    float64         // Part of logicalDataSizeMB still in memtables (set before each Update)
+	placedDataSizeMB       float64         // Bytes placed in the tree without a user write (initial LSM, scenario files)
+	compactionDroppedMB    float64         // Bytes the default column family's compactions merged away or deleted (input - output)
 	recentWrites           []WriteActivity // Recent write events for throughput calculation
+	recentCompactions      []WriteActivity // Recent non-trivial compactions (input and output) for efficiency calculation
 	inProgressWrites       []WriteActivity // Currently executing writes (not yet completed)
+	slotOccupancy          []SlotOccupancy // Background slot reservations overlapping the throughput window
+	queueWaitTotal         [2]float64      // Cumulative background queue wait per BackgroundTaskKind
+	queueWaitCount         [2]int          // Tasks submitted per BackgroundTaskKind
+	jobCoverageTotal       float64         // Sum of universal job coverage fractions
+	compactionWaitTotal    float64         // Cumulative time levels waited for a compaction to be scheduled
+	compactionWaitCount    int             // Waits included in compactionWaitTotal
+	l0CompactionOutputMB   float64         // Total output MB of non-trivial compactions out of L0
+	l0CompactionCount      int             // Non-trivial compactions out of L0
+	rateSamples            []rateSample    // Cumulative flush/write counters per update within the throughput window
+	jobCoverageCount       int             // Universal jobs scheduled
 	throughputWindow       float64         // Time window for throughput calculation (seconds)
 
+	// Durations of completed stalls since start (or ResetMetrics), kept sorted for percentiles
+	stallDurations []float64
+
+	// Integral of ReadAmplification over time, for AvgReadAmplification
+	readAmpSeconds    float64
+	readAmpIntegrated float64
+
+	// Previous stalled write backlog sample and the backlog's smoothed growth rate, for OOMRisk
+	lastBacklogMB      float64
+	lastBacklogAt      float64
+	backlogGrowthMBps  float64
+	backlogGrowthValid bool // backlogGrowthMBps has its first sample
+
+	// Disk read latencies within the throughput window (ReadLatencyMs, ReadLatencyMsP99)
+	readLatencies []readLatencySample
+
+	// Work spread over time, overlapping the throughput window
+	diskOps               []spreadActivity // Disk operations of flush, compaction, WAL and ingest I/O
+	diskReadMB            []spreadActivity // MB read from disk by compactions and the read workload
+	diskWriteMB           []spreadActivity // MB written to disk by flushes, compactions, the WAL and ingestion
+	hotTierSeconds        []spreadActivity // Disk seconds spent on hot-tier I/O (tiered storage only)
+	coldTierSeconds       []spreadActivity // Disk seconds spent on cold-tier I/O (tiered storage only)
+	compactionRateLimited []spreadActivity // Compaction MB granted by the rate limiter (CompactionRateLimitMBps)
+	compactionCPU         []spreadActivity // CPU thread-seconds of compaction merge work (CompactionMBPerCPUSec)
+
 	// Exponential moving average smoothing (alpha = 0.2 for ~5-sample average)
 	smoothingAlpha float64 // 0.2 = smooth over ~5 samples
 	isFirstSample  bool    // Track first sample to initialize EMA
@@ -112,6 +419,7 @@ func NewMetrics() *Metrics {
 		WriteAmplification:          1.0,
 		ReadAmplification:           1.0,
 		SpaceAmplification:          1.0,
+		CacheWarmth:                 1.0,
 		WriteLatencyMs:              0,
 		ReadLatencyMs:               0,
 		TotalDataWrittenMB:          0,
@@ -121,6 +429,9 @@ func NewMetrics() *Metrics {
 		CompactionThroughputMBps:    0,
 		TotalWriteThroughputMBps:    0,
 		PerLevelThroughputMBps:      make(map[int]float64),
+		PerLevelKeys:                make(map[int]int64),
+		IngestedFilesPerLevel:       make(map[int]int),
+		RefitFilesMovedPerLevel:     make(map[int]int),
 		MaxSustainableWriteRateMBps: 0,
 		MinSustainableWriteRateMBps: 0,
 		DiskUtilizationPercent:      0,
@@ -130,8 +441,15 @@ func NewMetrics() *Metrics {
 		totalCompactionInputMB:      0,
 		logicalDataSizeMB:           0,
 		recentWrites:                make([]WriteActivity, 0),
+		recentCompactions:           make([]WriteActivity, 0),
 		inProgressWrites:            make([]WriteActivity, 0),
-		throughputWindow:            5.0,  // 5-second sliding window
+		slotOccupancy:               make([]SlotOccupancy, 0),
+		BackgroundSlotUtilization:   make([]float64, 0),
+		FileSizeCompliancePerLevel:  make([]float64, 0),
+		PhysicalSizeMBPerLevel:      make([]float64, 0),
+		LogicalSizeMBPerLevel:       make([]float64, 0),
+		CompactionReadWriteRatio:    1.0,
+		throughputWindow:            defaultThroughputWindow,
 		smoothingAlpha:              0.2,  // Smooth over ~5 samples
 		isFirstSample:               true, // Initialize EMA with first sample
 		StalledWriteCount:           0,
@@ -147,22 +465,23 @@ func NewMetrics() *Metrics {
 }
 
 // StartWrite begins tracking a write activity (call when write starts, not completes)
-func (m *Metrics) StartWrite(inputMB, outputMB float64, startTime, endTime float64, fromLevel, toLevel int) {
+func (m *Metrics) StartWrite(inputMB, outputMB float64, startTime, endTime float64, fromLevel, toLevel, columnFamily int) {
 	m.inProgressWrites = append(m.inProgressWrites, WriteActivity{
-		StartTime: startTime,
-		EndTime:   endTime,
-		SizeMB:    outputMB,
-		InputMB:   inputMB,
-		Level:     fromLevel,
-		ToLevel:   toLevel,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		SizeMB:       outputMB,
+		InputMB:      inputMB,
+		Level:        fromLevel,
+		ToLevel:      toLevel,
+		ColumnFamily: columnFamily,
 	})
 }
 
 // CompleteWrite moves a write from in-progress to completed
-func (m *Metrics) CompleteWrite(endTime float64, level int) {
+func (m *Metrics) CompleteWrite(endTime float64, level, columnFamily int) {
 	// Find and remove the write from inProgressWrites
 	for i, w := range m.inProgressWrites {
-		if w.Level == level && w.EndTime == endTime {
+		if w.Level == level && w.EndTime == endTime && w.ColumnFamily == columnFamily {
 			// Move to recentWrites
 			m.recentWrites = append(m.recentWrites, w)
 			// Remove from inProgressWrites
@@ -177,15 +496,321 @@ func (m *Metrics) GetInProgressWrites() []WriteActivity {
 	return append([]WriteActivity{}, m.inProgressWrites...)
 }
 
+// RecordSlotOccupancy records that a background job slot is busy over [startTime, endTime)
+func (m *Metrics) RecordSlotOccupancy(slot int, startTime, endTime float64) {
+	m.slotOccupancy = append(m.slotOccupancy, SlotOccupancy{
+		Slot:      slot,
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+}
+
+// RecordDiskOps records ops disk operations issued by an I/O running over [startTime, endTime)
+func (m *Metrics) RecordDiskOps(startTime, endTime, ops float64) {
+	if ops > 0 {
+		m.diskOps = append(m.diskOps, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: ops})
+	}
+}
+
+// RecordDiskBytes records readMB and writeMB moved by an I/O running over [startTime, endTime)
+func (m *Metrics) RecordDiskBytes(startTime, endTime, readMB, writeMB float64) {
+	if readMB > 0 {
+		m.diskReadMB = append(m.diskReadMB, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: readMB})
+	}
+	if writeMB > 0 {
+		m.diskWriteMB = append(m.diskWriteMB, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: writeMB})
+	}
+}
+
+// RecordTierDiskTime records hotSeconds and coldSeconds of disk time on each storage tier taken by
+// an I/O running over [startTime, endTime)
+func (m *Metrics) RecordTierDiskTime(startTime, endTime, hotSeconds, coldSeconds float64) {
+	if hotSeconds > 0 {
+		m.hotTierSeconds = append(m.hotTierSeconds, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: hotSeconds})
+	}
+	if coldSeconds > 0 {
+		m.coldTierSeconds = append(m.coldTierSeconds, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: coldSeconds})
+	}
+}
+
+// RecordCompactionRateLimited records sizeMB of compaction I/O granted by the rate limiter,
+// transferred over [startTime, endTime)
+func (m *Metrics) RecordCompactionRateLimited(startTime, endTime, sizeMB float64) {
+	if sizeMB > 0 {
+		m.compactionRateLimited = append(m.compactionRateLimited, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: sizeMB})
+	}
+}
+
+// RecordCompactionCPU records threadSeconds of compaction merge work spread over [startTime, endTime)
+func (m *Metrics) RecordCompactionCPU(startTime, endTime, threadSeconds float64) {
+	if threadSeconds > 0 {
+		m.compactionCPU = append(m.compactionCPU, spreadActivity{StartTime: startTime, EndTime: endTime, Amount: threadSeconds})
+	}
+}
+
+// updateCPUUtilization computes the compaction merge work over the throughput window as a share of
+// the threads compactions share
+func (m *Metrics) updateCPUUtilization(virtualTime float64, threads int) {
+	windowStart := max(0, virtualTime-m.throughputWindow)
+	windowLength := virtualTime - windowStart
+
+	var threadSeconds float64
+	threadSeconds, m.compactionCPU = sumOverWindow(m.compactionCPU, windowStart, virtualTime)
+
+	m.CPUUtilizationPercent = 0
+	if threads > 0 && windowLength > 0 {
+		m.CPUUtilizationPercent = min(100.0, threadSeconds/(float64(threads)*windowLength)*100.0)
+	}
+}
+
+// updateIOPSUtilization computes the operations issued over the throughput window as a share of
+// diskIOPS. An I/O's operations are spread evenly over its duration, so only the portion
+// overlapping [virtualTime - window, virtualTime] is counted.
+func (m *Metrics) updateIOPSUtilization(virtualTime, diskIOPS float64) {
+	windowStart := max(0, virtualTime-m.throughputWindow)
+	windowLength := virtualTime - windowStart
+
+	var ops float64
+	ops, m.diskOps = sumOverWindow(m.diskOps, windowStart, virtualTime)
+
+	m.IOPSUtilizationPercent = 0
+	if diskIOPS > 0 && windowLength > 0 {
+		m.IOPSUtilizationPercent = min(100.0, ops/(diskIOPS*windowLength)*100.0)
+	}
+}
+
+// updateDiskDirectionUtilization computes the MB read and written over the throughput window as
+// shares of the read and write bandwidth, spreading each I/O's bytes over its duration
+func (m *Metrics) updateDiskDirectionUtilization(virtualTime, readMBps, writeMBps float64) {
+	windowStart := max(0, virtualTime-m.throughputWindow)
+	windowLength := virtualTime - windowStart
+
+	var readMB, writeMB float64
+	readMB, m.diskReadMB = sumOverWindow(m.diskReadMB, windowStart, virtualTime)
+	writeMB, m.diskWriteMB = sumOverWindow(m.diskWriteMB, windowStart, virtualTime)
+
+	m.DiskReadUtilizationPercent, m.DiskWriteUtilizationPercent = 0, 0
+	if windowLength > 0 && readMBps > 0 {
+		m.DiskReadUtilizationPercent = min(100.0, readMB/(readMBps*windowLength)*100.0)
+	}
+	if windowLength > 0 && writeMBps > 0 {
+		m.DiskWriteUtilizationPercent = min(100.0, writeMB/(writeMBps*windowLength)*100.0)
+	}
+}
+
+// updateTierUtilization computes the disk time each storage tier took over the throughput window
+// as a share of the window
+func (m *Metrics) updateTierUtilization(virtualTime float64) {
+	windowStart := max(0, virtualTime-m.throughputWindow)
+	windowLength := virtualTime - windowStart
+
+	var hotSeconds, coldSeconds float64
+	hotSeconds, m.hotTierSeconds = sumOverWindow(m.hotTierSeconds, windowStart, virtualTime)
+	coldSeconds, m.coldTierSeconds = sumOverWindow(m.coldTierSeconds, windowStart, virtualTime)
+
+	m.HotTierUtilizationPercent, m.ColdTierUtilizationPercent = 0, 0
+	if windowLength > 0 {
+		m.HotTierUtilizationPercent = min(100.0, hotSeconds/windowLength*100.0)
+		m.ColdTierUtilizationPercent = min(100.0, coldSeconds/windowLength*100.0)
+	}
+}
+
+// updateCompactionRateLimitUtilization computes the compaction I/O granted over the throughput
+// window as a share of the rate limiter's budget (rateMBps * window)
+func (m *Metrics) updateCompactionRateLimitUtilization(virtualTime, rateMBps float64) {
+	windowStart := max(0, virtualTime-m.throughputWindow)
+	windowLength := virtualTime - windowStart
+
+	var granted float64
+	granted, m.compactionRateLimited = sumOverWindow(m.compactionRateLimited, windowStart, virtualTime)
+
+	m.CompactionRateLimitUtilizationPercent = 0
+	if rateMBps > 0 && windowLength > 0 {
+		m.CompactionRateLimitUtilizationPercent = min(100.0, granted/(rateMBps*windowLength)*100.0)
+	}
+}
+
+// RecordBackgroundTaskWait records how long a task waited in the background pool queue
+func (m *Metrics) RecordBackgroundTaskWait(kind BackgroundTaskKind, waitSeconds float64) {
+	if kind < 0 || int(kind) >= len(m.queueWaitTotal) {
+		return
+	}
+	m.queueWaitTotal[kind] += max(0, waitSeconds)
+	m.queueWaitCount[kind]++
+	avg := m.queueWaitTotal[kind] / float64(m.queueWaitCount[kind])
+	switch kind {
+	case BackgroundTaskFlush:
+		m.AvgFlushQueueWaitSeconds = avg
+	case BackgroundTaskCompaction:
+		m.AvgCompactionQueueWaitSeconds = avg
+	}
+}
+
+// RecordCompactionWait records how long a level needed compaction before one was scheduled for it
+// (0 when it was scheduled as soon as it needed one)
+func (m *Metrics) RecordCompactionWait(waitSeconds float64) {
+	m.compactionWaitTotal += max(0, waitSeconds)
+	m.compactionWaitCount++
+	m.AvgCompactionWaitSec = m.compactionWaitTotal / float64(m.compactionWaitCount)
+}
+
+// RecordJobCoverage records the fraction of its picked sorted runs a universal compaction job covers
+func (m *Metrics) RecordJobCoverage(coverage float64) {
+	m.jobCoverageTotal += coverage
+	m.jobCoverageCount++
+	m.AvgUniversalJobCoverage = m.jobCoverageTotal / float64(m.jobCoverageCount)
+}
+
+// updateBackgroundQueueDepth counts tasks reserved on a worker that haven't started yet
+func (m *Metrics) updateBackgroundQueueDepth(virtualTime float64) {
+	depth := 0
+	for _, occ := range m.slotOccupancy {
+		if occ.StartTime > virtualTime {
+			depth++
+		}
+	}
+	m.BackgroundQueueDepth = depth
+}
+
+// updateBackgroundSlotUtilization computes the fraction of the throughput window each slot was busy.
+// Reservations may extend into the future (jobs are scheduled ahead), so only the portion
+// overlapping [virtualTime - window, virtualTime] is counted.
+func (m *Metrics) updateBackgroundSlotUtilization(virtualTime float64, maxBackgroundJobs int) {
+	windowStart := max(0, virtualTime-m.throughputWindow)
+	windowLength := virtualTime - windowStart
+
+	utilization := make([]float64, maxBackgroundJobs)
+	kept := m.slotOccupancy[:0]
+	for _, occ := range m.slotOccupancy {
+		// Drop reservations that ended before the window
+		if occ.EndTime < windowStart {
+			continue
+		}
+		kept = append(kept, occ)
+
+		if occ.Slot >= maxBackgroundJobs || windowLength <= 0 {
+			continue
+		}
+		overlap := min(occ.EndTime, virtualTime) - max(occ.StartTime, windowStart)
+		if overlap > 0 {
+			utilization[occ.Slot] += overlap
+		}
+	}
+	m.slotOccupancy = kept
+
+	for i := range utilization {
+		if windowLength > 0 {
+			utilization[i] = min(1.0, utilization[i]/windowLength)
+		}
+	}
+	m.BackgroundSlotUtilization = utilization
+}
+
+// fileSizeComplianceTolerance is how far (as a fraction) a file may deviate from its
+// level's target file size and still count as well-sized
+const fileSizeComplianceTolerance = 0.2
+
+// updateFileSizeCompliance computes, per level, the fraction of files whose size is
+// within fileSizeComplianceTolerance of the level's target file size
+func (m *Metrics) updateFileSizeCompliance(lsmTree *LSMTree, config SimConfig) {
+	compliance := make([]float64, len(lsmTree.Levels))
+	for i, level := range lsmTree.Levels {
+		if len(level.Files) == 0 {
+			compliance[i] = 1.0 // Nothing out of spec
+			continue
+		}
+		target := targetFileSizeForLevel(i, config)
+		withinTarget := 0
+		for _, f := range level.Files {
+			if math.Abs(f.SizeMB-target) <= target*fileSizeComplianceTolerance {
+				withinTarget++
+			}
+		}
+		compliance[i] = float64(withinTarget) / float64(len(level.Files))
+	}
+	m.FileSizeCompliancePerLevel = compliance
+}
+
+// updateCompactionDebt sums how far each level is over its target size (calculateLevelTargets).
+// In dynamic mode, levels above the base level have a 0 target; they are normally empty, and any
+// data left there is debt.
+//
+// L0 has no size target of its own (its compaction is triggered by file count), so it is measured
+// against max_bytes_for_level_base, the size the base level is allowed: L0 bytes beyond that are
+// more than one L0→base compaction can absorb. The last level is never compacted into a deeper
+// one, so data there is where it belongs and contributes no debt, whatever its target says.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB's EstimatedPendingCompactionBytes() also counts the bytes a
+// level's excess will rewrite in the level below (the fan-out); this is the excess alone
+func (m *Metrics) updateCompactionDebt(lsmTree *LSMTree, config SimConfig) {
+	debt := 0.0
+	targets := lsmTree.calculateLevelTargets(config)
+	for i := 0; i < len(targets)-1; i++ { // The last level has nowhere to compact to
+		debt += math.Max(0, lsmTree.Levels[i].TotalSize-targets[i])
+	}
+	m.CompactionDebtMB = debt
+	m.CompactionDebtRatio = 0
+	if lsmTree.TotalSizeMB > 0 {
+		m.CompactionDebtRatio = debt / lsmTree.TotalSizeMB
+	}
+}
+
+// updateLevelCompression computes stored and logical size per level from each level's compression factor
+// FIDELITY: ⚠️ SIMPLIFIED - One factor per level; real compression varies per file with data content and age
+func (m *Metrics) updateLevelCompression(lsmTree *LSMTree, config SimConfig) {
+	physical := make([]float64, len(lsmTree.Levels))
+	logical := make([]float64, len(lsmTree.Levels))
+	for i, level := range lsmTree.Levels {
+		physical[i] = level.TotalSize
+		logical[i] = level.TotalSize * config.RecompressionRatio(i, 0)
+	}
+	m.PhysicalSizeMBPerLevel = physical
+	m.LogicalSizeMBPerLevel = logical
+}
+
+// recordLevelSizeHistory appends the current level sizes to LevelSizeHistory, dropping the oldest
+// samples beyond maxSamples. Samples are only ever appended and dropped from the front, so a
+// shallow Clone keeps seeing the history as it was.
+func (m *Metrics) recordLevelSizeHistory(virtualTime float64, lsmTree *LSMTree, maxSamples int) {
+	if maxSamples <= 0 {
+		m.LevelSizeHistory = nil
+		return
+	}
+	sample := make([]float64, 1+len(lsmTree.Levels))
+	sample[0] = virtualTime
+	for i, level := range lsmTree.Levels {
+		sample[i+1] = level.TotalSize
+	}
+	if drop := len(m.LevelSizeHistory) + 1 - maxSamples; drop > 0 {
+		m.LevelSizeHistory = m.LevelSizeHistory[drop:]
+	}
+	// append reallocates once the dropped prefix exhausts the capacity, copying only the live samples
+	m.LevelSizeHistory = append(m.LevelSizeHistory, sample)
+}
+
 // RecordUserWrite records a write operation by the user
 func (m *Metrics) RecordUserWrite(sizeMB float64) {
 	m.TotalDataWrittenMB += sizeMB
 	m.logicalDataSizeMB += sizeMB
+	m.updateWriteAmplification()
+}
+
+// RecordColumnFamilyWrite records a user write to a non-default column family, whose tree space
+// amplification doesn't cover
+func (m *Metrics) RecordColumnFamilyWrite(sizeMB float64) {
+	m.TotalDataWrittenMB += sizeMB
+	m.updateWriteAmplification()
 }
 
 // RecordWALWrite records a WAL write operation (for disk throughput/utilization tracking)
 // WAL writes use Level = -2 to distinguish from flush (-1) and compactions (0+)
 func (m *Metrics) RecordWALWrite(startTime, endTime, sizeMB float64) {
+	// Counted in WriteAmplificationWAL but NOT in WriteAmplification: RocksDB's write amplification
+	// measures LSM compaction overhead only, not WAL writes.
+	// See: internal_stats.cc:1806-1842 (write_amp = compaction_output / flush_input)
+	m.WALBytesWritten += sizeMB
+	m.updateWriteAmplification()
+
 	m.recentWrites = append(m.recentWrites, WriteActivity{
 		StartTime: startTime,
 		EndTime:   endTime,
@@ -196,6 +821,34 @@ func (m *Metrics) RecordWALWrite(startTime, endTime, sizeMB float64) {
 	})
 }
 
+// RecordIngest records an external SST file ingestion
+// Ingestion uses Level = -3 to distinguish from WAL (-2), flush (-1) and compactions (0+)
+func (m *Metrics) RecordIngest(level int, sizeMB, startTime, endTime float64) {
+	m.IngestedFiles++
+	m.IngestedFilesPerLevel[level]++
+	m.IngestedBytes += sizeMB
+	m.logicalDataSizeMB += sizeMB
+	m.totalDiskWrittenMB += sizeMB
+	m.totalIngestedMB += sizeMB // Ingested bytes enter the LSM like flushed bytes do
+	m.updateWriteAmplification()
+
+	m.recentWrites = append(m.recentWrites, WriteActivity{
+		StartTime: startTime,
+		EndTime:   endTime,
+		SizeMB:    sizeMB,
+		Level:     -3,
+		ToLevel:   -3,
+	})
+}
+
+// RecordRefit records files re-fitted from one level to another. Refit is a metadata-only move,
+// so it adds no disk writes and leaves write amplification untouched.
+func (m *Metrics) RecordRefit(toLevel, fileCount int, sizeMB float64) {
+	m.RefitFilesMoved += fileCount
+	m.RefitBytesMoved += sizeMB
+	m.RefitFilesMovedPerLevel[toLevel] += fileCount
+}
+
 // RecordFlush records a memtable flush (writes to disk)
 func (m *Metrics) RecordFlush(sizeMB, startTime, endTime float64) {
 	m.totalDiskWrittenMB += sizeMB
@@ -213,20 +866,24 @@ func (m *Metrics) RecordFlush(sizeMB, startTime, endTime float64) {
 
 // RecordCompaction records a compaction (reads input, writes output)
 // isTrivialMove: if true, this is a metadata-only operation (no disk writes, RocksDB optimization)
-func (m *Metrics) RecordCompaction(inputSizeMB, outputSizeMB, startTime, endTime float64, fromLevel int, inputFileCount, outputFileCount int, isTrivialMove bool) {
+// columnFamily: compactions of column families other than the default (0) count toward the disk
+// totals and write amplification but not the per-level stats (CompactionsSinceUpdate, L0 output)
+func (m *Metrics) RecordCompaction(inputSizeMB, outputSizeMB, startTime, endTime float64, fromLevel int, inputFileCount, outputFileCount int, isTrivialMove bool, columnFamily int) {
 	// Trivial moves are metadata-only operations (no disk writes) - RocksDB optimization
 	// When files don't overlap with target level, RocksDB just updates file metadata (level pointer)
 	// See: db/compaction/compaction_picker_level.cc (TryExtendNonL0TrivialMove)
 	if isTrivialMove {
 		// Don't count trivial moves as disk writes - they're metadata-only
 		// Still track for aggregate stats (UI display) but don't contribute to write amplification
-		stats := m.CompactionsSinceUpdate[fromLevel]
-		stats.Count++
-		stats.TotalInputFiles += inputFileCount
-		stats.TotalOutputFiles += outputFileCount
-		stats.TotalInputMB += inputSizeMB
-		stats.TotalOutputMB += outputSizeMB
-		m.CompactionsSinceUpdate[fromLevel] = stats
+		if columnFamily == 0 {
+			stats := m.CompactionsSinceUpdate[fromLevel]
+			stats.Count++
+			stats.TotalInputFiles += inputFileCount
+			stats.TotalOutputFiles += outputFileCount
+			stats.TotalInputMB += inputSizeMB
+			stats.TotalOutputMB += outputSizeMB
+			m.CompactionsSinceUpdate[fromLevel] = stats
+		}
 
 		// Increment monotonic counter (used for rate calculation in UI)
 		m.TotalCompactionsCompleted++
@@ -236,32 +893,53 @@ func (m *Metrics) RecordCompaction(inputSizeMB, outputSizeMB, startTime, endTime
 	// Compaction reads input files and writes output files
 	m.totalDiskWrittenMB += outputSizeMB
 	m.totalCompactionInputMB += inputSizeMB // Track input for overhead calculation
+	m.CompactionReadMB += inputSizeMB
+	m.CompactionWrittenMB += outputSizeMB
+	if m.CompactionWrittenMB > 0 {
+		m.CompactionReadWriteRatio = m.CompactionReadMB / m.CompactionWrittenMB
+	}
 
 	// Note: We don't reduce logicalDataSizeMB here because it represents
 	// the cumulative user writes. Compaction deduplicates/compresses data
 	// on disk, but doesn't change how much data the user has written.
-	// Space amplification = disk space / logical data will show overhead
-	// from having multiple versions across levels.
+	// UpdateSpaceAmplification discounts the overwrites it will merge away.
 
 	m.updateWriteAmplification()
 
 	// Track compaction write activity
 	m.recentWrites = append(m.recentWrites, WriteActivity{
-		StartTime: startTime,
-		EndTime:   endTime,
-		SizeMB:    outputSizeMB,
-		Level:     fromLevel,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		SizeMB:       outputSizeMB,
+		Level:        fromLevel,
+		ColumnFamily: columnFamily,
 	})
 
-	// Aggregate stats for fast simulations (multiple compactions between UI updates)
-	// Track per-level (fromLevel) for display in UI
-	stats := m.CompactionsSinceUpdate[fromLevel]
-	stats.Count++
-	stats.TotalInputFiles += inputFileCount
-	stats.TotalOutputFiles += outputFileCount
-	stats.TotalInputMB += inputSizeMB
-	stats.TotalOutputMB += outputSizeMB
-	m.CompactionsSinceUpdate[fromLevel] = stats
+	m.recentCompactions = append(m.recentCompactions, WriteActivity{
+		StartTime:    startTime,
+		EndTime:      endTime,
+		SizeMB:       outputSizeMB,
+		InputMB:      inputSizeMB,
+		Level:        fromLevel,
+		ColumnFamily: columnFamily,
+	})
+
+	if columnFamily == 0 {
+		if fromLevel == 0 {
+			m.l0CompactionOutputMB += outputSizeMB
+			m.l0CompactionCount++
+		}
+
+		// Aggregate stats for fast simulations (multiple compactions between UI updates)
+		// Track per-level (fromLevel) for display in UI
+		stats := m.CompactionsSinceUpdate[fromLevel]
+		stats.Count++
+		stats.TotalInputFiles += inputFileCount
+		stats.TotalOutputFiles += outputFileCount
+		stats.TotalInputMB += inputSizeMB
+		stats.TotalOutputMB += outputSizeMB
+		m.CompactionsSinceUpdate[fromLevel] = stats
+	}
 
 	// Increment monotonic counter (used for rate calculation in UI)
 	m.TotalCompactionsCompleted++
@@ -271,47 +949,161 @@ func (m *Metrics) RecordCompaction(inputSizeMB, outputSizeMB, startTime, endTime
 // This allows tracking compactions that complete between UI updates (useful for fast simulations)
 func (m *Metrics) ResetAggregateStats() {
 	m.CompactionsSinceUpdate = make(map[int]CompactionStats)
+	m.StallsSinceUpdate = 0
+}
+
+// UpdateOOMRisk projects the stalled write backlog forward at its growth rate and sets OOMRisk
+// if it would exceed limitMB within warningSeconds. Returns true when the risk first appears,
+// along with the projected seconds until the limit is hit. The growth rate is smoothed like the
+// throughput metrics, so one flat or shrinking sample doesn't flip the risk off and back on.
+// A shrinking or steady smoothed rate, or a zero limit or warning horizon, clears the risk.
+func (m *Metrics) UpdateOOMRisk(virtualTime, backlogMB, limitMB, warningSeconds float64) (raised bool, secondsToOOM float64) {
+	if elapsed := virtualTime - m.lastBacklogAt; elapsed > 0 {
+		rate := (backlogMB - m.lastBacklogMB) / elapsed
+		if m.backlogGrowthValid {
+			m.backlogGrowthMBps = m.smoothingAlpha*rate + (1-m.smoothingAlpha)*m.backlogGrowthMBps
+		} else {
+			m.backlogGrowthMBps, m.backlogGrowthValid = rate, true
+		}
+	}
+	m.lastBacklogMB, m.lastBacklogAt = backlogMB, virtualTime
+
+	wasAtRisk := m.OOMRisk
+	m.OOMRisk = false
+	if limitMB <= 0 || warningSeconds <= 0 || m.backlogGrowthMBps <= 0 {
+		return false, 0
+	}
+	secondsToOOM = max(0, limitMB-backlogMB) / m.backlogGrowthMBps
+	m.OOMRisk = secondsToOOM <= warningSeconds
+	return m.OOMRisk && !wasAtRisk, secondsToOOM
+}
+
+// readLatencySample is the latency of count disk reads issued together
+type readLatencySample struct {
+	at        float64 // Virtual time the reads were issued
+	latencyMs float64
+	count     int
 }
 
-// UpdateSpaceAmplification updates space amplification based on LSM tree state
+// RecordReadLatency records count disk reads issued at virtual time at, each taking latencyMs
+// including the time spent waiting for the disk, and updates ReadLatencyMs and ReadLatencyMsP99
+// over the throughput window
+func (m *Metrics) RecordReadLatency(at, latencyMs float64, count int) {
+	if count <= 0 {
+		return
+	}
+	// Rebuild rather than filter in place: Clone() copies share the old backing array
+	windowStart := at - m.throughputWindow
+	samples := make([]readLatencySample, 0, len(m.readLatencies)+1)
+	for _, sample := range m.readLatencies {
+		if sample.at >= windowStart {
+			samples = append(samples, sample)
+		}
+	}
+	samples = append(samples, readLatencySample{at: at, latencyMs: latencyMs, count: count})
+	m.readLatencies = samples
+
+	sorted := append([]readLatencySample(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].latencyMs < sorted[j].latencyMs })
+	var total int
+	var sum float64
+	for _, sample := range sorted {
+		total += sample.count
+		sum += sample.latencyMs * float64(sample.count)
+	}
+	m.ReadLatencyMs = sum / float64(total)
+	// Weighted percentile: the latency below which 99% of the reads completed
+	var seen int
+	for _, sample := range sorted {
+		seen += sample.count
+		if float64(seen) >= 0.99*float64(total) {
+			m.ReadLatencyMsP99 = sample.latencyMs
+			break
+		}
+	}
+}
+
+// RecordStall records a completed write stall and updates the stall duration percentiles
+func (m *Metrics) RecordStall(durationSeconds float64) {
+	// Insert in order into a fresh slice: Clone() copies share the old backing array
+	i := sort.SearchFloat64s(m.stallDurations, durationSeconds)
+	m.stallDurations = append(append(m.stallDurations[:i:i], durationSeconds), m.stallDurations[i:]...)
+	m.StallCount = len(m.stallDurations)
+	m.StallsSinceUpdate++
+	m.StallDurationP50 = percentile(m.stallDurations, 0.50)
+	m.StallDurationP95 = percentile(m.stallDurations, 0.95)
+	m.StallDurationP99 = percentile(m.stallDurations, 0.99)
+}
+
+// UpdateSpaceAmplification updates space amplification: bytes on disk / live bytes
 //
 // RocksDB Definition: Space Amplification = size_on_file_system / size_of_user_data
 //
-// RocksDB Approximation: "So the size of the last level will be a good estimation of user data size.
-// So total size of the DB divided by the size of the last level will be a good estimation of space amplification."
-//
-// Reference: RocksDB blog post (2015-07-23): "Dynamic Level Size for Level-Based Compaction"
-// https://github.com/facebook/rocksdb/blob/main/docs/_posts/2015-07-23-dynamic-level.markdown
-//
-// Why use last level size instead of cumulative user writes:
-// - The last level contains the most recent version of each key
-// - If we compact everything to the last level, that's the actual user data size
-// - Naturally accounts for deletes/updates (unlike cumulative writes)
-// - In steady state, updates add roughly the same as deletes remove
+// Live bytes are estimated from the cumulative user write counter: the bytes users wrote or
+// ingested into the tree (less what still sits in memtables) minus the overwrites the model
+// drops. Those are the bytes compactions have already merged away or deleted, plus the bytes
+// data above the last level will still lose on its way down (the l0/deep/levelReductionFactors
+// and compactionGarbageFraction each compaction applies, and the last level's compression).
+// Data placed without a write (initialLSMSizeMB, scenario files) counts as live as is.
 //
-// Example: If total size is 1111MB (L0=1GB, L1=10GB, L2=100GB, L3=1000GB):
-//   - Space amp = 1111GB / 1000GB = 1.111x (excellent space efficiency)
+// Example: leveled, L0 reduction 0.9, deeper levels 0.99, 1200MB written and flushed:
+//   - Compactions so far merged 100MB away; 100MB in L1 will lose 1MB more into L2 (last)
+//   - Live = 1200 - 100 - 1 = 1099MB, space amp = 1100MB / 1099MB = 1.001x
 //
-// FIDELITY: ✓ Matches RocksDB's approximation method
-func (m *Metrics) UpdateSpaceAmplification(diskSpaceMB float64, lsmTree *LSMTree) {
-	// Find the last non-empty level (deepest level with data)
-	// This represents the "user data size" after all compactions
-	lastLevelSizeMB := 0.0
-	for i := len(lsmTree.Levels) - 1; i >= 0; i-- {
-		if lsmTree.Levels[i].TotalSize > 0 {
-			lastLevelSizeMB = lsmTree.Levels[i].TotalSize
-			break
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB doesn't report space amplification; its blog approximates the
+// user data size by the size of the last level ("Dynamic Level Size for Level-Based Compaction",
+// https://github.com/facebook/rocksdb/blob/main/docs/_posts/2015-07-23-dynamic-level.markdown),
+// which counts nothing above the last level as live and overstates space amp while the tree fills
+// FIDELITY: ⚠️ SIMPLIFIED - Overwrites are only as dead as the reduction factors say; the model
+// tracks no keys, so it can't tell which versions are shadowed. Column family writes land in
+// their own trees and aren't counted
+func (m *Metrics) UpdateSpaceAmplification(lsmTree *LSMTree, config SimConfig) {
+	last := len(lsmTree.Levels) - 1
+	pendingMB := 0.0
+	for i, level := range lsmTree.Levels {
+		if level.TotalSize > 0 {
+			pendingMB += level.TotalSize * (1 - liveFraction(lsmTree, config, i, last))
 		}
 	}
-
-	if lastLevelSizeMB > 0 {
-		m.SpaceAmplification = diskSpaceMB / lastLevelSizeMB
+	writtenMB := max(0, m.logicalDataSizeMB-m.unflushedDataSizeMB)
+	live := writtenMB - m.compactionDroppedMB - pendingMB + m.placedDataSizeMB
+	// Metadata and recompression make disk bytes drift from the write counter; live data can't
+	// outgrow the disk
+	m.LiveDataSizeMB = min(max(0, live), lsmTree.TotalSizeMB)
+
+	if m.LiveDataSizeMB > 0 {
+		m.SpaceAmplification = lsmTree.TotalSizeMB / m.LiveDataSizeMB
 	} else {
 		// No data on disk yet - space amplification is undefined (return 1.0 as default)
 		m.SpaceAmplification = 1.0
 	}
 }
 
+// liveFraction returns the fraction of level's bytes that survive compaction into the last level,
+// in the last level's stored units
+func liveFraction(lsmTree *LSMTree, config SimConfig, level, last int) float64 {
+	if level == last || config.CompactionStyle == CompactionStyleFIFO {
+		return 1 // FIFO never merges: data leaves only by whole-file deletion
+	}
+	fraction := config.RecompressionRatio(level, last)
+	pass := func(fromLevel int) float64 {
+		return config.CompactionReductionFactor(fromLevel) * (1 - config.CompactionGarbageFraction)
+	}
+	if config.CompactionStyle != CompactionStyleLeveled {
+		// Universal and tiered merge a run straight into the last level
+		return fraction * pass(level)
+	}
+	// Leveled data is merged once per level on its way down; empty levels are skipped over
+	// (dynamic level bytes leaves the levels above the base level empty)
+	fraction *= pass(level)
+	for j := level + 1; j < last; j++ {
+		if lsmTree.Levels[j].TotalSize > 0 {
+			fraction *= pass(j)
+		}
+	}
+	return fraction
+}
+
 // updateWriteAmplification recalculates write amplification
 //
 // RocksDB Definition: Write Amplification = (bytes written by flushes + bytes written by compactions) / bytes written by flushes
@@ -327,11 +1119,21 @@ func (m *Metrics) UpdateSpaceAmplification(diskSpaceMB float64, lsmTree *LSMTree
 //   - Our formula: 152MB / 80MB = 1.9x (isolates compaction overhead)
 //   - User-centric formula: 152MB / 100MB = 1.52x (includes compression savings)
 func (m *Metrics) updateWriteAmplification() {
-	if m.totalFlushWrittenMB > 0 {
-		m.WriteAmplification = m.totalDiskWrittenMB / m.totalFlushWrittenMB
+	// Ingested files enter the LSM without a flush, so they count as new data too
+	newDataMB := m.totalFlushWrittenMB + m.totalIngestedMB
+	if newDataMB > 0 {
+		m.WriteAmplification = m.totalDiskWrittenMB / newDataMB
 	} else {
 		m.WriteAmplification = 1.0
 	}
+
+	// Per user byte: WAL and SST writes are separate components (0 until the first user byte)
+	// Ingested files are user data that bypass the WAL and memtable
+	if userMB := m.TotalDataWrittenMB + m.IngestedBytes; userMB > 0 {
+		m.WriteAmplificationWAL = m.WALBytesWritten / userMB
+		m.WriteAmplificationCompaction = m.totalDiskWrittenMB / userMB
+		m.WriteAmplificationTotal = m.WriteAmplificationWAL + m.WriteAmplificationCompaction
+	}
 }
 
 // UpdateReadAmplification calculates read amplification based on LSM structure
@@ -341,6 +1143,7 @@ func (m *Metrics) updateWriteAmplification() {
 // RocksDB Behavior (point lookup):
 //   - Active memtable: Always checked (immutable memtables are already being flushed, not checked)
 //   - All L0 files: Must check all (L0 is unsorted/tiered, files may overlap)
+//     With L0 sub-levels enabled, only one file per sub-level (sub-levels don't overlap internally)
 //   - One file per level L1+: Binary search finds the file containing the key
 //
 // Reference: RocksDB uses READ_AMP_TOTAL_READ_BYTES / READ_AMP_ESTIMATE_USEFUL_BYTES for byte-based
@@ -349,12 +1152,32 @@ func (m *Metrics) updateWriteAmplification() {
 // We use file-count RA as a proxy for RocksDB's byte-count RA (simpler, correlates well).
 //
 // FIDELITY: ✓ Matches RocksDB's file-checking behavior for point lookups
-func (m *Metrics) UpdateReadAmplification(lsmTree *LSMTree, numMemtables int) {
+func (m *Metrics) UpdateReadAmplification(lsmTree *LSMTree, numMemtables int, enableL0SubLevels bool) {
 	// Read amplification = number of places to check for a key
 	// - Active memtable only (1 if exists, 0 if empty) - immutable memtables are already flushing
 	// - All L0 files (L0 is unsorted/tiered, must check all)
 	// - 1 file per level in L1+ (sorted levels, binary search)
 
+	if len(lsmTree.Levels) > 0 {
+		m.L0SubLevelCount = lsmTree.Levels[0].SubLevelCount()
+	}
+	m.ReadAmplification = readAmplificationFor(lsmTree, numMemtables, enableL0SubLevels)
+}
+
+// trackAchievedReadAmp folds the current ReadAmplification, held for the elapsed seconds, into
+// AvgReadAmplification and PeakReadAmplification
+func (m *Metrics) trackAchievedReadAmp(elapsed float64) {
+	m.PeakReadAmplification = max(m.PeakReadAmplification, m.ReadAmplification)
+	if elapsed <= 0 {
+		return
+	}
+	m.readAmpSeconds += elapsed
+	m.readAmpIntegrated += m.ReadAmplification * elapsed
+	m.AvgReadAmplification = m.readAmpIntegrated / m.readAmpSeconds
+}
+
+// readAmplificationFor returns the point-lookup read amplification of the tree (see UpdateReadAmplification)
+func readAmplificationFor(lsmTree *LSMTree, numMemtables int, enableL0SubLevels bool) float64 {
 	// Count active memtable only (RocksDB doesn't check immutable memtables during reads)
 	activeMemtableCount := 0
 	if numMemtables > 0 {
@@ -366,19 +1189,137 @@ func (m *Metrics) UpdateReadAmplification(lsmTree *LSMTree, numMemtables int) {
 	numLevels := len(lsmTree.Levels)
 	if numLevels > 0 {
 		l0FileCount = lsmTree.Levels[0].FileCount
+		if enableL0SubLevels {
+			l0FileCount = lsmTree.Levels[0].SubLevelCount()
+		}
 	}
 
-	m.ReadAmplification = float64(activeMemtableCount + l0FileCount + (numLevels - 1))
-
 	// Floor of 1.0 (at least check memtable)
-	if m.ReadAmplification < 1.0 {
-		m.ReadAmplification = 1.0
+	return max(1.0, float64(activeMemtableCount+l0FileCount+(numLevels-1)))
+}
+
+// RecordCompactionThroughputByDepth accumulates a completed compaction into the bottommost or
+// upper compaction throughput
+func (m *Metrics) RecordCompactionThroughputByDepth(bottommost bool, inputMB, durationSec float64) {
+	depth := 0
+	if bottommost {
+		depth = 1
+	}
+	m.compactionMBByDepth[depth] += inputMB
+	m.compactionSecByDepth[depth] += durationSec
+	if m.compactionSecByDepth[depth] <= 0 {
+		return
+	}
+	throughput := m.compactionMBByDepth[depth] / m.compactionSecByDepth[depth]
+	if bottommost {
+		m.BottommostCompactionThroughputMBps = throughput
+	} else {
+		m.UpperCompactionThroughputMBps = throughput
+	}
+}
+
+// bloomFilterFPR returns the false-positive rate of a bloom filter with bitsPerKey bits per key,
+// assuming the optimal number of hash functions: exp(-bits * ln(2)^2). Returns 1 (every probe
+// admitted) when bitsPerKey is 0.
+//
+// FIDELITY: ✓ Standard approximation; RocksDB's FastLocalBloom is within a few percent of it at 10 bits/key
+func bloomFilterFPR(bitsPerKey int) float64 {
+	if bitsPerKey <= 0 {
+		return 1.0
+	}
+	return math.Exp(-float64(bitsPerKey) * math.Ln2 * math.Ln2)
+}
+
+// RecordReadBatchAmplification folds the read amplification seen by one read batch into ReadAmplificationEMA
+func (m *Metrics) RecordReadBatchAmplification(readAmp float64) {
+	if m.ReadAmplificationEMA == 0 {
+		m.ReadAmplificationEMA = readAmp // First sample since start or ResetMetrics
+		return
+	}
+	m.ReadAmplificationEMA = m.smoothingAlpha*readAmp + (1-m.smoothingAlpha)*m.ReadAmplificationEMA
+}
+
+// updateLevelsTouched computes AvgLevelsTouchedPerRead from the read mix computed by updateReadMetrics.
+// Lookups that find their key (cache hits and cache-miss point lookups) stop at the first sorted run
+// holding it; bloom negatives (absent keys) and range scans probe every run.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Uniform key distribution: a key lives in a run with probability
+// proportional to the run's size, so most lookups resolve near the (large) bottom level. Under a
+// zipfian ReadKeyDistribution the distance to the first hit is scaled by ReadKeySkewFactor.
+// FIDELITY: ⚠️ SIMPLIFIED - Immutable memtables aren't probed, matching UpdateReadAmplification
+func (m *Metrics) updateLevelsTouched(config *ReadWorkloadConfig, lsmTree *LSMTree, enableL0SubLevels bool) {
+	if config == nil || !config.Enabled || m.CurrentReadReqsPerSec <= 0 {
+		m.AvgLevelsTouchedPerRead = 0
+		return
+	}
+
+	runSizes := sortedRunSizes(lsmTree, enableL0SubLevels)
+	allRuns := float64(max(len(runSizes), 1))
+	toFirstHit := allRuns
+	if totalSize, depth := uniformFirstHitDepth(runSizes); totalSize > 0 {
+		toFirstHit = depth
+		if m.ReadKeySkewFactor > 0 {
+			toFirstHit *= m.ReadKeySkewFactor
+		}
+	}
+
+	hitsPerSec := m.CacheHitsPerSec + m.PointLookupsPerSec
+	probeAllPerSec := m.BloomNegativesPerSec + m.ScansPerSec
+	m.AvgLevelsTouchedPerRead = (hitsPerSec*toFirstHit + probeAllPerSec*allRuns) / (hitsPerSec + probeAllPerSec)
+}
+
+// sortedRunSizes returns the sizes of the sorted runs a lookup probes, in lookup order (newest
+// data first): the memtable (when non-empty), L0 files or sub-levels, then non-empty L1+ levels
+func sortedRunSizes(lsmTree *LSMTree, enableL0SubLevels bool) []float64 {
+	runSizes := make([]float64, 0, len(lsmTree.Levels)+lsmTree.Levels[0].FileCount+1)
+	if lsmTree.MemtableCurrentSize > 0 {
+		runSizes = append(runSizes, lsmTree.MemtableCurrentSize)
+	}
+	if enableL0SubLevels {
+		subLevels := lsmTree.Levels[0].subLevels()
+		for i := len(subLevels) - 1; i >= 0; i-- { // Newest sub-level first
+			var sizeMB float64
+			for _, f := range subLevels[i] {
+				sizeMB += f.SizeMB
+			}
+			runSizes = append(runSizes, sizeMB)
+		}
+	} else {
+		for _, f := range lsmTree.Levels[0].Files {
+			runSizes = append(runSizes, f.SizeMB)
+		}
 	}
+	for _, level := range lsmTree.Levels[1:] {
+		if level.TotalSize > 0 {
+			runSizes = append(runSizes, level.TotalSize)
+		}
+	}
+	return runSizes
+}
+
+// uniformFirstHitDepth returns the runs' total size and, with every key equally likely, the
+// average number of runs a lookup probes to reach its key's run (depth is 0 when the runs are empty)
+func uniformFirstHitDepth(runSizes []float64) (totalSize, depth float64) {
+	var weighted float64
+	for i, size := range runSizes {
+		totalSize += size
+		weighted += float64(i+1) * size
+	}
+	if totalSize > 0 {
+		depth = weighted / totalSize
+	}
+	return totalSize, depth
 }
 
 // UpdateReadMetrics calculates read latency and bandwidth using statistical model
 // This samples latency distributions to build p50/p99 statistics without discrete read events
 func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64, blockSizeKB int, rng *rand.Rand) {
+	m.updateReadMetrics(config, readAmp, blockSizeKB, 0, rng)
+}
+
+// updateReadMetrics is UpdateReadMetrics with the average key-value size, which turns
+// AvgScanLengthKeys into scan bytes (0 = scans read AvgScanSizeKB)
+func (m *Metrics) updateReadMetrics(config *ReadWorkloadConfig, readAmp float64, blockSizeKB int, avgKeyValueSizeBytes int, rng *rand.Rand) {
 	if config == nil {
 		// Read path modeling disabled - config is nil
 		m.AvgReadLatencyMs = 0
@@ -390,6 +1331,8 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 		m.BloomNegativesPerSec = 0
 		m.ScansPerSec = 0
 		m.PointLookupsPerSec = 0
+		m.PointReadLatencyMs = 0
+		m.ScanLatencyMs = 0
 		return
 	}
 	if !config.Enabled {
@@ -404,6 +1347,8 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 		m.BloomNegativesPerSec = 0
 		m.ScansPerSec = 0
 		m.PointLookupsPerSec = 0
+		m.PointReadLatencyMs = 0
+		m.ScanLatencyMs = 0
 		return
 	}
 	log.Printf("[READ METRICS] Computing read metrics: RequestsPerSec=%v, CacheHitRate=%v, ReadAmp=%v", config.RequestsPerSec, config.CacheHitRate, readAmp)
@@ -438,9 +1383,25 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 	m.ScansPerSec = scansPerSec
 	m.PointLookupsPerSec = pointLookupsPerSec
 
+	// Scan size: AvgScanLengthKeys x key-value size when both are known, else AvgScanSizeKB
+	blockSizeMB := float64(blockSizeKB) / 1024.0
+	baseScanSizeMB := config.AvgScanSizeKB / 1024.0
+	scanSizeMB := baseScanSizeMB
+	if config.AvgScanLengthKeys > 0 && avgKeyValueSizeBytes > 0 {
+		scanSizeMB = float64(config.AvgScanLengthKeys) * float64(avgKeyValueSizeBytes) / (1024 * 1024)
+	}
+	multiLevelScans := config.AvgScanLengthKeys > 0
+
+	readAmpInt := int(readAmp)
+	if readAmpInt < 1 {
+		readAmpInt = 1
+	}
+
 	// Sample latencies to build distribution (1000 samples for good statistics)
 	const numSamples = 1000
 	latencies := make([]float64, 0, numSamples)
+	var pointLatencySum, scanLatencySum float64
+	var pointSamples, scanSamples int
 
 	// Sample proportionally based on request type distribution
 	for i := 0; i < numSamples; i++ {
@@ -456,13 +1417,22 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 			latency = SampleLatency(config.BloomNegativeLatency, rng)
 		} else if r < config.CacheHitRate+config.BloomNegativeRate+config.ScanRate {
 			// Range scan
-			latency = SampleLatency(config.ScanLatency, rng)
+			if multiLevelScans {
+				// A scan merges every sorted run over its key span: bloom filters can't skip runs,
+				// so it seeks all of them (parallel, take max) and reads longer spans for longer
+				for j := 0; j < readAmpInt; j++ {
+					latency = max(latency, SampleLatency(config.ScanLatency, rng))
+				}
+				if baseScanSizeMB > 0 {
+					latency *= scanSizeMB / baseScanSizeMB
+				}
+			} else {
+				latency = SampleLatency(config.ScanLatency, rng)
+			}
+			scanLatencySum += latency
+			scanSamples++
 		} else {
 			// Point lookup with cache miss - sample readAmp times, take max (parallel I/O)
-			readAmpInt := int(readAmp)
-			if readAmpInt < 1 {
-				readAmpInt = 1
-			}
 			maxLatency := 0.0
 			for j := 0; j < readAmpInt; j++ {
 				l := SampleLatency(config.PointLookupLatency, rng)
@@ -471,6 +1441,8 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 				}
 			}
 			latency = maxLatency
+			pointLatencySum += latency
+			pointSamples++
 		}
 
 		latencies = append(latencies, latency)
@@ -490,13 +1462,23 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 	// Cache hits and bloom negatives don't use disk I/O
 	// Point lookups read: blockSize * readAmp bytes per request
 	// Scans read: avgScanSizeKB bytes per request
-	blockSizeMB := float64(blockSizeKB) / 1024.0
-	scanSizeMB := config.AvgScanSizeKB / 1024.0
-
+	// Multi-level scans also read one block per sorted run to position their iterators
 	pointLookupBytes := pointLookupsPerSec * blockSizeMB * readAmp
 	scanBytes := scansPerSec * scanSizeMB
+	if multiLevelScans {
+		scanBytes += scansPerSec * blockSizeMB * readAmp
+	}
 	rawBandwidth := pointLookupBytes + scanBytes
 
+	// Per-type averages (0 when no samples of that type were drawn)
+	var pointLatency, scanLatency float64
+	if pointSamples > 0 {
+		pointLatency = pointLatencySum / float64(pointSamples)
+	}
+	if scanSamples > 0 {
+		scanLatency = scanLatencySum / float64(scanSamples)
+	}
+
 	// Apply EMA smoothing to read metrics (same as throughput metrics)
 	// Check if this is the first read metrics update (all values are 0)
 	if m.AvgReadLatencyMs == 0 && m.P50ReadLatencyMs == 0 && m.P99ReadLatencyMs == 0 && m.ReadBandwidthMBps == 0 {
@@ -505,6 +1487,8 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 		m.P50ReadLatencyMs = p50Latency
 		m.P99ReadLatencyMs = p99Latency
 		m.ReadBandwidthMBps = rawBandwidth
+		m.PointReadLatencyMs = pointLatency
+		m.ScanLatencyMs = scanLatency
 		log.Printf("[READ METRICS] Initialized: Avg=%.3f, P50=%.3f, P99=%.3f, BW=%.2f", m.AvgReadLatencyMs, m.P50ReadLatencyMs, m.P99ReadLatencyMs, m.ReadBandwidthMBps)
 	} else {
 		// Apply EMA smoothing: smoothed = alpha * new + (1-alpha) * previous
@@ -512,6 +1496,8 @@ func (m *Metrics) UpdateReadMetrics(config *ReadWorkloadConfig, readAmp float64,
 		m.P50ReadLatencyMs = m.smoothingAlpha*p50Latency + (1-m.smoothingAlpha)*m.P50ReadLatencyMs
 		m.P99ReadLatencyMs = m.smoothingAlpha*p99Latency + (1-m.smoothingAlpha)*m.P99ReadLatencyMs
 		m.ReadBandwidthMBps = m.smoothingAlpha*rawBandwidth + (1-m.smoothingAlpha)*m.ReadBandwidthMBps
+		m.PointReadLatencyMs = m.smoothingAlpha*pointLatency + (1-m.smoothingAlpha)*m.PointReadLatencyMs
+		m.ScanLatencyMs = m.smoothingAlpha*scanLatency + (1-m.smoothingAlpha)*m.ScanLatencyMs
 		log.Printf("[READ METRICS] Smoothed: Avg=%.3f, P50=%.3f, P99=%.3f, BW=%.2f", m.AvgReadLatencyMs, m.P50ReadLatencyMs, m.P99ReadLatencyMs, m.ReadBandwidthMBps)
 	}
 }
@@ -565,6 +1551,96 @@ func percentile(sortedValues []float64, p float64) float64 {
 	return sortedValues[lowerIdx]*(1-fraction) + sortedValues[upperIdx]*fraction
 }
 
+// updateEstimatedCompactionsToClearL0 estimates how many compactions the current L0 backlog needs:
+// L0 bytes divided by the average output of the L0 compactions completed so far (before the
+// first one, L0CompactionTrigger memtables' worth).
+// FIDELITY: ⚠️ Assumes future L0 compactions look like past ones; overlapping base-level bytes
+// are folded into the typical output size rather than modeled per job
+func (m *Metrics) updateEstimatedCompactionsToClearL0(lsmTree *LSMTree, config SimConfig) {
+	if len(lsmTree.Levels) == 0 || lsmTree.Levels[0].TotalSize <= 0 {
+		m.EstimatedCompactionsToClearL0 = 0
+		return
+	}
+
+	typicalOutputMB := float64(config.L0CompactionTrigger * config.MemtableFlushSizeMB)
+	if m.l0CompactionCount > 0 && m.l0CompactionOutputMB > 0 {
+		typicalOutputMB = m.l0CompactionOutputMB / float64(m.l0CompactionCount)
+	}
+	if typicalOutputMB <= 0 {
+		m.EstimatedCompactionsToClearL0 = 0
+		return
+	}
+	m.EstimatedCompactionsToClearL0 = int(math.Ceil(lsmTree.Levels[0].TotalSize / typicalOutputMB))
+}
+
+// updateFlushAndWriteRates averages flush and user write bytes over the throughput window.
+// The oldest sample kept is the last one at or before the window start, so the rate covers the
+// whole window once the simulation has run that long.
+func (m *Metrics) updateFlushAndWriteRates() {
+	m.rateSamples = append(m.rateSamples, rateSample{
+		time:      m.Timestamp,
+		flushedMB: m.totalFlushWrittenMB,
+		writtenMB: m.TotalDataWrittenMB,
+	})
+
+	windowStart := m.Timestamp - m.throughputWindow
+	drop := 0
+	for drop+1 < len(m.rateSamples) && m.rateSamples[drop+1].time <= windowStart {
+		drop++
+	}
+	m.rateSamples = m.rateSamples[drop:]
+
+	oldest := m.rateSamples[0]
+	elapsed := m.Timestamp - oldest.time
+	if elapsed <= 0 {
+		m.FlushRateMBps = 0
+		m.AchievedWriteRateMBps = 0
+		return
+	}
+	m.FlushRateMBps = (m.totalFlushWrittenMB - oldest.flushedMB) / elapsed
+	m.AchievedWriteRateMBps = (m.TotalDataWrittenMB - oldest.writtenMB) / elapsed
+}
+
+// updateCompactionEfficiency computes bytes reclaimed per byte written across compactions
+// that completed within the throughput window. Reports 0 when no compaction wrote output
+// (e.g., only FIFO deletions), since the ratio is undefined.
+// FIDELITY: ✓ Uses the same input/output sizes ExecuteCompaction reports for write amplification
+// FIDELITY: ⚠️ Reclaimed bytes come from the statistical overlap/dedup model, not actual key versions
+func (m *Metrics) updateCompactionEfficiency() {
+	valid := m.recentCompactions[:0]
+	var reclaimedMB, writtenMB float64
+	for _, c := range m.recentCompactions {
+		if c.EndTime < m.Timestamp-m.throughputWindow {
+			continue
+		}
+		valid = append(valid, c)
+		reclaimedMB += math.Max(0, c.InputMB-c.SizeMB)
+		writtenMB += c.SizeMB
+	}
+	m.recentCompactions = valid
+
+	if writtenMB <= 0 {
+		m.CompactionEfficiency = 0
+		return
+	}
+	m.CompactionEfficiency = reclaimedMB / writtenMB
+}
+
+// defaultThroughputWindow is the recentWrites history (seconds) kept when RecentWritesWindowSeconds is unset
+const defaultThroughputWindow = 5.0
+
+// applyThroughputWindow sizes the recentWrites history from RecentWritesWindowSeconds and matches
+// the EMA smoothing to it: one sample per base step, so alpha = step/window averages over about
+// one window (the default 1s step and 5s window give alpha 0.2)
+func (m *Metrics) applyThroughputWindow(config SimConfig) {
+	window := config.RecentWritesWindowSeconds
+	if window <= 0 {
+		window = defaultThroughputWindow
+	}
+	m.throughputWindow = window
+	m.smoothingAlpha = min(1.0, config.BaseStep()/window)
+}
+
 // calculateThroughput calculates INSTANTANEOUS write throughput
 // Shows what's actively being written RIGHT NOW, not historical average
 // FIX: Accounts for serialized compaction execution (diskBusyUntil serializes all disk operations)
@@ -575,7 +1651,7 @@ func (m *Metrics) calculateThroughput() {
 	// Clean up old completed writes (keep only recent history)
 	validWrites := make([]WriteActivity, 0)
 	for _, w := range m.recentWrites {
-		if w.EndTime >= m.Timestamp-5.0 { // Keep 5s of history
+		if w.EndTime >= m.Timestamp-m.throughputWindow { // Keep one window of history
 			validWrites = append(validWrites, w)
 		}
 	}
@@ -596,7 +1672,7 @@ func (m *Metrics) calculateThroughput() {
 	// Calculate instantaneous throughput
 	// CRITICAL FIX: Compactions are serialized via diskBusyUntil, so we can only count
 	// compactions that are ACTUALLY executing (not waiting). Find the active compaction.
-	var walBandwidth, flushBandwidth, compactionBandwidth float64
+	var walBandwidth, ingestBandwidth, flushBandwidth, compactionBandwidth float64
 	perLevelBandwidth := make(map[int]float64)
 
 	// Find the compaction that is currently using disk (only one can be active at a time)
@@ -629,7 +1705,11 @@ func (m *Metrics) calculateThroughput() {
 			continue
 		}
 
-		if w.Level == -2 {
+		if w.Level == -3 {
+			// Ingestion: file copied into the DB directory (sequential write)
+			bandwidth := w.SizeMB / writeDuration
+			ingestBandwidth += bandwidth
+		} else if w.Level == -2 {
 			// WAL write: sequential write bandwidth
 			bandwidth := w.SizeMB / writeDuration
 			walBandwidth += bandwidth
@@ -644,7 +1724,9 @@ func (m *Metrics) calculateThroughput() {
 				// This is the active compaction - count total disk bandwidth (read + write)
 				totalDiskBandwidth := (w.InputMB + w.SizeMB) / writeDuration
 				compactionBandwidth += totalDiskBandwidth
-				perLevelBandwidth[w.Level] += totalDiskBandwidth
+				if w.ColumnFamily == 0 {
+					perLevelBandwidth[w.Level] += totalDiskBandwidth
+				}
 			}
 			// Waiting compactions are ignored (they're not using disk yet)
 		}
@@ -654,7 +1736,7 @@ func (m *Metrics) calculateThroughput() {
 	// EMA formula: smoothed = alpha * instantaneous + (1-alpha) * previous_smoothed
 	// alpha = 0.2 gives approximately 5-sample average
 
-	totalBandwidth := walBandwidth + flushBandwidth + compactionBandwidth
+	totalBandwidth := walBandwidth + ingestBandwidth + flushBandwidth + compactionBandwidth
 
 	if m.isFirstSample {
 		// Initialize EMA with first sample
@@ -699,8 +1781,9 @@ func (m *Metrics) calculateThroughput() {
 // Total: 4 files per compaction.
 //
 // File sizes are calculated as: target_file_size_base × (target_file_size_multiplier ^ level),
-// capped at 2GB per file.
-func calculateWorstCaseCompactionIO(fromLevel int, targetFileSizeBase, targetFileSizeMultiplier int, maxCompactionBytesMB int) float64 {
+// capped at 2GB per file. reductionFactor is the compaction's output/input ratio
+// (SimConfig.CompactionReductionFactor).
+func calculateWorstCaseCompactionIO(fromLevel int, targetFileSizeBase, targetFileSizeMultiplier int, maxCompactionBytesMB int, reductionFactor float64) float64 {
 	// Calculate target file size for the target level (toLevel = fromLevel + 1)
 	toLevel := fromLevel + 1
 	targetFileSizeMB := float64(targetFileSizeBase)
@@ -733,8 +1816,7 @@ func calculateWorstCaseCompactionIO(fromLevel int, targetFileSizeBase, targetFil
 			// Would be limited by max_compaction_bytes
 			// In this case, compaction would read less, but worst-case estimate
 			// assumes we hit the limit, so use max_compaction_bytes for input
-			// Output is typically ~99% of input for deeper levels
-			outputSize := maxCompactionMB * 0.99
+			outputSize := maxCompactionMB * reductionFactor
 			worstCaseIO = maxCompactionMB + outputSize
 		}
 	}
@@ -765,6 +1847,7 @@ func (m *Metrics) CalculateWorstCaseSustainableRate(ioThroughputMBps float64, ma
 		config.TargetFileSizeMB,
 		config.TargetFileSizeMultiplier,
 		config.MaxCompactionBytesMB,
+		config.CompactionReductionFactor(deepestLevel-1),
 	)
 
 	// With maxBackgroundJobs compactions queued, total I/O scales linearly
@@ -836,12 +1919,37 @@ func (m *Metrics) CapThroughput(maxThroughputMBps float64) {
 // Update updates the timestamp and recalculates metrics
 func (m *Metrics) Update(virtualTime float64, lsmTree *LSMTree, numMemtables int, diskBusyUntil float64, ioThroughputMBps float64,
 	isStalled bool, stalledWriteCount int, activeBackgroundJobs int, maxBackgroundJobs int, config SimConfig, rng *rand.Rand) {
+	elapsed := virtualTime - m.Timestamp
 	m.Timestamp = virtualTime
-	m.UpdateSpaceAmplification(lsmTree.TotalSizeMB, lsmTree)
-	m.UpdateReadAmplification(lsmTree, numMemtables)
-	m.UpdateReadMetrics(config.ReadWorkload, m.ReadAmplification, config.BlockSizeKB, rng)
+	m.applyThroughputWindow(config)
+	m.UpdateSpaceAmplification(lsmTree, config)
+	m.UpdateReadAmplification(lsmTree, numMemtables, config.EnableL0SubLevels)
+	m.trackAchievedReadAmp(elapsed)
+	// Point lookups probe only the runs their bloom filters admit (sampled by the last read batch)
+	readAmp := m.ReadAmplification
+	m.BloomFilterFPR = 0
+	if config.BloomFilterBitsPerKey > 0 {
+		m.BloomFilterFPR = bloomFilterFPR(config.BloomFilterBitsPerKey)
+		if m.BloomAdmittedReadAmp > 0 {
+			readAmp = m.BloomAdmittedReadAmp
+		}
+	}
+	if m.ReadKeySkewFactor > 0 {
+		readAmp *= m.ReadKeySkewFactor // Hot keys resolve before the deeper runs
+	}
+	// After a restart, the cold part of the block cache turns would-be hits into disk reads
+	readWorkload := config.ReadWorkload
+	if readWorkload != nil && m.CacheWarmth < 1 {
+		cold := *readWorkload
+		cold.CacheHitRate *= m.CacheWarmth
+		readWorkload = &cold
+	}
+	m.updateReadMetrics(readWorkload, readAmp, config.BlockSizeKB, config.AvgKeyValueSizeBytes, rng)
+	m.updateLevelsTouched(config.ReadWorkload, lsmTree, config.EnableL0SubLevels)
 	m.calculateThroughput()
 	m.CapThroughput(ioThroughputMBps) // Enforce physical disk limits
+	m.updateCompactionEfficiency()
+	m.updateFlushAndWriteRates()
 
 	// Calculate disk utilization percentage
 	if ioThroughputMBps > 0 {
@@ -880,6 +1988,18 @@ func (m *Metrics) Update(virtualTime float64, lsmTree *LSMTree, numMemtables int
 		config,
 	)
 
+	m.updateFileSizeCompliance(lsmTree, config)
+	m.updateLevelCompression(lsmTree, config)
+	m.recordLevelSizeHistory(virtualTime, lsmTree, config.MaxHistorySamples)
+	m.updateEstimatedCompactionsToClearL0(lsmTree, config)
+	m.updateCompactionDebt(lsmTree, config)
+
+	// Update per-level key counts
+	m.PerLevelKeys = make(map[int]int64, len(lsmTree.Levels))
+	for i, level := range lsmTree.Levels {
+		m.PerLevelKeys[i] = keysInSSTs(level.TotalSize, config)
+	}
+
 	// Update stall metrics
 	m.IsStalled = isStalled
 	m.StalledWriteCount = stalledWriteCount
@@ -891,16 +2011,24 @@ func (m *Metrics) Update(virtualTime float64, lsmTree *LSMTree, numMemtables int
 	// Update background job metrics
 	m.ActiveBackgroundJobs = activeBackgroundJobs
 	m.MaxBackgroundJobs = maxBackgroundJobs
+	m.updateBackgroundSlotUtilization(virtualTime, maxBackgroundJobs)
+	m.updateIOPSUtilization(virtualTime, config.DiskIOPS)
+	m.updateCPUUtilization(virtualTime, config.CompactionThreads())
+	m.updateDiskDirectionUtilization(virtualTime, config.ReadThroughputMBps(), config.IOThroughputMBps)
+	m.updateTierUtilization(virtualTime)
+	m.updateCompactionRateLimitUtilization(virtualTime, float64(config.CompactionRateLimitMBps))
+	m.updateBackgroundQueueDepth(virtualTime)
 
 	// Update in-progress activities for UI display
 	m.InProgressCount = len(m.inProgressWrites)
 	m.InProgressDetails = make([]map[string]interface{}, 0, len(m.inProgressWrites))
 	for _, w := range m.inProgressWrites {
 		detail := map[string]interface{}{
-			"inputMB":   w.InputMB,
-			"outputMB":  w.SizeMB,
-			"fromLevel": w.Level,
-			"toLevel":   w.ToLevel,
+			"inputMB":      w.InputMB,
+			"outputMB":     w.SizeMB,
+			"fromLevel":    w.Level,
+			"toLevel":      w.ToLevel,
+			"columnFamily": w.ColumnFamily,
 		}
 		m.InProgressDetails = append(m.InProgressDetails, detail)
 	}
@@ -311,3 +311,319 @@ func TestZeroVariability(t *testing.T) {
 
 	t.Logf("Zero variability test passed: constant BW=%.2f MB/s", bandwidths[0])
 }
+
+// Test that scans and point lookups are tracked separately and multi-level scans scale with length
+func TestScanVsPointReadLatency(t *testing.T) {
+	workload := DefaultReadWorkload()
+	workload.Enabled = true
+	workload.RequestsPerSec = 1000
+	workload.CacheHitRate = 0.5
+	workload.BloomNegativeRate = 0
+	workload.ScanRate = 0.25 // Remaining 25% are point lookups
+	workload.PointLookupLatency = LatencySpec{Distribution: LatencyDistFixed, Mean: 2.0}
+	workload.ScanLatency = LatencySpec{Distribution: LatencyDistFixed, Mean: 10.0}
+	readAmp := 5.0
+	blockSizeKB := 4
+
+	metrics := NewMetrics()
+	metrics.updateReadMetrics(&workload, readAmp, blockSizeKB, 1024, rand.New(rand.NewSource(42)))
+	if metrics.PointReadLatencyMs != 2.0 || metrics.ScanLatencyMs != 10.0 {
+		t.Errorf("Expected point 2.0 ms and scan 10.0 ms, got %.3f and %.3f", metrics.PointReadLatencyMs, metrics.ScanLatencyMs)
+	}
+
+	// 64 keys x 1 KB = 64 KB per scan, 4x the 16 KB the scan latency is specified for
+	workload.AvgScanLengthKeys = 64
+	metrics = NewMetrics()
+	metrics.updateReadMetrics(&workload, readAmp, blockSizeKB, 1024, rand.New(rand.NewSource(42)))
+	if metrics.ScanLatencyMs != 40.0 {
+		t.Errorf("Expected 64 KB scan latency 40.0 ms, got %.3f", metrics.ScanLatencyMs)
+	}
+	if metrics.PointReadLatencyMs != 2.0 {
+		t.Errorf("Point lookups should be unaffected by scan length, got %.3f", metrics.PointReadLatencyMs)
+	}
+
+	// Scans read their span plus one positioning block per sorted run
+	expectedBW := 250*(4.0/1024.0)*readAmp + 250*(64.0/1024.0+(4.0/1024.0)*readAmp)
+	if diff := metrics.ReadBandwidthMBps - expectedBW; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected read bandwidth %.4f MB/s, got %.4f MB/s", expectedBW, metrics.ReadBandwidthMBps)
+	}
+}
+
+// TestAvgLevelsTouchedPerRead tests that lookups stop at the run holding the key while
+// bloom negatives and scans probe every run
+func TestAvgLevelsTouchedPerRead(t *testing.T) {
+	lsm := NewLSMTree(7, 64)
+	lsm.Levels[1].AddFile(&SSTFile{ID: "L1", SizeMB: 100})
+	lsm.Levels[6].AddFile(&SSTFile{ID: "L6", SizeMB: 900})
+
+	workload := DefaultReadWorkload()
+	workload.Enabled = true
+	workload.RequestsPerSec = 1000
+	workload.CacheHitRate = 0
+	workload.BloomNegativeRate = 0
+	workload.ScanRate = 0
+
+	metrics := NewMetrics()
+	metrics.updateReadMetrics(&workload, 7, 4, 0, rand.New(rand.NewSource(42)))
+	metrics.updateLevelsTouched(&workload, lsm, false)
+	// 10% of keys resolve at L1 (1 level), 90% at L6 (2 levels); empty levels aren't probed
+	if diff := metrics.AvgLevelsTouchedPerRead - 1.9; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected 1.9 levels touched for point lookups, got %.4f", metrics.AvgLevelsTouchedPerRead)
+	}
+
+	// Half bloom negatives: those probe both runs
+	workload.BloomNegativeRate = 0.5
+	metrics.updateReadMetrics(&workload, 7, 4, 0, rand.New(rand.NewSource(42)))
+	metrics.updateLevelsTouched(&workload, lsm, false)
+	if diff := metrics.AvgLevelsTouchedPerRead - 1.95; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected 1.95 levels touched with bloom negatives, got %.4f", metrics.AvgLevelsTouchedPerRead)
+	}
+
+	// Two overlapping L0 files add two runs in front of L1
+	lsm.Levels[0].AddFile(&SSTFile{ID: "L0-a", SizeMB: 50})
+	lsm.Levels[0].AddFile(&SSTFile{ID: "L0-b", SizeMB: 50})
+	workload.BloomNegativeRate = 0
+	workload.ScanRate = 1.0
+	metrics.updateReadMetrics(&workload, 7, 4, 0, rand.New(rand.NewSource(42)))
+	metrics.updateLevelsTouched(&workload, lsm, false)
+	if metrics.AvgLevelsTouchedPerRead != 4 {
+		t.Errorf("Expected scans to touch all 4 runs, got %.4f", metrics.AvgLevelsTouchedPerRead)
+	}
+
+	metrics.updateLevelsTouched(nil, lsm, false)
+	if metrics.AvgLevelsTouchedPerRead != 0 {
+		t.Errorf("Expected 0 with read modeling disabled, got %.4f", metrics.AvgLevelsTouchedPerRead)
+	}
+}
+
+func TestReadAmplificationEMA(t *testing.T) {
+	lsm := NewLSMTree(4, 64)
+	lsm.Levels[0].AddFile(&SSTFile{ID: "L0-a", SizeMB: 10})
+	lsm.Levels[0].AddFile(&SSTFile{ID: "L0-b", SizeMB: 10})
+
+	// Memtable + 2 L0 files + one file per L1..L3
+	if got := readAmplificationFor(lsm, 1, false); got != 6 {
+		t.Errorf("Expected read amplification 6, got %.2f", got)
+	}
+	if got := readAmplificationFor(lsm, 0, true); got != 5 {
+		t.Errorf("Expected read amplification 5 with empty memtable, got %.2f", got)
+	}
+
+	metrics := NewMetrics()
+	metrics.RecordReadBatchAmplification(6)
+	if metrics.ReadAmplificationEMA != 6 {
+		t.Errorf("Expected first sample to seed the EMA at 6, got %.2f", metrics.ReadAmplificationEMA)
+	}
+	metrics.RecordReadBatchAmplification(10)
+	if metrics.ReadAmplificationEMA <= 6 || metrics.ReadAmplificationEMA >= 10 {
+		t.Errorf("Expected EMA between 6 and 10, got %.2f", metrics.ReadAmplificationEMA)
+	}
+
+	// UI updates don't disturb the smoothing
+	ema := metrics.ReadAmplificationEMA
+	metrics.ResetAggregateStats()
+	if metrics.ReadAmplificationEMA != ema {
+		t.Errorf("Expected EMA %.2f to survive ResetAggregateStats, got %.2f", ema, metrics.ReadAmplificationEMA)
+	}
+	metrics.RecordReadBatchAmplification(3)
+	if metrics.ReadAmplificationEMA == 3 || metrics.ReadAmplificationEMA >= ema {
+		t.Errorf("Expected EMA to move from %.2f toward 3, got %.2f", ema, metrics.ReadAmplificationEMA)
+	}
+}
+
+func TestReadAmplificationEMA_SampledByReads(t *testing.T) {
+	config := DefaultConfig()
+	readWorkload := DefaultReadWorkload()
+	readWorkload.Enabled = true
+	readWorkload.RequestsPerSec = 1000
+	config.ReadWorkload = &readWorkload
+
+	sim, err := NewSimulator(config)
+	if err != nil {
+		t.Fatalf("Failed to create simulator: %v", err)
+	}
+	sim.Reset()
+	for i := 0; i < 10; i++ {
+		sim.Step()
+	}
+	if sim.Metrics().ReadAmplificationEMA < 1 {
+		t.Errorf("Expected reads to sample read amplification, got %.2f", sim.Metrics().ReadAmplificationEMA)
+	}
+}
+
+func TestBloomFilterFPR(t *testing.T) {
+	if fpr := bloomFilterFPR(0); fpr != 1.0 {
+		t.Errorf("Expected no filter to admit every probe, got %.4f", fpr)
+	}
+	if fpr := bloomFilterFPR(10); fpr < 0.008 || fpr > 0.009 {
+		t.Errorf("Expected ~0.82%% FPR at 10 bits/key, got %.4f", fpr)
+	}
+	if bloomFilterFPR(20) >= bloomFilterFPR(10) {
+		t.Errorf("Expected more bits per key to lower the FPR")
+	}
+}
+
+func TestBloomFilterReducesReadCost(t *testing.T) {
+	run := func(bitsPerKey int) *Simulator {
+		config := DefaultConfig()
+		config.RandomSeed = 42
+		config.BloomFilterBitsPerKey = bitsPerKey
+		readWorkload := DefaultReadWorkload()
+		readWorkload.Enabled = true
+		readWorkload.RequestsPerSec = 1000
+		readWorkload.CacheHitRate = 0
+		config.ReadWorkload = &readWorkload
+
+		sim, err := NewSimulator(config)
+		if err != nil {
+			t.Fatalf("Failed to create simulator: %v", err)
+		}
+		sim.Reset()
+		for i := 0; i < 60; i++ {
+			sim.Step()
+		}
+		return sim
+	}
+
+	unfiltered := run(0)
+	if unfiltered.Metrics().BloomAdmittedReadAmp != 0 || unfiltered.Metrics().BloomFilterFPR != 0 {
+		t.Errorf("Expected no bloom modeling at 0 bits/key")
+	}
+	if _, ok := unfiltered.State()["bloomFilterFPR"]; ok {
+		t.Errorf("Expected no bloomFilterFPR state key at 0 bits/key")
+	}
+
+	filtered := run(10)
+	metrics := filtered.Metrics()
+	if metrics.ReadAmplification <= 2 {
+		t.Fatalf("Expected several sorted runs to filter, got read amp %.2f", metrics.ReadAmplification)
+	}
+	if metrics.BloomAdmittedReadAmp < 1 || metrics.BloomAdmittedReadAmp > 1.2 {
+		t.Errorf("Expected ~1 file probed per lookup with 10 bits/key, got %.3f", metrics.BloomAdmittedReadAmp)
+	}
+	if fpr, ok := filtered.State()["bloomFilterFPR"].(float64); !ok || fpr != bloomFilterFPR(10) {
+		t.Errorf("Expected State() to expose the bloom filter FPR, got %v", filtered.State()["bloomFilterFPR"])
+	}
+	if metrics.PointReadLatencyMs >= unfiltered.Metrics().PointReadLatencyMs {
+		t.Errorf("Expected filtered point lookups to be faster: %.3f ms vs %.3f ms",
+			metrics.PointReadLatencyMs, unfiltered.Metrics().PointReadLatencyMs)
+	}
+}
+
+func TestZipfianGenerator(t *testing.T) {
+	gen := newZipfianGenerator(zipfianKeyRanks, defaultZipfianTheta)
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[int]int)
+	samples := 100000
+	for i := 0; i < samples; i++ {
+		rank := gen.Sample(rng)
+		if rank < 0 || rank >= zipfianKeyRanks {
+			t.Fatalf("Rank %d out of range [0, %d)", rank, zipfianKeyRanks)
+		}
+		counts[rank]++
+	}
+	// P(rank 0) = 1/zeta(n) at theta 0.99, about 10% for 10000 ranks
+	if share := float64(counts[0]) / float64(samples); share < 0.08 || share > 0.12 {
+		t.Errorf("Expected ~10%% of draws at the hottest rank, got %.3f", share)
+	}
+	if counts[0] <= counts[1] || counts[1] <= counts[10] {
+		t.Errorf("Expected popularity to fall with rank: %d, %d, %d", counts[0], counts[1], counts[10])
+	}
+}
+
+func TestZipfianReadsLowerReadAmp(t *testing.T) {
+	run := func(distribution ReadKeyDistribution) *Simulator {
+		config := DefaultConfig()
+		config.RandomSeed = 42
+		readWorkload := DefaultReadWorkload()
+		readWorkload.Enabled = true
+		readWorkload.RequestsPerSec = 1000
+		readWorkload.CacheHitRate = 0
+		readWorkload.ReadKeyDistribution = distribution
+		config.ReadWorkload = &readWorkload
+
+		sim, err := NewSimulator(config)
+		if err != nil {
+			t.Fatalf("Failed to create simulator: %v", err)
+		}
+		sim.Reset()
+		for i := 0; i < 60; i++ {
+			sim.Step()
+		}
+		return sim
+	}
+
+	uniform := run(ReadKeyDistributionUniform).Metrics()
+	if uniform.ReadKeySkewFactor != 1.0 {
+		t.Errorf("Expected no skew under uniform access, got %.3f", uniform.ReadKeySkewFactor)
+	}
+
+	zipfian := run(ReadKeyDistributionZipfian).Metrics()
+	if zipfian.ReadAmplification <= 2 {
+		t.Fatalf("Expected several sorted runs to skew across, got read amp %.2f", zipfian.ReadAmplification)
+	}
+	if zipfian.ReadKeySkewFactor <= 0 || zipfian.ReadKeySkewFactor >= 1 {
+		t.Errorf("Expected hot keys to resolve in upper runs (factor < 1), got %.3f", zipfian.ReadKeySkewFactor)
+	}
+	if zipfian.ReadMemtableHitRatio <= uniform.ReadMemtableHitRatio {
+		t.Errorf("Expected more memtable hits under zipfian access: %.3f vs %.3f",
+			zipfian.ReadMemtableHitRatio, uniform.ReadMemtableHitRatio)
+	}
+	if zipfian.PointReadLatencyMs >= uniform.PointReadLatencyMs {
+		t.Errorf("Expected zipfian point lookups to be faster: %.3f ms vs %.3f ms",
+			zipfian.PointReadLatencyMs, uniform.PointReadLatencyMs)
+	}
+}
+
+// TestReadWorkloadDiskIOPS verifies that read batches are charged against DiskIOPS: their
+// operations count toward IOPS utilization, and an IOPS-bound disk stretches their disk time
+func TestReadWorkloadDiskIOPS(t *testing.T) {
+	run := func(iops float64) *Simulator {
+		config := DefaultConfig()
+		config.WriteRateMBps = 0 // Reads are the only disk I/O
+		config.RandomSeed = 42
+		config.DiskIOPS = iops
+		readWorkload := DefaultReadWorkload()
+		readWorkload.Enabled = true
+		readWorkload.RequestsPerSec = 2000
+		config.ReadWorkload = &readWorkload
+
+		sim, err := NewSimulator(config)
+		if err != nil {
+			t.Fatalf("Failed to create simulator: %v", err)
+		}
+		sim.Reset()
+		sim.StepUntil(30)
+		return sim
+	}
+
+	bandwidth := run(0)
+	limited := run(100)
+	if limited.Metrics().IOPSUtilizationPercent <= 0 {
+		t.Errorf("Expected read operations to count toward IOPS utilization, got %.1f%%", limited.Metrics().IOPSUtilizationPercent)
+	}
+	if limited.TimeBreakdown()["read"] <= bandwidth.TimeBreakdown()["read"] {
+		t.Errorf("Expected IOPS-bound reads to hold the disk longer: %.3fs vs %.3fs",
+			limited.TimeBreakdown()["read"], bandwidth.TimeBreakdown()["read"])
+	}
+}
+
+func TestReadKeyDistributionValidation(t *testing.T) {
+	config := DefaultConfig()
+	readWorkload := DefaultReadWorkload()
+	config.ReadWorkload = &readWorkload
+
+	readWorkload.ReadKeyDistribution = "hotspot"
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected an unknown key distribution to be rejected")
+	}
+	readWorkload.ReadKeyDistribution = ReadKeyDistributionZipfian
+	readWorkload.ZipfianTheta = 1.0
+	if err := config.Validate(); err == nil {
+		t.Errorf("Expected zipfianTheta >= 1 to be rejected")
+	}
+	readWorkload.ZipfianTheta = 0
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected zipfianTheta 0 (default skew) to be valid: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+)
+
+// trackedSource wraps a math/rand source and counts the values drawn from it. Go's generator
+// state can't be read out, but seed plus draw count pins down its position exactly: reseeding
+// and drawing the same number of values puts a fresh source in the same place.
+//
+// Every rand.Rand method the simulator uses (Float64, Intn, NormFloat64, ...) consumes whole
+// Int63/Uint64 draws and keeps no state of its own, so the source's position is the RNG's.
+type trackedSource struct {
+	seed  int64
+	draws uint64
+	src   rand.Source64
+}
+
+// rngPosition is a tracked source's serialized position
+type rngPosition struct {
+	Seed  int64  `json:"seed"`
+	Draws uint64 `json:"draws"`
+}
+
+func (t *trackedSource) Int63() int64 {
+	t.draws++
+	return t.src.Int63()
+}
+
+func (t *trackedSource) Uint64() uint64 {
+	t.draws++
+	return t.src.Uint64()
+}
+
+func (t *trackedSource) Seed(seed int64) {
+	t.seed = seed
+	t.draws = 0
+	t.src.Seed(seed)
+}
+
+// position returns where the source is in its sequence
+func (t *trackedSource) position() rngPosition {
+	return rngPosition{Seed: t.seed, Draws: t.draws}
+}
+
+// setPosition reseeds the source and skips ahead to pos (cost is linear in pos.Draws)
+func (t *trackedSource) setPosition(pos rngPosition) {
+	t.Seed(pos.Seed)
+	for t.draws < pos.Draws {
+		t.Uint64()
+	}
+}
+
+// rngSet is the sources a component draws from, in creation order, so their positions can be
+// saved and restored together
+type rngSet []*trackedSource
+
+// newRand returns an RNG seeded with seed whose position is tracked in the set
+func (r *rngSet) newRand(seed int64) *rand.Rand {
+	src := &trackedSource{seed: seed, src: rand.NewSource(seed).(rand.Source64)}
+	*r = append(*r, src)
+	return rand.New(src)
+}
+
+// positions returns the position of every source in the set
+func (r rngSet) positions() []rngPosition {
+	positions := make([]rngPosition, len(r))
+	for i, src := range r {
+		positions[i] = src.position()
+	}
+	return positions
+}
+
+// setPositions moves every source to its saved position (positions must match the set in length)
+func (r rngSet) setPositions(positions []rngPosition) {
+	for i, src := range r {
+		src.setPosition(positions[i])
+	}
+}
+
+// randomSourcer is implemented by components (compactors, traffic distributions) that draw from
+// their own RNGs
+type randomSourcer interface {
+	randomSources() rngSet
+}
+
+// rngState is the serialized form produced by RNGState
+type rngState struct {
+	Simulator []rngPosition `json:"simulator"` // Read path and other simulator-level sampling
+	Compactor []rngPosition `json:"compactor"` // File selection and overlap sampling
+	Traffic   []rngPosition `json:"traffic"`   // Write size and arrival sampling
+}
+
+// componentSources returns the random sources of a compactor or traffic distribution (nil if it
+// draws no random numbers)
+func componentSources(component interface{}) rngSet {
+	if sourcer, ok := component.(randomSourcer); ok {
+		return sourcer.randomSources()
+	}
+	return nil
+}
+
+// compactorSources returns the random sources of every compactor: the default column family's,
+// then each extra column family's
+func (s *Simulator) compactorSources() rngSet {
+	sources := componentSources(s.compactor)
+	for _, family := range s.columnFamilies {
+		sources = append(sources[:len(sources):len(sources)], componentSources(family.compactor)...)
+	}
+	return sources
+}
+
+// RNGState returns the position of every random number generator the simulator draws from: its
+// own, the compactors' and the traffic distribution's. Passing it to SetRNGState, on this
+// simulator or another built from the same config, puts those generators at the same point in
+// their sequences, so a run can be forked at a precise random position.
+//
+// Only the generators are captured; LSM tree, event queue and model state are not (see Snapshot).
+// Works for time-seeded runs too: the seed actually used is recorded.
+func (s *Simulator) RNGState() []byte {
+	data, err := json.Marshal(rngState{
+		Simulator: s.rngSources.positions(),
+		Compactor: s.compactorSources().positions(),
+		Traffic:   componentSources(s.trafficDistribution).positions(),
+	})
+	if err != nil {
+		// Only integers are marshaled
+		panic(fmt.Sprintf("marshal RNG state: %v", err))
+	}
+	return data
+}
+
+// SetRNGState restores generator positions saved by RNGState. Each generator is reseeded and
+// advanced to its saved position, so the cost grows with the number of values drawn so far.
+//
+// Returns an error, leaving the generators untouched, if the data isn't an RNG state or was taken
+// from a simulator with a different set of generators (compaction style, traffic model, OverlapSeed).
+func (s *Simulator) SetRNGState(data []byte) error {
+	var state rngState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("set RNG state: %w", err)
+	}
+	compactor := s.compactorSources()
+	traffic := componentSources(s.trafficDistribution)
+	if len(state.Simulator) != len(s.rngSources) || len(state.Compactor) != len(compactor) || len(state.Traffic) != len(traffic) {
+		return fmt.Errorf("set RNG state: state has %d/%d/%d simulator/compactor/traffic sources, simulator has %d/%d/%d",
+			len(state.Simulator), len(state.Compactor), len(state.Traffic), len(s.rngSources), len(compactor), len(traffic))
+	}
+	s.rngSources.setPositions(state.Simulator)
+	compactor.setPositions(state.Compactor)
+	traffic.setPositions(state.Traffic)
+	s.record(journalEntry{Op: "set_rng_state", RNGState: data})
+	return nil
+}
@@ -0,0 +1,219 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Scenario is everything needed to reproduce a run in one shareable file: the starting config,
+// the LSM contents to start from, changes to apply as virtual time passes, and how long to run.
+// Together with a non-zero randomSeed it replays the same run every time, which makes it the
+// unit for bug reports, benchmarks and teaching examples.
+type Scenario struct {
+	Name            string           `json:"name,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	Config          SimConfig        `json:"config"`
+	DurationSeconds float64          `json:"durationSeconds"`        // Virtual seconds the scenario runs for
+	InitialFiles    []ScenarioFiles  `json:"initialFiles,omitempty"` // SST files placed before the run starts (after initialLSMSizeMB's, if set)
+	Timeline        []ScenarioChange `json:"timeline,omitempty"`     // Changes in time order
+}
+
+// ScenarioFiles is a run of equally sized SST files placed in one level
+type ScenarioFiles struct {
+	Level  int     `json:"level"`
+	Count  int     `json:"count"`
+	SizeMB float64 `json:"sizeMB"` // Size of each file
+}
+
+// ScenarioChange is one scheduled change to a running scenario. Only the fields Op needs are set.
+type ScenarioChange struct {
+	AtSeconds float64 `json:"atSeconds"` // Virtual time the change takes effect
+	Op        string  `json:"op"`        // "config", "pause_level", "resume_level", "ingest", "reset_metrics", "restart"
+
+	// For "config": the SimConfig fields to change, as JSON (e.g. {"writeRateMBps": 200}). Only
+	// dynamic params (those UpdateConfig applies without a reset) may change mid-run.
+	Config json.RawMessage `json:"config,omitempty"`
+
+	Level  int     `json:"level,omitempty"`  // For "pause_level", "resume_level" and "ingest"
+	SizeMB float64 `json:"sizeMB,omitempty"` // For "ingest"
+}
+
+// LoadScenario reads a JSON scenario and validates it. Config fields the scenario leaves out take
+// their DefaultConfig values, so a hand-written scenario only needs the settings it cares about.
+func LoadScenario(r io.Reader) (*Scenario, error) {
+	scenario := Scenario{Config: DefaultConfig()}
+	if err := json.NewDecoder(r).Decode(&scenario); err != nil {
+		return nil, fmt.Errorf("load scenario: %w", err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("load scenario: %w", err)
+	}
+	return &scenario, nil
+}
+
+// SaveScenario writes the scenario as indented JSON, readable by LoadScenario
+func (sc *Scenario) SaveScenario(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sc); err != nil {
+		return fmt.Errorf("save scenario: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the config, the initial files and every timeline change, including that each
+// config change leaves a valid config and touches only dynamic params
+func (sc *Scenario) Validate() error {
+	if err := sc.Config.Validate(); err != nil {
+		return err
+	}
+	if sc.DurationSeconds <= 0 {
+		return fmt.Errorf("durationSeconds must be > 0")
+	}
+	numLevels := sc.Config.NumLevels
+	for i, files := range sc.InitialFiles {
+		if files.Level < 0 || files.Level >= numLevels {
+			return fmt.Errorf("initialFiles[%d]: level %d out of range [0, %d)", i, files.Level, numLevels)
+		}
+		if files.Count <= 0 || files.SizeMB <= 0 {
+			return fmt.Errorf("initialFiles[%d]: count and sizeMB must be > 0", i)
+		}
+	}
+
+	config := sc.Config
+	lastAt := 0.0
+	for i, change := range sc.Timeline {
+		if change.AtSeconds < lastAt {
+			return fmt.Errorf("timeline[%d]: atSeconds %.3f is before the previous change (%.3f)", i, change.AtSeconds, lastAt)
+		}
+		lastAt = change.AtSeconds
+		switch change.Op {
+		case "config":
+			next, err := applyConfigChange(config, change.Config)
+			if err != nil {
+				return fmt.Errorf("timeline[%d]: %w", i, err)
+			}
+			if err := next.Validate(); err != nil {
+				return fmt.Errorf("timeline[%d]: %w", i, err)
+			}
+			if staticConfigChanged(config, next) {
+				return fmt.Errorf("timeline[%d]: only dynamic params can change mid-run (a static change would reset the simulation)", i)
+			}
+			config = next
+		case "pause_level", "resume_level", "ingest":
+			if change.Level < 0 || change.Level >= numLevels {
+				return fmt.Errorf("timeline[%d]: level %d out of range [0, %d)", i, change.Level, numLevels)
+			}
+			if change.Op == "ingest" && change.SizeMB <= 0 {
+				return fmt.Errorf("timeline[%d]: ingest sizeMB must be > 0", i)
+			}
+		case "reset_metrics", "restart":
+		default:
+			return fmt.Errorf("timeline[%d]: unknown op %q", i, change.Op)
+		}
+	}
+	return nil
+}
+
+// applyConfigChange returns config with the fields in change (SimConfig JSON) overwritten. The
+// config is round-tripped through JSON first so the result shares no pointers with config.
+func applyConfigChange(config SimConfig, change json.RawMessage) (SimConfig, error) {
+	if len(change) == 0 {
+		return config, fmt.Errorf("config change is empty")
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return config, err
+	}
+	var next SimConfig
+	if err := json.Unmarshal(data, &next); err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(change, &next); err != nil {
+		return config, fmt.Errorf("config change: %w", err)
+	}
+	return next, nil
+}
+
+// NewScenarioSimulator creates a simulator for the scenario. Like NewSimulator, it needs a Reset
+// before stepping; Reset places the scenario's initial files and restarts its timeline (from the
+// scenario's config, whatever changes were made since). Timeline changes are applied at the start
+// of the first Step at or after their time, so they land within one Step of it; lower
+// simulationSpeedMultiplier for finer placement.
+//
+// Changes go through the same methods as external calls (UpdateConfig, IngestFile, ...), so they
+// are journaled and snapshots of a scenario run restore exactly. The restored simulator has no
+// timeline: changes still pending at the snapshot are not applied after RestoreSnapshot.
+func NewScenarioSimulator(scenario *Scenario) (*Simulator, error) {
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	s, err := NewSimulator(scenario.Config)
+	if err != nil {
+		return nil, err
+	}
+	s.scenario = scenario
+	return s, nil
+}
+
+// placeScenarioFiles creates the scenario's initial files, without disk I/O, as part of Reset
+func (s *Simulator) placeScenarioFiles() {
+	for _, files := range s.scenario.InitialFiles {
+		s.placeFiles(files.Level, files.Count, files.SizeMB)
+	}
+}
+
+// placeFiles adds count files of sizeMB to level as pre-existing data (created at t=0, no I/O)
+func (s *Simulator) placeFiles(level, count int, sizeMB float64) {
+	for i := 0; i < count; i++ {
+		s.lsm.CreateSSTFile(level, sizeMB, 0)
+	}
+	s.metrics.placedDataSizeMB += float64(count) * sizeMB
+	s.record(journalEntry{Op: "place_files", Level: level, Count: count, SizeMB: sizeMB})
+}
+
+// applyDueScenarioChanges applies the timeline changes whose time has come
+func (s *Simulator) applyDueScenarioChanges() {
+	if s.scenario == nil {
+		return
+	}
+	for s.scenarioNext < len(s.scenario.Timeline) && s.scenario.Timeline[s.scenarioNext].AtSeconds <= s.virtualTime {
+		change := s.scenario.Timeline[s.scenarioNext]
+		s.scenarioNext++
+		if err := s.applyScenarioChange(change); err != nil {
+			s.logEvent("[t=%.1fs] SCENARIO: %s at t=%.1fs failed: %v", s.virtualTime, change.Op, change.AtSeconds, err)
+			continue
+		}
+		s.logEvent("[t=%.1fs] SCENARIO: applied %s scheduled for t=%.1fs", s.virtualTime, change.Op, change.AtSeconds)
+	}
+}
+
+// applyScenarioChange applies one timeline change
+func (s *Simulator) applyScenarioChange(change ScenarioChange) error {
+	switch change.Op {
+	case "config":
+		next, err := applyConfigChange(s.config, change.Config)
+		if err != nil {
+			return err
+		}
+		if staticConfigChanged(s.config, next) {
+			return fmt.Errorf("static config change would reset the simulation")
+		}
+		return s.UpdateConfig(next)
+	case "pause_level":
+		return s.PauseLevelCompaction(change.Level)
+	case "resume_level":
+		return s.ResumeLevelCompaction(change.Level)
+	case "ingest":
+		return s.IngestFile(change.Level, change.SizeMB)
+	case "reset_metrics":
+		s.ResetMetrics()
+		return nil
+	case "restart":
+		s.Restart()
+		return nil
+	default:
+		return fmt.Errorf("unknown op %q", change.Op)
+	}
+}
@@ -0,0 +1,149 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testScenario returns a leveled scenario with initial files and a write-rate change, pause and
+// ingest along its timeline
+func testScenario() *Scenario {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.RandomSeed = 42
+	config.WriteRateMBps = 10
+	config.SimulationSpeedMultiplier = 1
+	return &Scenario{
+		Name:            "burst",
+		Config:          config,
+		DurationSeconds: 200,
+		InitialFiles: []ScenarioFiles{
+			{Level: 2, Count: 4, SizeMB: 64},
+			{Level: 6, Count: 10, SizeMB: 64},
+		},
+		Timeline: []ScenarioChange{
+			{AtSeconds: 50, Op: "config", Config: json.RawMessage(`{"writeRateMBps": 30}`)},
+			{AtSeconds: 100, Op: "pause_level", Level: 1},
+			{AtSeconds: 120, Op: "ingest", Level: 5, SizeMB: 128},
+			{AtSeconds: 150, Op: "resume_level", Level: 1},
+		},
+	}
+}
+
+// runScenario steps a fresh scenario simulator to the scenario's duration
+func runScenario(t *testing.T, scenario *Scenario) *Simulator {
+	sim, err := NewScenarioSimulator(scenario)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	for sim.VirtualTime() < scenario.DurationSeconds {
+		sim.Step()
+	}
+	return sim
+}
+
+// TestScenarioRoundTrip tests that a saved scenario loads back identically and replays the same run
+func TestScenarioRoundTrip(t *testing.T) {
+	scenario := testScenario()
+	var buf bytes.Buffer
+	require.NoError(t, scenario.SaveScenario(&buf))
+	loaded, err := LoadScenario(&buf)
+	require.NoError(t, err)
+	require.Equal(t, scenario.Config, loaded.Config)
+	require.Equal(t, scenario.InitialFiles, loaded.InitialFiles)
+	require.Len(t, loaded.Timeline, len(scenario.Timeline))
+
+	original := runScenario(t, scenario)
+	replayed := runScenario(t, loaded)
+	require.Equal(t, original.State(), replayed.State())
+	require.Equal(t, original.Metrics(), replayed.Metrics())
+}
+
+// TestScenarioTimeline tests that initial files are placed and timeline changes land on time
+func TestScenarioTimeline(t *testing.T) {
+	scenario := testScenario()
+	sim, err := NewScenarioSimulator(scenario)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	require.Equal(t, 4, sim.lsm.Levels[2].FileCount)
+	require.Equal(t, 10, sim.lsm.Levels[6].FileCount)
+	require.InDelta(t, 14*64.0, sim.lsm.TotalSizeMB, 0.001)
+
+	for sim.VirtualTime() < 50 {
+		sim.Step()
+	}
+	require.Equal(t, 10.0, sim.Config().WriteRateMBps, "changes wait for their time")
+	sim.Step()
+	require.Equal(t, 30.0, sim.Config().WriteRateMBps)
+	require.Equal(t, 30.0, sim.Config().TrafficDistribution.WriteRateMBps)
+
+	for sim.VirtualTime() < 130 {
+		sim.Step()
+	}
+	require.True(t, sim.lsm.Levels[1].CompactionPaused)
+	require.Equal(t, 1, sim.Metrics().IngestedFiles)
+	for sim.VirtualTime() < scenario.DurationSeconds {
+		sim.Step()
+	}
+	require.False(t, sim.lsm.Levels[1].CompactionPaused)
+
+	// Reset starts the scenario over from its own config
+	require.NoError(t, sim.Reset())
+	require.Equal(t, 10.0, sim.Config().WriteRateMBps)
+	require.Equal(t, 0, sim.scenarioNext)
+	require.Equal(t, 10, sim.lsm.Levels[6].FileCount)
+
+	// A static config change ends the scenario
+	updated := sim.Config()
+	updated.NumLevels = 5
+	require.NoError(t, sim.UpdateConfig(updated))
+	require.Nil(t, sim.scenario)
+	require.Equal(t, 0, sim.lsm.Levels[4].FileCount)
+}
+
+// TestScenarioSnapshot tests that a snapshot of a scenario run restores exactly
+func TestScenarioSnapshot(t *testing.T) {
+	scenario := testScenario()
+	original := runScenario(t, scenario)
+	data, err := original.Snapshot()
+	require.NoError(t, err)
+
+	restored, err := NewSimulator(DefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, restored.RestoreSnapshot(data))
+	for i := 0; i < 50; i++ {
+		original.Step()
+		restored.Step()
+	}
+	require.Equal(t, original.State(), restored.State())
+}
+
+// TestScenarioValidation tests that malformed scenarios are rejected on load
+func TestScenarioValidation(t *testing.T) {
+	for name, mutate := range map[string]func(*Scenario){
+		"zero duration":       func(sc *Scenario) { sc.DurationSeconds = 0 },
+		"file level":          func(sc *Scenario) { sc.InitialFiles[0].Level = 7 },
+		"file count":          func(sc *Scenario) { sc.InitialFiles[0].Count = 0 },
+		"out of order":        func(sc *Scenario) { sc.Timeline[1].AtSeconds = 10 },
+		"unknown op":          func(sc *Scenario) { sc.Timeline[1].Op = "compact" },
+		"invalid config":      func(sc *Scenario) { sc.Timeline[0].Config = json.RawMessage(`{"writeRateMBps": -1}`) },
+		"static change":       func(sc *Scenario) { sc.Timeline[0].Config = json.RawMessage(`{"numLevels": 4}`) },
+		"empty config":        func(sc *Scenario) { sc.Timeline[0].Config = nil },
+		"ingest without size": func(sc *Scenario) { sc.Timeline[2].SizeMB = 0 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			scenario := testScenario()
+			mutate(scenario)
+			var buf bytes.Buffer
+			require.NoError(t, scenario.SaveScenario(&buf))
+			_, err := LoadScenario(&buf)
+			require.Error(t, err)
+		})
+	}
+
+	_, err := LoadScenario(strings.NewReader("{"))
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+)
+
+// selfCheckTolerance is the relative slack allowed when comparing accumulated float sizes
+const selfCheckTolerance = 1e-6
+
+// SelfCheck runs the simulator's internal consistency checks and returns one message per
+// violation (empty when everything is consistent). It does not modify state, so it is safe
+// to call between steps when a result looks suspicious or as a CI smoke test.
+func (s *Simulator) SelfCheck() []string {
+	var violations []string
+	report := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+
+	// LSM trees: the default column family's and each extra column family's
+	checkLSM("", s.lsm, report)
+	for _, family := range s.columnFamilies {
+		checkLSM(family.name+" ", family.lsm, report)
+	}
+
+	// Immutable memtables: the count and the tracked sizes describe the same memtables
+	if s.numImmutableMemtables < 0 {
+		report("numImmutableMemtables=%d is negative", s.numImmutableMemtables)
+	}
+	if s.numImmutableMemtables != len(s.immutableMemtableSizes) {
+		report("numImmutableMemtables=%d but %d immutable memtable sizes tracked", s.numImmutableMemtables, len(s.immutableMemtableSizes))
+	}
+	for i, size := range s.immutableMemtableSizes {
+		if size < 0 || math.IsNaN(size) {
+			report("immutable memtable %d has size %v", i, size)
+		}
+	}
+
+	// Event queue: nothing may be scheduled in the past
+	for _, event := range s.queue.Events() {
+		ts := event.Timestamp()
+		if math.IsNaN(ts) || ts < s.virtualTime {
+			report("event %s scheduled at t=%.6f, before virtual time %.6f", event.String(), ts, s.virtualTime)
+		}
+	}
+
+	// Cumulative counters never go negative
+	counters := map[string]float64{
+		"totalDataWrittenMB":  s.metrics.TotalDataWrittenMB,
+		"walBytesWritten":     s.metrics.WALBytesWritten,
+		"compactionReadMB":    s.metrics.CompactionReadMB,
+		"compactionWrittenMB": s.metrics.CompactionWrittenMB,
+	}
+	for name, value := range counters {
+		if value < 0 || math.IsNaN(value) {
+			report("metric %s is %v", name, value)
+		}
+	}
+
+	return violations
+}
+
+// checkLSM reports inconsistencies in one LSM tree: per-level bookkeeping must match the file
+// lists, and no file may appear twice. prefix names the column family in each message.
+func checkLSM(prefix string, lsm *LSMTree, report func(format string, args ...interface{})) {
+	seen := make(map[*SSTFile]int)
+	var levelsTotal float64
+	for levelNum, level := range lsm.Levels {
+		if level.FileCount != len(level.Files) {
+			report("%sL%d: fileCount=%d but %d files listed", prefix, levelNum, level.FileCount, len(level.Files))
+		}
+		var filesTotal float64
+		for _, file := range level.Files {
+			if prev, ok := seen[file]; ok {
+				report("%sfile %s appears in both L%d and L%d", prefix, file.ID, prev, levelNum)
+			}
+			seen[file] = levelNum
+			if math.IsNaN(file.SizeMB) || file.SizeMB < 0 {
+				report("%sL%d: file %s has size %v", prefix, levelNum, file.ID, file.SizeMB)
+			}
+			filesTotal += file.SizeMB
+		}
+		if !sizesMatch(level.TotalSize, filesTotal) {
+			report("%sL%d: totalSize=%.6f MB but files sum to %.6f MB", prefix, levelNum, level.TotalSize, filesTotal)
+		}
+		levelsTotal += level.TotalSize
+	}
+	if !sizesMatch(lsm.TotalSizeMB, levelsTotal) {
+		report("%sLSM totalSizeMB=%.6f but levels sum to %.6f", prefix, lsm.TotalSizeMB, levelsTotal)
+	}
+	if lsm.MemtableCurrentSize < 0 || math.IsNaN(lsm.MemtableCurrentSize) {
+		report("%sactive memtable size is %v", prefix, lsm.MemtableCurrentSize)
+	}
+}
+
+// sizesMatch compares two accumulated sizes with a relative tolerance for float drift
+func sizesMatch(a, b float64) bool {
+	return math.Abs(a-b) <= selfCheckTolerance*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
@@ -8,11 +8,13 @@ import (
 
 // ActiveCompactionInfo tracks details of an in-progress compaction
 type ActiveCompactionInfo struct {
+	ColumnFamily    int  `json:"columnFamily"` // 0 = default column family, i = ColumnFamilies entry i-1
 	FromLevel       int  `json:"fromLevel"`
 	ToLevel         int  `json:"toLevel"`
 	SourceFileCount int  `json:"sourceFileCount"`
 	TargetFileCount int  `json:"targetFileCount"`
 	IsIntraL0       bool `json:"isIntraL0"`
+	Subcompactions  int  `json:"subcompactions"` // Parallel pieces the job runs as (1 = no subcompactions)
 }
 
 // Simulator is a PURE discrete event simulator with NO concurrency primitives.
@@ -26,24 +28,72 @@ type Simulator struct {
 	virtualTime             float64
 	diskBusyUntil           float64                 // Virtual time when disk I/O will be free (global disk resource)
 	backgroundJobSlots      []float64               // Per-slot busy times (len = max_background_jobs, tracks when each background thread slot is free)
+	flushJobSlots           []float64               // Per-slot busy times of the dedicated flush pool (len = max_background_flushes, empty = flushes use backgroundJobSlots)
 	numImmutableMemtables   int                     // Memtables waiting to flush (in addition to active)
 	immutableMemtableSizes  []float64               // Sizes (MB) of immutable memtables waiting to flush
+	walGroupMB              float64                 // WAL bytes gathered by the open group commit, written when it closes (walGroupCommitIntervalMs > 0)
+	walGroupCommitAt        float64                 // Virtual time the open group commit closes
 	compactor               Compactor               // Compaction strategy
 	activeCompactionInfos   []*ActiveCompactionInfo // Detailed info about active compactions
 	pendingCompactions      map[int]*CompactionJob  // Jobs waiting to execute (keyed by compaction ID, not fromLevel)
 	nextCompactionID        int                     // Unique ID for each compaction job
 	stallStartTime          float64                 // When the current stall started (0 if not stalled)
+	slowdownStartTime       float64                 // When writes entered the slowdown band (see inSlowdown)
+	inSlowdown              bool                    // Immutable memtables are between immutableMemtableSlowdownNumber and the stall limit
 	stalledWriteBacklog     int                     // Number of writes waiting during stall (for OOM detection)
 	nextFlushCompletionTime float64                 // When the next flush that will clear the stall completes (0 if none scheduled)
 	trafficDistribution     TrafficDistribution     // Traffic distribution generator
 	rng                     *rand.Rand              // Random number generator (for read path modeling and other features)
+	rngSources              rngSet                  // rng's source, for RNGState
+	seed                    int64                   // Seed every random stream derives from (config.RandomSeed, or drawn when that is 0)
+	zipfian                 *zipfianGenerator       // Key ranks for zipfian reads (built on first use; see sampleReadKeySkew)
+	pendingIngestMB         float64                 // Traffic buffered toward the next ingested file (ingestion mode only)
+	diskTimeByCategory      map[string]float64      // Cumulative disk-busy seconds per I/O category (for TimeBreakdown)
+	backupBusyUntil         float64                 // Virtual time when the backup link finishes shipping queued compaction output
+	compactionRateTokensMB  float64                 // Compaction rate limiter budget (negative = debt earlier jobs still owe)
+	compactionRateUpdatedAt float64                 // Virtual time compactionRateTokensMB was last refilled to
+	restartedAt             float64                 // Virtual time of the last Restart
+	cacheWarming            bool                    // Block cache is re-warming after a Restart (see cacheWarmth)
+	readLatencyRecovering   bool                    // Read latency hasn't yet returned to its pre-restart level
+	preRestartReadLatencyMs float64                 // AvgReadLatencyMs just before the last Restart
+	clock                   func() float64          // Overrides virtual time as the CreatedAt of flushed/ingested files (nil = virtual time; see SetClock)
+	metricsStartTime        float64                 // Virtual time measurements start from (last ResetMetrics, 0 = start of run)
+	steadyState             *SteadyState            // Set when RunUntilSteadyState finds the run has settled (nil until then)
+	compactionHistory       []CompactionRecord      // Most recent completed compactions (up to maxCompactionHistory), in completion order
+	originConfig            SimConfig               // Config the run started from (at creation or the last Reset), for snapshot replay
+	journal                 []journalEntry          // External mutations since originConfig, for snapshot replay (see Snapshot)
+	unjournaled             string                  // Why the journal was dropped ("" = complete; see stopJournal)
+	scenario                *Scenario               // Scenario being run (nil = none; see NewScenarioSimulator)
+	scenarioNext            int                     // Index of the next scenario timeline change to apply
+	steps                   int64                   // Step() calls since creation or the last Reset
+	stepSeconds             float64                 // Length of the last Step iteration (see nextStepSeconds)
+
+	// When each level waiting for compaction was first seen needing it (see sampleCompactionQueue)
+	compactionNeededSince map[compactionNeed]float64
+
+	// Levels queued by RequestManualCompaction, oldest first (-1 = whole tree)
+	manualCompactions []int
+
+	// Column families beyond the default one (empty unless config.ColumnFamilies names some)
+	columnFamilies             []*columnFamily
+	nextCompactionColumnFamily int // Column family scheduleCompaction tries first
 
 	// Event logging callback (optional, for UI/debugging)
 	LogEvent func(msg string)
 }
 
+// maxDrawnSeed bounds the seeds drawn for randomSeed=0 runs, keeping them short enough to read
+// off and type back in (and exact as JavaScript numbers)
+const maxDrawnSeed = 1_000_000
+
 // NewSimulator creates a new simulator
 func NewSimulator(config SimConfig) (*Simulator, error) {
+	return newSimulator(config, 0)
+}
+
+// newSimulator is NewSimulator with the seed a randomSeed=0 config runs with: drawn when
+// drawnSeed is 0, otherwise drawnSeed (to replay a run that drew it)
+func newSimulator(config SimConfig, drawnSeed int64) (*Simulator, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
@@ -55,30 +105,37 @@ func NewSimulator(config SimConfig) (*Simulator, error) {
 
 	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
 
+	// A zero randomSeed draws a seed for this run. Every random stream is seeded from it, so
+	// passing Seed() back as randomSeed reproduces the run.
+	seed := config.RandomSeed
+	if seed == 0 {
+		seed = drawnSeed
+	}
+	if seed == 0 {
+		seed = 1 + rand.Int63n(maxDrawnSeed-1)
+	}
+	seeded := config
+	seeded.RandomSeed = seed
+
 	// Create appropriate compactor based on compaction style
-	var compactor Compactor
-	switch config.CompactionStyle {
-	case CompactionStyleLeveled:
-		compactor = NewLeveledCompactorWithOverlapDist(config.RandomSeed, config.OverlapDistribution)
-	case CompactionStyleUniversal:
-		compactor = NewUniversalCompactorWithOverlapDist(config.RandomSeed, config.OverlapDistribution)
-	case CompactionStyleFIFO:
-		compactor = NewFIFOCompactor(config.RandomSeed)
-	default:
-		// Default to universal compaction
-		compactor = NewUniversalCompactorWithOverlapDist(config.RandomSeed, config.OverlapDistribution)
+	compactor := newCompactor(seeded)
+
+	// Extra column families get their own LSM tree and compactor
+	var columnFamilies []*columnFamily
+	for i, cf := range config.columnFamilyConfigs() {
+		family, err := newColumnFamily(cf, seeded, i)
+		if err != nil {
+			return nil, err
+		}
+		columnFamilies = append(columnFamilies, family)
 	}
 
 	// Create traffic distribution
-	trafficDist := NewTrafficDistribution(config.TrafficDistribution, config.RandomSeed)
+	trafficDist := NewTrafficDistribution(config.TrafficDistribution, seed)
 
 	// Create random number generator for read path modeling
-	var rng *rand.Rand
-	if config.RandomSeed == 0 {
-		rng = rand.New(rand.NewSource(rand.Int63()))
-	} else {
-		rng = rand.New(rand.NewSource(config.RandomSeed))
-	}
+	var rngSources rngSet
+	rng := rngSources.newRand(seed)
 
 	// Initialize background job slots (all free initially)
 	jobSlots := make([]float64, config.MaxBackgroundJobs)
@@ -94,6 +151,7 @@ func NewSimulator(config SimConfig) (*Simulator, error) {
 		virtualTime:             0,
 		diskBusyUntil:           0,
 		backgroundJobSlots:      jobSlots,
+		flushJobSlots:           make([]float64, config.MaxBackgroundFlushes),
 		numImmutableMemtables:   0,
 		immutableMemtableSizes:  make([]float64, 0),
 		compactor:               compactor,
@@ -105,6 +163,12 @@ func NewSimulator(config SimConfig) (*Simulator, error) {
 		nextFlushCompletionTime: 0,
 		trafficDistribution:     trafficDist,
 		rng:                     rng,
+		rngSources:              rngSources,
+		seed:                    seed,
+		diskTimeByCategory:      make(map[string]float64),
+		compactionNeededSince:   make(map[compactionNeed]float64),
+		originConfig:            config,
+		columnFamilies:          columnFamilies,
 	}
 
 	// Note: Simulator starts in "dormant" state with no events scheduled
@@ -112,6 +176,23 @@ func NewSimulator(config SimConfig) (*Simulator, error) {
 	return sim, nil
 }
 
+// newCompactor creates the compactor for config's compaction style
+func newCompactor(config SimConfig) Compactor {
+	switch config.CompactionStyle {
+	case CompactionStyleLeveled:
+		return NewLeveledCompactorWithOverlapDist(config.RandomSeed, config.OverlapSeed, config.OverlapDistribution)
+	case CompactionStyleUniversal:
+		return NewUniversalCompactorWithOverlapDist(config.RandomSeed, config.OverlapSeed, config.OverlapDistribution)
+	case CompactionStyleFIFO:
+		return NewFIFOCompactor(config.RandomSeed)
+	case CompactionStyleTiered:
+		return NewTieredCompactorWithOverlapDist(config.RandomSeed, config.OverlapSeed, config.OverlapDistribution)
+	default:
+		// Default to universal compaction
+		return NewUniversalCompactorWithOverlapDist(config.RandomSeed, config.OverlapSeed, config.OverlapDistribution)
+	}
+}
+
 // ensureEventsScheduled ensures the simulation has the necessary recurring events
 // Called internally after reset or when starting/resuming
 func (s *Simulator) ensureEventsScheduled() {
@@ -122,7 +203,7 @@ func (s *Simulator) ensureEventsScheduled() {
 		// Find all flush events in the queue and save their sizes
 		// We'll re-schedule them after clearing the queue
 		for _, event := range s.queue.Events() {
-			if flushEvent, ok := event.(*FlushEvent); ok {
+			if flushEvent, ok := event.(*FlushEvent); ok && flushEvent.ColumnFamily() == 0 {
 				pendingFlushSizes = append(pendingFlushSizes, flushEvent.SizeMB())
 			}
 		}
@@ -137,11 +218,11 @@ func (s *Simulator) ensureEventsScheduled() {
 	s.queue.Clear()
 
 	// Recreate traffic distribution (in case config changed)
-	s.trafficDistribution = NewTrafficDistribution(s.config.TrafficDistribution, s.config.RandomSeed)
+	s.trafficDistribution = NewTrafficDistribution(s.config.TrafficDistribution, s.seed)
 
-	// Initialize time tracking for advanced traffic distribution
-	if advDist, ok := s.trafficDistribution.(*AdvancedTrafficDistribution); ok {
-		advDist.UpdateTime(s.virtualTime)
+	// Initialize time tracking for time-varying traffic distributions (advanced, burst)
+	if timed, ok := s.trafficDistribution.(timedTrafficDistribution); ok {
+		timed.UpdateTime(s.virtualTime)
 	}
 
 	// Re-schedule flush events for existing immutable memtables
@@ -162,21 +243,29 @@ func (s *Simulator) ensureEventsScheduled() {
 			}
 
 			// Phase 2: Disk write (I/O)
-			outputSizeMB := sizeMB * s.config.CompressionFactor
-			ioDuration := (outputSizeMB / s.config.IOThroughputMBps) + (s.config.IOLatencyMs / 1000.0)
+			outputSizeMB := sizeMB * s.config.CompressionFactorForLevel(0) * s.config.MetadataOverheadFactor()
+			ops := diskOps(1, outputSizeMB)
+			ioDuration := s.diskIOTime(outputSizeMB, ops) + (s.config.IOLatencyMs / 1000.0)
 
-			// Allocate a background job slot
-			arrivalTime := s.virtualTime
-			_, cpuStartTime, _, completionTime := s.allocateJobSlot(arrivalTime, cpuDuration, ioDuration)
+			// Submit to the background pool
+			cpuStartTime, completionTime := s.submitBackgroundTask(BackgroundTaskFlush, s.virtualTime, cpuDuration, ioDuration)
+			s.metrics.RecordDiskOps(completionTime-ioDuration, completionTime, ops)
+			s.metrics.RecordDiskBytes(completionTime-ioDuration, completionTime, 0, outputSizeMB)
+			s.recordTierDiskTime(completionTime-ioDuration, completionTime, ioDuration, 0)
 
 			// Track this write as in-progress for throughput calculation
-			s.metrics.StartWrite(sizeMB, sizeMB, cpuStartTime, completionTime, -1, 0)
+			s.metrics.StartWrite(sizeMB, sizeMB, cpuStartTime, completionTime, -1, 0, 0)
 
 			// Schedule flush event
 			s.queue.Push(NewFlushEvent(completionTime, cpuStartTime, sizeMB))
 		}
 	}
 
+	// Re-schedule the open WAL group commit so its gathered writes still reach the WAL
+	if s.walGroupMB > 0 {
+		s.queue.Push(NewWALGroupCommitEvent(max(s.virtualTime, s.walGroupCommitAt)))
+	}
+
 	// Schedule write scheduler event (if rate > 0)
 	// This continuously schedules writes at the configured rate
 	writeRate := s.config.TrafficDistribution.WriteRateMBps
@@ -184,8 +273,13 @@ func (s *Simulator) ensureEventsScheduled() {
 		s.scheduleNextScheduleWrite(s.virtualTime)
 	} else if s.config.TrafficDistribution.Model == TrafficModelAdvancedONOFF && s.config.TrafficDistribution.BaseRateMBps > 0 {
 		s.scheduleNextScheduleWrite(s.virtualTime)
+	} else if s.config.TrafficDistribution.Model == TrafficModelBurst && s.config.TrafficDistribution.BurstRateMBps > 0 {
+		s.scheduleNextScheduleWrite(s.virtualTime)
 	}
 
+	// Column families' pending flushes and write streams
+	s.scheduleColumnFamilyEvents()
+
 	// Always schedule compaction checks
 	s.scheduleNextCompactionCheck(s.virtualTime)
 
@@ -197,6 +291,9 @@ func (s *Simulator) ensureEventsScheduled() {
 	writeRateStr := fmt.Sprintf("%.1f MB/s", writeRate)
 	if s.config.TrafficDistribution.Model == TrafficModelAdvancedONOFF {
 		writeRateStr = fmt.Sprintf("advanced (base=%.1f MB/s)", s.config.TrafficDistribution.BaseRateMBps)
+	} else if s.config.TrafficDistribution.Model == TrafficModelBurst {
+		writeRateStr = fmt.Sprintf("burst (%.1f MB/s for %.0fs, then %.0fs quiet)", s.config.TrafficDistribution.BurstRateMBps,
+			s.config.TrafficDistribution.BurstDurationSec, s.config.TrafficDistribution.QuietDurationSec)
 	}
 	fmt.Printf("[INIT] Scheduled initial events at t=%.1f (write_rate=%s)\n",
 		s.virtualTime, writeRateStr)
@@ -204,8 +301,30 @@ func (s *Simulator) ensureEventsScheduled() {
 
 // Step advances the simulation by one UI update interval.
 // The actual amount of virtual time advanced is determined by SimulationSpeedMultiplier.
-// This is the ONLY method that advances the simulation.
+// This (or StepToward) is the ONLY method that advances the simulation.
 func (s *Simulator) Step() {
+	s.step(math.Inf(1))
+}
+
+// StepToward is Step, except that virtual time stops at limit instead of running past it, for
+// callers that must pause at an exact time (the step ends early once limit is reached)
+func (s *Simulator) StepToward(limit float64) {
+	speedMultiplier := max(1, s.config.SimulationSpeedMultiplier)
+	longestIteration := max(s.config.BaseStep(), s.config.AdaptiveStepMaxSeconds)
+	if limit >= s.virtualTime+float64(speedMultiplier)*longestIteration {
+		s.Step() // Can't reach limit this step: an ordinary step, nothing to journal
+		return
+	}
+	s.record(journalEntry{Op: "step_toward", Timestamp: limit})
+	s.step(limit)
+}
+
+// step advances the simulation by one Step, stopping early at virtual time limit
+func (s *Simulator) step(limit float64) {
+	// Before counting the step, so a snapshot replays the journaled changes at the same point
+	s.applyDueScenarioChanges()
+	s.steps++
+
 	// If OOM already occurred, don't process any more events
 	if s.metrics.IsOOMKilled {
 		return
@@ -222,18 +341,16 @@ func (s *Simulator) Step() {
 		panic("BUG: Event queue is empty! Self-perpetuating events (ScheduleWriteEvent, CompactionCheckEvent) should keep it populated.")
 	}
 
-	// Base step size: 1.0 second of virtual time per iteration
-	// The UI doesn't need to know about virtual time - we control it here
-	baseStepSeconds := 1.0
-
 	// Apply simulation speed multiplier - process multiple steps per call
 	speedMultiplier := s.config.SimulationSpeedMultiplier
 	if speedMultiplier < 1 {
 		speedMultiplier = 1
 	}
 
-	for i := 0; i < speedMultiplier; i++ {
-		targetTime := s.virtualTime + baseStepSeconds
+	for i := 0; i < speedMultiplier && s.virtualTime < limit; i++ {
+		// Step size: virtual time per iteration (BaseStepSeconds, or adaptive)
+		// The UI doesn't need to know about virtual time - we control it here
+		targetTime := min(limit, s.virtualTime+s.nextStepSeconds())
 
 		// Process all events up to target time
 		for !s.queue.IsEmpty() && s.queue.Peek().Timestamp() <= targetTime {
@@ -285,9 +402,24 @@ func (s *Simulator) Step() {
 			}
 		}
 
+		// Warn while the backlog is still on its way to the OOM limit
+		if raised, secondsToOOM := s.metrics.UpdateOOMRisk(s.virtualTime, float64(stalledCount)*1.0,
+			float64(s.config.MaxStalledWriteMemoryMB), s.config.OOMWarningSeconds); raised {
+			s.logEvent("[t=%.1fs] OOM RISK: stalled write backlog (%d MB) projected to exceed the %d MB limit in %.1fs",
+				s.virtualTime, stalledCount, s.config.MaxStalledWriteMemoryMB, secondsToOOM)
+		}
+
 		activeJobs := s.countActiveBackgroundJobs()
+		s.metrics.ActiveCompactionBytesMB = s.activeCompactionInputMB()
+		s.metrics.CacheWarmth = s.cacheWarmth()
+		s.metrics.unflushedDataSizeMB = s.lsm.MemtableCurrentSize
+		for _, sizeMB := range s.immutableMemtableSizes {
+			s.metrics.unflushedDataSizeMB += sizeMB
+		}
 		s.metrics.Update(s.virtualTime, s.lsm, numMemtables, s.diskBusyUntil, s.config.IOThroughputMBps,
-			isStalled, stalledCount, activeJobs, s.config.MaxBackgroundJobs, s.config, s.rng)
+			isStalled, stalledCount, activeJobs, s.backgroundSlotCount(), s.config, s.rng)
+		s.trackRestartRecovery()
+		s.metrics.BackupLagSeconds = max(0, s.backupBusyUntil-s.virtualTime)
 
 		// Invariant check: Queue should never be empty after initialization (unless OOM killed)
 		// ScheduleWriteEvent and CompactionCheckEvent are self-perpetuating
@@ -308,28 +440,77 @@ func (s *Simulator) Step() {
 	}
 }
 
+// nextStepSeconds returns the length of the next Step iteration. With AdaptiveStepMaxSeconds set,
+// the length doubles each quiescent iteration up to that maximum and drops back to
+// BaseStepSeconds while something worth watching closely is happening or about to (see
+// needsFineSteps). Events are processed in timestamp order either way; coarse iterations only
+// sample metrics, check for OOM and return control to the caller less often.
+func (s *Simulator) nextStepSeconds() float64 {
+	base := s.config.BaseStep()
+	if s.config.AdaptiveStepMaxSeconds <= base {
+		s.stepSeconds = base
+	} else if s.needsFineSteps() {
+		if s.stepSeconds > base {
+			s.metrics.AdaptiveStepRefinements++
+		}
+		s.stepSeconds = base
+	} else {
+		s.stepSeconds = min(s.config.AdaptiveStepMaxSeconds, max(base, 2*s.stepSeconds))
+	}
+	s.metrics.StepSeconds = s.stepSeconds
+	return s.stepSeconds
+}
+
+// needsFineSteps reports whether conditions call for BaseStepSeconds iterations: writes are
+// stalled or slowed, one more immutable memtable would stall them, the stalled backlog is headed
+// for OOM, or compactions occupy every background job (a burst that can leave L0 piling up)
+func (s *Simulator) needsFineSteps() bool {
+	return s.stallStartTime > 0 ||
+		s.inSlowdown ||
+		s.numImmutableMemtables >= s.config.MaxWriteBufferNumber-1 ||
+		s.metrics.OOMRisk ||
+		len(s.pendingCompactions) >= s.config.MaxBackgroundJobs
+}
+
 // Reset resets the simulation to initial state and schedules events
 func (s *Simulator) Reset() error {
-	// Create a fresh simulator using the same config
+	return s.reset(0)
+}
+
+// reset is Reset with the seed a randomSeed=0 config runs with (see newSimulator)
+func (s *Simulator) reset(drawnSeed int64) error {
+	// Create a fresh simulator using the same config (a scenario restarts from its own)
 	// This ensures all internal state (including compactor's activeCompactions) is fresh
-	newSim, err := NewSimulator(s.config)
+	config := s.config
+	if s.scenario != nil {
+		config = s.scenario.Config
+	}
+	newSim, err := newSimulator(config, drawnSeed)
 	if err != nil {
 		return fmt.Errorf("reset failed: %w", err)
 	}
 
-	// Preserve the LogEvent callback if it was set
+	// Preserve the LogEvent callback, clock override and scenario if they were set
 	logEvent := s.LogEvent
+	clock := s.clock
+	scenario := s.scenario
 
 	// Copy all fields from the new simulator
 	*s = *newSim
 
-	// Restore the LogEvent callback
+	// Restore the LogEvent callback, clock override and scenario
 	s.LogEvent = logEvent
+	s.clock = clock
+	s.scenario = scenario
+	s.record(journalEntry{Op: "reset", Seed: s.seed})
 
 	// Pre-populate LSM with initial data if configured
 	if s.config.InitialLSMSizeMB > 0 {
 		s.populateInitialLSM()
 	}
+	if s.scenario != nil {
+		s.placeScenarioFiles()
+	}
 
 	// Schedule events so simulator is ready to run
 	s.ensureEventsScheduled()
@@ -337,6 +518,35 @@ func (s *Simulator) Reset() error {
 	return nil
 }
 
+// ResetMetrics starts measuring afresh from the current state: cumulative counters, windows and
+// the time breakdown are cleared, while the LSM tree, virtual time and event queue are untouched.
+// Writes and background work still in flight are carried over so their completions are accounted
+// normally, as are the stall/OOM status and the logical data size (which describe the DB, not the
+// measurement period).
+func (s *Simulator) ResetMetrics() {
+	old := s.metrics
+	s.metrics = NewMetrics()
+	s.metrics.Timestamp = s.virtualTime
+	s.metrics.inProgressWrites = old.inProgressWrites
+	s.metrics.slotOccupancy = old.slotOccupancy
+	s.metrics.compactionCPU = old.compactionCPU
+	s.metrics.diskOps = old.diskOps
+	s.metrics.diskReadMB = old.diskReadMB
+	s.metrics.diskWriteMB = old.diskWriteMB
+	s.metrics.hotTierSeconds = old.hotTierSeconds
+	s.metrics.coldTierSeconds = old.coldTierSeconds
+	s.metrics.compactionRateLimited = old.compactionRateLimited
+	s.metrics.logicalDataSizeMB = old.logicalDataSizeMB
+	s.metrics.placedDataSizeMB = old.placedDataSizeMB
+	s.metrics.compactionDroppedMB = old.compactionDroppedMB
+	s.metrics.IsStalled = old.IsStalled
+	s.metrics.StalledWriteCount = old.StalledWriteCount
+	s.metrics.IsOOMKilled = old.IsOOMKilled
+	s.diskTimeByCategory = make(map[string]float64)
+	s.metricsStartTime = s.virtualTime
+	s.record(journalEntry{Op: "reset_metrics"})
+}
+
 // populateInitialLSM pre-populates the LSM tree with data to skip warmup phase
 func (s *Simulator) populateInitialLSM() {
 	fmt.Printf("[INIT] Populating LSM with %d MB initial data\n", s.config.InitialLSMSizeMB)
@@ -414,6 +624,125 @@ func (s *Simulator) populateLevel(level int, sizeMB float64) {
 		s.lsm.CreateSSTFile(level, currentFileSize, 0) // Created at t=0
 		remainingSize -= currentFileSize
 	}
+	s.metrics.placedDataSizeMB += sizeMB
+}
+
+// SetClock overrides the CreatedAt timestamp given to newly flushed and ingested files, so tests
+// can control file ages precisely (e.g., cluster files at one time to exercise FIFO TTL).
+// Pass nil to go back to stamping files with the virtual time. The override survives Reset().
+func (s *Simulator) SetClock(clock func() float64) {
+	s.clock = clock
+}
+
+// defaultCacheWarmupSeconds is the cache re-warming time constant when CacheWarmupSeconds is unset
+const defaultCacheWarmupSeconds = 300.0
+
+// readLatencyRecoveryTolerance is how close to its pre-restart level average read latency must
+// return for the post-restart recovery to count as complete
+const readLatencyRecoveryTolerance = 0.1
+
+// Restart models a process restart as the read path sees it: the block cache keeps only
+// CachePersistedFraction of its contents (a persistent or secondary cache) and re-warms as reads
+// repopulate it, so until then some cache hits become disk reads and read latency spikes. The LSM
+// tree, memtables (recovered from the WAL) and background work carry on unchanged.
+// Metrics report the cache warmth and how long read latency took to recover.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - No downtime or WAL replay time, and the cache re-warms on a fixed
+// exponential curve rather than with the blocks reads actually touch
+func (s *Simulator) Restart() {
+	s.restartedAt = s.virtualTime
+	s.cacheWarming = s.config.CachePersistedFraction < 1
+	s.readLatencyRecovering = s.cacheWarming
+	s.preRestartReadLatencyMs = s.metrics.AvgReadLatencyMs
+	s.metrics.Restarts++
+	s.metrics.PostRestartPeakReadLatencyMs = 0
+	s.metrics.ReadLatencyRecoverySeconds = 0
+	s.metrics.CacheWarmth = s.cacheWarmth()
+	s.record(journalEntry{Op: "restart"})
+	s.logEvent("[t=%.1fs] RESTART: block cache %.0f%% warm (cachePersistedFraction)",
+		s.virtualTime, s.config.CachePersistedFraction*100)
+}
+
+// cacheWarmth returns the fraction of the block cache that is warm: 1 until a Restart, then
+// recovering from CachePersistedFraction toward 1 with time constant CacheWarmupSeconds
+func (s *Simulator) cacheWarmth() float64 {
+	if !s.cacheWarming {
+		return 1
+	}
+	warmup := s.config.CacheWarmupSeconds
+	if warmup <= 0 {
+		warmup = defaultCacheWarmupSeconds
+	}
+	elapsed := max(0, s.virtualTime-s.restartedAt)
+	warmth := 1 - (1-s.config.CachePersistedFraction)*math.Exp(-elapsed/warmup)
+	if warmth >= 0.999 {
+		s.cacheWarming = false
+		return 1
+	}
+	return warmth
+}
+
+// trackRestartRecovery follows read latency after a Restart: the peak it reaches, and how long
+// it takes to return within readLatencyRecoveryTolerance of its pre-restart level
+func (s *Simulator) trackRestartRecovery() {
+	if !s.readLatencyRecovering || s.virtualTime <= s.restartedAt {
+		return
+	}
+	latency := s.metrics.AvgReadLatencyMs
+	s.metrics.PostRestartPeakReadLatencyMs = max(s.metrics.PostRestartPeakReadLatencyMs, latency)
+	if latency <= s.preRestartReadLatencyMs*(1+readLatencyRecoveryTolerance) {
+		s.metrics.ReadLatencyRecoverySeconds = s.virtualTime - s.restartedAt
+		s.readLatencyRecovering = false
+		s.logEvent("[t=%.1fs] RESTART RECOVERED: read latency back to %.3f ms after %.1fs (peak %.3f ms)",
+			s.virtualTime, latency, s.metrics.ReadLatencyRecoverySeconds, s.metrics.PostRestartPeakReadLatencyMs)
+	}
+}
+
+// fileClock returns the current time on the clock files are stamped with: the SetClock override,
+// or virtual time
+func (s *Simulator) fileClock() float64 {
+	if s.clock != nil {
+		return s.clock()
+	}
+	return s.virtualTime
+}
+
+// fileCreatedAt returns the CreatedAt timestamp for a file created now, applying the clock
+// override and CreatedAtJitterSeconds backdating
+func (s *Simulator) fileCreatedAt() float64 {
+	createdAt := s.fileClock()
+	if s.config.CreatedAtJitterSeconds > 0 {
+		// Only draw when jitter is on, so the RNG sequence is unchanged otherwise
+		createdAt = max(0, createdAt-s.rng.Float64()*s.config.CreatedAtJitterSeconds)
+	}
+	return createdAt
+}
+
+// SetSpeedMultiplier changes SimulationSpeedMultiplier on the live simulation.
+// Speed only controls how much virtual time each Step() covers, so unlike UpdateConfig this
+// skips reset detection and leaves the compactor and event queue untouched.
+func (s *Simulator) SetSpeedMultiplier(multiplier int) error {
+	if multiplier < 1 {
+		return ErrInvalidConfig("simulationSpeedMultiplier must be >= 1")
+	}
+	s.config.SimulationSpeedMultiplier = multiplier
+	s.record(journalEntry{Op: "set_speed", Multiplier: multiplier})
+	return nil
+}
+
+// staticConfigChanged reports whether going from oldConfig to newConfig changes any static
+// parameter, which UpdateConfig can only apply by resetting the simulation. The dynamic params
+// are writeRateMBps, simulationSpeedMultiplier, baseStepSeconds, adaptiveStepMaxSeconds,
+// trafficDistribution and readWorkload. Everything else is static, including columnFamilies:
+// the reset rebuilds the column families from the new config.
+func staticConfigChanged(oldConfig, newConfig SimConfig) bool {
+	oldConfig.WriteRateMBps = newConfig.WriteRateMBps                         // Ignore dynamic params
+	oldConfig.SimulationSpeedMultiplier = newConfig.SimulationSpeedMultiplier // Ignore dynamic params
+	oldConfig.BaseStepSeconds = newConfig.BaseStepSeconds                     // Ignore dynamic params
+	oldConfig.AdaptiveStepMaxSeconds = newConfig.AdaptiveStepMaxSeconds       // Ignore dynamic params
+	oldConfig.TrafficDistribution = newConfig.TrafficDistribution             // Ignore dynamic params
+	oldConfig.ReadWorkload = newConfig.ReadWorkload                           // Ignore dynamic params (read metrics only)
+	return oldConfig != newConfig
 }
 
 // UpdateConfig updates the simulation configuration
@@ -421,21 +750,19 @@ func (s *Simulator) UpdateConfig(newConfig SimConfig) error {
 	if err := newConfig.Validate(); err != nil {
 		return err
 	}
+	requested := newConfig
 
 	// Save original values before checking for changes
 	originalWriteRate := s.config.WriteRateMBps
 	originalTrafficModel := s.config.TrafficDistribution.Model
 	originalSpeedMultiplier := s.config.SimulationSpeedMultiplier
 
-	// Check if any static parameters changed (dynamic params: writeRateMBps, simulationSpeedMultiplier, trafficDistribution, readWorkload)
-	oldConfig := s.config
-	oldConfig.WriteRateMBps = newConfig.WriteRateMBps                         // Ignore dynamic params
-	oldConfig.SimulationSpeedMultiplier = newConfig.SimulationSpeedMultiplier // Ignore dynamic params
-	oldConfig.TrafficDistribution = newConfig.TrafficDistribution             // Ignore dynamic params
-	oldConfig.ReadWorkload = newConfig.ReadWorkload                           // Ignore dynamic params (read metrics only)
-	newConfigCopy := newConfig
-
-	needsReset := oldConfig != newConfigCopy
+	// Pinning a randomSeed=0 run to the seed it drew (as reported by Seed) doesn't change the run
+	current := s.config
+	if current.RandomSeed == 0 && newConfig.RandomSeed == s.seed {
+		current.RandomSeed = s.seed
+	}
+	needsReset := staticConfigChanged(current, newConfig)
 
 	// Sync top-level WriteRateMBps to TrafficDistribution.WriteRateMBps for constant model
 	// MUST do this BEFORE checking trafficDistChanged to ensure sync is detected
@@ -467,7 +794,7 @@ func (s *Simulator) UpdateConfig(newConfig SimConfig) error {
 			fmt.Printf("[CONFIG] Traffic distribution parameters changed (t=%.1f)\n", s.virtualTime)
 		}
 		// Recreate traffic distribution
-		s.trafficDistribution = NewTrafficDistribution(newConfig.TrafficDistribution, newConfig.RandomSeed)
+		s.trafficDistribution = NewTrafficDistribution(newConfig.TrafficDistribution, s.seed)
 	}
 	if originalSpeedMultiplier != newConfig.SimulationSpeedMultiplier {
 		fmt.Printf("[CONFIG] Speed multiplier changed: %d → %d (t=%.1f)\n",
@@ -475,7 +802,7 @@ func (s *Simulator) UpdateConfig(newConfig SimConfig) error {
 	}
 
 	// If compaction style or overlap distribution changed, create new compactor
-	overlapDistChanged := s.config.OverlapDistribution != newConfig.OverlapDistribution
+	overlapDistChanged := s.config.OverlapDistribution != newConfig.OverlapDistribution || s.config.OverlapSeed != newConfig.OverlapSeed
 	if s.config.CompactionStyle != newConfig.CompactionStyle || overlapDistChanged {
 		if s.config.CompactionStyle != newConfig.CompactionStyle {
 			fmt.Printf("[CONFIG] Compaction style changed: %s → %s (t=%.1f)\n",
@@ -484,22 +811,16 @@ func (s *Simulator) UpdateConfig(newConfig SimConfig) error {
 		if overlapDistChanged {
 			fmt.Printf("[CONFIG] Overlap distribution changed (t=%.1f)\n", s.virtualTime)
 		}
-		var compactor Compactor
-		switch newConfig.CompactionStyle {
-		case CompactionStyleLeveled:
-			compactor = NewLeveledCompactorWithOverlapDist(newConfig.RandomSeed, newConfig.OverlapDistribution)
-		case CompactionStyleUniversal:
-			compactor = NewUniversalCompactorWithOverlapDist(newConfig.RandomSeed, newConfig.OverlapDistribution)
-		default:
-			compactor = NewUniversalCompactorWithOverlapDist(newConfig.RandomSeed, newConfig.OverlapDistribution)
-		}
-		s.compactor = compactor
+		seeded := newConfig
+		seeded.RandomSeed = s.seed
+		s.compactor = newCompactor(seeded)
 	}
 
 	s.config = newConfig
 
 	if needsReset {
 		fmt.Printf("[CONFIG] Static config changed - resetting simulation (t=%.1f)\n", s.virtualTime)
+		s.scenario = nil // The reset starts a plain run from the new config, not the scenario over
 		if err := s.Reset(); err != nil {
 			return fmt.Errorf("failed to reset simulation: %w", err)
 		}
@@ -509,12 +830,18 @@ func (s *Simulator) UpdateConfig(newConfig SimConfig) error {
 		writeRate := newConfig.TrafficDistribution.WriteRateMBps
 		if newConfig.TrafficDistribution.Model == TrafficModelAdvancedONOFF {
 			writeRate = newConfig.TrafficDistribution.BaseRateMBps
+		} else if newConfig.TrafficDistribution.Model == TrafficModelBurst {
+			writeRate = newConfig.TrafficDistribution.BurstRateMBps
 		}
 		if writeRate > 0 {
 			fmt.Printf("[CONFIG] Re-scheduling events (rate was 0, now %.1f MB/s)\n", writeRate)
 		}
 		s.ensureEventsScheduled()
 	}
+	if !needsReset {
+		// A reset restarts the journal from the new config instead
+		s.record(journalEntry{Op: "update_config", Config: &requested})
+	}
 
 	return nil
 }
@@ -522,12 +849,64 @@ func (s *Simulator) UpdateConfig(newConfig SimConfig) error {
 // getEffectiveWriteRateMBps returns the effective write rate for metrics/debugging
 // For constant model: returns WriteRateMBps from TrafficDistribution
 // For advanced model: returns BaseRateMBps (average rate)
+// For burst model: returns BurstRateMBps during a burst and 0 while quiet
+// All are scaled down while writes are slowed by memtable backpressure.
 func (s *Simulator) getEffectiveWriteRateMBps() float64 {
 	if s.config.TrafficDistribution.Model == TrafficModelConstant {
-		return s.config.TrafficDistribution.WriteRateMBps
+		return s.config.TrafficDistribution.WriteRateMBps * s.writeSlowdownMultiplier()
+	}
+	if burst, ok := s.trafficDistribution.(*BurstTrafficDistribution); ok {
+		return burst.GetCurrentRateMBps() * s.writeSlowdownMultiplier()
 	}
 	// For advanced model, use base rate as effective rate
-	return s.config.TrafficDistribution.BaseRateMBps
+	return s.config.TrafficDistribution.BaseRateMBps * s.writeSlowdownMultiplier()
+}
+
+// writeSlowdownMultiplier returns the fraction of the offered write rate admitted under
+// graduated memtable backpressure. From immutableMemtableSlowdownNumber up to the stall limit,
+// each additional immutable memtable takes another equal step off the rate, so with
+// slowdown=2 and max=4 writes run at 2/3 and then 1/3 before stalling at 4.
+//
+// FIDELITY: RocksDB Reference - WriteController delayed writes
+// https://github.com/facebook/rocksdb/blob/main/db/column_family.cc (GetWriteStallConditionAndCause)
+// RocksDB delays writes when max_write_buffer_number > 3 and the immutable count reaches
+// max_write_buffer_number - 1, throttling to delayed_write_rate and lowering that rate further
+// while the condition persists.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Configurable threshold with linear steps instead of RocksDB's fixed
+// threshold and adaptive delayed_write_rate; delayed writes slow the client (closed loop) rather
+// than queueing, so slowed-down demand is not made up later
+func (s *Simulator) writeSlowdownMultiplier() float64 {
+	slowdown := s.config.ImmutableMemtableSlowdownNumber
+	if slowdown <= 0 || s.numImmutableMemtables < slowdown || s.numImmutableMemtables >= s.config.MaxWriteBufferNumber {
+		return 1.0
+	}
+	band := s.config.MaxWriteBufferNumber - slowdown
+	depth := s.numImmutableMemtables - slowdown + 1
+	return 1.0 - float64(depth)/float64(band+1)
+}
+
+// updateWriteSlowdown tracks entry into and exit from the slowdown band; call it whenever
+// numImmutableMemtables changes
+func (s *Simulator) updateWriteSlowdown() {
+	inBand := s.writeSlowdownMultiplier() < 1.0
+	if inBand == s.inSlowdown {
+		return
+	}
+	s.inSlowdown = inBand
+	if inBand {
+		s.slowdownStartTime = s.virtualTime
+		s.logEvent("[t=%.1fs] WRITE SLOWDOWN: %d immutable memtables (slowdown=%d, max=%d), writes at %.0f%%",
+			s.virtualTime, s.numImmutableMemtables, s.config.ImmutableMemtableSlowdownNumber, s.config.MaxWriteBufferNumber, s.writeSlowdownMultiplier()*100)
+		return
+	}
+	s.metrics.SlowdownSeconds += s.virtualTime - max(s.slowdownStartTime, s.metricsStartTime)
+}
+
+// nextWriteIntervalSeconds is the traffic distribution's next write interval, stretched while
+// writes are slowed (0 = no writes)
+func (s *Simulator) nextWriteIntervalSeconds() float64 {
+	return s.trafficDistribution.NextIntervalSeconds() / s.writeSlowdownMultiplier()
 }
 
 // Config returns a copy of the current configuration
@@ -535,6 +914,12 @@ func (s *Simulator) Config() SimConfig {
 	return s.config
 }
 
+// Seed returns the random seed the run uses: config.RandomSeed, or the seed drawn for the run when
+// that is 0. Running again with this as randomSeed reproduces the run.
+func (s *Simulator) Seed() int64 {
+	return s.seed
+}
+
 // VirtualTime returns the current virtual time
 func (s *Simulator) VirtualTime() float64 {
 	return s.virtualTime
@@ -545,40 +930,361 @@ func (s *Simulator) Metrics() *Metrics {
 	return s.metrics.Clone()
 }
 
+// ActiveMemtableSizeMB returns how much data the active (mutable) memtable holds.
+// It fills between flushes up to MemtableFlushSizeMB, then becomes immutable.
+func (s *Simulator) ActiveMemtableSizeMB() float64 {
+	return s.lsm.MemtableCurrentSize
+}
+
+// Disk operation model for DiskIOPS: every SST read or written costs a few operations beyond its
+// data (open or create, footer, index and filter blocks, sync), and data moves in requests of
+// diskIORequestMB (roughly RocksDB's compaction readahead and writable-file buffer)
+const (
+	diskOpsPerFile  = 4
+	diskIORequestMB = 1.0
+)
+
+// diskOps estimates the disk operations needed to read or write fileCount files totalling sizeMB.
+// Pass fileCount 0 for appends to an open file (WAL).
+func diskOps(fileCount int, sizeMB float64) float64 {
+	if sizeMB <= 0 && fileCount == 0 {
+		return 0
+	}
+	return float64(fileCount*diskOpsPerFile) + math.Ceil(sizeMB/diskIORequestMB)
+}
+
+// diskIOTime returns how long the disk takes to write sizeMB in ops operations: the transfer time
+// at IOThroughputMBps, or the time to issue the operations at DiskIOPS if that is longer. Many tiny
+// files are IOPS-bound on some storage even though their bytes would transfer quickly.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Operations are issued serially at the average rate; no queue depth
+// or request merging
+func (s *Simulator) diskIOTime(sizeMB, ops float64) float64 {
+	return s.diskTransferTime(sizeMB, ops, s.config.IOThroughputMBps)
+}
+
+// diskReadTime is diskIOTime for reads, which transfer at the read throughput
+// (IOReadThroughputMBps, or IOThroughputMBps when unset).
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Reads and writes still queue on one disk timeline (diskBusyUntil),
+// each charged at its own budget's rate; devices that serve reads and writes concurrently
+// (separate NVMe queues) overlap them, which isn't modeled
+func (s *Simulator) diskReadTime(sizeMB, ops float64) float64 {
+	return s.diskTransferTime(sizeMB, ops, s.config.ReadThroughputMBps())
+}
+
+// levelReadTime is diskReadTime for data stored in level: levels on the cold tier read at the
+// cold tier's throughput
+func (s *Simulator) levelReadTime(level int, sizeMB, ops float64) float64 {
+	if s.config.IsColdLevel(level) {
+		return s.diskTransferTime(sizeMB, ops, s.config.ColdThroughputMBps())
+	}
+	return s.diskReadTime(sizeMB, ops)
+}
+
+// levelWriteTime is diskIOTime for data written to level: levels on the cold tier are written at
+// the cold tier's throughput
+func (s *Simulator) levelWriteTime(level int, sizeMB, ops float64) float64 {
+	if s.config.IsColdLevel(level) {
+		return s.diskTransferTime(sizeMB, ops, s.config.ColdThroughputMBps())
+	}
+	return s.diskIOTime(sizeMB, ops)
+}
+
+// compactionIOTime returns a compaction's read and write transfer times and how many of those
+// seconds are on the cold tier. Source files (sourceShare of the input) are read from FromLevel's
+// tier and target files from ToLevel's; the output is written to ToLevel's.
+func (s *Simulator) compactionIOTime(job *CompactionJob, sourceShare, readMB, readOps, writeMB, writeOps float64) (readSec, writeSec, coldSec float64) {
+	fromCold, toCold := s.config.IsColdLevel(job.FromLevel), s.config.IsColdLevel(job.ToLevel)
+	if fromCold == toCold {
+		readSec = s.levelReadTime(job.ToLevel, readMB, readOps)
+		if toCold {
+			coldSec += readSec
+		}
+	} else {
+		sourceSec := s.levelReadTime(job.FromLevel, readMB*sourceShare, readOps*sourceShare)
+		targetSec := s.levelReadTime(job.ToLevel, readMB*(1-sourceShare), readOps*(1-sourceShare))
+		readSec = sourceSec + targetSec
+		if fromCold {
+			coldSec += sourceSec
+		} else {
+			coldSec += targetSec
+		}
+	}
+	writeSec = s.levelWriteTime(job.ToLevel, writeMB, writeOps)
+	if toCold {
+		coldSec += writeSec
+	}
+	return readSec, writeSec, coldSec
+}
+
+// readWorkloadTransferTime splits the transfer time of sizeMB of read workload I/O between the
+// tiers, in proportion to the data each holds.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Assumes the bytes read come from each tier in proportion to its share
+// of the data (uniform key access), rather than tracking which runs each lookup probes
+func (s *Simulator) readWorkloadTransferTime(sizeMB float64) (hotSec, coldSec float64) {
+	coldShare := s.coldDataShare()
+	return sizeMB * (1 - coldShare) / s.config.ReadThroughputMBps(), sizeMB * coldShare / s.config.ColdThroughputMBps()
+}
+
+// coldDataShare returns the fraction of the LSM tree's data stored on the cold tier (0 when single-tier)
+func (s *Simulator) coldDataShare() float64 {
+	if !s.config.Tiered() {
+		return 0
+	}
+	var total, cold float64
+	for i, level := range s.lsm.Levels {
+		total += level.TotalSize
+		if s.config.IsColdLevel(i) {
+			cold += level.TotalSize
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+	return cold / total
+}
+
+// recordTierDiskTime attributes an I/O's disk time over [start, end) to the hot and cold tiers
+// for the per-tier utilization metrics (nothing to attribute when single-tier)
+func (s *Simulator) recordTierDiskTime(start, end, hotSec, coldSec float64) {
+	if s.config.Tiered() {
+		s.metrics.RecordTierDiskTime(start, end, hotSec, coldSec)
+	}
+}
+
+func (s *Simulator) diskTransferTime(sizeMB, ops, throughputMBps float64) float64 {
+	ioTime := sizeMB / throughputMBps
+	if s.config.DiskIOPS > 0 {
+		ioTime = max(ioTime, ops/s.config.DiskIOPS)
+	}
+	return ioTime
+}
+
+// recordDiskTime attributes a disk reservation [start, end) to an I/O category for TimeBreakdown.
+// Time is attributed when the disk is reserved, so it may run slightly ahead of virtual time.
+func (s *Simulator) recordDiskTime(category string, start, end float64) {
+	if end > start {
+		s.diskTimeByCategory[category] += end - start
+	}
+}
+
+// TimeBreakdown attributes elapsed virtual time to what the system was doing, accumulated over the run
+// (or since the last ResetMetrics).
+//
+// Disk categories ("flush", "compaction", "wal", "ingest", "read") plus "idle" sum to the elapsed
+// virtual time: the disk is a single serialized resource, so each second is busy with at most one
+// I/O category or idle. "stalled" is the time writes spent stalled; it overlaps the disk categories
+// (a stall usually waits on flush/compaction I/O) rather than adding to them.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Only disk time is attributed; CPU phases (SSTable build) overlap I/O
+func (s *Simulator) TimeBreakdown() map[string]float64 {
+	breakdown := map[string]float64{
+		"flush":      s.diskTimeByCategory["flush"],
+		"compaction": s.diskTimeByCategory["compaction"],
+		"wal":        s.diskTimeByCategory["wal"],
+		"ingest":     s.diskTimeByCategory["ingest"],
+		"read":       s.diskTimeByCategory["read"],
+	}
+
+	busy := 0.0
+	for _, seconds := range breakdown {
+		busy += seconds
+	}
+	breakdown["idle"] = max(0, s.virtualTime-s.metricsStartTime-busy)
+
+	stalled := s.metrics.StallDurationSeconds
+	if s.stallStartTime > 0 {
+		stalled += s.virtualTime - max(s.stallStartTime, s.metricsStartTime) // Ongoing stall
+	}
+	breakdown["stalled"] = stalled
+
+	return breakdown
+}
+
+// Thresholds for RecommendCompactionStyle
+const (
+	recommendWriteBudgetFraction = 0.5   // Leveled compaction is "too expensive" once its writes need this share of disk bandwidth
+	recommendReadHeavyRatio      = 100.0 // Reads/sec per MB/s written above which the workload counts as read-heavy
+	recommendSpaceTolerantPct    = 100   // max_size_amplification_percent at or above this means 2x space is acceptable
+)
+
+// RecommendCompactionStyle suggests a compaction style for the configured workload and
+// explains why. Heuristic and advisory - it does not run trials:
+//   - No reads: FIFO (~1x write amp), provided data may be dropped once it exceeds fifoMaxTableFilesSizeMB
+//   - Leveled can't keep up (write rate × leveled write amp exceeds half the disk bandwidth, or writes
+//     are already stalling under leveled) and 2x space is tolerable: universal
+//   - Otherwise (read-heavy, space-constrained, or balanced): leveled
+//
+// Leveled write amp is taken from the running simulation when it uses leveled compaction,
+// otherwise estimated as the level multiplier.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Rules of thumb from the RocksDB tuning guide, not a cost model
+// https://github.com/facebook/rocksdb/wiki/RocksDB-Tuning-Guide
+func (s *Simulator) RecommendCompactionStyle() (CompactionStyle, string) {
+	readsPerSec := 0.0
+	if s.config.ReadWorkload != nil && s.config.ReadWorkload.Enabled {
+		readsPerSec = s.config.ReadWorkload.RequestsPerSec
+	}
+	writeRate := s.config.WriteRateMBps
+
+	if readsPerSec <= 0 {
+		return CompactionStyleFIFO, fmt.Sprintf(
+			"No read workload: FIFO writes each byte ~once and never merges. Only suitable if data older than the %d MB retention cap may be dropped; otherwise use universal.",
+			s.config.FIFOMaxTableFilesSizeMB)
+	}
+
+	leveledWA := float64(s.config.LevelMultiplier)
+	observed := ""
+	if s.config.CompactionStyle == CompactionStyleLeveled && s.metrics.WriteAmplification > 1.0 {
+		leveledWA = s.metrics.WriteAmplification
+		observed = " (observed)"
+	}
+	leveledDiskMBps := writeRate * leveledWA
+	diskBudgetMBps := s.config.IOThroughputMBps * recommendWriteBudgetFraction
+	stallingUnderLeveled := s.config.CompactionStyle == CompactionStyleLeveled && s.metrics.StallDurationSeconds > 0
+	spaceTolerant := s.config.MaxSizeAmplificationPercent >= recommendSpaceTolerantPct
+
+	if leveledDiskMBps > diskBudgetMBps || stallingUnderLeveled {
+		reason := fmt.Sprintf("leveled compaction would write %.0f MB/s (%.0f MB/s × %.1fx write amp%s), over half the %.0f MB/s disk",
+			leveledDiskMBps, writeRate, leveledWA, observed, s.config.IOThroughputMBps)
+		if stallingUnderLeveled {
+			reason = fmt.Sprintf("writes stalled for %.1fs under leveled compaction", s.metrics.StallDurationSeconds)
+		}
+		if spaceTolerant {
+			return CompactionStyleUniversal, fmt.Sprintf(
+				"Write-heavy: %s. Universal trades up to %d%% space amplification for much lower write amp.",
+				reason, s.config.MaxSizeAmplificationPercent)
+		}
+		return CompactionStyleLeveled, fmt.Sprintf(
+			"Write-heavy (%s), but max size amplification of %d%% rules out universal; stay leveled and add disk bandwidth or background jobs.",
+			reason, s.config.MaxSizeAmplificationPercent)
+	}
+
+	if writeRate <= 0 || readsPerSec/writeRate >= recommendReadHeavyRatio {
+		return CompactionStyleLeveled, fmt.Sprintf(
+			"Read-heavy: %.0f reads/s vs %.0f MB/s written. Leveled keeps one sorted run per level, minimizing read amplification.",
+			readsPerSec, writeRate)
+	}
+	return CompactionStyleLeveled, fmt.Sprintf(
+		"Balanced: leveled write cost (%.0f MB/s) fits the disk budget (%.0f MB/s) and gives the lowest read and space amplification.",
+		leveledDiskMBps, diskBudgetMBps)
+}
+
 // GetDiskBusyUntil returns when the disk will be free
 func (s *Simulator) GetDiskBusyUntil() float64 {
 	return s.diskBusyUntil
 }
 
-// findEarliestJobSlot returns the index and busy-until time of the earliest available background job slot
-func (s *Simulator) findEarliestJobSlot() (slotIndex int, earliestBusyUntil float64) {
-	earliestBusyUntil = s.backgroundJobSlots[0]
+// BackgroundTaskKind identifies the type of work submitted to the background pool
+type BackgroundTaskKind int
+
+const (
+	BackgroundTaskFlush      BackgroundTaskKind = iota // Memtable → L0
+	BackgroundTaskCompaction                           // Any compaction (including intra-L0)
+)
+
+// String returns the task kind name (also used as its TimeBreakdown category)
+func (k BackgroundTaskKind) String() string {
+	switch k {
+	case BackgroundTaskFlush:
+		return "flush"
+	case BackgroundTaskCompaction:
+		return "compaction"
+	default:
+		return "unknown"
+	}
+}
+
+// submitBackgroundTask runs a flush or compaction on the shared background pool.
+// The task is dispatched to the earliest free of MaxBackgroundJobs workers (queueing
+// until one frees up), then consumes disk bandwidth for its I/O phase.
+// With MaxBackgroundFlushes > 0, flushes run on their own pool of that many workers
+// instead, still sharing the disk with compactions.
+// Returns when the task starts on a worker and when it completes.
+//
+// FIDELITY: RocksDB Reference - max_background_jobs is shared by flushes and compactions,
+// unless max_background_flushes gives flushes their own HIGH priority thread pool
+// https://github.com/facebook/rocksdb/blob/main/db/db_impl/db_impl_compaction_flush.cc
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Without maxBackgroundFlushes one FIFO pool; RocksDB reserves a
+// share of max_background_jobs for flushes so flushes never queue behind compactions
+func (s *Simulator) submitBackgroundTask(kind BackgroundTaskKind, arrivalTime, cpuDuration, ioDuration float64) (cpuStartTime, completionTime float64) {
+	return s.submitThrottledBackgroundTask(kind, arrivalTime, cpuDuration, ioDuration, 1.0)
+}
+
+// submitThrottledBackgroundTask is submitBackgroundTask for a task limited to ioShare of the disk
+// bandwidth (1.0 = unthrottled). See allocateThrottledJobSlot.
+func (s *Simulator) submitThrottledBackgroundTask(kind BackgroundTaskKind, arrivalTime, cpuDuration, ioDuration, ioShare float64) (cpuStartTime, completionTime float64) {
+	var ioStartTime float64
+	if kind == BackgroundTaskFlush && len(s.flushJobSlots) > 0 {
+		_, cpuStartTime, ioStartTime, completionTime = s.allocateFlushSlot(arrivalTime, cpuDuration, ioDuration)
+	} else {
+		_, cpuStartTime, ioStartTime, completionTime = s.allocateThrottledJobSlot(arrivalTime, cpuDuration, ioDuration, ioShare)
+	}
+	s.recordDiskTime(kind.String(), ioStartTime, ioStartTime+ioDuration)
+	s.metrics.RecordBackgroundTaskWait(kind, cpuStartTime-arrivalTime)
+	return cpuStartTime, completionTime
+}
+
+// earliestSlot returns the index and busy-until time of the earliest available slot in slots
+func earliestSlot(slots []float64) (slotIndex int, earliestBusyUntil float64) {
+	earliestBusyUntil = slots[0]
 	slotIndex = 0
-	for i := 1; i < len(s.backgroundJobSlots); i++ {
-		if s.backgroundJobSlots[i] < earliestBusyUntil {
-			earliestBusyUntil = s.backgroundJobSlots[i]
+	for i := 1; i < len(slots); i++ {
+		if slots[i] < earliestBusyUntil {
+			earliestBusyUntil = slots[i]
 			slotIndex = i
 		}
 	}
 	return slotIndex, earliestBusyUntil
 }
 
-// countActiveBackgroundJobs returns the number of background job slots currently busy
+// countActiveBackgroundJobs returns the number of background job slots (including flush slots) currently busy
 func (s *Simulator) countActiveBackgroundJobs() int {
 	activeCount := 0
-	for _, busyUntil := range s.backgroundJobSlots {
-		if busyUntil > s.virtualTime {
-			activeCount++
+	for _, slots := range [][]float64{s.backgroundJobSlots, s.flushJobSlots} {
+		for _, busyUntil := range slots {
+			if busyUntil > s.virtualTime {
+				activeCount++
+			}
 		}
 	}
 	return activeCount
 }
 
+// backgroundSlotCount returns the number of background workers: MaxBackgroundJobs plus any dedicated flush slots
+func (s *Simulator) backgroundSlotCount() int {
+	return len(s.backgroundJobSlots) + len(s.flushJobSlots)
+}
+
 // allocateJobSlot finds the earliest available slot and reserves it until the given completion time
 // Returns the slot index and when the job can actually start (max of arrival time and slot availability)
 func (s *Simulator) allocateJobSlot(arrivalTime, cpuDuration, ioDuration float64) (slotIndex int, cpuStartTime, ioStartTime, completionTime float64) {
+	return s.allocateThrottledJobSlot(arrivalTime, cpuDuration, ioDuration, 1.0)
+}
+
+// allocateThrottledJobSlot is allocateJobSlot for a job limited to ioShare of the disk bandwidth:
+// its I/O phase stretches to ioDuration/ioShare, but it reserves the disk only for the ioDuration
+// its bytes need, so jobs behind it get the rest of the bandwidth instead of waiting it out.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - The disk stays a FIFO queue: the bandwidth a throttled job gives up is
+// handed to later jobs up front rather than interleaved with its I/O
+func (s *Simulator) allocateThrottledJobSlot(arrivalTime, cpuDuration, ioDuration, ioShare float64) (slotIndex int, cpuStartTime, ioStartTime, completionTime float64) {
+	return s.reserveJobSlot(s.backgroundJobSlots, 0, arrivalTime, cpuDuration, ioDuration, ioShare)
+}
+
+// allocateFlushSlot is allocateJobSlot on the dedicated flush pool (MaxBackgroundFlushes > 0).
+// Flush slots are numbered after the shared pool's slots in the returned index and slot metrics.
+func (s *Simulator) allocateFlushSlot(arrivalTime, cpuDuration, ioDuration float64) (slotIndex int, cpuStartTime, ioStartTime, completionTime float64) {
+	return s.reserveJobSlot(s.flushJobSlots, len(s.backgroundJobSlots), arrivalTime, cpuDuration, ioDuration, 1.0)
+}
+
+// reserveJobSlot reserves the earliest free of slots (numbered from firstSlot) and the disk for a job
+func (s *Simulator) reserveJobSlot(slots []float64, firstSlot int, arrivalTime, cpuDuration, ioDuration, ioShare float64) (slotIndex int, cpuStartTime, ioStartTime, completionTime float64) {
 	// Find earliest free slot
-	slotIndex, slotBusyUntil := s.findEarliestJobSlot()
+	slotIndex, slotBusyUntil := earliestSlot(slots)
 
 	// CPU phase can start when slot is free
 	cpuStartTime = max(arrivalTime, slotBusyUntil)
@@ -586,22 +1292,179 @@ func (s *Simulator) allocateJobSlot(arrivalTime, cpuDuration, ioDuration float64
 
 	// I/O phase can start when both CPU is done AND disk is free
 	ioStartTime = max(cpuCompleteTime, s.diskBusyUntil)
-	completionTime = ioStartTime + ioDuration
+	completionTime = ioStartTime + ioDuration/ioShare
 
 	// Reserve the slot until job completes
-	s.backgroundJobSlots[slotIndex] = completionTime
+	slots[slotIndex] = completionTime
+	slotIndex += firstSlot
 
-	// Reserve disk until I/O completes
-	s.diskBusyUntil = completionTime
+	// Reserve disk for the job's share of the I/O (all of it when unthrottled)
+	s.diskBusyUntil = ioStartTime + ioDuration
+
+	// Track slot occupancy for per-slot utilization metrics
+	s.metrics.RecordSlotOccupancy(slotIndex, cpuStartTime, completionTime)
 
 	return slotIndex, cpuStartTime, ioStartTime, completionTime
 }
 
+// maxDetailedL0Files caps the per-file L0 view (L0 can grow to hundreds of files during a stall)
+const maxDetailedL0Files = 100
+
+// detailedL0State describes every L0 file (up to maxDetailedL0Files) and the thresholds that act on L0.
+// Flushed L0 files span the whole key range, so each overlaps every other L0 file except those
+// sharing its sub-level (outputs of one intra-L0 compaction).
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Overlap is all-or-nothing per sub-level rather than computed from each
+// file's key range (the two agree while every flushed file spans the whole key space).
+// level0_slowdown_writes_trigger/level0_stop_writes_trigger aren't modeled (stalls come from
+// max_write_buffer_number), so only compaction triggers are reported.
+func (s *Simulator) detailedL0State() map[string]interface{} {
+	l0 := s.lsm.Levels[0]
+
+	compacting := make(map[*SSTFile]int) // File → ID of the compaction consuming it
+	for id, job := range s.pendingCompactions {
+		if job.FromLevel != 0 || job.ColumnFamily != 0 {
+			continue
+		}
+		for _, f := range job.SourceFiles {
+			compacting[f] = id
+		}
+	}
+
+	subLevels := l0.subLevels()
+	subLevelOf := make(map[*SSTFile]int, len(l0.Files))
+	for i, files := range subLevels {
+		for _, f := range files {
+			subLevelOf[f] = i
+		}
+	}
+
+	fileCount := min(len(l0.Files), maxDetailedL0Files)
+	files := make([]map[string]interface{}, fileCount)
+	for i, f := range l0.Files[:fileCount] {
+		minKey, maxKey := f.keyRange()
+		overlapping := len(overlappingFiles(l0.Files, minKey, maxKey)) - 1
+		compactionID, beingCompacted := compacting[f]
+		files[i] = map[string]interface{}{
+			"id":               f.ID,
+			"sizeMB":           f.SizeMB,
+			"ageSeconds":       s.virtualTime - f.CreatedAt,
+			"subLevel":         subLevelOf[f],
+			"overlappingFiles": overlapping,
+			"beingCompacted":   beingCompacted,
+			"compactionID":     compactionID,
+		}
+	}
+
+	return map[string]interface{}{
+		"files":                   files,
+		"fileCount":               l0.FileCount,
+		"totalSizeMB":             l0.TotalSize,
+		"subLevelCount":           len(subLevels),
+		"subLevelsEnabled":        s.config.EnableL0SubLevels,
+		"filesBeingCompacted":     len(compacting),
+		"compactionTrigger":       s.config.L0CompactionTrigger,
+		"urgentCompactionTrigger": s.config.UrgentL0CompactionTrigger,
+	}
+}
+
 // IsQueueEmpty returns true if the event queue is empty
 func (s *Simulator) IsQueueEmpty() bool {
 	return s.queue.IsEmpty()
 }
 
+// PauseLevelCompaction stops new compactions from taking input from a level, so data piles up
+// there while the rest of the tree keeps compacting. Compactions already running finish normally,
+// and compactions into the level still happen.
+//
+// FIDELITY: ⚠️ NOT IN ROCKSDB - RocksDB can only disable auto-compaction for a whole column
+// family; per-level pausing is an experimentation aid
+func (s *Simulator) PauseLevelCompaction(level int) error {
+	return s.setLevelCompactionPaused(level, true)
+}
+
+// ResumeLevelCompaction lets a paused level compact again; its backlog drains through the
+// normal picking logic
+func (s *Simulator) ResumeLevelCompaction(level int) error {
+	return s.setLevelCompactionPaused(level, false)
+}
+
+func (s *Simulator) setLevelCompactionPaused(level int, paused bool) error {
+	if level < 0 || level >= len(s.lsm.Levels) {
+		return fmt.Errorf("level %d out of range [0, %d)", level, len(s.lsm.Levels))
+	}
+	if s.lsm.Levels[level].CompactionPaused == paused {
+		return nil
+	}
+	s.lsm.Levels[level].CompactionPaused = paused
+	if paused {
+		s.logEvent("[t=%.1fs] COMPACTION PAUSED: L%d", s.virtualTime, level)
+		s.record(journalEntry{Op: "pause_level", Level: level})
+	} else {
+		s.logEvent("[t=%.1fs] COMPACTION RESUMED: L%d", s.virtualTime, level)
+		s.record(journalEntry{Op: "resume_level", Level: level})
+	}
+	return nil
+}
+
+// LevelDetail summarizes one LSM level for exporters that need typed values rather than State()'s map
+type LevelDetail struct {
+	Level        int     `json:"level"`
+	FileCount    int     `json:"fileCount"`
+	SizeMB       float64 `json:"sizeMB"`
+	TargetSizeMB float64 `json:"targetSizeMB"` // Same targets State() reports (L0's is nominal; it triggers on file count)
+}
+
+// LevelDetails returns per-level file counts, sizes and targets, L0 first
+func (s *Simulator) LevelDetails() []LevelDetail {
+	targets := s.lsm.calculateLevelTargets(s.config)
+	details := make([]LevelDetail, len(s.lsm.Levels))
+	for i, level := range s.lsm.Levels {
+		details[i] = LevelDetail{
+			Level:        level.Number,
+			FileCount:    level.FileCount,
+			SizeMB:       level.TotalSize,
+			TargetSizeMB: targets[i],
+		}
+	}
+	return details
+}
+
+// SimState is a typed snapshot of the LSM tree for Go callers (tests, tooling, sim_runner) that
+// would otherwise pick values out of State()'s map. It covers the tree's shape, not everything
+// State() reports for the UI.
+type SimState struct {
+	VirtualTime              float64                `json:"virtualTime"`
+	NumLevels                int                    `json:"numLevels"`
+	Levels                   []LevelDetail          `json:"levels"` // L0 first
+	TotalSizeMB              float64                `json:"totalSizeMB"`
+	ActiveMemtableMB         float64                `json:"activeMemtableMB"`
+	MemtableFlushSizeMB      int                    `json:"memtableFlushSizeMB"`
+	ImmutableMemtableSizesMB []float64              `json:"immutableMemtableSizesMB"` // Oldest first
+	ActiveCompactions        int                    `json:"activeCompactions"`        // Scheduled jobs, running or waiting for a slot
+	ActiveCompactionInfos    []ActiveCompactionInfo `json:"activeCompactionInfos"`
+}
+
+// StateSnapshot returns the current LSM tree state as a SimState. The slices are copies, so the
+// snapshot stays valid as the simulation advances.
+func (s *Simulator) StateSnapshot() SimState {
+	infos := make([]ActiveCompactionInfo, len(s.activeCompactionInfos))
+	for i, info := range s.activeCompactionInfos {
+		infos[i] = *info
+	}
+	return SimState{
+		VirtualTime:              s.virtualTime,
+		NumLevels:                len(s.lsm.Levels),
+		Levels:                   s.LevelDetails(),
+		TotalSizeMB:              s.lsm.TotalSizeMB,
+		ActiveMemtableMB:         s.ActiveMemtableSizeMB(),
+		MemtableFlushSizeMB:      s.config.MemtableFlushSizeMB,
+		ImmutableMemtableSizesMB: append([]float64{}, s.immutableMemtableSizes...),
+		ActiveCompactions:        s.ActiveCompactions(),
+		ActiveCompactionInfos:    infos,
+	}
+}
+
 // State returns the current LSM tree state
 func (s *Simulator) State() map[string]interface{} {
 	state := s.lsm.State(s.virtualTime, s.config)
@@ -609,7 +1472,27 @@ func (s *Simulator) State() map[string]interface{} {
 	state["activeCompactions"] = s.ActiveCompactions()
 	state["activeCompactionInfos"] = s.activeCompactionInfos
 	state["numImmutableMemtables"] = s.numImmutableMemtables
+	state["activeMemtableMB"] = s.ActiveMemtableSizeMB()
+	state["memtableFlushSizeMB"] = s.config.MemtableFlushSizeMB
 	state["immutableMemtableSizesMB"] = s.immutableMemtableSizes
+	state["backgroundSlotUtilization"] = s.metrics.BackgroundSlotUtilization
+	state["fileSizeCompliancePerLevel"] = s.metrics.FileSizeCompliancePerLevel
+	state["physicalSizeMBPerLevel"] = s.metrics.PhysicalSizeMBPerLevel
+	state["logicalSizeMBPerLevel"] = s.metrics.LogicalSizeMBPerLevel
+	state["liveDataSizeMB"] = s.metrics.LiveDataSizeMB
+	state["spaceAmplification"] = s.metrics.SpaceAmplification
+	state["compactionEfficiency"] = s.metrics.CompactionEfficiency
+	state["estimatedCompactionsToClearL0"] = s.metrics.EstimatedCompactionsToClearL0
+	state["flushRateMBps"] = s.metrics.FlushRateMBps
+	state["achievedWriteRateMBps"] = s.metrics.AchievedWriteRateMBps
+	state["avgLevelsTouchedPerRead"] = s.metrics.AvgLevelsTouchedPerRead
+	state["l0SubLevelCount"] = s.lsm.Levels[0].SubLevelCount() // What EnableL0SubLevels reads and scores L0 by
+	if s.config.BloomFilterBitsPerKey > 0 {
+		state["bloomFilterFPR"] = bloomFilterFPR(s.config.BloomFilterBitsPerKey)
+	}
+	if s.config.DetailedL0State {
+		state["l0Detail"] = s.detailedL0State()
+	}
 
 	// Add base level for universal compaction and leveled compaction with dynamic level bytes
 	// FIDELITY: ✓ Unified implementation - uses appropriate method for each compaction style
@@ -623,14 +1506,23 @@ func (s *Simulator) State() map[string]interface{} {
 		state["baseLevel"] = baseLevel
 	}
 
-	// Add current incoming write rate (for advanced traffic models)
-	if advDist, ok := s.trafficDistribution.(*AdvancedTrafficDistribution); ok {
-		state["currentIncomingRateMBps"] = advDist.GetCurrentRateMBps()
+	// Add queued follow-up compactions (split over-large candidates)
+	if leveled, ok := s.compactor.(*LeveledCompactor); ok {
+		state["pendingFollowUpCompactions"] = leveled.PendingFollowUpJobs()
+	}
+
+	// Add current incoming write rate (for advanced and burst traffic models)
+	if timed, ok := s.trafficDistribution.(timedTrafficDistribution); ok {
+		state["currentIncomingRateMBps"] = timed.GetCurrentRateMBps()
 	} else {
 		// For constant model, use the configured rate
 		state["currentIncomingRateMBps"] = s.config.TrafficDistribution.WriteRateMBps
 	}
 
+	if len(s.columnFamilies) > 0 {
+		state["columnFamilies"] = s.columnFamilyStates(state)
+	}
+
 	return state
 }
 
@@ -649,10 +1541,14 @@ func (s *Simulator) processEvent(event Event) {
 		s.processScheduleWrite(e)
 	case *WALWriteEvent:
 		s.processWALWrite(e)
+	case *WALGroupCommitEvent:
+		s.processWALGroupCommit(e)
 	case *ScheduleReadEvent:
 		s.processScheduleRead(e)
 	case *ReadBatchEvent:
 		s.processReadBatch(e)
+	case *ColumnFamilyWriteEvent:
+		s.processColumnFamilyWrite(e)
 	default:
 		panic(fmt.Sprintf("unknown event type: %T", e))
 	}
@@ -691,17 +1587,16 @@ func (s *Simulator) processEvent(event Event) {
 //	  - Check every 1ms of virtual time (0.001 seconds)
 //	  - Same effect: writes slow down when memtables pile up
 func (s *Simulator) processWrite(event *WriteEvent) {
-	// Write stall check - matches RocksDB's max_write_buffer_number limit
-	if s.numImmutableMemtables >= s.config.MaxWriteBufferNumber {
+	// Ingestion mode: traffic arrives as external SST files, bypassing WAL and memtable
+	if s.config.IngestionMode {
+		s.bufferIngestWrite(event.SizeMB())
+		return
+	}
+
+	// Write stall check - matches RocksDB's max_write_buffer_number limit, in any column family
+	if cf := s.stalledColumnFamily(); cf >= 0 {
 		// Write stall! Initialize stall state if this is the first stalled write
-		isFirstStall := s.stallStartTime == 0
-		if isFirstStall {
-			s.stallStartTime = s.virtualTime
-			s.stalledWriteBacklog = 0
-			// Log only when entering stall state (not for every retry)
-			s.logEvent("[t=%.1fs] WRITE STALL: %d immutable memtables (max=%d), writes delayed",
-				s.virtualTime, s.numImmutableMemtables, s.config.MaxWriteBufferNumber)
-		}
+		s.beginWriteStall(cf)
 
 		// Calculate backlog based on stall duration and write rate
 		// This is more accurate than counting events, especially at high simulation speeds
@@ -726,32 +1621,12 @@ func (s *Simulator) processWrite(event *WriteEvent) {
 		}
 
 		// Reschedule this write - use flush-aware scheduling to avoid event explosion
-		// Schedule retry at next flush completion time, or fallback to 1ms if no flush scheduled
-		var stallTime float64
-		if s.nextFlushCompletionTime > s.virtualTime {
-			// Schedule retry slightly after flush completes to ensure flush processes first
-			stallTime = s.nextFlushCompletionTime + 0.0001
-		} else {
-			// Fallback: no flush scheduled, schedule 1ms retry (matches RocksDB's check interval)
-			stallTime = s.virtualTime + 0.001 // 1ms = 0.001 seconds
-		}
-		// CRITICAL BUG FIX: Ensure stallTime is never in the past
-		stallTime = max(stallTime, s.virtualTime)
-		s.queue.Push(NewStalledWriteEvent(stallTime, event.SizeMB()))
+		s.queue.Push(NewStalledWriteEvent(s.stalledWriteRetryTime(), event.SizeMB()))
 		return
 	}
 
 	// Stall cleared - log if we were previously stalled
-	if s.stallStartTime > 0 {
-		duration := s.virtualTime - s.stallStartTime
-		// Accumulate stall duration in metrics
-		s.metrics.StallDurationSeconds += duration
-		s.logEvent("[t=%.1fs] WRITE STALL CLEARED: %d immutable memtables (max=%d), writes resuming (stall duration: %.3fs, backlog cleared: %d writes)",
-			s.virtualTime, s.numImmutableMemtables, s.config.MaxWriteBufferNumber, duration, s.stalledWriteBacklog)
-		s.stallStartTime = 0
-		s.stalledWriteBacklog = 0     // Clear backlog when stall clears
-		s.nextFlushCompletionTime = 0 // No need to track flush completion time when not stalled
-	}
+	s.endWriteStall()
 
 	// Write to WAL BEFORE memtable (durability guarantee)
 	// FIDELITY: RocksDB Reference - WriteToWAL happens before memtable insert
@@ -760,43 +1635,17 @@ func (s *Simulator) processWrite(event *WriteEvent) {
 	// RocksDB always writes to WAL before memtable to ensure durability.
 	// WAL writes are sequential and may include fsync() for durability.
 	if s.config.EnableWAL {
-		walSizeMB := event.SizeMB()
-
-		// Calculate WAL write duration: sequential write time + optional sync
-		ioTimeSec := walSizeMB / s.config.IOThroughputMBps
-		walDuration := ioTimeSec
-
-		// Add fsync latency if WALSync is enabled
-		if s.config.WALSync {
-			syncTimeSec := s.config.WALSyncLatencyMs / 1000.0
-			walDuration += syncTimeSec
+		if s.config.WALGroupCommitIntervalMs > 0 {
+			s.joinWALGroupCommit(event.SizeMB())
+		} else {
+			s.writeWAL(event.SizeMB())
 		}
-
-		// WAL write contends for disk bandwidth
-		walStartTime := max(s.virtualTime, s.diskBusyUntil)
-		walCompleteTime := walStartTime + walDuration
-
-		// Reserve disk bandwidth
-		s.diskBusyUntil = walCompleteTime
-
-		// Schedule WAL completion event
-		walEvent := NewWALWriteEvent(walCompleteTime, walStartTime, walSizeMB)
-		s.queue.Push(walEvent)
-
-		// Track WAL bytes separately (NOT included in LSM write amplification)
-		// RocksDB's write amplification metric measures LSM compaction overhead only,
-		// not WAL writes. See: internal_stats.cc:1806-1842 (write_amp = compaction_output / flush_input)
-		s.metrics.WALBytesWritten += walSizeMB
-		// NOTE: WAL bytes are NOT added to totalDiskWrittenMB (which tracks flush + compaction only)
-
-		// Track WAL write activity for disk throughput/utilization calculations
-		// Use Level = -2 to distinguish WAL from flush (-1) and compactions (0+)
-		s.metrics.RecordWALWrite(walStartTime, walCompleteTime, walSizeMB)
 	}
 
 	// Add write to memtable (after WAL)
 	s.lsm.AddWrite(event.SizeMB(), s.virtualTime)
 	s.metrics.RecordUserWrite(event.SizeMB())
+	s.metrics.TotalKeys += keysForSizeMB(event.SizeMB(), s.config.AvgKeyValueSizeBytes)
 
 	// Check if flush is needed (size-based)
 	// FIDELITY: ✓ Flush trigger matches RocksDB's write_buffer_size check (see lsm.go:NeedsFlush)
@@ -813,6 +1662,7 @@ func (s *Simulator) processWrite(event *WriteEvent) {
 		sizeMB := s.lsm.MemtableCurrentSize
 		s.numImmutableMemtables++                                           // One more immutable memtable
 		s.immutableMemtableSizes = append(s.immutableMemtableSizes, sizeMB) // Track its size
+		s.updateWriteSlowdown()
 
 		// IMMEDIATELY reset the active memtable (simulate creating a new one)
 		// New writes will now go to this fresh memtable
@@ -843,29 +1693,26 @@ func (s *Simulator) processWrite(event *WriteEvent) {
 		}
 
 		// Phase 2: Disk write (I/O-bound)
-		outputSizeMB := sizeMB * s.config.CompressionFactor
-		ioDuration := (outputSizeMB / s.config.IOThroughputMBps) + (s.config.IOLatencyMs / 1000.0)
+		outputSizeMB := sizeMB * s.config.CompressionFactorForLevel(0) * s.config.MetadataOverheadFactor()
+		ops := diskOps(1, outputSizeMB)
+		ioDuration := s.diskIOTime(outputSizeMB, ops) + (s.config.IOLatencyMs / 1000.0)
 
-		// Allocate a background job slot
-		arrivalTime := s.virtualTime
-		_, cpuStartTime, _, completionTime := s.allocateJobSlot(arrivalTime, cpuDuration, ioDuration)
+		// Submit to the background pool
+		cpuStartTime, completionTime := s.submitBackgroundTask(BackgroundTaskFlush, s.virtualTime, cpuDuration, ioDuration)
+		s.metrics.RecordDiskOps(completionTime-ioDuration, completionTime, ops)
+		s.metrics.RecordDiskBytes(completionTime-ioDuration, completionTime, 0, outputSizeMB)
+		s.recordTierDiskTime(completionTime-ioDuration, completionTime, ioDuration, 0)
 
 		// Track this write as in-progress for throughput calculation
 		// Use cpuStartTime as the overall start time (when background job begins)
-		s.metrics.StartWrite(sizeMB, sizeMB, cpuStartTime, completionTime, -1, 0) // Flush: memtable → L0
+		s.metrics.StartWrite(sizeMB, sizeMB, cpuStartTime, completionTime, -1, 0, 0) // Flush: memtable → L0
 
 		// Schedule flush event with the SIZE that was frozen
 		s.queue.Push(NewFlushEvent(completionTime, cpuStartTime, sizeMB))
 
 		// Track earliest flush completion time if we're stalled
 		// This allows stalled writes to schedule retries at flush completion instead of every 1ms
-		if s.numImmutableMemtables >= s.config.MaxWriteBufferNumber {
-			// Find the earliest flush event (which might be the one we just scheduled, or an earlier one)
-			earliestFlush := s.queue.FindNextFlushEvent()
-			if earliestFlush != nil {
-				s.nextFlushCompletionTime = earliestFlush.Timestamp()
-			}
-		}
+		s.updateNextFlushCompletionTime()
 	}
 
 	// Writes are now scheduled continuously by ScheduleWriteEvent, independent of
@@ -873,6 +1720,215 @@ func (s *Simulator) processWrite(event *WriteEvent) {
 	// at the configured rate regardless of system state.
 }
 
+// stalledColumnFamily returns the column family whose immutable memtables have reached
+// max_write_buffer_number (0 = default, checked first), or -1 when writes aren't stalled.
+//
+// FIDELITY: ✓ RocksDB's WriteController is DB-wide: one column family's full memtables stall
+// writes to every column family
+func (s *Simulator) stalledColumnFamily() int {
+	if s.numImmutableMemtables >= s.config.MaxWriteBufferNumber {
+		return 0
+	}
+	for _, family := range s.columnFamilies {
+		if len(family.immutableMemtableSizes) >= family.config.MaxWriteBufferNumber {
+			return family.id
+		}
+	}
+	return -1
+}
+
+// beginWriteStall enters the write stall state caused by column family cf's memtables, unless
+// writes are already stalled
+func (s *Simulator) beginWriteStall(cf int) {
+	if s.stallStartTime > 0 {
+		return
+	}
+	s.stallStartTime = s.virtualTime
+	s.stalledWriteBacklog = 0
+	s.updateNextFlushCompletionTime()
+	// Log only when entering stall state (not for every retry)
+	if cf == 0 {
+		s.logEvent("[t=%.1fs] WRITE STALL: %d immutable memtables (max=%d), writes delayed",
+			s.virtualTime, s.numImmutableMemtables, s.config.MaxWriteBufferNumber)
+		return
+	}
+	family := s.columnFamily(cf)
+	s.logEvent("[t=%.1fs] WRITE STALL: column family %q has %d immutable memtables (max=%d), writes delayed",
+		s.virtualTime, family.name, len(family.immutableMemtableSizes), family.config.MaxWriteBufferNumber)
+}
+
+// endWriteStall leaves the write stall state, if writes were stalled, and records its duration
+func (s *Simulator) endWriteStall() {
+	if s.stallStartTime == 0 {
+		return
+	}
+	duration := s.virtualTime - s.stallStartTime
+	// Accumulate stall duration in metrics
+	s.metrics.StallDurationSeconds += duration
+	s.metrics.RecordStall(duration)
+	s.logEvent("[t=%.1fs] WRITE STALL CLEARED: %d immutable memtables (max=%d), writes resuming (stall duration: %.3fs, backlog cleared: %d writes)",
+		s.virtualTime, s.numImmutableMemtables, s.config.MaxWriteBufferNumber, duration, s.stalledWriteBacklog)
+	s.stallStartTime = 0
+	s.stalledWriteBacklog = 0     // Clear backlog when stall clears
+	s.nextFlushCompletionTime = 0 // No need to track flush completion time when not stalled
+}
+
+// stalledWriteRetryTime returns when a stalled write should retry: just after the next flush of
+// the stalled column family completes, or in 1ms if no flush is scheduled
+func (s *Simulator) stalledWriteRetryTime() float64 {
+	var stallTime float64
+	if s.nextFlushCompletionTime > s.virtualTime {
+		// Schedule retry slightly after flush completes to ensure flush processes first
+		stallTime = s.nextFlushCompletionTime + 0.0001
+	} else {
+		// Fallback: no flush scheduled, schedule 1ms retry (matches RocksDB's check interval)
+		stallTime = s.virtualTime + 0.001 // 1ms = 0.001 seconds
+	}
+	// CRITICAL BUG FIX: Ensure stallTime is never in the past
+	return max(stallTime, s.virtualTime)
+}
+
+// updateNextFlushCompletionTime points stalled writes at the next flush of the column family
+// holding the stall (0 when writes aren't stalled, or no flush is scheduled: 1ms retries)
+func (s *Simulator) updateNextFlushCompletionTime() {
+	s.nextFlushCompletionTime = 0
+	if cf := s.stalledColumnFamily(); cf >= 0 {
+		if nextFlush := s.queue.FindNextFlushEvent(cf); nextFlush != nil {
+			s.nextFlushCompletionTime = nextFlush.Timestamp()
+		}
+	}
+}
+
+// bufferIngestWrite accumulates ingestion-mode traffic and ingests a file each time
+// a full file's worth of data has arrived. Files land in L0, or in the bottommost
+// level with IngestBehind.
+//
+// FIDELITY: RocksDB Reference - IngestExternalFileOptions::ingest_behind
+// https://github.com/facebook/rocksdb/blob/main/include/rocksdb/options.h
+// With allow_ingest_behind, the last level is reserved and ingested files go straight
+// there, so sorted bulk loads are never rewritten by compaction.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB only supports ingest_behind with universal compaction
+// and assumes ingested data is older than everything in the DB; we allow any style
+func (s *Simulator) bufferIngestWrite(sizeMB float64) {
+	fileSizeMB := float64(s.config.IngestFileSizeMB)
+	if fileSizeMB <= 0 {
+		fileSizeMB = float64(s.config.TargetFileSizeMB)
+	}
+
+	level := 0
+	if s.config.IngestBehind {
+		level = len(s.lsm.Levels) - 1
+	}
+
+	s.pendingIngestMB += sizeMB
+	for s.pendingIngestMB >= fileSizeMB {
+		if err := s.ingestFile(level, fileSizeMB); err != nil {
+			s.logEvent("[t=%.1fs] INGEST FAILED: %v", s.virtualTime, err)
+			return
+		}
+		s.pendingIngestMB -= fileSizeMB
+	}
+}
+
+// IngestFile adds an externally built SST file directly to the given level,
+// bypassing the WAL, memtable and flush pipeline (bulk load / replica apply).
+//
+// FIDELITY: RocksDB Reference - External SST file ingestion
+// https://github.com/facebook/rocksdb/blob/main/db/external_sst_file_ingestion_job.cc
+//
+// FIDELITY: ✓ File is added to the LSM as-is (no WAL write, no memtable, no flush)
+// FIDELITY: ⚠️ SIMPLIFIED - Caller picks the level; RocksDB picks the lowest non-overlapping level
+// FIDELITY: ⚠️ SIMPLIFIED - Always modeled as a file copy (disk write), never a hard link
+// FIDELITY: ⚠️ SIMPLIFIED - File is visible immediately; the copy only reserves disk bandwidth
+func (s *Simulator) IngestFile(level int, sizeMB float64) error {
+	if err := s.ingestFile(level, sizeMB); err != nil {
+		return err
+	}
+	s.record(journalEntry{Op: "ingest", Level: level, SizeMB: sizeMB})
+	return nil
+}
+
+// ingestFile is IngestFile without the snapshot journal entry (for ingestion-mode traffic,
+// which replays with the steps that produced it)
+func (s *Simulator) ingestFile(level int, sizeMB float64) error {
+	if level < 0 || level >= len(s.lsm.Levels) {
+		return fmt.Errorf("ingest level %d out of range [0, %d)", level, len(s.lsm.Levels))
+	}
+	if sizeMB <= 0 {
+		return fmt.Errorf("ingest size must be > 0, got %.2f MB", sizeMB)
+	}
+
+	// Copying the file into the DB directory contends for disk bandwidth
+	ops := diskOps(1, sizeMB)
+	transferTime := s.levelWriteTime(level, sizeMB, ops)
+	ioDuration := transferTime + s.config.IOLatencyMs/1000.0
+	startTime := max(s.virtualTime, s.diskBusyUntil)
+	completeTime := startTime + ioDuration
+	s.diskBusyUntil = completeTime
+	s.recordDiskTime("ingest", startTime, completeTime)
+	s.metrics.RecordDiskOps(startTime, completeTime, ops)
+	s.metrics.RecordDiskBytes(startTime, completeTime, 0, sizeMB)
+	if s.config.IsColdLevel(level) {
+		s.recordTierDiskTime(startTime, completeTime, ioDuration-transferTime, transferTime)
+	} else {
+		s.recordTierDiskTime(startTime, completeTime, ioDuration, 0)
+	}
+
+	s.lsm.CreateSSTFile(level, sizeMB, s.fileCreatedAt())
+	s.metrics.RecordIngest(level, sizeMB, startTime, completeTime)
+
+	s.logEvent("[t=%.1fs] INGEST: %.1f MB file into L%d", s.virtualTime, sizeMB, level)
+	return nil
+}
+
+// RefitLevels re-fits each level's files into the lowest level they can occupy without
+// overlapping anything, without rewriting any data (RocksDB's ReFitLevel, used by CompactRange
+// with change_level after auto-compactions are disabled and the tree has been manually compacted).
+// Levels are processed bottom-up so a level can follow files that moved before it.
+// Returns the number of files moved; fails while compactions are pending, since refit must not
+// race with jobs that hold references to the files it moves.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - A level can only be re-fitted past levels that are empty, so files
+// move as a whole level (RocksDB's per-file refit by key range isn't modeled). L0 moves only when it is a single sorted run
+// (one sub-level, i.e. no two files overlap); overlapping L0 files stay put.
+// FIDELITY: ✓ Metadata-only operation (MANIFEST edit) - no disk I/O or virtual time
+func (s *Simulator) RefitLevels() (int, error) {
+	if len(s.pendingCompactions) > 0 {
+		return 0, fmt.Errorf("cannot refit levels with %d compactions pending", len(s.pendingCompactions))
+	}
+
+	moved := 0
+	levels := s.lsm.Levels
+	for from := len(levels) - 2; from >= 0; from-- {
+		source := levels[from]
+		if source.FileCount == 0 || (from == 0 && source.SubLevelCount() > 1) {
+			continue
+		}
+
+		// Lowest level reachable through a run of empty levels
+		target := from
+		for target+1 < len(levels) && levels[target+1].FileCount == 0 {
+			target++
+		}
+		if target == from {
+			continue
+		}
+
+		files := source.Files
+		sizeMB := source.TotalSize
+		source.RemoveFiles(files)
+		for _, f := range files {
+			levels[target].AddFile(f)
+		}
+		moved += len(files)
+		s.metrics.RecordRefit(target, len(files), sizeMB)
+		s.logEvent("[t=%.1fs] REFIT: %d files (%.1f MB) L%d → L%d", s.virtualTime, len(files), sizeMB, from, target)
+	}
+	s.record(journalEntry{Op: "refit_levels"})
+	return moved, nil
+}
+
 // processFlush processes a flush event (memtable → L0 SST file)
 //
 // FIDELITY: RocksDB Reference - Flush completion
@@ -907,6 +1963,11 @@ func (s *Simulator) processWrite(event *WriteEvent) {
 //	    (flush takes ~0.1s, compactions take seconds/minutes)
 //	  - Same long-term behavior: compactions still triggered when needed
 func (s *Simulator) processFlush(event *FlushEvent) {
+	if event.ColumnFamily() > 0 {
+		s.processColumnFamilyFlush(event)
+		return
+	}
+
 	// Flush the immutable memtable (with the size that was frozen)
 	// NOT the current active memtable!
 	frozenSizeMB := event.SizeMB()
@@ -914,14 +1975,16 @@ func (s *Simulator) processFlush(event *FlushEvent) {
 		return
 	}
 
-	// Create the L0 SST file with the frozen size
-	file := s.lsm.CreateSSTFile(0, frozenSizeMB, s.virtualTime)
+	// Create the L0 SST file with the frozen size, plus its index and filter blocks
+	file := s.lsm.CreateSSTFile(0, frozenSizeMB*s.config.MetadataOverheadFactor(), s.fileCreatedAt())
+	s.metrics.MetadataBytes += frozenSizeMB * (s.config.MetadataOverheadFactor() - 1)
 
 	// One less immutable memtable (remove the first one - FIFO)
 	s.numImmutableMemtables--
 	if s.numImmutableMemtables < 0 {
 		s.numImmutableMemtables = 0 // Safety check
 	}
+	s.updateWriteSlowdown()
 	if len(s.immutableMemtableSizes) > 0 {
 		// Avoid memory leak: copy to new slice instead of re-slicing
 		// Re-slicing (x = x[1:]) keeps underlying array, causing memory leak
@@ -929,29 +1992,79 @@ func (s *Simulator) processFlush(event *FlushEvent) {
 	}
 
 	// Move from in-progress to completed
-	s.metrics.CompleteWrite(event.Timestamp(), -1) // -1 = flush
+	s.metrics.CompleteWrite(event.Timestamp(), -1, 0) // -1 = flush
 	s.metrics.RecordFlush(file.SizeMB, event.StartTime(), event.Timestamp())
 
 	// Update nextFlushCompletionTime for stalled writes
-	// If still stalled, find the next flush completion time
-	if s.numImmutableMemtables >= s.config.MaxWriteBufferNumber {
-		// Still stalled - find next flush completion time
-		nextFlush := s.queue.FindNextFlushEvent()
-		if nextFlush != nil {
-			s.nextFlushCompletionTime = nextFlush.Timestamp()
-		} else {
-			// No more flushes scheduled - fallback to 1ms retries
-			s.nextFlushCompletionTime = 0
-		}
-	} else {
-		// Stall cleared - no need to track flush completion time
-		s.nextFlushCompletionTime = 0
-	}
+	s.updateNextFlushCompletionTime()
 
 	// Compactions are handled by periodic CompactionCheckEvent, not triggered by flushes
 	// This is acceptable - RocksDB also uses background threads that wake up periodically
 }
 
+// writeWAL appends walSizeMB to the WAL (one write, synced if WALSync is set), reserving disk
+// bandwidth and scheduling its completion
+func (s *Simulator) writeWAL(walSizeMB float64) {
+	// Calculate WAL write duration: sequential write time + optional sync
+	walOps := diskOps(0, walSizeMB)
+	ioTimeSec := s.diskIOTime(walSizeMB, walOps)
+	walDuration := ioTimeSec
+
+	// Add fsync latency if WALSync is enabled
+	if s.config.WALSync {
+		syncTimeSec := s.config.WALSyncLatencyMs / 1000.0
+		walDuration += syncTimeSec
+	}
+
+	// WAL write contends for disk bandwidth
+	walStartTime := max(s.virtualTime, s.diskBusyUntil)
+	walCompleteTime := walStartTime + walDuration
+
+	// Reserve disk bandwidth
+	s.diskBusyUntil = walCompleteTime
+	s.recordDiskTime("wal", walStartTime, walCompleteTime)
+	s.metrics.RecordDiskOps(walStartTime, walCompleteTime, walOps)
+	s.metrics.RecordDiskBytes(walStartTime, walCompleteTime, 0, walSizeMB)
+	s.recordTierDiskTime(walStartTime, walCompleteTime, walDuration, 0)
+
+	// Schedule WAL completion event
+	walEvent := NewWALWriteEvent(walCompleteTime, walStartTime, walSizeMB)
+	s.queue.Push(walEvent)
+
+	// Track WAL bytes (WriteAmplificationWAL, not the LSM's WriteAmplification) and WAL write
+	// activity for disk throughput/utilization calculations
+	// Use Level = -2 to distinguish WAL from flush (-1) and compactions (0+)
+	s.metrics.RecordWALWrite(walStartTime, walCompleteTime, walSizeMB)
+}
+
+// joinWALGroupCommit adds a write's WAL bytes to the open group commit, opening one (closing
+// WALGroupCommitIntervalMs from now) if none is open. The group is written and synced once when
+// it closes, so the sync latency is paid per interval instead of per write.
+//
+// FIDELITY: RocksDB Reference - WriteThread group commit
+// https://github.com/facebook/rocksdb/blob/main/db/write_thread.cc
+// Concurrent writers join a leader, which writes the whole batch group to the WAL with one
+// write and one sync.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Groups close on a fixed interval rather than when the leader's
+// previous write finishes, and writes go into the memtable without waiting for their group's sync
+func (s *Simulator) joinWALGroupCommit(sizeMB float64) {
+	if s.walGroupMB == 0 {
+		s.walGroupCommitAt = s.virtualTime + float64(s.config.WALGroupCommitIntervalMs)/1000.0
+		s.queue.Push(NewWALGroupCommitEvent(s.walGroupCommitAt))
+	}
+	s.walGroupMB += sizeMB
+}
+
+// processWALGroupCommit closes the open group commit, writing everything it gathered as one WAL write
+func (s *Simulator) processWALGroupCommit(event *WALGroupCommitEvent) {
+	sizeMB := s.walGroupMB
+	s.walGroupMB = 0
+	if sizeMB > 0 {
+		s.writeWAL(sizeMB)
+	}
+}
+
 // processWALWrite handles WAL write completion
 func (s *Simulator) processWALWrite(event *WALWriteEvent) {
 	// Note: WAL bytes and activity tracking are done in processWrite() before scheduling this event
@@ -985,7 +2098,7 @@ func (s *Simulator) processScheduleRead(event *ScheduleReadEvent) {
 	}
 
 	// Break down requests by type
-	cacheHits := int(float64(totalRequests) * s.config.ReadWorkload.CacheHitRate)
+	cacheHits := int(float64(totalRequests) * s.config.ReadWorkload.CacheHitRate * s.cacheWarmth())
 	bloomNegatives := int(float64(totalRequests) * s.config.ReadWorkload.BloomNegativeRate)
 	scans := int(float64(totalRequests) * s.config.ReadWorkload.ScanRate)
 	pointLookups := totalRequests - cacheHits - bloomNegatives - scans
@@ -995,11 +2108,22 @@ func (s *Simulator) processScheduleRead(event *ScheduleReadEvent) {
 	// Point lookups read: blockSize * readAmp bytes per request
 	// Scans read: avgScanSizeKB bytes per request
 
+	// Sample the tree's read amplification as this batch sees it
+	s.metrics.RecordReadBatchAmplification(readAmplificationFor(s.lsm, 1+s.numImmutableMemtables, s.config.EnableL0SubLevels))
+
 	// Get read amplification from metrics (calculated from LSM structure)
 	readAmp := s.metrics.ReadAmplification
 	if readAmp < 1.0 {
 		readAmp = 1.0 // At minimum, one file must be read
 	}
+	if s.config.BloomFilterBitsPerKey > 0 && pointLookups > 0 {
+		readAmp = s.sampleBloomAdmittedReadAmp(readAmp, pointLookups)
+		s.metrics.BloomAdmittedReadAmp = readAmp
+	}
+	if pointLookups > 0 {
+		s.sampleReadKeySkew(pointLookups)
+		readAmp *= s.metrics.ReadKeySkewFactor
+	}
 
 	blockSizeMB := float64(s.config.BlockSizeKB) / 1024.0
 	scanSizeMB := s.config.ReadWorkload.AvgScanSizeKB / 1024.0
@@ -1016,7 +2140,14 @@ func (s *Simulator) processScheduleRead(event *ScheduleReadEvent) {
 
 	// Calculate duration based on disk I/O
 	// Duration = data_size / throughput + latency
-	ioTimeSec := totalReadMB / s.config.IOThroughputMBps
+	// Every block a point lookup probes is its own random read; scans stream in diskIORequestMB
+	// requests. With DiskIOPS set, the batch takes at least as long as issuing them.
+	readOps := float64(pointLookups)*readAmp + float64(scans)*math.Ceil(scanSizeMB/diskIORequestMB)
+	hotSec, coldSec := s.readWorkloadTransferTime(totalReadMB)
+	ioTimeSec := hotSec + coldSec
+	if s.config.DiskIOPS > 0 {
+		ioTimeSec = max(ioTimeSec, readOps/s.config.DiskIOPS)
+	}
 	latencySec := s.config.IOLatencyMs / 1000.0
 	readDuration := ioTimeSec + latencySec
 
@@ -1026,6 +2157,11 @@ func (s *Simulator) processScheduleRead(event *ScheduleReadEvent) {
 
 	// Reserve disk bandwidth
 	s.diskBusyUntil = readCompleteTime
+	s.recordDiskTime("read", readStartTime, readCompleteTime)
+	s.metrics.RecordDiskOps(readStartTime, readCompleteTime, readOps)
+	s.metrics.RecordDiskBytes(readStartTime, readCompleteTime, totalReadMB, 0)
+	s.recordTierDiskTime(readStartTime, readCompleteTime, readDuration-coldSec, coldSec)
+	s.recordQueuedReadLatency(readStartTime, readAmp, pointLookups, scans)
 
 	// Schedule read batch completion event
 	readEvent := NewReadBatchEvent(readCompleteTime, readStartTime, totalRequests, pointLookups, scans, cacheHits, bloomNegatives)
@@ -1035,6 +2171,101 @@ func (s *Simulator) processScheduleRead(event *ScheduleReadEvent) {
 	s.scheduleNextScheduleRead(s.virtualTime + readBatchIntervalSec)
 }
 
+// recordQueuedReadLatency records the latency of a batch's disk reads: the wait for the disk to
+// come free (readStartTime), then one seek per sorted run probed plus the request's own transfer.
+//
+// The disk is shared first come, first served: a read batch queues behind flush, compaction and
+// WAL I/O already reserved, and I/O reserved after it queues behind the batch. Batches are issued
+// once a second, so background writes wait at most one batch's worth of reads, and reads wait
+// out whatever the background work reserved (large compactions show up as read latency spikes).
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Requests within a batch don't queue behind one another, and the disk
+// serves one request at a time (no queue depth); RocksDB reads and background I/O interleave at
+// the block level, so real reads wait less behind a large compaction
+func (s *Simulator) recordQueuedReadLatency(readStartTime, readAmp float64, pointLookups, scans int) {
+	queueMs := (readStartTime - s.virtualTime) * 1000
+	seekMs := readAmp * s.config.IOLatencyMs
+	transferMs := func(sizeMB float64) float64 {
+		hotSec, coldSec := s.readWorkloadTransferTime(sizeMB)
+		return (hotSec + coldSec) * 1000
+	}
+	blockSizeMB := float64(s.config.BlockSizeKB) / 1024.0
+	s.metrics.RecordReadLatency(s.virtualTime, queueMs+seekMs+transferMs(blockSizeMB*readAmp), pointLookups)
+	s.metrics.RecordReadLatency(s.virtualTime, queueMs+seekMs+transferMs(s.config.ReadWorkload.AvgScanSizeKB/1024.0), scans)
+}
+
+// sampleBloomAdmittedReadAmp simulates each point lookup against the bloom filters of the runs it
+// would otherwise probe and returns the average number actually probed. The run holding the key
+// is always admitted; every other run is admitted only on a false positive.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Every lookup finds its key in the deepest run; RocksDB stops at the
+// first run holding it, and the memtable (counted as a run here) has no filter
+func (s *Simulator) sampleBloomAdmittedReadAmp(readAmp float64, pointLookups int) float64 {
+	fpr := bloomFilterFPR(s.config.BloomFilterBitsPerKey)
+	otherRuns := int(readAmp) - 1
+	probed := pointLookups // One true-positive probe per lookup
+	for i := 0; i < pointLookups; i++ {
+		for j := 0; j < otherRuns; j++ {
+			if s.rng.Float64() < fpr {
+				probed++
+			}
+		}
+	}
+	return float64(probed) / float64(pointLookups)
+}
+
+// sampleReadKeySkew sets ReadKeySkewFactor and ReadMemtableHitRatio for a batch of point lookups.
+// Keys are laid out by recency: the newest data (memtable, then L0, then down the levels) holds
+// the hottest keys. Under uniform access a lookup lands in a run with probability proportional to
+// its size, so the factor is 1 and the memtable's share of the data is its hit ratio. Under zipfian
+// access each lookup draws a popularity rank from s.rng (seeded from RandomSeed), resolves at the
+// run holding that slice of the data, and the factor is the average depth reached relative to
+// uniform access.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Popularity is pure recency: RocksDB workloads also have hot keys that
+// were written long ago (and sit in the bottom level), which only the block cache serves
+// FIDELITY: ⚠️ SIMPLIFIED - The factor scales the whole point-lookup read amplification; lookups
+// that stop early still pay the bloom false positives of the runs they pass
+func (s *Simulator) sampleReadKeySkew(pointLookups int) {
+	runSizes := sortedRunSizes(s.lsm, s.config.EnableL0SubLevels)
+	totalSize, uniformDepth := uniformFirstHitDepth(runSizes)
+	memtableRun := s.lsm.MemtableCurrentSize > 0 // runSizes[0] is the memtable
+	s.metrics.ReadKeySkewFactor = 1.0
+	s.metrics.ReadMemtableHitRatio = 0
+	if totalSize <= 0 {
+		return
+	}
+	if s.config.ReadWorkload.ReadKeyDistribution != ReadKeyDistributionZipfian {
+		if memtableRun {
+			s.metrics.ReadMemtableHitRatio = runSizes[0] / totalSize
+		}
+		return
+	}
+
+	theta := s.config.ReadWorkload.ZipfianTheta
+	if theta == 0 {
+		theta = defaultZipfianTheta
+	}
+	if s.zipfian == nil || s.zipfian.theta != theta {
+		s.zipfian = newZipfianGenerator(zipfianKeyRanks, theta)
+	}
+	depthSum, memtableHits := 0, 0
+	for i := 0; i < pointLookups; i++ {
+		// Offset of the key's data from the newest end, then the run holding that offset
+		offset := (float64(s.zipfian.Sample(s.rng)) + 0.5) / zipfianKeyRanks * totalSize
+		run := 0
+		for covered := runSizes[0]; covered < offset && run < len(runSizes)-1; covered += runSizes[run] {
+			run++
+		}
+		depthSum += run + 1
+		if run == 0 && memtableRun {
+			memtableHits++
+		}
+	}
+	s.metrics.ReadKeySkewFactor = float64(depthSum) / float64(pointLookups) / uniformDepth
+	s.metrics.ReadMemtableHitRatio = float64(memtableHits) / float64(pointLookups)
+}
+
 // processReadBatch handles read batch completion
 func (s *Simulator) processReadBatch(event *ReadBatchEvent) {
 	// Note: Read metrics are tracked separately by the metrics system
@@ -1063,14 +2294,20 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 		fmt.Printf("[ERROR] No pending compaction job for ID %d (L%d→L%d)\n", compactionID, fromLevel, event.ToLevel())
 		return
 	}
+	if s.config.CompactionFailureRate > 0 && s.rng.Float64() < s.config.CompactionFailureRate {
+		s.retryCompaction(job, event)
+		return
+	}
 	delete(s.pendingCompactions, compactionID)
+	family := s.columnFamily(job.ColumnFamily)
+	lsm := family.lsm
 
 	// Remove from activeCompactionInfos
 	var newInfos []*ActiveCompactionInfo
 	if len(s.activeCompactionInfos) > 0 {
 		newInfos = make([]*ActiveCompactionInfo, 0, len(s.activeCompactionInfos)-1)
 		for _, info := range s.activeCompactionInfos {
-			if info.FromLevel != fromLevel || info.ToLevel != job.ToLevel {
+			if info.ColumnFamily != job.ColumnFamily || info.FromLevel != fromLevel || info.ToLevel != job.ToLevel {
 				newInfos = append(newInfos, info)
 			}
 		}
@@ -1082,22 +2319,22 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 	for _, f := range job.SourceFiles {
 		sourceSize += f.SizeMB
 	}
-	s.lsm.Levels[fromLevel].CompactingSize -= sourceSize
-	if s.lsm.Levels[fromLevel].CompactingSize < 0 {
-		s.lsm.Levels[fromLevel].CompactingSize = 0 // Safety check
+	lsm.Levels[fromLevel].CompactingSize -= sourceSize
+	if lsm.Levels[fromLevel].CompactingSize < 0 {
+		lsm.Levels[fromLevel].CompactingSize = 0 // Safety check
 	}
 
 	// Reduce source level file count
-	s.lsm.Levels[fromLevel].CompactingFileCount -= len(job.SourceFiles)
-	if s.lsm.Levels[fromLevel].CompactingFileCount < 0 {
-		s.lsm.Levels[fromLevel].CompactingFileCount = 0 // Safety check
+	lsm.Levels[fromLevel].CompactingFileCount -= len(job.SourceFiles)
+	if lsm.Levels[fromLevel].CompactingFileCount < 0 {
+		lsm.Levels[fromLevel].CompactingFileCount = 0 // Safety check
 	}
 
 	// Reduce target level file count
-	if job.ToLevel < len(s.lsm.Levels) {
-		s.lsm.Levels[job.ToLevel].TargetCompactingFiles -= len(job.TargetFiles)
-		if s.lsm.Levels[job.ToLevel].TargetCompactingFiles < 0 {
-			s.lsm.Levels[job.ToLevel].TargetCompactingFiles = 0 // Safety check
+	if job.ToLevel < len(lsm.Levels) {
+		lsm.Levels[job.ToLevel].TargetCompactingFiles -= len(job.TargetFiles)
+		if lsm.Levels[job.ToLevel].TargetCompactingFiles < 0 {
+			lsm.Levels[job.ToLevel].TargetCompactingFiles = 0 // Safety check
 		}
 	}
 
@@ -1115,6 +2352,9 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 	if job.IsIntraL0 {
 		compactionType = "L0→L0"
 	}
+	if job.ColumnFamily != 0 {
+		compactionType = family.name + " " + compactionType
+	}
 	s.logEvent("[COMPACTION START] %s: %d src files (%.1f MB) + %d tgt files (%.1f MB) = %.1f MB input",
 		compactionType,
 		len(job.SourceFiles), sourceSize,
@@ -1124,8 +2364,19 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 	// Track start time for duration calculation
 	compactionStartTime := event.StartTime()
 
+	// Snapshot what recompaction tracking needs before the compactor rewrites the levels
+	var recompactedMB float64
+	var priorTargetFiles map[*SSTFile]bool
+	if s.config.RecompactionWindowSeconds > 0 {
+		recompactedMB = s.recentlyCompactedInputMB(job)
+		priorTargetFiles = make(map[*SSTFile]bool, len(lsm.Levels[job.ToLevel].Files))
+		for _, f := range lsm.Levels[job.ToLevel].Files {
+			priorTargetFiles[f] = true
+		}
+	}
+
 	// Execute the compaction using the compactor interface
-	inputSize, outputSize, outputFileCount := s.compactor.ExecuteCompaction(job, s.lsm, s.config, s.virtualTime)
+	inputSize, outputSize, outputFileCount := family.compactor.ExecuteCompaction(job, lsm, family.config, s.virtualTime)
 
 	if inputSize == 0 {
 		return
@@ -1133,7 +2384,16 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 
 	// Update LSM total size (critical for FIFO compaction which manipulates files directly)
 	// For leveled/universal, this is redundant with lsm.CompactLevel(), but harmless
-	s.lsm.TotalSizeMB = s.lsm.TotalSizeMB - inputSize + outputSize
+	lsm.TotalSizeMB = lsm.TotalSizeMB - inputSize + outputSize
+	if job.ColumnFamily == 0 {
+		s.metrics.compactionDroppedMB += inputSize - outputSize
+	}
+	if job.ColumnFamily == 0 && family.config.CompactionStyle == CompactionStyleFIFO && !job.IsIntraL0 {
+		s.metrics.FIFODroppedMB += inputSize
+		if job.Reason == "ttl" {
+			s.metrics.FIFOTTLDroppedMB += inputSize
+		}
+	}
 
 	// Calculate compaction duration and throughput
 	compactionDuration := event.Timestamp() - compactionStartTime
@@ -1163,11 +2423,54 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 	// Update metrics with last compaction performance
 	s.metrics.LastCompactionDurationSec = compactionDuration
 	s.metrics.LastCompactionThroughputMBps = compactionThroughput
+	if !isTrivialMove {
+		s.metrics.RecordCompactionThroughputByDepth(isBottommostCompaction(lsm, job), inputSize, compactionDuration)
+	}
+	if len(s.compactionHistory) >= maxCompactionHistory {
+		s.compactionHistory = s.compactionHistory[1:] // Drop the oldest record
+	}
+	s.compactionHistory = append(s.compactionHistory, CompactionRecord{
+		ColumnFamily: job.ColumnFamily,
+		StartTime:    compactionStartTime,
+		EndTime:      event.Timestamp(),
+		FromLevel:    fromLevel,
+		ToLevel:      job.ToLevel,
+		InputMB:      inputSize,
+		OutputMB:     outputSize,
+		TrivialMove:  isTrivialMove,
+		Reason:       compactionReason(job),
+	})
 
 	// Move from in-progress to completed
-	s.metrics.CompleteWrite(event.Timestamp(), fromLevel)
+	s.metrics.CompleteWrite(event.Timestamp(), fromLevel, job.ColumnFamily)
 	inputFileCount := len(job.SourceFiles) + len(job.TargetFiles)
-	s.metrics.RecordCompaction(inputSize, outputSize, event.StartTime(), event.Timestamp(), fromLevel, inputFileCount, outputFileCount, isTrivialMove)
+	s.metrics.RecordCompaction(inputSize, outputSize, event.StartTime(), event.Timestamp(), fromLevel, inputFileCount, outputFileCount, isTrivialMove, job.ColumnFamily)
+	if job.IsIntraL0 && job.ColumnFamily == 0 {
+		s.metrics.IntraL0Compactions++
+		s.metrics.IntraL0BytesMB += inputSize
+	}
+	if job.ExtraOutputFiles > 0 {
+		s.metrics.BoundarySplitCompactions++
+		s.metrics.BoundarySplitExtraFiles += job.ExtraOutputFiles
+	}
+	if s.config.BackupBandwidthMBps > 0 {
+		s.metrics.BackupBytesShipped += event.OutputSizeMB() // The scheduled estimate is what went over the link
+	}
+	// Inputs already carry their metadata and size factors scale whole files, so the output holds
+	// the same share of rewritten index/filter blocks without compounding
+	if !isTrivialMove && outputSize > 0 {
+		factor := s.config.MetadataOverheadFactor()
+		s.metrics.MetadataBytes += outputSize * (factor - 1) / factor
+	}
+	// Trivial moves and FIFO deletions rewrite nothing, so they neither waste nor produce compaction work
+	if priorTargetFiles != nil && !isTrivialMove && outputFileCount > 0 && outputSize > 0 {
+		s.metrics.RecompactionBytes += recompactedMB
+		for _, f := range lsm.Levels[job.ToLevel].Files {
+			if !priorTargetFiles[f] {
+				f.CompactedAt = s.virtualTime
+			}
+		}
+	}
 
 	// DON'T immediately schedule another compaction after this one completes
 	// Compactions are scheduled by periodic CompactionCheckEvent (background threads)
@@ -1175,7 +2478,24 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 	// another compaction; the background scheduler checks periodically.
 }
 
-// tryScheduleCompaction tries to schedule a compaction if resources are available
+// recentlyCompactedInputMB returns the MB of a job's input files that an earlier compaction wrote
+// within RecompactionWindowSeconds: that earlier work is about to be redone.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Whole files count; RocksDB rewrites only the overlapping key ranges
+func (s *Simulator) recentlyCompactedInputMB(job *CompactionJob) float64 {
+	var recompactedMB float64
+	for _, files := range [][]*SSTFile{job.SourceFiles, job.TargetFiles} {
+		for _, f := range files {
+			if f.CompactedAt > 0 && s.virtualTime-f.CompactedAt < s.config.RecompactionWindowSeconds {
+				recompactedMB += f.SizeMB
+			}
+		}
+	}
+	return recompactedMB
+}
+
+// tryScheduleCompaction tries to schedule a compaction of a column family's tree if resources
+// are available
 //
 // RocksDB Reference: DBImpl::BackgroundCompaction() and PickCompaction()
 // See: db/db_impl/db_impl_compaction_flush.cc
@@ -1184,25 +2504,59 @@ func (s *Simulator) processCompaction(event *CompactionEvent) {
 // - Respects max_background_jobs parallelism limit
 // - Picks highest-scoring level (most urgent based on size/file count)
 // - Schedules compaction job for execution when disk becomes available
-func (s *Simulator) tryScheduleCompaction() bool {
+func (s *Simulator) tryScheduleCompaction(family *columnFamily) bool {
 	// Check if we've hit max parallel compactions
 	// RocksDB's max_background_jobs limits concurrent compaction threads
+	// An urgent L0 compaction may still queue for the next slot to free up
+	urgent := false
 	if len(s.pendingCompactions) >= s.config.MaxBackgroundJobs {
+		if !s.needsUrgentL0Compaction(family) {
+			return false
+		}
+		urgent = true
+	}
+
+	// Byte-based concurrency limit: many large concurrent compactions pressure memory and I/O
+	// even when the job count is within max_background_jobs
+	activeBytes := s.activeCompactionInputMB()
+	s.metrics.ActiveCompactionBytesMB = activeBytes
+	if s.config.MaxActiveCompactionBytesMB > 0 && activeBytes >= float64(s.config.MaxActiveCompactionBytesMB) {
+		s.metrics.CompactionsBlockedByActiveBytes++
 		return false
 	}
 
-	// Delegate compaction scheduling logic to the compactor
-	// Compactor internally tracks active compactions and picks the best compaction
-	job := s.compactor.PickCompaction(s.lsm, s.config)
+	// A requested manual compaction goes first and holds back automatic ones until it is done
+	job, blocked := s.pickManualCompaction(family)
+	if blocked && (job == nil || urgent) {
+		return false // Waiting on the manual compaction, or on a free slot for it
+	}
+	if job == nil {
+		// Delegate compaction scheduling logic to the compactor
+		// Compactor internally tracks active compactions and picks the best compaction
+		if clocked, ok := family.compactor.(clockedCompactor); ok {
+			clocked.setVirtualTime(s.fileClock())
+		}
+		restore := checkpointCompactor(family.compactor)
+		job = s.pickCompaction(family)
+		// Only an L0 job may queue past MaxBackgroundJobs: when L0 can't be picked (paused, or
+		// its target too busy), the compactor's next-best job waits for a free slot instead
+		if urgent && job != nil && job.FromLevel != 0 {
+			restore()
+			return false
+		}
+	}
 	if job == nil {
 		return false // No compaction needed
 	}
+	if !s.resolveBusyTargetFiles(family, job) {
+		return false // Deferred until the running compaction releases its files
+	}
 
 	// Check if we've hit max parallel compactions
 	// For now, we approximate by checking if we have too many pending compactions
 	// TODO: Compactor should track this internally and return nil when at capacity
 	activeCount := len(s.pendingCompactions)
-	if activeCount >= s.config.MaxBackgroundJobs {
+	if activeCount >= s.config.MaxBackgroundJobs && !urgent {
 		// Can't schedule more - but compactor should have prevented this
 		// If we get here, there's a bug: compactor returned a job when at capacity
 		fmt.Printf("[WARNING] PickCompaction returned job but at max capacity (%d/%d)\n", activeCount, s.config.MaxBackgroundJobs)
@@ -1211,25 +2565,100 @@ func (s *Simulator) tryScheduleCompaction() bool {
 
 	fmt.Printf("[SCHEDULE] t=%.1f: L%d→L%d: scheduling compaction with %d source files, %d target files\n",
 		s.virtualTime, job.FromLevel, job.ToLevel, len(job.SourceFiles), len(job.TargetFiles))
+	if job.IsFollowUp {
+		s.metrics.FollowUpCompactions++
+	}
+	if job.Reason == manualCompactionReason {
+		s.metrics.ManualCompactions++
+	}
+	if job.Reason == "size_amplification_age" {
+		s.metrics.AgeTriggeredSizeAmpCompactions++
+	}
+	if job.IsSmallFileMerge {
+		s.metrics.SmallFileMerges++
+	}
+	if job.Coverage > 0 {
+		s.metrics.RecordJobCoverage(job.Coverage)
+	}
+	// A level scheduled before any check saw it waiting waited 0s
+	key := compactionNeed{columnFamily: family.id, level: job.FromLevel}
+	var waitSeconds float64
+	if since, ok := s.compactionNeededSince[key]; ok {
+		waitSeconds = s.virtualTime - since
+		delete(s.compactionNeededSince, key)
+	}
+	s.metrics.RecordCompactionWait(waitSeconds)
 
 	// Calculate input and output sizes
-	var inputSize float64
+	var sourceSize float64
 	for _, f := range job.SourceFiles {
-		inputSize += f.SizeMB
+		sourceSize += f.SizeMB
 	}
+	inputSize := sourceSize
 	for _, f := range job.TargetFiles {
 		inputSize += f.SizeMB
 	}
 
-	// Apply reduction factors (deduplication + compression)
-	var deduplicationFactor float64
-	if job.FromLevel == 0 && job.ToLevel == 1 {
-		deduplicationFactor = s.config.DeduplicationFactor
+	// Garbage still costs read I/O and decompression below; only the write side shrinks
+	outputSize := estimateCompactionOutput(family.config, job, inputSize)
+
+	// Trivial moves only repoint file metadata: no CPU work and no disk bandwidth to reserve
+	var cpuStartTime, completionTime float64
+	subcompactions := 1
+	if mover, ok := family.compactor.(trivialMover); ok && mover.isTrivialMove(job, family.lsm) {
+		outputSize = inputSize
+		cpuStartTime, completionTime = s.virtualTime, s.virtualTime
 	} else {
-		deduplicationFactor = 0.99 // Minimal dedup for deeper levels
+		cpuStartTime, completionTime, subcompactions = s.submitCompaction(family, job, sourceSize, inputSize, outputSize, urgent)
 	}
-	outputSize := inputSize * deduplicationFactor * s.config.CompressionFactor
 
+	// Compactor handles activeCompactions tracking (marked in PickCompaction)
+
+	// Track detailed compaction info for UI
+	info := &ActiveCompactionInfo{
+		ColumnFamily:    family.id,
+		FromLevel:       job.FromLevel,
+		ToLevel:         job.ToLevel,
+		SourceFileCount: len(job.SourceFiles),
+		TargetFileCount: len(job.TargetFiles),
+		IsIntraL0:       job.FromLevel == 0 && job.ToLevel == 0,
+		Subcompactions:  subcompactions,
+	}
+	s.activeCompactionInfos = append(s.activeCompactionInfos, info)
+
+	// Track compacting bytes and file counts for accurate score calculation and overlap detection
+	// Source files are being compacted FROM this level
+	family.lsm.Levels[job.FromLevel].CompactingSize += sourceSize
+	family.lsm.Levels[job.FromLevel].CompactingFileCount += len(job.SourceFiles)
+
+	// Target files are being used as overlap targets at the TO level
+	if job.ToLevel < len(family.lsm.Levels) {
+		family.lsm.Levels[job.ToLevel].TargetCompactingFiles += len(job.TargetFiles)
+	}
+
+	// Assign unique compaction ID
+	compactionID := s.nextCompactionID
+	s.nextCompactionID++
+	job.ID = compactionID
+	job.ColumnFamily = family.id
+
+	// Store the job so we can execute it when the event fires (keyed by compaction ID, not fromLevel)
+	s.pendingCompactions[compactionID] = job
+
+	// Track this write as in-progress for throughput calculation
+	s.metrics.StartWrite(inputSize, outputSize, cpuStartTime, completionTime, job.FromLevel, job.ToLevel, family.id)
+
+	// Schedule compaction event
+	compactionEvent := NewCompactionEvent(completionTime, cpuStartTime, compactionID, job.FromLevel, job.ToLevel, inputSize, outputSize)
+	s.queue.Push(compactionEvent)
+
+	return true
+}
+
+// submitCompaction charges a scheduled compaction of a column family's tree for its CPU and I/O on
+// the background pool and the disk, returning when it starts on a worker, when it completes and how many subcompactions it
+// runs as
+func (s *Simulator) submitCompaction(family *columnFamily, job *CompactionJob, sourceSize, inputSize, outputSize float64, urgent bool) (cpuStartTime, completionTime float64, subcompactions int) {
 	// Calculate compaction duration using TWO-PHASE MODEL
 	// Phase 1 (CPU): Decompress input + build output SSTable (merge, compress, bloom, index)
 	// Phase 2 (I/O): Read input + write output to disk
@@ -1246,63 +2675,472 @@ func (s *Simulator) tryScheduleCompaction() bool {
 		sstableBuildTimeSec = outputSize / s.config.SSTableBuildThroughputMBps
 	}
 
-	// CPU phase: decompress + build (sequential CPU work)
-	cpuDuration := decompressTimeSec + sstableBuildTimeSec
+	// CPU phase: fixed setup + decompress + build (sequential CPU work)
+	// Setup doesn't shrink with job size, so it dominates many tiny compactions
+	setupTimeSec := s.config.CompactionSetupLatencyMs / 1000.0
+	s.metrics.CompactionSetupSeconds += setupTimeSec
+	// Subcompactions split the key range into equal pieces processed on parallel threads, so the
+	// CPU work takes as long as the slowest (any one) piece
+	subcompactions = subcompactionCount(family.config, job)
+	cpuWorkSec := decompressTimeSec + sstableBuildTimeSec
+	cpuDuration := setupTimeSec + cpuWorkSec/float64(subcompactions)
+	if subcompactions > 1 {
+		s.metrics.SubcompactionJobs++
+		s.metrics.SubcompactionSecondsSaved += cpuWorkSec - cpuWorkSec/float64(subcompactions)
+	}
 
 	// I/O phase: read + write + seek
-	readIOTimeSec := inputSize / s.config.IOThroughputMBps
-	writeIOTimeSec := outputSize / s.config.IOThroughputMBps
+	// Input blocks already in the block cache don't need to be read from disk
+	warmInputMB := inputSize * s.compactionCacheFraction()
+	// Each input file is opened and read unless it is entirely cached; outputs are cut at the
+	// target file size
+	readFiles := len(job.SourceFiles) + len(job.TargetFiles)
+	if warmInputMB >= inputSize {
+		readFiles = 0
+	}
+	readOps := diskOps(readFiles, inputSize-warmInputMB)
+	var writeFiles int
+	if outputSize > 0 {
+		writeFiles = max(1, int(math.Ceil(outputSize/targetFileSizeForLevel(job.ToLevel, family.config))))
+	}
+	writeOps := diskOps(writeFiles, outputSize)
+	sourceShare := 1.0
+	if inputSize > 0 {
+		sourceShare = sourceSize / inputSize
+	}
+	readIOTimeSec, writeIOTimeSec, coldIOTimeSec := s.compactionIOTime(job, sourceShare, inputSize-warmInputMB, readOps, outputSize, writeOps)
 	seekTimeSec := s.config.IOLatencyMs / 1000.0
+	// Subcompactions share the disk: each of n pieces gets 1/n of the bandwidth, so the slowest
+	// piece's I/O takes as long as the whole job's would on its own
 	ioDuration := readIOTimeSec + writeIOTimeSec + seekTimeSec
+	s.metrics.WarmCompactionBytes += warmInputMB
 
-	// Allocate a background job slot
+	// Submit to the background pool
+	// Compactions into the deepest level may be limited to a fraction of the disk bandwidth
 	arrivalTime := s.virtualTime
-	_, cpuStartTime, _, completionTime := s.allocateJobSlot(arrivalTime, cpuDuration, ioDuration)
+	ioShare := 1.0
+	if isBottommostCompaction(family.lsm, job) && s.config.BottommostCompactionIOPriority > 0 {
+		ioShare = s.config.BottommostCompactionIOPriority
+	}
+	// The rate limiter holds the job until earlier compactions' bytes are paid for, then caps its
+	// transfer at CompactionRateLimitMBps (leaving the rest of the disk to flushes and the WAL)
+	rateLimitedMB := inputSize - warmInputMB + outputSize
+	if s.config.CompactionRateLimitMBps > 0 {
+		arrivalTime = s.acquireCompactionRateLimit(s.virtualTime, rateLimitedMB)
+		s.metrics.CompactionRateLimitDelaySeconds += arrivalTime - s.virtualTime
+		if limitedDuration := rateLimitedMB / float64(s.config.CompactionRateLimitMBps); limitedDuration > ioDuration {
+			ioShare = min(ioShare, ioDuration/limitedDuration)
+		}
+	}
+	// CPU ceiling: the merge streams input through the CPU while the I/O runs, so a job whose CPU
+	// threads can't keep up with the disk takes as long as its CPU work instead
+	ioTime := ioDuration / ioShare
+	mergeCPUSec, cpuThreads := s.compactionMergeCPU(inputSize, subcompactions)
+	if mergeCPUSec > ioTime {
+		cpuDuration += mergeCPUSec - ioTime
+	}
+	cpuStartTime, completionTime = s.submitThrottledBackgroundTask(BackgroundTaskCompaction, arrivalTime, cpuDuration, ioDuration, ioShare)
+	if mergeCPUSec > 0 {
+		mergeTime := max(mergeCPUSec, ioTime)
+		s.metrics.RecordCompactionCPU(completionTime-mergeTime, completionTime, cpuThreads*mergeCPUSec)
+	}
+	s.metrics.RecordDiskOps(completionTime-ioDuration/ioShare, completionTime, readOps+writeOps)
+	s.metrics.RecordDiskBytes(completionTime-ioDuration/ioShare, completionTime, inputSize-warmInputMB, outputSize)
+	s.recordTierDiskTime(completionTime-ioDuration/ioShare, completionTime, ioDuration-coldIOTimeSec, coldIOTimeSec)
+	if s.config.CompactionRateLimitMBps > 0 {
+		s.metrics.RecordCompactionRateLimited(completionTime-ioDuration/ioShare, completionTime, rateLimitedMB)
+	}
+	completionTime = s.shipToBackup(outputSize, cpuStartTime, completionTime)
+	if urgent {
+		wait := cpuStartTime - arrivalTime
+		s.metrics.UrgentCompactionWaitSeconds += wait
+		s.logEvent("[t=%.1fs] URGENT COMPACTION: L%d→L%d queued behind busy slots, starts in %.2fs (L0 has %d files)",
+			s.virtualTime, job.FromLevel, job.ToLevel, wait, family.lsm.Levels[0].FileCount)
+	}
+	return cpuStartTime, completionTime, subcompactions
+}
 
-	// Compactor handles activeCompactions tracking (marked in PickCompaction)
+// compactionMergeCPU returns how long a compaction's merge keeps its CPU threads busy and how many
+// threads it gets: one per subcompaction, at most an equal share of the CompactionThreads pool
+// among the scheduled compactions (0, 0 when CompactionMBPerCPUSec is 0)
+//
+// FIDELITY: ⚠️ SIMPLIFIED - The share is fixed when the job is scheduled; RocksDB's threads are
+// scheduled by the OS, so a job speeds up as others finish
+// FIDELITY: ⚠️ NOT IN ROCKSDB - RocksDB has no CPU throughput setting; merge and compression speed
+// come from the hardware and the compression type
+func (s *Simulator) compactionMergeCPU(inputSize float64, subcompactions int) (seconds, threads float64) {
+	if s.config.CompactionMBPerCPUSec <= 0 || inputSize <= 0 {
+		return 0, 0
+	}
+	threads = min(float64(subcompactions), float64(s.config.CompactionThreads())/float64(len(s.pendingCompactions)+1))
+	return inputSize / (float64(s.config.CompactionMBPerCPUSec) * threads), threads
+}
 
-	// Track detailed compaction info for UI
-	info := &ActiveCompactionInfo{
-		FromLevel:       job.FromLevel,
-		ToLevel:         job.ToLevel,
-		SourceFileCount: len(job.SourceFiles),
-		TargetFileCount: len(job.TargetFiles),
-		IsIntraL0:       job.FromLevel == 0 && job.ToLevel == 0,
+// subcompactionCount returns how many parallel subcompactions a job under config runs as: up to
+// MaxSubcompactions for the jobs RocksDB splits, one per input file at most.
+//
+// RocksDB Reference: Compaction::ShouldFormSubcompactions() (db/compaction/compaction.cc)
+// forms subcompactions for leveled L0→base-level jobs and universal jobs that output below L0.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB cuts pieces at key-range boundaries sampled from the input
+// files, so they are rarely equal; here every piece is the same size
+// FIDELITY: ⚠️ SIMPLIFIED - The extra threads don't take background job slots (RocksDB reserves
+// them from the compaction thread pool when it can)
+func subcompactionCount(config SimConfig, job *CompactionJob) int {
+	if config.MaxSubcompactions <= 1 || job.IsIntraL0 || job.ToLevel == 0 {
+		return 1
 	}
-	s.activeCompactionInfos = append(s.activeCompactionInfos, info)
+	switch config.CompactionStyle {
+	case CompactionStyleLeveled:
+		if job.FromLevel != 0 {
+			return 1
+		}
+	case CompactionStyleUniversal:
+	default:
+		return 1
+	}
+	return max(1, min(config.MaxSubcompactions, len(job.SourceFiles)+len(job.TargetFiles)))
+}
 
-	// Track compacting bytes and file counts for accurate score calculation and overlap detection
-	// Source files are being compacted FROM this level
-	var sourceSize float64
+// pickCompaction asks a column family's compactor for its next compaction. Under the min_read_amp objective a
+// leveled compactor is first asked with every level below L0 held back, so a due L0 compaction
+// (to the base level or intra-L0) runs ahead of higher-scoring deeper levels; only when L0 has
+// nothing to do is the score-driven pick taken. This is an L0-priority switch, not a per-candidate
+// read amplification estimate: ReadAmplification counts each level below L0 once whether or not
+// it is compacted, so L0 sorted runs are the only ones a compaction can remove.
+//
+// FIDELITY: ⚠️ NOT IN ROCKSDB - RocksDB always compacts the highest-scoring level; this objective
+// trades deeper-level debt (and write amplification) for fewer sorted runs per lookup
+func (s *Simulator) pickCompaction(family *columnFamily) *CompactionJob {
+	if family.config.CompactionObjective != CompactionObjectiveMinReadAmp || family.config.CompactionStyle != CompactionStyleLeveled {
+		return family.compactor.PickCompaction(family.lsm, family.config)
+	}
+	deeper := family.lsm.Levels[1:]
+	paused := make([]bool, len(deeper))
+	for i, level := range deeper {
+		paused[i] = level.CompactionPaused
+		level.CompactionPaused = true
+	}
+	job := family.compactor.PickCompaction(family.lsm, family.config)
+	for i, level := range deeper {
+		level.CompactionPaused = paused[i]
+	}
+	if job == nil {
+		return family.compactor.PickCompaction(family.lsm, family.config)
+	}
+	if job.FromLevel == 0 && !job.IsFollowUp {
+		for i := range deeper {
+			if !paused[i] && family.compactor.NeedsCompaction(i+1, family.lsm, family.config) {
+				s.metrics.ReadAmpObjectiveOverrides++
+				break
+			}
+		}
+	}
+	return job
+}
+
+// estimateCompactionOutput estimates the output of a job reading inputSize MB by applying
+// config's reduction factors (deduplication + compression + dropped garbage). This is the
+// scheduling estimate that sizes the job's I/O; ExecuteCompaction computes the actual output.
+func estimateCompactionOutput(config SimConfig, job *CompactionJob, inputSize float64) float64 {
+	return inputSize * config.CompactionReductionFactor(job.FromLevel) * config.CompressionFactor * (1 - config.CompactionGarbageFraction) *
+		config.RecompressionRatio(job.FromLevel, job.ToLevel)
+}
+
+// CompactionPreview describes the compaction the compactor would pick next (see PeekCompaction)
+type CompactionPreview struct {
+	FromLevel         int     `json:"fromLevel"`
+	ToLevel           int     `json:"toLevel"`
+	SourceFiles       int     `json:"sourceFiles"`       // Files compacted out of FromLevel
+	TargetFiles       int     `json:"targetFiles"`       // Overlapping files in ToLevel rewritten with them
+	InputMB           float64 `json:"inputMB"`           // Source + target file bytes
+	EstimatedOutputMB float64 `json:"estimatedOutputMB"` // Scheduling estimate after dedup, compression and dropped garbage
+	IsIntraL0         bool    `json:"isIntraL0"`
+	Reason            string  `json:"reason"`        // Why the job would be picked (see compactionReason)
+	SlotAvailable     bool    `json:"slotAvailable"` // A background job slot is free, so the job would start on the next scheduling pass
+	RunningCount      int     `json:"runningCount"`  // Compactions already running
+}
+
+// PeekCompaction returns the compaction the default column family's compactor would pick next,
+// or nil if none is due, without claiming it: the compactor's bookkeeping and RNG positions are
+// put back afterwards and its picking debug output is silenced, so peeking doesn't change what
+// the run does next or log a compaction that never happens. Scheduling limits
+// (background slots, maxActiveCompactionBytesMB, busy target files) aren't applied;
+// SlotAvailable reports whether a slot is free.
+func (s *Simulator) PeekCompaction() *CompactionPreview {
+	family := s.columnFamily(0)
+	defer checkpointCompactor(family.compactor)()
+	if quiet, ok := family.compactor.(quietPicker); ok {
+		quiet.setQuiet(true)
+		defer quiet.setQuiet(false)
+	}
+	defer func(overrides int) { s.metrics.ReadAmpObjectiveOverrides = overrides }(s.metrics.ReadAmpObjectiveOverrides)
+
+	if clocked, ok := family.compactor.(clockedCompactor); ok {
+		clocked.setVirtualTime(s.fileClock())
+	}
+	job := s.pickCompaction(family)
+	if job == nil {
+		return nil
+	}
+	var inputSize float64
 	for _, f := range job.SourceFiles {
-		sourceSize += f.SizeMB
+		inputSize += f.SizeMB
+	}
+	for _, f := range job.TargetFiles {
+		inputSize += f.SizeMB
+	}
+	return &CompactionPreview{
+		FromLevel:         job.FromLevel,
+		ToLevel:           job.ToLevel,
+		SourceFiles:       len(job.SourceFiles),
+		TargetFiles:       len(job.TargetFiles),
+		InputMB:           inputSize,
+		EstimatedOutputMB: estimateCompactionOutput(family.config, job, inputSize),
+		IsIntraL0:         job.IsIntraL0,
+		Reason:            compactionReason(job),
+		SlotAvailable:     len(s.pendingCompactions) < s.config.MaxBackgroundJobs,
+		RunningCount:      len(s.pendingCompactions),
 	}
-	s.lsm.Levels[job.FromLevel].CompactingSize += sourceSize
-	s.lsm.Levels[job.FromLevel].CompactingFileCount += len(job.SourceFiles)
+}
 
-	// Target files are being used as overlap targets at the TO level
-	if job.ToLevel < len(s.lsm.Levels) {
-		s.lsm.Levels[job.ToLevel].TargetCompactingFiles += len(job.TargetFiles)
+// checkpointCompactor saves compactor's bookkeeping and RNG positions and returns a function
+// that puts them back, undoing every pick made in between
+func checkpointCompactor(compactor Compactor) (restore func()) {
+	restoreBookkeeping := func() {}
+	if checkpointer, ok := compactor.(compactionCheckpointer); ok {
+		restoreBookkeeping = checkpointer.checkpoint()
+	}
+	sources := componentSources(compactor)
+	positions := sources.positions()
+	return func() {
+		restoreBookkeeping()
+		sources.setPositions(positions)
 	}
+}
 
-	// Assign unique compaction ID
-	compactionID := s.nextCompactionID
-	s.nextCompactionID++
-	job.ID = compactionID
+// resolveBusyTargetFiles makes sure none of the target files of a job on family's tree is already
+// an input of a running
+// compaction (e.g. the source of a deeper job), which would merge the same bytes twice. With
+// RepickBusyTargetFiles the busy files are swapped for free files of the target level; otherwise,
+// or when there aren't enough free files, the job is handed back to the compactor and false is
+// returned so it is picked again once the running compaction finishes. A job whose source files
+// are busy (e.g. the targets of a shallower job) is always deferred: sources are never re-picked.
+//
+// FIDELITY: ✓ RocksDB won't start a compaction whose output-level inputs are being compacted
+// (AreFilesInCompaction in compaction_picker.cc); the pick is abandoned and retried later
+// FIDELITY: ⚠️ NOT IN ROCKSDB - Re-picking: real target files are fixed by key range; re-picking
+// stands in for a picker that chooses a different, non-conflicting key range instead
+func (s *Simulator) resolveBusyTargetFiles(family *columnFamily, job *CompactionJob) bool {
+	busy := s.runningCompactionInputs()
+	conflicts := 0
+	for _, f := range job.TargetFiles {
+		if busy[f] {
+			conflicts++
+		}
+	}
+	sourceBusy := false
+	for _, f := range job.SourceFiles {
+		sourceBusy = sourceBusy || busy[f]
+	}
+	if conflicts == 0 && !sourceBusy {
+		return true
+	}
 
-	// Store the job so we can execute it when the event fires (keyed by compaction ID, not fromLevel)
-	s.pendingCompactions[compactionID] = job
+	if s.config.RepickBusyTargetFiles && !s.config.UseKeyRangeOverlap && !sourceBusy {
+		if targets, ok := repickTargetFiles(job, family.lsm.Levels[job.ToLevel].Files, busy); ok {
+			job.TargetFiles = targets
+			s.metrics.RepickedBusyTargetFiles += conflicts
+			return true
+		}
+	}
+	if releaser, ok := family.compactor.(jobReleaser); ok {
+		releaser.releaseJob(job)
+	}
+	s.metrics.CompactionsDeferredForBusyTargets++
+	return false
+}
 
-	// Track this write as in-progress for throughput calculation
-	s.metrics.StartWrite(inputSize, outputSize, cpuStartTime, completionTime, job.FromLevel, job.ToLevel)
+// runningCompactionInputs returns every source and target file of the scheduled compactions
+func (s *Simulator) runningCompactionInputs() map[*SSTFile]bool {
+	inputs := make(map[*SSTFile]bool)
+	for _, job := range s.pendingCompactions {
+		for _, f := range job.SourceFiles {
+			inputs[f] = true
+		}
+		for _, f := range job.TargetFiles {
+			inputs[f] = true
+		}
+	}
+	return inputs
+}
 
-	// Schedule compaction event
-	compactionEvent := NewCompactionEvent(completionTime, cpuStartTime, compactionID, job.FromLevel, job.ToLevel, inputSize, outputSize)
-	s.queue.Push(compactionEvent)
+// repickTargetFiles returns job's target files with the busy ones replaced by free files of the
+// target level (in level order, skipping the job's own files), or false if the level doesn't have
+// enough free files to keep the overlap count
+func repickTargetFiles(job *CompactionJob, levelFiles []*SSTFile, busy map[*SSTFile]bool) ([]*SSTFile, bool) {
+	chosen := make(map[*SSTFile]bool, len(job.SourceFiles)+len(job.TargetFiles))
+	for _, f := range job.SourceFiles {
+		chosen[f] = true
+	}
+	targets := make([]*SSTFile, 0, len(job.TargetFiles))
+	for _, f := range job.TargetFiles {
+		chosen[f] = true
+		if !busy[f] {
+			targets = append(targets, f)
+		}
+	}
+	for _, f := range levelFiles {
+		if len(targets) == len(job.TargetFiles) {
+			break
+		}
+		if !busy[f] && !chosen[f] {
+			chosen[f] = true
+			targets = append(targets, f)
+		}
+	}
+	return targets, len(targets) == len(job.TargetFiles)
+}
+
+// isBottommostCompaction reports whether a job writes into the deepest level of lsm
+//
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB's bottommost level is the deepest one holding data (nothing
+// below the output's key range); here it is always the last configured level
+func isBottommostCompaction(lsm *LSMTree, job *CompactionJob) bool {
+	return job.ToLevel == len(lsm.Levels)-1
+}
+
+// compactionRateLimitRefillSeconds is the rate limiter's refill period: the budget holds at most
+// this many seconds' worth of bytes (RocksDB refill_period_us default: 100 ms)
+const compactionRateLimitRefillSeconds = 0.1
+
+// acquireCompactionRateLimit charges sizeMB of compaction I/O arriving at arrivalTime against the
+// CompactionRateLimitMBps budget and returns when the job may start. The budget refills
+// continuously over virtual time up to one refill period's worth; a job may overdraw it, and the
+// debt holds back the next job until it is repaid, so compaction I/O averages at most the limit.
+//
+// FIDELITY: RocksDB Reference - GenericRateLimiter (util/rate_limiter.cc)
+// https://github.com/facebook/rocksdb/blob/main/util/rate_limiter.cc
+//
+// FIDELITY: ⚠️ SIMPLIFIED - A job is charged for all its bytes up front instead of per write
+// request, and flushes aren't charged (RocksDB charges them at high priority)
+func (s *Simulator) acquireCompactionRateLimit(arrivalTime, sizeMB float64) float64 {
+	rate := float64(s.config.CompactionRateLimitMBps)
+	if rate <= 0 {
+		return arrivalTime
+	}
+	now := max(arrivalTime, s.compactionRateUpdatedAt)
+	tokens := min(rate*compactionRateLimitRefillSeconds, s.compactionRateTokensMB+rate*(now-s.compactionRateUpdatedAt))
+	startTime := now
+	if tokens < 0 {
+		startTime += -tokens / rate
+		tokens = 0
+	}
+	s.compactionRateTokensMB = tokens - sizeMB
+	s.compactionRateUpdatedAt = startTime
+	return startTime
+}
+
+// shipToBackup queues a compaction's output on the backup link and returns when the compaction
+// can complete: the later of its local write and the end of the transfer. Shipping streams as
+// the output is built, so it starts with the job but waits behind earlier transfers.
+//
+// FIDELITY: ⚠️ NOT IN ROCKSDB - Models external SST shipping (follower replication, object-store
+// backup) as a single FIFO link; the job holds its compaction slot until the transfer finishes
+func (s *Simulator) shipToBackup(outputMB, startTime, localCompletionTime float64) float64 {
+	if s.config.BackupBandwidthMBps <= 0 {
+		return localCompletionTime
+	}
+	shipStart := max(startTime, s.backupBusyUntil)
+	s.backupBusyUntil = shipStart + outputMB/s.config.BackupBandwidthMBps
+	return max(localCompletionTime, s.backupBusyUntil)
+}
+
+// retryCompaction re-runs a failed compaction after the configured backoff. The job keeps its
+// inputs and its place in pendingCompactions, so its files stay marked as compacting and it keeps
+// counting against MaxBackgroundJobs while it waits, like a RocksDB job retrying a retryable error.
+//
+// FIDELITY: ⚠️ The re-run takes as long as the failed attempt and does not re-reserve the disk or
+// a worker slot, so retries don't contend with other background work
+func (s *Simulator) retryCompaction(job *CompactionJob, event *CompactionEvent) {
+	job.RetryCount++
+	backoff := s.config.CompactionRetryBackoff.Delay(job.RetryCount)
+	s.metrics.CompactionRetries++
+	s.metrics.CompactionBackoffSeconds += backoff
+
+	duration := event.Timestamp() - event.StartTime()
+	startTime := s.virtualTime + backoff
+	s.logEvent("[t=%.1fs] COMPACTION FAILED: L%d→L%d (attempt %d), retrying in %.2fs",
+		s.virtualTime, job.FromLevel, job.ToLevel, job.RetryCount, backoff)
+
+	// The failed attempt still used the disk; track the re-run as a new in-progress write
+	s.metrics.CompleteWrite(event.Timestamp(), job.FromLevel, job.ColumnFamily)
+	s.metrics.StartWrite(event.InputSizeMB(), event.OutputSizeMB(), startTime, startTime+duration, job.FromLevel, job.ToLevel, job.ColumnFamily)
+	s.queue.Push(NewCompactionEvent(startTime+duration, startTime, job.ID, job.FromLevel, job.ToLevel,
+		event.InputSizeMB(), event.OutputSizeMB()))
+}
 
+// activeCompactionInputMB returns the total input size of all scheduled, not yet completed compactions.
+//
+// FIDELITY: ⚠️ NOT IN ROCKSDB - RocksDB only limits concurrency by job count (max_background_jobs)
+// and per-job size (max_compaction_bytes). The limit is checked before picking, so the last
+// admitted job may take the total past it.
+func (s *Simulator) activeCompactionInputMB() float64 {
+	var total float64
+	for _, job := range s.pendingCompactions {
+		for _, f := range job.SourceFiles {
+			total += f.SizeMB
+		}
+		for _, f := range job.TargetFiles {
+			total += f.SizeMB
+		}
+	}
+	return total
+}
+
+// needsUrgentL0Compaction reports whether a column family's L0 is deep enough that its compaction should
+// queue for the next free slot even though every slot is taken by lower-priority
+// (deeper-level) compactions.
+//
+// FIDELITY: RocksDB Reference - RocksDB cannot preempt a running compaction, but it
+// prioritizes at scheduling time: when a thread frees up, the highest-score level
+// (L0 once it is this deep) is picked first.
+// https://github.com/facebook/rocksdb/blob/main/db/db_impl/db_impl_compaction_flush.cc
+//
+// FIDELITY: ⚠️ SIMPLIFIED - The job is picked now and starts when the earliest slot frees
+// (allocateJobSlot), instead of being picked when that slot frees; the wait is recorded
+// in UrgentCompactionWaitSeconds. At most one urgent job is queued at a time.
+func (s *Simulator) needsUrgentL0Compaction(family *columnFamily) bool {
+	if s.config.UrgentL0CompactionTrigger <= 0 || family.lsm.Levels[0].FileCount < s.config.UrgentL0CompactionTrigger {
+		return false
+	}
+	if len(s.pendingCompactions) > s.config.MaxBackgroundJobs {
+		return false // An urgent job is already queued
+	}
+	for _, job := range s.pendingCompactions {
+		if job.FromLevel == 0 && job.ColumnFamily == family.id {
+			return false // L0 is already being compacted
+		}
+	}
 	return true
 }
 
+// compactionCacheFraction estimates the fraction of compaction input found in the block cache.
+// Returns 0 unless WarmCompactionReads is enabled and a read workload is populating the cache.
+//
+// FIDELITY: RocksDB Reference - Compaction input iterators read through the block cache
+// https://github.com/facebook/rocksdb/blob/main/table/block_based/block_based_table_reader.cc
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Uses the read workload's cache hit rate for every level;
+// in practice hot blocks concentrate in upper levels and compaction reads
+// default to fill_cache=false, so this is an upper bound on the effect.
+func (s *Simulator) compactionCacheFraction() float64 {
+	if !s.config.WarmCompactionReads || s.config.ReadWorkload == nil || s.config.ReadWorkload.RequestsPerSec <= 0 {
+		return 0
+	}
+	return min(1.0, max(0.0, s.config.ReadWorkload.CacheHitRate*s.cacheWarmth()))
+}
+
 // processCompactionCheck simulates RocksDB's background compaction threads
 //
 // FIDELITY: RocksDB Reference - Background compaction scheduling
@@ -1342,11 +3180,12 @@ func (s *Simulator) processCompactionCheck(event *CompactionCheckEvent) {
 	// Try to schedule compactions to fill all available slots
 	// Loop until we've filled all MaxBackgroundJobs slots or no more levels need compaction
 	for len(s.pendingCompactions) < s.config.MaxBackgroundJobs {
-		scheduled := s.tryScheduleCompaction()
+		scheduled := s.scheduleCompaction()
 		if !scheduled {
 			break // No more levels need compaction
 		}
 	}
+	s.sampleCompactionQueue()
 
 	// Schedule next compaction check (every 1 virtual second, simulating background thread wake-ups)
 	// CRITICAL: Always schedule from current virtualTime, NEVER from event.Timestamp()
@@ -1357,21 +3196,66 @@ func (s *Simulator) processCompactionCheck(event *CompactionCheckEvent) {
 	s.queue.Push(NewCompactionCheckEvent(nextCheckTime))
 }
 
+// compactionNeed identifies a level of a column family that needs compaction
+type compactionNeed struct {
+	columnFamily int
+	level        int
+}
+
+// sampleCompactionQueue counts the levels, across column families, that need compaction but have
+// none scheduled, and notes when each started waiting. The wait ends when tryScheduleCompaction
+// schedules a compaction out of the level (AvgCompactionWaitSec), or when the level stops needing
+// one.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - Sampled at each compaction check (every virtual second), so waits are
+// measured from the first check that sees the need rather than the write that created it
+func (s *Simulator) sampleCompactionQueue() {
+	scheduled := make(map[compactionNeed]bool, len(s.pendingCompactions))
+	for _, job := range s.pendingCompactions {
+		scheduled[compactionNeed{columnFamily: job.ColumnFamily, level: job.FromLevel}] = true
+	}
+
+	depth := 0
+	for cf := 0; cf <= len(s.columnFamilies); cf++ {
+		family := s.columnFamily(cf)
+		// The last level has nowhere to compact to, unless it is L0: a single-level tree (FIFO,
+		// universal with numLevels=1) compacts within L0
+		for level := 0; level < max(1, len(family.lsm.Levels)-1); level++ {
+			key := compactionNeed{columnFamily: cf, level: level}
+			if scheduled[key] || !family.compactor.NeedsCompaction(level, family.lsm, family.config) {
+				delete(s.compactionNeededSince, key)
+				continue
+			}
+			depth++
+			if _, ok := s.compactionNeededSince[key]; !ok {
+				s.compactionNeededSince[key] = s.virtualTime
+			}
+		}
+	}
+	s.metrics.CompactionQueueDepth = depth
+}
+
 // processScheduleWrite processes a ScheduleWriteEvent
 // This continuously schedules new writes at the configured rate, independent of
 // whether writes are being stalled or not. This separation allows for flexible
 // write arrival patterns (e.g., different distributions in the future).
 func (s *Simulator) processScheduleWrite(event *ScheduleWriteEvent) {
-	// Update traffic distribution with current virtual time (for advanced models)
-	if advDist, ok := s.trafficDistribution.(*AdvancedTrafficDistribution); ok {
-		advDist.UpdateTime(s.virtualTime)
+	// Update traffic distribution with current virtual time (for advanced and burst models)
+	if timed, ok := s.trafficDistribution.(timedTrafficDistribution); ok {
+		timed.UpdateTime(s.virtualTime)
 	}
 
 	// Check if traffic distribution indicates we should schedule writes
 	writeSizeMB := s.trafficDistribution.NextWriteSizeMB()
-	intervalSeconds := s.trafficDistribution.NextIntervalSeconds()
-
-	if writeSizeMB <= 0 || intervalSeconds <= 0 {
+	if writeSizeMB <= 0 {
+		// Traffic is paused (a burst model's quiet period): wake up when it resumes
+		if resumeIn := s.trafficDistribution.NextIntervalSeconds(); resumeIn > 0 {
+			s.queue.Push(NewScheduleWriteEvent(s.virtualTime + resumeIn))
+		}
+		return
+	}
+	intervalSeconds := s.nextWriteIntervalSeconds()
+	if intervalSeconds <= 0 {
 		// No writes to schedule
 		return
 	}
@@ -1392,14 +3276,14 @@ func (s *Simulator) processScheduleWrite(event *ScheduleWriteEvent) {
 
 // scheduleNextScheduleWrite schedules the next ScheduleWriteEvent
 func (s *Simulator) scheduleNextScheduleWrite(currentTime float64) {
-	// Update traffic distribution with current virtual time (for advanced models)
+	// Update traffic distribution with current virtual time (for advanced and burst models)
 	// Use s.virtualTime (actual current time) not currentTime parameter (which might be future time)
-	if advDist, ok := s.trafficDistribution.(*AdvancedTrafficDistribution); ok {
-		advDist.UpdateTime(s.virtualTime)
+	if timed, ok := s.trafficDistribution.(timedTrafficDistribution); ok {
+		timed.UpdateTime(s.virtualTime)
 	}
 
 	// Check if traffic distribution indicates we should schedule writes
-	intervalSeconds := s.trafficDistribution.NextIntervalSeconds()
+	intervalSeconds := s.nextWriteIntervalSeconds()
 	if intervalSeconds <= 0 {
 		return
 	}
@@ -1447,13 +3331,15 @@ func (s *Simulator) IsWriteStalled() bool {
 	return s.stallStartTime > 0
 }
 
-// ScheduleWrite schedules a write event at the specified virtual time
+// ScheduleWrite schedules a write event at the specified virtual time. Scheduled writes aren't
+// journaled, so the run can't be snapshotted until the next Reset (see Snapshot).
 func (s *Simulator) ScheduleWrite(sizeMB float64, timestamp float64) {
 	writeEvent := &WriteEvent{
 		timestamp: timestamp,
 		sizeMB:    sizeMB,
 	}
 	s.queue.Push(writeEvent)
+	s.stopJournal("writes were added with ScheduleWrite")
 }
 
 // StepUntil advances the simulation until the specified target virtual time is reached
@@ -1467,6 +3353,152 @@ func (s *Simulator) StepUntil(targetTime float64) float64 {
 	return s.virtualTime
 }
 
+// SteadyState reports where RunUntilSteadyState found the run had settled
+type SteadyState struct {
+	ReachedAt          float64   `json:"reachedAt"`          // Virtual time the settling window closed (time-to-steady-state for a fresh run)
+	WriteAmplification float64   `json:"writeAmplification"` // Write amplification at ReachedAt
+	LevelSizesMB       []float64 `json:"levelSizesMB"`       // Size of each level at ReachedAt
+}
+
+// steadyStateHorizonWindows bounds RunUntilSteadyState: a run that hasn't settled after this
+// many windows is reported as not converging rather than stepped forever
+const steadyStateHorizonWindows = 100
+
+// steadyStateSample is one observation in RunUntilSteadyState's sliding window
+type steadyStateSample struct {
+	time        float64
+	writeAmp    float64
+	levelShares []float64 // Each level's fraction of the tree's total size
+}
+
+// RunUntilSteadyState steps the simulation until write amplification and the shape of the tree
+// hold steady over the trailing windowSeconds, then stops and records the steady-state values
+// (see SteadyState). Settled means write amplification varies by at most tolerance relative to
+// its window mean, and each level's share of the total size by at most tolerance (absolute).
+// Level shares are used rather than raw sizes because a tree under constant writes keeps growing.
+// The window only opens once a compaction has completed: before that the tree is trivially
+// "steady" at write-amp 1 with everything in L0.
+//
+// Returns an error if the run OOMs, runs out of events, or hasn't settled within
+// steadyStateHorizonWindows windows.
+func (s *Simulator) RunUntilSteadyState(tolerance float64, windowSeconds float64) error {
+	if tolerance <= 0 {
+		return fmt.Errorf("tolerance must be > 0, got %v", tolerance)
+	}
+	if windowSeconds <= 0 {
+		return fmt.Errorf("windowSeconds must be > 0, got %v", windowSeconds)
+	}
+
+	s.steadyState = nil
+	startTime := s.virtualTime
+	deadline := startTime + windowSeconds*steadyStateHorizonWindows
+	var window []steadyStateSample
+	windowOpenedAt := -1.0 // Virtual time of the first sample after warmup (-1 = still warming up)
+	for s.virtualTime < deadline {
+		if s.metrics.IsOOMKilled {
+			return fmt.Errorf("OOM killed at t=%.1fs before reaching steady state", s.virtualTime)
+		}
+		if s.queue.IsEmpty() {
+			return fmt.Errorf("event queue drained at t=%.1fs before reaching steady state", s.virtualTime)
+		}
+		s.Step()
+		if s.metrics.TotalCompactionsCompleted == 0 {
+			continue
+		}
+		if windowOpenedAt < 0 {
+			windowOpenedAt = s.virtualTime
+		}
+
+		window = append(window, s.steadyStateSample())
+		for len(window) > 1 && window[0].time < s.virtualTime-windowSeconds {
+			window = window[1:]
+		}
+		// Judge only a full window
+		if s.virtualTime-windowOpenedAt < windowSeconds {
+			continue
+		}
+		if windowIsSteady(window, tolerance) {
+			levelSizes := make([]float64, len(s.lsm.Levels))
+			for i, level := range s.lsm.Levels {
+				levelSizes[i] = level.TotalSize
+			}
+			s.steadyState = &SteadyState{
+				ReachedAt:          s.virtualTime,
+				WriteAmplification: s.metrics.WriteAmplification,
+				LevelSizesMB:       levelSizes,
+			}
+			s.logEvent("[STEADY STATE] Reached at t=%.1fs: write-amp %.2f, total %.1f MB",
+				s.virtualTime, s.metrics.WriteAmplification, s.lsm.TotalSizeMB)
+			return nil
+		}
+	}
+	return fmt.Errorf("no steady state within %.0fs (tolerance %v over %.0fs windows)",
+		deadline-startTime, tolerance, windowSeconds)
+}
+
+// maxCompactionHistory caps the compaction history kept for CompactionHistory (long runs complete
+// hundreds of thousands of compactions)
+const maxCompactionHistory = 10000
+
+// CompactionHistory returns the compactions completed since the simulator was created or reset,
+// in completion order: all of them, or the most recent maxCompactionHistory in long runs
+// (a copy; safe to keep while the simulation runs)
+func (s *Simulator) CompactionHistory() []CompactionRecord {
+	history := make([]CompactionRecord, len(s.compactionHistory))
+	copy(history, s.compactionHistory)
+	return history
+}
+
+// SteadyState returns where the last RunUntilSteadyState settled (nil if it hasn't)
+func (s *Simulator) SteadyState() *SteadyState {
+	return s.steadyState
+}
+
+// steadyStateSample captures the metrics RunUntilSteadyState watches at the current time
+func (s *Simulator) steadyStateSample() steadyStateSample {
+	sample := steadyStateSample{
+		time:        s.virtualTime,
+		writeAmp:    s.metrics.WriteAmplification,
+		levelShares: make([]float64, len(s.lsm.Levels)),
+	}
+	var totalMB float64
+	for _, level := range s.lsm.Levels {
+		totalMB += level.TotalSize
+	}
+	if totalMB > 0 {
+		for i, level := range s.lsm.Levels {
+			sample.levelShares[i] = level.TotalSize / totalMB
+		}
+	}
+	return sample
+}
+
+// windowIsSteady reports whether every watched metric stays within tolerance across the window
+func windowIsSteady(window []steadyStateSample, tolerance float64) bool {
+	minWA, maxWA, sumWA := math.Inf(1), math.Inf(-1), 0.0
+	for _, sample := range window {
+		minWA = min(minWA, sample.writeAmp)
+		maxWA = max(maxWA, sample.writeAmp)
+		sumWA += sample.writeAmp
+	}
+	meanWA := sumWA / float64(len(window))
+	if meanWA <= 0 || (maxWA-minWA)/meanWA > tolerance {
+		return false
+	}
+
+	for level := range window[0].levelShares {
+		minShare, maxShare := math.Inf(1), math.Inf(-1)
+		for _, sample := range window {
+			minShare = min(minShare, sample.levelShares[level])
+			maxShare = max(maxShare, sample.levelShares[level])
+		}
+		if maxShare-minShare > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
 // StepByDelta advances the simulation by the specified time delta (in seconds)
 func (s *Simulator) StepByDelta(deltaSeconds float64) float64 {
 	targetTime := s.virtualTime + deltaSeconds
@@ -83,7 +83,7 @@ func TestSimulator_Step1_UniversalCompaction_LevelToCompactNegative_Bug(t *testi
 	// BUG EXPOSURE: tryScheduleCompaction should return true
 	// For universal compaction, levelToCompact = -1 means "let PickCompaction choose"
 	// But currently it returns false because levelToCompact < 0 check happens before PickCompaction
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 
 	// EXPECTATION: Should schedule compaction
 	// ACTUAL: Returns false (BUG - levelToCompact = -1 causes early return)
@@ -118,11 +118,11 @@ func TestSimulator_Step2_MaxBackgroundJobsLimit(t *testing.T) {
 
 	// Can't directly set - compactor manages this internally
 	// Instead, schedule one compaction first
-	scheduled1 := sim.tryScheduleCompaction()
+	scheduled1 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled1, "First compaction should schedule")
 
 	// Should return false because MaxBackgroundJobs = 1 and we already have 1 pending
-	scheduled2 := sim.tryScheduleCompaction()
+	scheduled2 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.False(t, scheduled2, "Should not schedule compaction when MaxBackgroundJobs limit reached")
 	// After second call fails, we should still have exactly 1 pending compaction
 	require.Equal(t, 1, len(sim.pendingCompactions), "Should have exactly 1 pending compaction (from first call)")
@@ -152,12 +152,12 @@ func TestSimulator_Step3_MaxBackgroundJobsOneSlot(t *testing.T) {
 	}
 
 	// First compaction should succeed
-	scheduled1 := sim.tryScheduleCompaction()
+	scheduled1 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled1, "First compaction should schedule")
 	require.Equal(t, 1, sim.ActiveCompactions(), "Should have 1 active compaction")
 
 	// Second compaction should fail (no slots)
-	scheduled2 := sim.tryScheduleCompaction()
+	scheduled2 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.False(t, scheduled2, "Second compaction should not schedule (MaxBackgroundJobs = 1)")
 	require.Equal(t, 1, sim.ActiveCompactions(), "Should still have only 1 active compaction")
 }
@@ -185,7 +185,7 @@ func TestSimulator_Step4_PendingCompactionsStored(t *testing.T) {
 		})
 	}
 
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled, "Should schedule compaction")
 
 	// Verify job is stored in pendingCompactions keyed by compaction ID
@@ -232,7 +232,7 @@ func TestSimulator_Step5_ProcessCompactionExecutesJob(t *testing.T) {
 	require.Equal(t, 3, initialL0Count, "L0 should have 3 files initially")
 
 	// Schedule compaction
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled, "Should schedule compaction")
 
 	// Find the job (iterate through pendingCompactions to find L0 compaction)
@@ -538,7 +538,7 @@ func TestSimulator_Step13_TryScheduleCompaction_ReservesBandwidth(t *testing.T)
 	require.Equal(t, 0.0, initialDiskBusyUntil, "Disk should be free initially")
 
 	// Schedule compaction
-	scheduled := sim.tryScheduleCompaction()
+	scheduled := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled, "Should schedule compaction")
 
 	// Verify disk is now busy (diskBusyUntil advanced)
@@ -939,12 +939,12 @@ func TestSimulator_Step25_Integration_CompactionCompletes_FreesSlot(t *testing.T
 	}
 
 	// Schedule first compaction
-	scheduled1 := sim.tryScheduleCompaction()
+	scheduled1 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled1, "First compaction should schedule")
 	require.Equal(t, 1, sim.ActiveCompactions(), "Should have 1 active compaction")
 
 	// Second compaction should fail (no slots)
-	scheduled2 := sim.tryScheduleCompaction()
+	scheduled2 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.False(t, scheduled2, "Second compaction should not schedule (slot taken)")
 
 	// Complete first compaction
@@ -974,7 +974,7 @@ func TestSimulator_Step25_Integration_CompactionCompletes_FreesSlot(t *testing.T
 	}
 
 	// Should be able to schedule compaction now (slot freed, L0 has >= trigger files)
-	scheduled3 := sim.tryScheduleCompaction()
+	scheduled3 := sim.tryScheduleCompaction(sim.columnFamily(0))
 	require.True(t, scheduled3, "Should be able to schedule compaction after slot freed and L0 has >= trigger files")
 	require.Equal(t, 1, sim.ActiveCompactions(), "Should have 1 active compaction again")
 }
@@ -1145,6 +1145,106 @@ func TestSimulator_Step31_SimulationSpeedMultiplier_StopsOnOOM(t *testing.T) {
 	require.Equal(t, 1, sim.queue.Len(), "Queue should still have event (not processed when OOM)")
 }
 
+// TestSetSpeedMultiplier_LeavesQueueIntact tests that changing speed on a live simulation
+// takes effect on the next Step without rebuilding the event queue
+func TestSetSpeedMultiplier_LeavesQueueIntact(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 0
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	sim.virtualTime = 0.0
+	sim.queue.Clear()
+	sim.queue.Push(NewCompactionCheckEvent(10.0))
+
+	require.NoError(t, sim.SetSpeedMultiplier(4))
+	require.Equal(t, 4, sim.Config().SimulationSpeedMultiplier)
+	require.Equal(t, 1, sim.queue.Len(), "pending events survive a speed change")
+
+	sim.Step()
+	require.Equal(t, 4.0, sim.virtualTime, "next Step uses the new multiplier")
+
+	require.Error(t, sim.SetSpeedMultiplier(0))
+	require.Equal(t, 4, sim.Config().SimulationSpeedMultiplier, "invalid speed is rejected")
+}
+
+// TestSetClock_ControlsFileCreatedAt tests that SetClock and CreatedAtJitterSeconds control new file ages
+func TestSetClock_ControlsFileCreatedAt(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 0
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	sim.virtualTime = 100
+
+	sim.SetClock(func() float64 { return 40 })
+	sim.processFlush(NewFlushEvent(100, 99, 64))
+	require.NoError(t, sim.IngestFile(2, 32))
+	require.Equal(t, 40.0, sim.lsm.Levels[0].Files[0].CreatedAt, "flushed file uses the clock")
+	require.Equal(t, 40.0, sim.lsm.Levels[2].Files[0].CreatedAt, "ingested file uses the clock")
+
+	require.NoError(t, sim.Reset())
+	sim.virtualTime = 100
+	sim.processFlush(NewFlushEvent(100, 99, 64))
+	require.Equal(t, 40.0, sim.lsm.Levels[0].Files[0].CreatedAt, "clock survives Reset")
+
+	sim.SetClock(nil)
+	sim.config.CreatedAtJitterSeconds = 30
+	for i := 0; i < 20; i++ {
+		sim.processFlush(NewFlushEvent(100, 99, 64))
+	}
+	for _, f := range sim.lsm.Levels[0].Files[:20] {
+		require.GreaterOrEqual(t, f.CreatedAt, 70.0)
+		require.LessOrEqual(t, f.CreatedAt, 100.0)
+	}
+	require.NotEqual(t, sim.lsm.Levels[0].Files[0].CreatedAt, sim.lsm.Levels[0].Files[1].CreatedAt, "jitter spreads ages")
+
+	config.CreatedAtJitterSeconds = -1
+	require.Error(t, config.Validate())
+}
+
+// TestRetryBackoffConfig_Delay tests fixed and capped exponential retry delays
+func TestRetryBackoffConfig_Delay(t *testing.T) {
+	fixed := RetryBackoffConfig{Type: BackoffFixed, BaseSeconds: 2, MaxSeconds: 5}
+	require.Equal(t, 2.0, fixed.Delay(1))
+	require.Equal(t, 2.0, fixed.Delay(10))
+
+	exponential := RetryBackoffConfig{Type: BackoffExponential, BaseSeconds: 1, MaxSeconds: 10}
+	require.Equal(t, []float64{1, 2, 4, 8, 10, 10}, []float64{
+		exponential.Delay(1), exponential.Delay(2), exponential.Delay(3),
+		exponential.Delay(4), exponential.Delay(5), exponential.Delay(6),
+	})
+
+	config := DefaultConfig()
+	config.CompactionRetryBackoff.MaxSeconds = 0.5
+	require.Error(t, config.Validate(), "exponential cap below base")
+}
+
+// TestCompactionFailure_RetriesWithBackoff tests that failed compactions are re-run after a
+// backoff, keep their inputs reserved, and still complete
+func TestCompactionFailure_RetriesWithBackoff(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.WriteRateMBps = 20
+	config.RandomSeed = 11
+	config.CompactionFailureRate = 0.5
+	config.CompactionRetryBackoff = RetryBackoffConfig{Type: BackoffExponential, BaseSeconds: 0.5, MaxSeconds: 4}
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+
+	for sim.VirtualTime() < 300 {
+		sim.Step()
+		checkFileInvariants(t, sim.lsm)
+	}
+
+	m := sim.Metrics()
+	require.Greater(t, m.CompactionRetries, 0)
+	require.GreaterOrEqual(t, m.CompactionBackoffSeconds, 0.5*float64(m.CompactionRetries))
+	require.LessOrEqual(t, m.CompactionBackoffSeconds, 4*float64(m.CompactionRetries))
+	require.Greater(t, m.TotalCompactionsCompleted, 0, "compactions still finish despite failures")
+}
+
 // STEP 32: Test that virtual time NEVER goes backwards when rescheduling stalled writes
 // Given: diskBusyUntil < virtualTime (disk already free), stalled write arrives
 // When: processWrite reschedules the write
@@ -1646,3 +1746,2035 @@ func TestDiskUtilization_CappedAt100Percent(t *testing.T) {
 	t.Logf("SUCCESS: Disk utilization capped at 100%% (totalWriteThroughput=%.2f MB/s, ioThroughput=%.2f MB/s)",
 		sim.metrics.TotalWriteThroughputMBps, config.IOThroughputMBps)
 }
+
+// TestKeyCounts_DerivedFromAvgKeyValueSize tests that writes translate into key counts
+func TestKeyCounts_DerivedFromAvgKeyValueSize(t *testing.T) {
+	config := DefaultConfig()
+	config.AvgKeyValueSizeBytes = 512
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	sim.virtualTime = 1.0
+
+	// 1 MB at 512 bytes per key = 2048 keys
+	sim.processWrite(NewWriteEvent(1.0, 1.0))
+	require.Equal(t, int64(2048), sim.metrics.TotalKeys)
+
+	// Per-level keys follow level size
+	sim.lsm.CreateSSTFile(1, 2.0, 1.0)
+	sim.metrics.Update(sim.virtualTime, sim.lsm, 1, 0, config.IOThroughputMBps, false, 0, 0, config.MaxBackgroundJobs, config, sim.rng)
+	require.Equal(t, int64(4096), sim.metrics.PerLevelKeys[1])
+	require.Equal(t, int64(0), sim.metrics.PerLevelKeys[2])
+
+	// Zero disables key modeling
+	config.AvgKeyValueSizeBytes = 0
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+	sim.processWrite(NewWriteEvent(0.0, 1.0))
+	require.Equal(t, int64(0), sim.metrics.TotalKeys)
+}
+
+// TestIngestFile_BypassesMemtable tests that ingested files land directly in the LSM
+func TestIngestFile_BypassesMemtable(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	require.NoError(t, sim.IngestFile(3, 64.0))
+	require.Equal(t, 1, sim.lsm.Levels[3].FileCount)
+	require.Equal(t, 64.0, sim.lsm.TotalSizeMB)
+	require.Equal(t, 0.0, sim.lsm.MemtableCurrentSize, "Ingestion must bypass the memtable")
+	require.Equal(t, 0.0, sim.metrics.WALBytesWritten, "Ingestion must bypass the WAL")
+	require.Equal(t, 1, sim.metrics.IngestedFiles)
+	require.Equal(t, 64.0, sim.metrics.IngestedBytes)
+	require.Greater(t, sim.diskBusyUntil, 0.0, "File copy should reserve disk bandwidth")
+
+	require.Error(t, sim.IngestFile(config.NumLevels, 64.0))
+	require.Error(t, sim.IngestFile(0, 0))
+}
+
+// TestIngestionMode_WritesBecomeL0Files tests that ingestion mode turns traffic into L0 files
+func TestIngestionMode_WritesBecomeL0Files(t *testing.T) {
+	config := DefaultConfig()
+	config.IngestionMode = true
+	config.IngestFileSizeMB = 4
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		sim.processWrite(NewWriteEvent(0.0, 1.0))
+	}
+
+	require.Equal(t, 2, sim.lsm.Levels[0].FileCount, "10 MB at 4 MB per file = 2 files")
+	require.Equal(t, 2.0, sim.pendingIngestMB)
+	require.Equal(t, 0.0, sim.lsm.MemtableCurrentSize)
+	require.Equal(t, 0, sim.numImmutableMemtables)
+	require.Equal(t, 2, sim.metrics.IngestedFiles)
+}
+
+// TestIngestBehind_FilesLandInBottommostLevel tests that ingest_behind skips L0 and compaction
+func TestIngestBehind_FilesLandInBottommostLevel(t *testing.T) {
+	config := DefaultConfig()
+	config.IngestionMode = true
+	config.IngestBehind = true
+	config.IngestFileSizeMB = 4
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		sim.processWrite(NewWriteEvent(0.0, 1.0))
+	}
+
+	bottom := config.NumLevels - 1
+	require.Equal(t, 0, sim.lsm.Levels[0].FileCount)
+	require.Equal(t, 10, sim.lsm.Levels[bottom].FileCount)
+	require.Equal(t, map[int]int{bottom: 10}, sim.metrics.IngestedFilesPerLevel)
+	require.False(t, sim.tryScheduleCompaction(sim.columnFamily(0)), "nothing to compact after ingest-behind")
+	require.InDelta(t, 1.0, sim.metrics.WriteAmplification, 1e-9)
+}
+
+// TestRefitLevels_MovesFilesToLowestEmptyLevel tests that refit moves whole levels down past empty levels
+func TestRefitLevels_MovesFilesToLowestEmptyLevel(t *testing.T) {
+	config := DefaultConfig()
+	config.NumLevels = 5
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	// L1 holds a manually compacted run; the bottommost level is populated, so L1 can only drop to L3
+	for i := 0; i < 4; i++ {
+		require.NoError(t, sim.IngestFile(1, 64.0))
+	}
+	require.NoError(t, sim.IngestFile(4, 256.0))
+	// Two overlapping flushed files stay in L0
+	require.NoError(t, sim.IngestFile(0, 32.0))
+	require.NoError(t, sim.IngestFile(0, 32.0))
+	totalBefore := sim.lsm.TotalSizeMB
+	diskBefore := sim.diskBusyUntil
+
+	moved, err := sim.RefitLevels()
+	require.NoError(t, err)
+	require.Equal(t, 4, moved)
+	require.Equal(t, 2, sim.lsm.Levels[0].FileCount, "overlapping L0 files can't be re-fitted")
+	require.Equal(t, 0, sim.lsm.Levels[1].FileCount)
+	require.Equal(t, 0, sim.lsm.Levels[2].FileCount)
+	require.Equal(t, 4, sim.lsm.Levels[3].FileCount)
+	require.Equal(t, 256.0, sim.lsm.Levels[3].TotalSize)
+	require.Equal(t, 1, sim.lsm.Levels[4].FileCount)
+	require.Equal(t, totalBefore, sim.lsm.TotalSizeMB)
+	require.Equal(t, diskBefore, sim.diskBusyUntil, "refit is metadata-only")
+	require.Empty(t, sim.SelfCheck())
+
+	require.Equal(t, 4, sim.metrics.RefitFilesMoved)
+	require.Equal(t, 256.0, sim.metrics.RefitBytesMoved)
+	require.Equal(t, map[int]int{3: 4}, sim.metrics.RefitFilesMovedPerLevel)
+
+	// Already fitted: a second refit is a no-op
+	moved, err = sim.RefitLevels()
+	require.NoError(t, err)
+	require.Equal(t, 0, moved)
+}
+
+// TestWarmCompactionReads_ShortensCompactionIO tests that cached compaction input skips disk reads
+func TestWarmCompactionReads_ShortensCompactionIO(t *testing.T) {
+	scheduleWithCache := func(warm bool) *Simulator {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleUniversal
+		config.L0CompactionTrigger = 2
+		config.WriteRateMBps = 0
+		config.SSTableBuildThroughputMBps = 0 // Isolate I/O time
+		config.DecompressionThroughputMBps = 0
+		config.WarmCompactionReads = warm
+		readWorkload := DefaultReadWorkload()
+		readWorkload.CacheHitRate = 0.5
+		config.ReadWorkload = &readWorkload
+
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			sim.lsm.Levels[0].AddFile(&SSTFile{ID: fmt.Sprintf("L0-%d", i), SizeMB: 64.0})
+		}
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		return sim
+	}
+
+	cold := scheduleWithCache(false)
+	warm := scheduleWithCache(true)
+
+	require.Equal(t, 0.0, cold.metrics.WarmCompactionBytes)
+	require.InDelta(t, 96.0, warm.metrics.WarmCompactionBytes, 1e-9, "Half of 192 MB input served from cache")
+	require.Less(t, warm.diskBusyUntil, cold.diskBusyUntil, "Warm compaction should finish sooner")
+}
+
+// TestBaseStepSeconds_ControlsStepGranularity tests that BaseStepSeconds sets virtual time per iteration
+func TestBaseStepSeconds_ControlsStepGranularity(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 0
+	config.SimulationSpeedMultiplier = 4
+	config.BaseStepSeconds = 0.25
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	sim.queue.Clear()
+	sim.queue.Push(NewCompactionCheckEvent(10.0))
+
+	sim.Step()
+	require.InDelta(t, 1.0, sim.virtualTime, 1e-9, "4 iterations x 0.25s = 1.0s")
+
+	// Unset means the 1s default, as in configs written before baseStepSeconds existed
+	config.BaseStepSeconds = 0
+	require.NoError(t, config.Validate())
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+	sim.queue.Clear()
+	sim.queue.Push(NewCompactionCheckEvent(10.0))
+	sim.Step()
+	require.InDelta(t, 4.0, sim.virtualTime, 1e-9, "4 iterations x 1s")
+
+	config.BaseStepSeconds = -1
+	require.Error(t, config.Validate())
+}
+
+// TestFileSizeCompliancePerLevel tests the fraction of well-sized files per level
+func TestFileSizeCompliancePerLevel(t *testing.T) {
+	config := DefaultConfig()
+	config.NumLevels = 3
+	config.TargetFileSizeMB = 64
+	config.TargetFileSizeMultiplier = 2
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	// L0: target 64 MB - one in spec, one tiny
+	sim.lsm.CreateSSTFile(0, 60, 0)
+	sim.lsm.CreateSSTFile(0, 8, 0)
+	// L2: target 128 MB - both in spec (±20%)
+	sim.lsm.CreateSSTFile(2, 110, 0)
+	sim.lsm.CreateSSTFile(2, 150, 0)
+
+	sim.metrics.updateFileSizeCompliance(sim.lsm, config)
+
+	require.Equal(t, []float64{0.5, 1.0, 1.0}, sim.metrics.FileSizeCompliancePerLevel)
+}
+
+// TestCompactionEfficiency tests that efficiency is reclaimed bytes per written byte
+// over the throughput window, ignoring trivial moves and aging out old compactions
+func TestCompactionEfficiency(t *testing.T) {
+	m := NewMetrics()
+
+	// 300 MB in, 200 MB out: reclaimed 100, written 200
+	m.RecordCompaction(300, 200, 0, 1, 0, 4, 2, false, 0)
+	// Trivial move writes nothing and must not count
+	m.RecordCompaction(64, 64, 1, 1, 1, 1, 1, true, 0)
+	m.Timestamp = 2
+	m.updateCompactionEfficiency()
+	require.InDelta(t, 0.5, m.CompactionEfficiency, 1e-9)
+
+	// 100 MB in, 100 MB out (pure rewrite): reclaimed 100, written 300
+	m.RecordCompaction(100, 100, 2, 3, 1, 2, 2, false, 0)
+	m.Timestamp = 3
+	m.updateCompactionEfficiency()
+	require.InDelta(t, 100.0/300.0, m.CompactionEfficiency, 1e-9)
+
+	// Once both compactions leave the window there is nothing to measure
+	m.Timestamp = 100
+	m.updateCompactionEfficiency()
+	require.Equal(t, 0.0, m.CompactionEfficiency)
+}
+
+// TestEstimatedCompactionsToClearL0 tests the L0 backlog estimate before and after L0 compactions have run
+func TestEstimatedCompactionsToClearL0(t *testing.T) {
+	config := DefaultConfig()
+	config.L0CompactionTrigger = 4
+	config.MemtableFlushSizeMB = 64
+
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	m := NewMetrics()
+
+	m.updateEstimatedCompactionsToClearL0(lsm, config)
+	require.Equal(t, 0, m.EstimatedCompactionsToClearL0, "empty L0 needs no compactions")
+
+	// Before any L0 compaction, typical output is one trigger's worth: 4 x 64 = 256 MB
+	for i := 0; i < 5; i++ {
+		lsm.CreateSSTFile(0, 64, 0)
+	}
+	m.updateEstimatedCompactionsToClearL0(lsm, config)
+	require.Equal(t, 2, m.EstimatedCompactionsToClearL0, "320 MB / 256 MB rounds up")
+
+	// Completed L0 compactions set the typical size; deeper compactions are ignored
+	m.RecordCompaction(160, 100, 0, 1, 0, 3, 2, false, 0)
+	m.RecordCompaction(200, 60, 1, 2, 0, 3, 1, false, 0)
+	m.RecordCompaction(500, 500, 2, 3, 1, 8, 8, false, 0)
+	m.updateEstimatedCompactionsToClearL0(lsm, config)
+	require.Equal(t, 4, m.EstimatedCompactionsToClearL0, "320 MB / 80 MB typical output")
+}
+
+// TestSpaceAmplification tests that live bytes are the flushed user writes minus the overwrites
+// compactions merged away and those data above the last level will lose, per compaction style
+func TestSpaceAmplification(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.NumLevels = 4
+	config.L0ReductionFactor = 0.5
+	config.DeepReductionFactor = 0.8
+
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	m := NewMetrics()
+	m.UpdateSpaceAmplification(lsm, config)
+	require.Equal(t, 1.0, m.SpaceAmplification, "empty tree")
+
+	// Data placed at reset counts as live
+	lsm.CreateSSTFile(3, 1000, 0)
+	m.placedDataSizeMB = 1000
+	m.UpdateSpaceAmplification(lsm, config)
+	require.Equal(t, 1000.0, m.LiveDataSizeMB)
+	require.Equal(t, 1.0, m.SpaceAmplification)
+
+	// 300MB written, 100MB still in the memtable. L0 will lose 100 * (1 - 0.5*0.8) = 60 merging
+	// through L1 into L3 (empty L2 is skipped), L1 100 * (1 - 0.8) = 20
+	m.RecordUserWrite(300)
+	m.unflushedDataSizeMB = 100
+	lsm.CreateSSTFile(0, 100, 0)
+	lsm.CreateSSTFile(1, 100, 0)
+	m.UpdateSpaceAmplification(lsm, config)
+	require.InDelta(t, 1000+200-60-20, m.LiveDataSizeMB, 1e-9)
+	require.InDelta(t, 1200/1120.0, m.SpaceAmplification, 1e-9)
+
+	// Overwrites compactions already merged away are gone from the written bytes too
+	m.RecordUserWrite(250)
+	m.compactionDroppedMB = 50
+	lsm.CreateSSTFile(3, 200, 0)
+	m.UpdateSpaceAmplification(lsm, config)
+	require.InDelta(t, 1000+450-50-60-20, m.LiveDataSizeMB, 1e-9)
+
+	// Universal merges each run straight into the last level
+	config.CompactionStyle = CompactionStyleUniversal
+	m.UpdateSpaceAmplification(lsm, config)
+	require.InDelta(t, 1000+450-50-50-20, m.LiveDataSizeMB, 1e-9)
+
+	// FIFO never merges: whatever hasn't been deleted is live
+	config.CompactionStyle = CompactionStyleFIFO
+	m.UpdateSpaceAmplification(lsm, config)
+	require.Equal(t, 1.0, m.SpaceAmplification)
+
+	// Writes to other column families land in their own trees
+	m.RecordColumnFamilyWrite(500)
+	require.Equal(t, 1050.0, m.TotalDataWrittenMB)
+	require.Equal(t, 550.0, m.logicalDataSizeMB)
+}
+
+// TestCompactionDebt tests that debt sums the bytes each level holds above its target,
+// under both dynamic and static level sizing
+func TestCompactionDebt(t *testing.T) {
+	config := DefaultConfig()
+	config.NumLevels = 4
+	config.MaxBytesForLevelBaseMB = 256
+	config.LevelMultiplier = 10
+
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	m := NewMetrics()
+	m.updateCompactionDebt(lsm, config)
+	require.Equal(t, 0.0, m.CompactionDebtMB, "empty tree has no debt")
+	require.Equal(t, 0.0, m.CompactionDebtRatio, "empty tree must not divide by zero")
+
+	lsm.CreateSSTFile(1, 300, 0)
+	lsm.CreateSSTFile(3, 10000, 0)
+
+	// Dynamic: base level L1, targets L1=256 (floored at base), L2=1000, L3=10000
+	config.LevelCompactionDynamicLevelBytes = true
+	m.updateCompactionDebt(lsm, config)
+	require.InDelta(t, 44.0, m.CompactionDebtMB, 1e-9, "only L1 is over target")
+	require.InDelta(t, 44.0/10300.0, m.CompactionDebtRatio, 1e-9)
+
+	// Static: targets L1=256, L2=2560, L3=25600
+	config.LevelCompactionDynamicLevelBytes = false
+	lsm.CreateSSTFile(2, 3000, 0)
+	m.updateCompactionDebt(lsm, config)
+	require.InDelta(t, 44.0+440.0, m.CompactionDebtMB, 1e-9, "L1 and L2 are over target")
+	require.InDelta(t, 484.0/13300.0, m.CompactionDebtRatio, 1e-9)
+
+	// L0 is measured against max_bytes_for_level_base; the last level is never debt, even over
+	// its static target
+	lsm.CreateSSTFile(0, 300, 0)
+	lsm.CreateSSTFile(3, 20000, 0)
+	m.updateCompactionDebt(lsm, config)
+	require.InDelta(t, 44.0+44.0+440.0, m.CompactionDebtMB, 1e-9, "L0, L1 and L2 are over target")
+}
+
+// TestLevelSizeHistory tests that every Step samples the level sizes into a bounded, resettable history
+func TestLevelSizeHistory(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxHistorySamples = 10
+	config.RandomSeed = 42
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	sim.StepUntil(5)
+
+	history := sim.Metrics().LevelSizeHistory
+	require.Len(t, history, 5, "one sample per 1s step")
+	for i, sample := range history {
+		require.Len(t, sample, 1+config.NumLevels)
+		require.Equal(t, float64(i+1), sample[0], "samples are oldest first")
+	}
+	last := history[len(history)-1]
+	for level := range sim.lsm.Levels {
+		require.Equal(t, sim.lsm.Levels[level].TotalSize, last[level+1])
+	}
+
+	// Capped at MaxHistorySamples, dropping the oldest; earlier copies are unaffected
+	sim.StepUntil(30)
+	capped := sim.Metrics().LevelSizeHistory
+	require.Len(t, capped, 10)
+	require.Equal(t, 21.0, capped[0][0])
+	require.Equal(t, 30.0, capped[9][0])
+	require.Equal(t, 1.0, history[0][0])
+
+	sim.ResetMetrics()
+	require.Empty(t, sim.Metrics().LevelSizeHistory)
+
+	config.MaxHistorySamples = -1
+	require.Error(t, config.Validate())
+}
+
+// TestRecentWritesWindow tests that RecentWritesWindowSeconds sets history retention and EMA smoothing
+func TestRecentWritesWindow(t *testing.T) {
+	m := NewMetrics()
+	config := DefaultConfig()
+	m.applyThroughputWindow(config)
+	require.Equal(t, 5.0, m.throughputWindow)
+	require.InDelta(t, 0.2, m.smoothingAlpha, 1e-9, "defaults keep the original smoothing")
+
+	config.RecentWritesWindowSeconds = 0
+	m.applyThroughputWindow(config)
+	require.Equal(t, 5.0, m.throughputWindow, "0 means default")
+
+	config.RecentWritesWindowSeconds = 2
+	m.applyThroughputWindow(config)
+	require.InDelta(t, 0.5, m.smoothingAlpha, 1e-9)
+
+	m.RecordFlush(64, 0, 1)
+	m.RecordFlush(64, 7, 8)
+	m.Timestamp = 9
+	m.calculateThroughput()
+	require.Len(t, m.recentWrites, 1, "writes that ended before the 2s window are pruned")
+
+	config.RecentWritesWindowSeconds = -1
+	require.Error(t, config.Validate())
+}
+
+// TestFlushRateVsWriteRate tests that flush and achieved write rates are averaged over the throughput window
+func TestFlushRateVsWriteRate(t *testing.T) {
+	m := NewMetrics()
+	window := m.throughputWindow
+
+	m.Timestamp = 0
+	m.updateFlushAndWriteRates()
+	require.Equal(t, 0.0, m.FlushRateMBps, "no elapsed time yet")
+
+	// Writes arrive at 20 MB/s but only 10 MB/s gets flushed
+	for i := 1; i <= int(2*window); i++ {
+		m.RecordUserWrite(20)
+		m.RecordFlush(10, float64(i)-0.5, float64(i))
+		m.Timestamp = float64(i)
+		m.updateFlushAndWriteRates()
+	}
+	require.InDelta(t, 10.0, m.FlushRateMBps, 1e-9)
+	require.InDelta(t, 20.0, m.AchievedWriteRateMBps, 1e-9)
+	require.LessOrEqual(t, m.rateSamples[len(m.rateSamples)-1].time-m.rateSamples[0].time, window, "old samples are pruned")
+
+	// Flush catches up: the window average reflects only recent activity
+	for i := int(2*window) + 1; i <= int(4*window); i++ {
+		m.RecordUserWrite(20)
+		m.RecordFlush(20, float64(i)-0.5, float64(i))
+		m.Timestamp = float64(i)
+		m.updateFlushAndWriteRates()
+	}
+	require.InDelta(t, 20.0, m.FlushRateMBps, 1e-9)
+}
+
+// TestActiveMemtableSizeInState tests that State exposes the memtable fill gauge
+func TestActiveMemtableSizeInState(t *testing.T) {
+	config := DefaultConfig()
+	config.MemtableFlushSizeMB = 64
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	sim.lsm.AddWrite(24, 0)
+
+	require.Equal(t, 24.0, sim.ActiveMemtableSizeMB())
+	state := sim.State()
+	require.Equal(t, 24.0, state["activeMemtableMB"])
+	require.Equal(t, 64, state["memtableFlushSizeMB"])
+}
+
+// TestTimeBreakdown_AttributesDiskTime tests that disk time is attributed per category
+// and that disk categories plus idle account for all elapsed virtual time
+func TestTimeBreakdown_AttributesDiskTime(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	config.RandomSeed = 42
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+
+	for sim.VirtualTime() < 300 {
+		sim.Step()
+	}
+
+	breakdown := sim.TimeBreakdown()
+	require.Greater(t, breakdown["flush"], 0.0)
+	require.Greater(t, breakdown["compaction"], 0.0)
+	require.Greater(t, breakdown["idle"], 0.0)
+
+	total := 0.0
+	for _, category := range []string{"flush", "compaction", "wal", "ingest", "read", "idle"} {
+		total += breakdown[category]
+	}
+	// Reservations can run ahead of virtual time by at most the in-flight I/O
+	require.InDelta(t, sim.VirtualTime(), total, sim.GetDiskBusyUntil()-sim.VirtualTime()+1e-9)
+
+	// Ingestion is attributed to its own category
+	before := breakdown["ingest"]
+	require.NoError(t, sim.IngestFile(6, 125))
+	require.InDelta(t, before+125/config.IOThroughputMBps+config.IOLatencyMs/1000.0, sim.TimeBreakdown()["ingest"], 1e-9)
+}
+
+// TestUrgentL0Compaction_QueuesBehindBusySlots tests that a deep L0 gets its compaction
+// queued for the next free slot when all slots hold deeper compactions, recording the wait
+func TestUrgentL0Compaction_QueuesBehindBusySlots(t *testing.T) {
+	setup := func(urgentTrigger int) *Simulator {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.LevelCompactionDynamicLevelBytes = false
+		config.MaxBackgroundJobs = 1
+		config.UrgentL0CompactionTrigger = urgentTrigger
+
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+
+		// L1 well over its 256 MB target occupies the only slot with an L1→L2 compaction
+		for i := 0; i < 10; i++ {
+			sim.lsm.CreateSSTFile(1, 64, 0)
+		}
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		require.Len(t, sim.pendingCompactions, 1)
+
+		// L0 piles up past the urgent trigger while the slot is busy
+		for i := 0; i < 12; i++ {
+			sim.lsm.CreateSSTFile(0, 64, 0)
+		}
+		return sim
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		sim := setup(0)
+		require.False(t, sim.tryScheduleCompaction(sim.columnFamily(0)), "L0 compaction deferred until a slot frees")
+		require.Zero(t, sim.metrics.UrgentCompactionWaitSeconds)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		sim := setup(8)
+		slotFreeAt := sim.backgroundJobSlots[0]
+		require.Greater(t, slotFreeAt, 0.0)
+
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		require.Len(t, sim.pendingCompactions, 2)
+		require.InDelta(t, slotFreeAt, sim.metrics.UrgentCompactionWaitSeconds, 1e-9, "waits until the deep compaction finishes")
+
+		fromL0 := 0
+		for _, job := range sim.pendingCompactions {
+			if job.FromLevel == 0 {
+				fromL0++
+			}
+		}
+		require.Equal(t, 1, fromL0)
+		require.False(t, sim.tryScheduleCompaction(sim.columnFamily(0)), "only one urgent job is queued at a time")
+	})
+}
+
+// TestUrgentL0Compaction_OnlyL0JobsExceedSlots tests that when L0 needs an urgent compaction but
+// the compactor picks a deeper job, that job waits for a free slot and the pick is undone
+func TestUrgentL0Compaction_OnlyL0JobsExceedSlots(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+	config.MaxBackgroundJobs = 1
+	config.UrgentL0CompactionTrigger = 8
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	compactor := sim.compactor.(*LeveledCompactor)
+
+	// An L1→L2 compaction occupies the only slot
+	for i := 0; i < 10; i++ {
+		sim.lsm.CreateSSTFile(1, 64, 0)
+	}
+	require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+	require.Len(t, sim.pendingCompactions, 1)
+
+	// L0 is past the urgent trigger but paused, so the picker's best job is L2→L3
+	for i := 0; i < 12; i++ {
+		sim.lsm.CreateSSTFile(0, 64, 0)
+	}
+	for i := 0; i < 50; i++ {
+		sim.lsm.CreateSSTFile(2, 64, 0)
+	}
+	require.NoError(t, sim.PauseLevelCompaction(0))
+	require.True(t, sim.needsUrgentL0Compaction(sim.columnFamily(0)))
+	draws := compactor.rngs.positions()
+
+	require.False(t, sim.tryScheduleCompaction(sim.columnFamily(0)), "a deep job doesn't take the urgent slot")
+	require.Len(t, sim.pendingCompactions, 1)
+	require.Zero(t, sim.metrics.UrgentCompactionWaitSeconds)
+	require.False(t, compactor.activeCompactions[2], "the L2 pick is undone")
+	require.Equal(t, draws, compactor.rngs.positions())
+
+	// Once L0 is resumed its compaction queues for the slot
+	require.NoError(t, sim.ResumeLevelCompaction(0))
+	require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+	require.Len(t, sim.pendingCompactions, 2)
+	require.Greater(t, sim.metrics.UrgentCompactionWaitSeconds, 0.0)
+}
+
+// TestRecommendCompactionStyle tests the heuristic style recommendation
+func TestRecommendCompactionStyle(t *testing.T) {
+	withReads := func(config SimConfig, reqPerSec float64) SimConfig {
+		config.ReadWorkload = &ReadWorkloadConfig{Enabled: true, RequestsPerSec: reqPerSec}
+		return config
+	}
+
+	t.Run("write-only workload suggests FIFO", func(t *testing.T) {
+		sim, err := NewSimulator(DefaultConfig())
+		require.NoError(t, err)
+		style, rationale := sim.RecommendCompactionStyle()
+		require.Equal(t, CompactionStyleFIFO, style)
+		require.Contains(t, rationale, "retention")
+	})
+
+	t.Run("write-heavy and space tolerant suggests universal", func(t *testing.T) {
+		config := withReads(DefaultConfig(), 1000)
+		config.WriteRateMBps = 50 // 50 MB/s × 10x leveled WA = 500 MB/s > 62.5 MB/s budget
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		style, _ := sim.RecommendCompactionStyle()
+		require.Equal(t, CompactionStyleUniversal, style)
+	})
+
+	t.Run("write-heavy but space constrained stays leveled", func(t *testing.T) {
+		config := withReads(DefaultConfig(), 1000)
+		config.WriteRateMBps = 50
+		config.MaxSizeAmplificationPercent = 25
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		style, rationale := sim.RecommendCompactionStyle()
+		require.Equal(t, CompactionStyleLeveled, style)
+		require.Contains(t, rationale, "rules out universal")
+	})
+
+	t.Run("read-heavy suggests leveled", func(t *testing.T) {
+		config := withReads(DefaultConfig(), 50000)
+		config.WriteRateMBps = 2
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		style, rationale := sim.RecommendCompactionStyle()
+		require.Equal(t, CompactionStyleLeveled, style)
+		require.Contains(t, rationale, "Read-heavy")
+	})
+}
+
+// TestMaxActiveCompactionBytes_BlocksScheduling tests the byte-based compaction concurrency limit
+func TestMaxActiveCompactionBytes_BlocksScheduling(t *testing.T) {
+	setup := func(limitMB int) *Simulator {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.LevelCompactionDynamicLevelBytes = false
+		config.MaxBackgroundJobs = 4
+		config.MaxActiveCompactionBytesMB = limitMB
+
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+
+		// L0 and L1 both need compaction
+		for i := 0; i < 10; i++ {
+			sim.lsm.CreateSSTFile(1, 64, 0)
+		}
+		for i := 0; i < 8; i++ {
+			sim.lsm.CreateSSTFile(0, 64, 0)
+		}
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		return sim
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		sim := setup(0)
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		require.Zero(t, sim.metrics.CompactionsBlockedByActiveBytes)
+	})
+
+	t.Run("limit reached", func(t *testing.T) {
+		sim := setup(64)
+		require.GreaterOrEqual(t, sim.activeCompactionInputMB(), 64.0)
+		require.False(t, sim.tryScheduleCompaction(sim.columnFamily(0)), "first job's input already fills the budget")
+		require.Equal(t, 1, sim.metrics.CompactionsBlockedByActiveBytes)
+		require.Len(t, sim.pendingCompactions, 1)
+	})
+}
+
+// TestSelfCheck tests that SelfCheck passes on a fresh simulator and reports corrupted bookkeeping
+func TestSelfCheck(t *testing.T) {
+	config := DefaultConfig()
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	require.Empty(t, sim.SelfCheck())
+
+	sim.lsm.CreateSSTFile(1, 64, 0)
+	require.Empty(t, sim.SelfCheck(), "CreateSSTFile keeps level and tree totals in sync")
+
+	sim.lsm.Levels[1].FileCount++
+	sim.lsm.Levels[1].TotalSize += 10
+	sim.numImmutableMemtables = 1
+	sim.queue.Push(NewWriteEvent(sim.VirtualTime()-1, 1))
+
+	violations := sim.SelfCheck()
+	require.Len(t, violations, 5, "%v", violations)
+	require.Contains(t, violations[0], "L1: fileCount=2 but 1 files listed")
+	require.Contains(t, violations[1], "L1: totalSize=74.000000 MB but files sum to 64.000000 MB")
+	require.Contains(t, violations[2], "LSM totalSizeMB")
+	require.Contains(t, violations[3], "numImmutableMemtables=1 but 0 immutable memtable sizes tracked")
+	require.Contains(t, violations[4], "before virtual time")
+
+	// Extra column families' trees are checked too
+	config.ColumnFamilies[0] = ColumnFamilyConfig{Name: "index", WriteRateMBps: 10}
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	require.Empty(t, sim.SelfCheck())
+	sim.columnFamily(1).lsm.Levels[1].FileCount++
+	violations = sim.SelfCheck()
+	require.Len(t, violations, 1, "%v", violations)
+	require.Contains(t, violations[0], "index L1: fileCount=1 but 0 files listed")
+}
+
+// TestColumnFamilyWriteStall tests that a column family with max_write_buffer_number immutable
+// memtables stalls every column family's writes until its flush frees a buffer
+func TestColumnFamilyWriteStall(t *testing.T) {
+	config := DefaultConfig()
+	config.RandomSeed = 42
+	config.MaxWriteBufferNumber = 2
+	config.ColumnFamilies[0] = ColumnFamilyConfig{Name: "index", WriteRateMBps: 10}
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	sim.queue.Clear()
+	sim.virtualTime = 10
+
+	family := sim.columnFamily(1)
+	family.immutableMemtableSizes = []float64{64, 64}
+	sim.queue.Push(NewColumnFamilyFlushEvent(12, 11, 64, 1))
+
+	// The full column family's writes wait for its next flush
+	sim.processColumnFamilyWrite(NewColumnFamilyWriteEvent(10, 1))
+	require.True(t, sim.IsWriteStalled())
+	require.Equal(t, 0.0, family.lsm.MemtableCurrentSize, "stalled writes don't reach the memtable")
+	require.Equal(t, 12.0, sim.nextFlushCompletionTime)
+	var retry Event
+	for _, event := range sim.queue.Events() {
+		if _, ok := event.(*ColumnFamilyWriteEvent); ok {
+			retry = event
+		}
+	}
+	require.NotNil(t, retry)
+	require.Greater(t, retry.Timestamp(), 12.0, "the write retries after the flush")
+
+	// The default column family stalls too: the write controller is DB-wide
+	sim.processWrite(NewWriteEvent(10, 1))
+	require.Equal(t, 0.0, sim.lsm.MemtableCurrentSize)
+	require.Equal(t, 1, sim.queue.CountWriteEvents(), "default write requeued as stalled")
+
+	// The flush frees a buffer and the next write clears the stall
+	sim.virtualTime = 12
+	sim.processFlush(sim.queue.Pop().(*FlushEvent))
+	require.Len(t, family.immutableMemtableSizes, 1)
+	require.Equal(t, 0.0, sim.nextFlushCompletionTime)
+	sim.processColumnFamilyWrite(NewColumnFamilyWriteEvent(12, 1))
+	require.False(t, sim.IsWriteStalled())
+	require.Equal(t, 10.0, family.lsm.MemtableCurrentSize)
+	require.Equal(t, 2.0, sim.metrics.StallDurationSeconds)
+}
+
+// TestCompactionHistory tests that every completed compaction is recorded in completion order
+func TestCompactionHistory(t *testing.T) {
+	for _, style := range []CompactionStyle{CompactionStyleLeveled, CompactionStyleUniversal} {
+		t.Run(style.String(), func(t *testing.T) {
+			config := DefaultConfig()
+			config.CompactionStyle = style
+			config.WriteRateMBps = 50
+			config.RandomSeed = 42
+			sim, err := NewSimulator(config)
+			require.NoError(t, err)
+			require.NoError(t, sim.Reset())
+			require.Empty(t, sim.CompactionHistory())
+
+			sim.StepUntil(300)
+			history := sim.CompactionHistory()
+			require.NotEmpty(t, history)
+			require.Len(t, history, sim.metrics.TotalCompactionsCompleted)
+
+			var inputMB float64
+			for i, rec := range history {
+				require.GreaterOrEqual(t, rec.EndTime, rec.StartTime, "record %d", i)
+				if i > 0 {
+					require.GreaterOrEqual(t, rec.EndTime, history[i-1].EndTime, "record %d out of order", i)
+				}
+				require.GreaterOrEqual(t, rec.ToLevel, rec.FromLevel)
+				require.NotEmpty(t, rec.Reason)
+				if rec.TrivialMove {
+					require.Equal(t, rec.InputMB, rec.OutputMB, "trivial move relocates files unchanged")
+				} else {
+					inputMB += rec.InputMB
+				}
+			}
+			require.InDelta(t, sim.metrics.CompactionReadMB, inputMB, 1e-6, "rewritten input matches compaction reads")
+
+			history[0].Reason = "mutated"
+			require.NotEqual(t, "mutated", sim.CompactionHistory()[0].Reason, "returns a copy")
+
+			require.NoError(t, sim.Reset())
+			require.Empty(t, sim.CompactionHistory(), "reset clears the history")
+		})
+	}
+}
+
+// TestBackupBandwidth tests that a slow backup link delays compaction completion and is tracked
+func TestBackupBandwidth(t *testing.T) {
+	config := DefaultConfig()
+	config.BackupBandwidthMBps = 10
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	// Local write finishes first: the transfer (100 MB at 10 MB/s) decides completion
+	require.Equal(t, 10.0, sim.shipToBackup(100, 0, 2))
+	// A second job queues behind the first on the link
+	require.Equal(t, 15.0, sim.shipToBackup(50, 1, 3))
+	// A slow local job isn't held up once the link is free
+	require.Equal(t, 40.0, sim.shipToBackup(10, 20, 40))
+
+	sim.config.BackupBandwidthMBps = 0
+	require.Equal(t, 2.0, sim.shipToBackup(100, 0, 2), "no backup: local completion stands")
+
+	// End to end: shipped bytes accumulate and the link falls behind a fast write rate
+	config.WriteRateMBps = 20
+	config.BackupBandwidthMBps = 5
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	for sim.VirtualTime() < 300 && !sim.metrics.IsOOMKilled {
+		sim.Step()
+	}
+	require.Greater(t, sim.metrics.BackupBytesShipped, 0.0)
+	require.Greater(t, sim.metrics.BackupLagSeconds, 0.0)
+}
+
+// TestDetailedL0State tests the opt-in per-file L0 view in State
+func TestDetailedL0State(t *testing.T) {
+	config := DefaultConfig()
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NotContains(t, sim.State(), "l0Detail", "off by default")
+
+	config.DetailedL0State = true
+	config.EnableL0SubLevels = true
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+
+	flushed := sim.lsm.CreateSSTFile(0, 64, 10)
+	merged := []*SSTFile{sim.lsm.CreateSSTFile(0, 32, 20), sim.lsm.CreateSSTFile(0, 32, 20)}
+	assignKeyRanges(merged, 0, keySpaceMax) // Disjoint, like intra-L0 outputs
+	sim.pendingCompactions[3] = &CompactionJob{ID: 3, FromLevel: 0, ToLevel: 1, SourceFiles: []*SSTFile{flushed}}
+	sim.virtualTime = 25
+
+	detail := sim.State()["l0Detail"].(map[string]interface{})
+	require.Equal(t, 3, detail["fileCount"])
+	require.Equal(t, 2, detail["subLevelCount"])
+	require.Equal(t, 1, detail["filesBeingCompacted"])
+	require.Equal(t, config.L0CompactionTrigger, detail["compactionTrigger"])
+
+	byID := make(map[string]map[string]interface{})
+	for _, f := range detail["files"].([]map[string]interface{}) {
+		byID[f["id"].(string)] = f
+	}
+	require.Equal(t, 15.0, byID[flushed.ID]["ageSeconds"])
+	require.Equal(t, 2, byID[flushed.ID]["overlappingFiles"], "flushed file overlaps everything else")
+	require.Equal(t, true, byID[flushed.ID]["beingCompacted"])
+	require.Equal(t, 3, byID[flushed.ID]["compactionID"])
+	require.Equal(t, 1, byID[merged[0].ID]["overlappingFiles"], "sub-level siblings don't overlap")
+	require.Equal(t, 0, byID[flushed.ID]["subLevel"], "oldest")
+	require.Equal(t, 1, byID[merged[0].ID]["subLevel"])
+	require.Equal(t, 1, byID[merged[1].ID]["subLevel"])
+	require.Equal(t, false, byID[merged[0].ID]["beingCompacted"])
+}
+
+// TestResetMetrics_KeepsLSMAndQueue tests that ResetMetrics clears measurements but not simulation state
+func TestResetMetrics_KeepsLSMAndQueue(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	for sim.VirtualTime() < 60 {
+		sim.Step()
+	}
+	writtenBefore := sim.metrics.TotalDataWrittenMB
+	require.Greater(t, writtenBefore, 0.0)
+
+	virtualTime := sim.VirtualTime()
+	queueLen := sim.queue.Len()
+	lsmSize := sim.lsm.TotalSizeMB
+	inProgress := len(sim.metrics.inProgressWrites)
+
+	sim.ResetMetrics()
+	require.Equal(t, 0.0, sim.metrics.TotalDataWrittenMB)
+	require.Equal(t, 0, sim.metrics.TotalCompactionsCompleted)
+	require.Empty(t, sim.TimeBreakdown()["flush"])
+	require.Empty(t, sim.TimeBreakdown()["idle"], "no time has elapsed since the reset")
+	require.Equal(t, virtualTime, sim.VirtualTime())
+	require.Equal(t, queueLen, sim.queue.Len())
+	require.Equal(t, lsmSize, sim.lsm.TotalSizeMB)
+	require.Len(t, sim.metrics.inProgressWrites, inProgress, "in-flight work is still tracked")
+
+	for sim.VirtualTime() < 120 {
+		sim.Step()
+	}
+	require.InDelta(t, writtenBefore, sim.metrics.TotalDataWrittenMB, writtenBefore*0.1, "counts only writes since the reset")
+}
+
+// TestCompactionSetupLatency tests that the fixed setup cost lengthens every compaction by the same amount
+func TestCompactionSetupLatency(t *testing.T) {
+	schedule := func(setupMs float64) (float64, *Simulator) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.CompactionSetupLatencyMs = setupMs
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			sim.lsm.CreateSSTFile(0, 64, 0)
+			sim.lsm.CreateSSTFile(1, 25, 0)
+		}
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		for _, event := range sim.queue.Events() {
+			if e, ok := event.(*CompactionEvent); ok {
+				return e.Timestamp() - e.StartTime(), sim
+			}
+		}
+		t.Fatal("no compaction event scheduled")
+		return 0, nil
+	}
+
+	base, sim := schedule(0)
+	require.Zero(t, sim.metrics.CompactionSetupSeconds)
+
+	withSetup, sim := schedule(500)
+	require.InDelta(t, base+0.5, withSetup, 1e-9)
+	require.InDelta(t, 0.5, sim.metrics.CompactionSetupSeconds, 1e-9)
+}
+
+// TestCompactionCPUCeiling tests that on a fast disk a compaction lasts as long as its CPU work
+// when the CPU can't keep up with the I/O, and that the work shows up as CPU utilization
+func TestCompactionCPUCeiling(t *testing.T) {
+	schedule := func(mbPerCPUSec int) (duration, inputMB float64, sim *Simulator) {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.IOLatencyMs = 0.01
+		config.IOThroughputMBps = 10000
+		config.SSTableBuildThroughputMBps = 0
+		config.DecompressionThroughputMBps = 0
+		config.CompactionMBPerCPUSec = mbPerCPUSec
+		config.NumCompactionThreads = 1
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			sim.lsm.CreateSSTFile(0, 64, 0)
+			sim.lsm.CreateSSTFile(1, 25, 0)
+		}
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		for _, job := range sim.pendingCompactions {
+			for _, f := range append(job.SourceFiles, job.TargetFiles...) {
+				inputMB += f.SizeMB
+			}
+		}
+		for _, event := range sim.queue.Events() {
+			if e, ok := event.(*CompactionEvent); ok {
+				return e.Timestamp() - e.StartTime(), inputMB, sim
+			}
+		}
+		t.Fatal("no compaction event scheduled")
+		return 0, 0, nil
+	}
+
+	ioBound, inputMB, sim := schedule(0)
+	require.Less(t, ioBound, inputMB/100, "a fast disk finishes well within the CPU-bound time")
+	sim.metrics.Update(ioBound, sim.lsm, 0, 0, 0, false, 0, 0, sim.config.MaxBackgroundJobs, sim.config, sim.rng)
+	require.Zero(t, sim.metrics.CPUUtilizationPercent)
+
+	cpuBound, inputMB, sim := schedule(100)
+	require.InDelta(t, inputMB/100, cpuBound, 1e-6, "one thread merging 100 MB/s sets the pace")
+	sim.metrics.Update(cpuBound, sim.lsm, 0, 0, 0, false, 0, 0, sim.config.MaxBackgroundJobs, sim.config, sim.rng)
+	require.InDelta(t, 100, sim.metrics.CPUUtilizationPercent, 1e-6, "the only thread was busy the whole time")
+
+	config := DefaultConfig()
+	config.CompactionMBPerCPUSec = -1
+	require.Error(t, config.Validate())
+}
+
+// TestSingleLevel tests NumLevels == 1: universal and FIFO keep everything in L0, leveled is rejected
+func TestSingleLevel(t *testing.T) {
+	config := DefaultConfig()
+	config.NumLevels = 1
+	config.CompactionStyle = CompactionStyleLeveled
+	require.Error(t, config.Validate(), "leveled compaction needs a level to compact into")
+
+	for _, style := range []CompactionStyle{CompactionStyleUniversal, CompactionStyleFIFO} {
+		t.Run(style.String(), func(t *testing.T) {
+			config := DefaultConfig()
+			config.NumLevels = 1
+			config.CompactionStyle = style
+			config.WriteRateMBps = 5
+
+			sim, err := NewSimulator(config)
+			require.NoError(t, err)
+			require.NoError(t, sim.Reset())
+			sim.StepUntil(1800)
+
+			require.False(t, sim.metrics.IsOOMKilled)
+			require.Empty(t, sim.SelfCheck())
+			require.Len(t, sim.lsm.Levels, 1)
+			require.Greater(t, sim.lsm.Levels[0].FileCount, 0)
+			require.Equal(t, sim.lsm.TotalSizeMB, sim.lsm.Levels[0].TotalSize)
+
+			state := sim.State()
+			require.NotNil(t, state["levels"])
+			if style == CompactionStyleUniversal {
+				require.Equal(t, 0, state["baseLevel"])
+				// Each compaction writes one sorted run back into L0, so the run count stays near the trigger
+				require.LessOrEqual(t, sim.lsm.Levels[0].FileCount, 2*config.L0CompactionTrigger)
+			}
+		})
+	}
+}
+
+// TestImmutableMemtableSlowdown tests graduated write slowdown before the hard memtable stall
+func TestImmutableMemtableSlowdown(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxWriteBufferNumber = 4
+	config.ImmutableMemtableSlowdownNumber = 2
+	config.WriteRateMBps = 30
+	config.RandomSeed = 42
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	for n, want := range map[int]float64{0: 1.0, 1: 1.0, 2: 2.0 / 3, 3: 1.0 / 3, 4: 1.0} {
+		sim.numImmutableMemtables = n
+		require.InDelta(t, want, sim.writeSlowdownMultiplier(), 1e-9, "%d immutable memtables", n)
+	}
+	sim.numImmutableMemtables = 3
+	require.InDelta(t, 10.0, sim.getEffectiveWriteRateMBps(), 1e-9)
+
+	// Flushes slower than the write rate: the slowdown band absorbs pressure that would otherwise stall
+	run := func(slowdown int) *Simulator {
+		config.ImmutableMemtableSlowdownNumber = slowdown
+		config.IOThroughputMBps = 20
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(300)
+		return sim
+	}
+	cliff := run(0)
+	graduated := run(2)
+	require.Zero(t, cliff.metrics.SlowdownSeconds)
+	require.Greater(t, graduated.metrics.SlowdownSeconds, 0.0)
+	require.Less(t, graduated.metrics.StallDurationSeconds, cliff.metrics.StallDurationSeconds)
+
+	config.ImmutableMemtableSlowdownNumber = 4
+	require.Error(t, config.Validate(), "slowdown must start below the stall limit")
+}
+
+// TestPauseLevelCompaction tests that a paused level accumulates data while other levels keep
+// compacting, and drains after it is resumed
+func TestPauseLevelCompaction(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+	config.WriteRateMBps = 20
+	config.MaxBytesForLevelBaseMB = 128
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	require.Error(t, sim.PauseLevelCompaction(config.NumLevels))
+	require.NoError(t, sim.PauseLevelCompaction(1))
+
+	sim.StepUntil(300)
+	l1 := sim.lsm.Levels[1]
+	require.Greater(t, l1.TotalSize, 2*float64(config.MaxBytesForLevelBaseMB), "L1 should pile up past its target")
+	require.Zero(t, sim.lsm.Levels[2].FileCount, "nothing compacts out of paused L1")
+	require.Less(t, sim.lsm.Levels[0].FileCount, 2*config.L0CompactionTrigger, "L0 keeps compacting into L1")
+	require.True(t, sim.State()["levels"].([]map[string]interface{})[1]["paused"].(bool))
+
+	require.NoError(t, sim.ResumeLevelCompaction(1))
+	sim.StepUntil(600)
+	require.Greater(t, sim.lsm.Levels[2].FileCount, 0, "L1 drains once resumed")
+	checkFileInvariants(t, sim.lsm)
+}
+
+// TestRequestManualCompaction tests that a manual compaction empties a level whose score doesn't
+// call for compaction, and that a full-tree request moves every file into the last level
+func TestRequestManualCompaction(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelCompactionDynamicLevelBytes = false
+	config.WriteRateMBps = 0
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	require.Error(t, sim.RequestManualCompaction(config.NumLevels-1), "the last level has nowhere to go")
+
+	require.NoError(t, sim.IngestFile(0, 16))
+	require.NoError(t, sim.IngestFile(1, 64))
+	require.NoError(t, sim.IngestFile(3, 64))
+	require.NoError(t, sim.RequestManualCompaction(1))
+	sim.StepUntil(60)
+	require.Zero(t, sim.lsm.Levels[1].FileCount, "L1 is under its target but compacted on request")
+	require.Greater(t, sim.lsm.Levels[2].FileCount, 0)
+	require.Equal(t, 1, sim.lsm.Levels[0].FileCount, "L0 is untouched")
+	require.Equal(t, 1, sim.metrics.ManualCompactions)
+
+	require.NoError(t, sim.RequestManualCompaction(-1))
+	require.NoError(t, sim.RequestManualCompaction(1), "nothing left in L1: dropped")
+	sim.StepUntil(120)
+	last := config.NumLevels - 1
+	for level := 0; level < last; level++ {
+		require.Zero(t, sim.lsm.Levels[level].FileCount, "L%d should be compacted into the last level", level)
+	}
+	require.Greater(t, sim.lsm.Levels[last].TotalSize, 0.0)
+	require.Equal(t, 2, sim.metrics.ManualCompactions)
+	checkFileInvariants(t, sim.lsm)
+
+	config.CompactionStyle = CompactionStyleFIFO
+	fifo, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.Error(t, fifo.RequestManualCompaction(0))
+}
+
+// TestRecompactionBytes tests that compaction input written by a recent compaction is counted
+// as recompaction, and that outputs are stamped only when tracking is enabled
+func TestRecompactionBytes(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.WriteRateMBps = 50
+	config.RandomSeed = 42
+
+	run := func(window float64) *Simulator {
+		config.RecompactionWindowSeconds = window
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(300)
+		return sim
+	}
+
+	disabled := run(0)
+	require.Greater(t, disabled.metrics.CompactionReadMB, 0.0)
+	require.Zero(t, disabled.metrics.RecompactionBytes)
+	for _, level := range disabled.lsm.Levels {
+		for _, f := range level.Files {
+			require.Zero(t, f.CompactedAt, "file %s stamped with tracking disabled", f.ID)
+		}
+	}
+
+	tracked := run(3600)
+	require.Greater(t, tracked.metrics.RecompactionBytes, 0.0)
+	require.LessOrEqual(t, tracked.metrics.RecompactionBytes, tracked.metrics.CompactionReadMB)
+
+	// A shorter window counts less of the same seeded run
+	narrow := run(1)
+	require.Less(t, narrow.metrics.RecompactionBytes, tracked.metrics.RecompactionBytes)
+
+	config.RecompactionWindowSeconds = -1
+	require.Error(t, config.Validate())
+}
+
+// TestRunUntilSteadyState tests that a run stops once write-amp and the tree's shape settle, and
+// reports where it settled
+func TestRunUntilSteadyState(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.RandomSeed = 42
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	require.Error(t, sim.RunUntilSteadyState(0, 60))
+	require.Error(t, sim.RunUntilSteadyState(0.1, 0))
+	require.Nil(t, sim.SteadyState())
+
+	require.NoError(t, sim.RunUntilSteadyState(0.1, 60))
+	steady := sim.SteadyState()
+	require.NotNil(t, steady)
+	require.Equal(t, sim.virtualTime, steady.ReachedAt, "run stops as soon as it settles")
+	require.Greater(t, steady.ReachedAt, 60.0, "window opens only after the first compaction")
+	require.Greater(t, steady.WriteAmplification, 1.0)
+	require.Len(t, steady.LevelSizesMB, config.NumLevels)
+	var totalMB float64
+	for _, sizeMB := range steady.LevelSizesMB {
+		totalMB += sizeMB
+	}
+	require.Greater(t, totalMB, 0.0)
+}
+
+// TestBottommostCompactionIOPriority tests that compactions into the deepest level run at a share
+// of the disk bandwidth while releasing the rest to other jobs
+func TestBottommostCompactionIOPriority(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.NumLevels = 3
+	config.RandomSeed = 42
+	config.BottommostCompactionIOPriority = 0.25
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	_, cpuStart, ioStart, done := sim.allocateThrottledJobSlot(0, 1, 2, 0.25)
+	require.Equal(t, 0.0, cpuStart)
+	require.Equal(t, 1.0, ioStart)
+	require.Equal(t, 9.0, done, "2s of I/O at a quarter of the bandwidth")
+	require.Equal(t, 3.0, sim.diskBusyUntil, "disk is held only for the job's own bytes")
+	_, _, ioStart, _ = sim.allocateJobSlot(0, 0, 1)
+	require.Equal(t, 3.0, ioStart, "next job's I/O doesn't wait for the throttled job to finish")
+
+	run := func(priority float64) *Metrics {
+		config.BottommostCompactionIOPriority = priority
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(600)
+		return sim.metrics
+	}
+	full := run(0)
+	throttled := run(0.25)
+	require.Greater(t, full.BottommostCompactionThroughputMBps, 0.0)
+	require.Greater(t, full.UpperCompactionThroughputMBps, 0.0)
+	require.Less(t, throttled.BottommostCompactionThroughputMBps, full.BottommostCompactionThroughputMBps)
+
+	config.BottommostCompactionIOPriority = 1.5
+	require.Error(t, config.Validate())
+}
+
+// TestMetadataOverhead verifies flushed SSTs carry MetadataOverheadPercent extra for index/filter
+// blocks, and that the rewritten metadata is counted in MetadataBytes
+func TestMetadataOverhead(t *testing.T) {
+	run := func(percent float64) *Simulator {
+		config := DefaultConfig()
+		config.WriteRateMBps = 20
+		config.RandomSeed = 42
+		config.MetadataOverheadPercent = percent
+		config.IOThroughputMBps = 1000 // Writes never back up, so both runs flush the same memtables
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(300)
+		return sim
+	}
+
+	plain := run(0)
+	require.Zero(t, plain.metrics.MetadataBytes)
+
+	sim := run(5)
+	require.Greater(t, sim.metrics.totalFlushWrittenMB, 0.0)
+	require.InDelta(t, plain.metrics.totalFlushWrittenMB*1.05, sim.metrics.totalFlushWrittenMB, 1e-6,
+		"every flushed SST grows by the overhead")
+	flushMetadataMB := sim.metrics.totalFlushWrittenMB * 5 / 105
+	require.Greater(t, sim.metrics.MetadataBytes, flushMetadataMB, "compactions rewrite metadata too")
+
+	config := DefaultConfig()
+	config.MetadataOverheadPercent = -1
+	require.Error(t, config.Validate())
+	config.MetadataOverheadPercent = 101
+	require.Error(t, config.Validate())
+}
+
+// TestFilterBlocksFollowKeyCount verifies bloom filter blocks are sized from the key count, so
+// small key-value pairs write larger SSTs than large ones for the same data
+func TestFilterBlocksFollowKeyCount(t *testing.T) {
+	run := func(bitsPerKey, avgKeyValueSizeBytes int) *Simulator {
+		config := DefaultConfig()
+		config.WriteRateMBps = 20
+		config.RandomSeed = 42
+		config.BloomFilterBitsPerKey = bitsPerKey
+		config.AvgKeyValueSizeBytes = avgKeyValueSizeBytes
+		config.IOThroughputMBps = 1000 // Writes never back up, so the runs flush the same memtables
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(300)
+		return sim
+	}
+
+	// 10 bits for each of 16384 keys per MB of 64-byte pairs, 1024 keys per MB of 1 KB pairs
+	small, large, unfiltered := run(10, 64), run(10, 1024), run(0, 64)
+	require.InDelta(t, 1+10.0/(8*64), small.config.MetadataOverheadFactor(), 1e-12)
+	require.InDelta(t, 1+10.0/(8*1024), large.config.MetadataOverheadFactor(), 1e-12)
+	require.Equal(t, 1.0, unfiltered.config.MetadataOverheadFactor())
+	require.Equal(t, 1.0, run(10, 0).config.MetadataOverheadFactor(), "no key counts, no filter sizing")
+	require.Zero(t, unfiltered.metrics.MetadataBytes)
+
+	for _, sim := range []*Simulator{small, large} {
+		factor := sim.config.MetadataOverheadFactor()
+		require.Greater(t, sim.metrics.MetadataBytes, sim.metrics.totalFlushWrittenMB*(factor-1)/factor,
+			"flushed SSTs carry their filters and compactions rewrite them")
+	}
+	require.Greater(t, small.metrics.MetadataBytes, 10*large.metrics.MetadataBytes)
+
+	// Key counts leave the filter blocks out
+	small.lsm.CreateSSTFile(1, small.config.MetadataOverheadFactor(), 0)
+	small.metrics.Update(small.virtualTime, small.lsm, 1, 0, small.config.IOThroughputMBps, false, 0, 0, small.config.MaxBackgroundJobs, small.config, small.rng)
+	require.Equal(t, keysForSizeMB(small.lsm.Levels[1].TotalSize/small.config.MetadataOverheadFactor(), 64), small.metrics.PerLevelKeys[1])
+}
+
+// TestDiskIOPS verifies that with DiskIOPS set, I/O takes whichever is longer of its bandwidth
+// and its operation count, and that the operations issued are reported as IOPS utilization
+func TestDiskIOPS(t *testing.T) {
+	require.Zero(t, diskOps(0, 0))
+	require.Equal(t, 1.0, diskOps(0, 0.01), "a small append is one operation")
+	require.Equal(t, float64(2*diskOpsPerFile+4), diskOps(2, 3.5))
+
+	config := DefaultConfig()
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.InDelta(t, 10/config.IOThroughputMBps, sim.diskIOTime(10, 1000), 1e-9, "bandwidth only when DiskIOPS is 0")
+
+	config.DiskIOPS = 100
+	sim, err = NewSimulator(config)
+	require.NoError(t, err)
+	require.InDelta(t, 10.0, sim.diskIOTime(10, 1000), 1e-9, "many small operations are IOPS-bound")
+	require.InDelta(t, 100/config.IOThroughputMBps, sim.diskIOTime(100, 10), 1e-9, "few large operations are bandwidth-bound")
+
+	config.DiskIOPS = -1
+	require.Error(t, config.Validate())
+
+	run := func(iops float64) *Simulator {
+		config := DefaultConfig()
+		config.WriteRateMBps = 20
+		config.RandomSeed = 42
+		config.DiskIOPS = iops
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(120)
+		return sim
+	}
+
+	bandwidth := run(0)
+	require.Zero(t, bandwidth.metrics.IOPSUtilizationPercent)
+
+	limited := run(50)
+	require.Greater(t, limited.metrics.IOPSUtilizationPercent, 0.0)
+	require.LessOrEqual(t, limited.metrics.IOPSUtilizationPercent, 100.0)
+	require.Greater(t, limited.TimeBreakdown()["flush"], bandwidth.TimeBreakdown()["flush"],
+		"flushes wait on operations, not bytes")
+}
+
+// TestCompactionRateLimit verifies the compaction rate limiter delays jobs once earlier jobs have
+// spent the budget, and that compaction I/O is reported against it
+func TestCompactionRateLimit(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionRateLimitMBps = 10
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	require.Equal(t, 0.0, sim.acquireCompactionRateLimit(0, 50), "first job starts at once and overdraws the budget")
+	require.InDelta(t, 5.0, sim.acquireCompactionRateLimit(1, 20), 1e-9, "40 MB of debt left at t=1 takes 4s to repay")
+	require.InDelta(t, 7.0, sim.acquireCompactionRateLimit(2, 10), 1e-9, "queues behind the previous job's 20 MB")
+	require.InDelta(t, 100.0, sim.acquireCompactionRateLimit(100, 10), 1e-9, "budget refilled while idle")
+
+	config.CompactionRateLimitMBps = -1
+	require.Error(t, config.Validate())
+
+	run := func(limitMBps int) *Simulator {
+		config := DefaultConfig()
+		config.WriteRateMBps = 20
+		config.RandomSeed = 42
+		config.CompactionRateLimitMBps = limitMBps
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(300)
+		return sim
+	}
+
+	unlimited := run(0)
+	require.Zero(t, unlimited.metrics.CompactionRateLimitDelaySeconds)
+	require.Zero(t, unlimited.metrics.CompactionRateLimitUtilizationPercent)
+
+	limited := run(10)
+	require.Greater(t, limited.metrics.CompactionRateLimitDelaySeconds, 0.0)
+	require.Greater(t, limited.metrics.CompactionRateLimitUtilizationPercent, 0.0)
+	require.LessOrEqual(t, limited.metrics.CompactionRateLimitUtilizationPercent, 100.0)
+	require.Less(t, limited.metrics.totalCompactionInputMB, unlimited.metrics.totalCompactionInputMB,
+		"throttled compactions fall behind")
+}
+
+// TestStallDurationPercentiles verifies completed stall durations feed the stall percentiles and
+// count, and that ResetAggregateStats clears only the per-update count
+func TestStallDurationPercentiles(t *testing.T) {
+	m := NewMetrics()
+	for i := 100; i >= 1; i-- {
+		m.RecordStall(float64(i))
+	}
+	require.Equal(t, 100, m.StallCount)
+	require.InDelta(t, 50.5, m.StallDurationP50, 1e-9)
+	require.InDelta(t, 95.05, m.StallDurationP95, 1e-9)
+	require.InDelta(t, 99.01, m.StallDurationP99, 1e-9)
+
+	clone := m.Clone()
+	m.RecordStall(0.5)
+	require.Equal(t, 100, clone.StallCount)
+	require.Equal(t, 1.0, clone.stallDurations[0], "recording doesn't disturb earlier clones")
+
+	require.Equal(t, 101, m.StallsSinceUpdate)
+	m.ResetAggregateStats()
+	require.Equal(t, 101, m.StallCount)
+	require.InDelta(t, 99.0, m.StallDurationP99, 1e-9)
+	require.Zero(t, m.StallsSinceUpdate)
+	m.RecordStall(2)
+	require.Equal(t, 102, m.StallCount)
+	require.Equal(t, 1, m.StallsSinceUpdate)
+
+	// A disk too slow for the write rate stalls repeatedly
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	config.IOThroughputMBps = 15
+	config.RandomSeed = 42
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	sim.StepUntil(120)
+	require.Greater(t, sim.metrics.StallCount, 0)
+	require.Greater(t, sim.metrics.StallDurationP50, 0.0)
+	require.GreaterOrEqual(t, sim.metrics.StallDurationP95, sim.metrics.StallDurationP50)
+	require.GreaterOrEqual(t, sim.metrics.StallDurationP99, sim.metrics.StallDurationP95)
+}
+
+// TestRestartCacheWarmth verifies a restart cools the block cache down to CachePersistedFraction,
+// spiking read latency until the cache re-warms, and that a persisted cache softens the spike
+func TestRestartCacheWarmth(t *testing.T) {
+	run := func(persisted float64) *Simulator {
+		config := DefaultConfig()
+		config.WriteRateMBps = 10
+		config.RandomSeed = 42
+		config.CachePersistedFraction = persisted
+		config.CacheWarmupSeconds = 60
+		readWorkload := DefaultReadWorkload()
+		readWorkload.Enabled = true
+		config.ReadWorkload = &readWorkload
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.StepUntil(60)
+		require.Equal(t, 1.0, sim.metrics.CacheWarmth)
+
+		sim.Restart()
+		require.InDelta(t, persisted, sim.metrics.CacheWarmth, 1e-9)
+		require.Equal(t, 1, sim.metrics.Restarts)
+		sim.StepUntil(600)
+		return sim
+	}
+
+	cold := run(0)
+	require.Equal(t, 1.0, cold.metrics.CacheWarmth, "re-warmed after ~9 time constants")
+	require.Greater(t, cold.metrics.ReadLatencyRecoverySeconds, 0.0)
+	require.Greater(t, cold.metrics.PostRestartPeakReadLatencyMs, cold.preRestartReadLatencyMs)
+
+	warm := run(0.8)
+	require.Less(t, warm.metrics.PostRestartPeakReadLatencyMs, cold.metrics.PostRestartPeakReadLatencyMs)
+	require.Less(t, warm.metrics.ReadLatencyRecoverySeconds, cold.metrics.ReadLatencyRecoverySeconds)
+
+	config := DefaultConfig()
+	config.CachePersistedFraction = 1.5
+	require.Error(t, config.Validate())
+}
+
+// TestBusyTargetFiles verifies a compaction whose target files are inputs of a running compaction
+// is deferred (handed back to the compactor) by default, and with RepickBusyTargetFiles avoids the
+// busy files by picking free files of the same level instead; busy source files always defer
+func TestBusyTargetFiles(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.NumLevels = 3
+	config.RandomSeed = 42
+
+	setup := func(config SimConfig) (*Simulator, *LeveledCompactor, []*SSTFile) {
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			sim.lsm.CreateSSTFile(1, 64, 0)
+		}
+		l1 := sim.lsm.Levels[1].Files
+		// First compaction (L1→L2) is running with the first two L1 files as its sources
+		sim.pendingCompactions[1] = &CompactionJob{ID: 1, FromLevel: 1, ToLevel: 2, SourceFiles: []*SSTFile{l1[0], l1[1]}}
+		compactor := sim.compactor.(*LeveledCompactor)
+		compactor.activeCompactions[0] = true // As if PickCompaction just picked the L0 job below
+		return sim, compactor, l1
+	}
+	l0Job := func(targets ...*SSTFile) *CompactionJob {
+		return &CompactionJob{FromLevel: 0, ToLevel: 1, SourceFiles: []*SSTFile{{ID: "l0", SizeMB: 64}}, TargetFiles: targets}
+	}
+
+	// Default: the second compaction (L0→L1) waits for the first to release L1[0]
+	sim, compactor, l1 := setup(config)
+	job := l0Job(l1[0], l1[2])
+	require.False(t, sim.resolveBusyTargetFiles(sim.columnFamily(0), job))
+	require.Equal(t, 1, sim.metrics.CompactionsDeferredForBusyTargets)
+	require.False(t, compactor.activeCompactions[0], "deferred job is released so L0 can be picked again")
+
+	// Free targets are left alone
+	job = l0Job(l1[2], l1[3])
+	require.True(t, sim.resolveBusyTargetFiles(sim.columnFamily(0), job))
+	require.Equal(t, []*SSTFile{l1[2], l1[3]}, job.TargetFiles)
+
+	// Re-pick: the busy file is swapped for the free one the job didn't already have
+	config.RepickBusyTargetFiles = true
+	sim, compactor, l1 = setup(config)
+	job = l0Job(l1[0], l1[2])
+	require.True(t, sim.resolveBusyTargetFiles(sim.columnFamily(0), job))
+	require.Equal(t, []*SSTFile{l1[2], l1[3]}, job.TargetFiles)
+	require.Equal(t, 1, sim.metrics.RepickedBusyTargetFiles)
+	require.True(t, compactor.activeCompactions[0], "re-picked job still runs")
+
+	// Not enough free files to keep the overlap count: defer after all
+	job = l0Job(l1[0], l1[1], l1[2])
+	require.False(t, sim.resolveBusyTargetFiles(sim.columnFamily(0), job))
+	require.Equal(t, 1, sim.metrics.CompactionsDeferredForBusyTargets)
+
+	// Busy sources are never re-picked: a deeper job can't merge files a shallower one is rewriting
+	sim.pendingCompactions[1].TargetFiles = []*SSTFile{{ID: "l2", SizeMB: 64}}
+	job = &CompactionJob{FromLevel: 2, ToLevel: 3, SourceFiles: sim.pendingCompactions[1].TargetFiles}
+	require.False(t, sim.resolveBusyTargetFiles(sim.columnFamily(0), job))
+	require.Equal(t, 2, sim.metrics.CompactionsDeferredForBusyTargets)
+}
+
+func TestColumnFamilies(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.RandomSeed = 42
+	config.WriteRateMBps = 20
+	config.SimulationSpeedMultiplier = 1
+
+	run := func(config SimConfig, seconds int) *Simulator {
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		for i := 0; i < seconds; i++ {
+			sim.Step()
+			require.LessOrEqual(t, len(sim.pendingCompactions), config.MaxBackgroundJobs+1,
+				"column families share the background job pool (one urgent L0 job may queue past it)")
+		}
+		return sim
+	}
+
+	// No column families configured: exactly the single-tree simulation
+	baseline := run(config, 300)
+	require.Empty(t, baseline.columnFamilies)
+	_, ok := baseline.State()["columnFamilies"]
+	require.False(t, ok)
+
+	config.ColumnFamilies[0] = ColumnFamilyConfig{Name: "index", WriteRateMBps: 10, MemtableFlushSizeMB: 32}
+	config.ColumnFamilies[1] = ColumnFamilyConfig{Name: "blobs", WriteRateMBps: 15, CompactionStyle: "universal"}
+	sim := run(config, 300)
+	require.Len(t, sim.columnFamilies, 2)
+	require.Equal(t, CompactionStyleUniversal, sim.columnFamily(2).config.CompactionStyle)
+	for _, family := range sim.columnFamilies {
+		require.Greater(t, family.lsm.TotalSizeMB, 0.0, "%s flushed to its own tree", family.name)
+	}
+	require.Less(t, sim.columnFamily(1).lsm.Levels[0].TotalSize, sim.columnFamily(1).lsm.TotalSizeMB,
+		"index column family was compacted out of L0")
+	require.Greater(t, sim.metrics.TotalDataWrittenMB, baseline.metrics.TotalDataWrittenMB,
+		"column family writes count toward user writes")
+
+	// Column family compactions are tagged and stay out of the default tree's per-level stats
+	familyCompactions := 0
+	for _, record := range sim.CompactionHistory() {
+		if record.ColumnFamily != 0 {
+			familyCompactions++
+		}
+	}
+	require.Greater(t, familyCompactions, 0)
+	defaultCompactions := 0
+	for _, stats := range sim.metrics.CompactionsSinceUpdate {
+		defaultCompactions += stats.Count
+	}
+	require.Equal(t, sim.metrics.TotalCompactionsCompleted-familyCompactions, defaultCompactions,
+		"only the default column family's compactions are counted per level")
+	for _, info := range sim.activeCompactionInfos {
+		job := false
+		for _, pending := range sim.pendingCompactions {
+			job = job || (pending.ColumnFamily == info.ColumnFamily && pending.FromLevel == info.FromLevel)
+		}
+		require.True(t, job, "active compaction info %+v matches a scheduled job", *info)
+	}
+
+	states := sim.State()["columnFamilies"].([]map[string]interface{})
+	require.Len(t, states, 3)
+	require.Equal(t, "default", states[0]["name"])
+	require.Equal(t, "index", states[1]["name"])
+	require.Equal(t, "blobs", states[2]["name"])
+	require.Equal(t, "universal", states[2]["compactionStyle"])
+	require.Equal(t, 15.0, states[2]["writeRateMBps"])
+	require.Empty(t, sim.SelfCheck())
+
+	// Changing the column families at runtime resets the simulation with the new ones
+	updated := sim.config
+	updated.ColumnFamilies[1] = ColumnFamilyConfig{}
+	require.NoError(t, sim.UpdateConfig(updated))
+	require.Len(t, sim.columnFamilies, 1)
+	require.Equal(t, 0.0, sim.VirtualTime())
+
+	// Names must be unique and can't shadow the default column family
+	config.ColumnFamilies[1].Name = "index"
+	require.Error(t, config.Validate())
+	config.ColumnFamilies[1].Name = "default"
+	require.Error(t, config.Validate())
+	config.ColumnFamilies[1] = ColumnFamilyConfig{Name: "bad", CompactionStyle: "btree"}
+	require.Error(t, config.Validate())
+}
+
+func TestQueuedReadLatency(t *testing.T) {
+	config := DefaultConfig()
+	config.RandomSeed = 42
+	config.WriteRateMBps = 0
+	config.IOLatencyMs = 1
+	config.IOThroughputMBps = 100
+	readWorkload := DefaultReadWorkload()
+	readWorkload.Enabled = true
+	config.ReadWorkload = &readWorkload
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+
+	// Idle disk: reads take only their seeks and transfer
+	sim.processScheduleRead(NewScheduleReadEvent(sim.virtualTime))
+	idleP99 := sim.metrics.ReadLatencyMsP99
+	require.Greater(t, sim.metrics.ReadLatencyMs, 0.0)
+	require.Less(t, idleP99, 10.0)
+
+	// A compaction holding the disk for 2s: the next batch queues behind it
+	sim.virtualTime = 1
+	sim.diskBusyUntil = 3
+	sim.processScheduleRead(NewScheduleReadEvent(sim.virtualTime))
+	require.Greater(t, sim.metrics.ReadLatencyMsP99, 2000.0, "reads wait for the disk to come free")
+	require.Greater(t, sim.metrics.ReadLatencyMs, idleP99)
+	require.Greater(t, sim.diskBusyUntil, 3.0, "background I/O reserved after the batch queues behind it")
+
+	// Outside the throughput window the spike no longer counts
+	sim.virtualTime = 1 + sim.metrics.throughputWindow + 1
+	sim.diskBusyUntil = 0
+	sim.processScheduleRead(NewScheduleReadEvent(sim.virtualTime))
+	require.InDelta(t, idleP99, sim.metrics.ReadLatencyMsP99, 1.0)
+}
+
+func TestOOMRisk(t *testing.T) {
+	m := NewMetrics()
+
+	raised, _ := m.UpdateOOMRisk(1, 0, 200, 30)
+	require.False(t, raised)
+	// Smoothed growth 0.2*100 = 20 MB/s with 100 MB of headroom: 5s to OOM
+	raised, secondsToOOM := m.UpdateOOMRisk(2, 100, 200, 0.5)
+	require.False(t, raised, "OOM projected further out than the warning horizon")
+	require.InDelta(t, 5.0, secondsToOOM, 1e-9)
+	// Smoothed growth 0.2*10 + 0.8*20 = 18 MB/s with 90 MB of headroom: 5s to OOM
+	raised, secondsToOOM = m.UpdateOOMRisk(3, 110, 200, 30)
+	require.True(t, raised)
+	require.True(t, m.OOMRisk)
+	require.InDelta(t, 5.0, secondsToOOM, 1e-9)
+	raised, _ = m.UpdateOOMRisk(4, 120, 200, 30)
+	require.False(t, raised, "raised only when the risk first appears")
+	require.True(t, m.OOMRisk)
+	// One flat sample doesn't clear the risk
+	raised, _ = m.UpdateOOMRisk(5, 120, 200, 30)
+	require.False(t, raised)
+	require.True(t, m.OOMRisk)
+
+	// Backlog draining: no risk
+	m.UpdateOOMRisk(6, 50, 200, 30)
+	require.False(t, m.OOMRisk)
+
+	// Disabled
+	m.UpdateOOMRisk(7, 150, 200, 0)
+	require.False(t, m.OOMRisk)
+
+	// A run headed for OOM is flagged before it dies
+	config := DefaultConfig()
+	config.WriteRateMBps = 200
+	config.IOThroughputMBps = 100
+	config.MaxWriteBufferNumber = 3
+	config.MaxStalledWriteMemoryMB = 256
+	config.OOMWarningSeconds = 30
+	config.SimulationSpeedMultiplier = 1
+	config.RandomSeed = 42
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	var logs []string
+	sim.LogEvent = func(msg string) { logs = append(logs, msg) }
+	require.NoError(t, sim.Reset())
+	warned := false
+	for i := 0; i < 60 && !sim.metrics.IsOOMKilled; i++ {
+		sim.Step()
+		warned = warned || sim.metrics.OOMRisk
+	}
+	require.True(t, sim.metrics.IsOOMKilled)
+	require.True(t, warned, "oomRisk set before the kill")
+	require.Contains(t, fmt.Sprint(logs), "OOM RISK", "warning logged")
+}
+
+func TestAdaptiveStepping(t *testing.T) {
+	config := DefaultConfig()
+	config.RandomSeed = 42
+	config.WriteRateMBps = 5
+	config.SimulationSpeedMultiplier = 1
+	config.AdaptiveStepMaxSeconds = 16
+
+	run := func(config SimConfig, until float64) (*Simulator, int) {
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		steps := 0
+		for sim.VirtualTime() < until {
+			sim.Step()
+			steps++
+		}
+		return sim, steps
+	}
+
+	// Quiescent: iterations double up to the maximum, so far fewer Steps cover the run
+	coarse, coarseSteps := run(config, 600)
+	require.Equal(t, 16.0, coarse.metrics.StepSeconds)
+	fixedConfig := config
+	fixedConfig.AdaptiveStepMaxSeconds = 0
+	fixed, fixedSteps := run(fixedConfig, 600)
+	require.Equal(t, 1.0, fixed.metrics.StepSeconds)
+	require.Less(t, coarseSteps, fixedSteps/4)
+	require.Equal(t, fixed.lsm.TotalSizeMB, coarse.lsm.TotalSizeMB, "events are processed identically, only sampled less often")
+
+	// Approaching a stall: back to fine steps
+	config.WriteRateMBps = 200
+	config.IOThroughputMBps = 100
+	config.MaxWriteBufferNumber = 3
+	busy, _ := run(config, 30)
+	require.Equal(t, config.BaseStepSeconds, busy.metrics.StepSeconds)
+	require.Greater(t, busy.metrics.AdaptiveStepRefinements, 0)
+
+	config.AdaptiveStepMaxSeconds = 0.5
+	require.Error(t, config.Validate(), "maximum below baseStepSeconds")
+}
+
+func TestCompactionObjectiveMinReadAmp(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.RandomSeed = 42
+	config.WriteRateMBps = 40
+	config.MaxBackgroundJobs = 2
+	config.SimulationSpeedMultiplier = 1
+
+	run := func(config SimConfig) *Simulator {
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		for i := 0; i < 600; i++ {
+			sim.Step()
+		}
+		return sim
+	}
+
+	score := run(config)
+	require.Equal(t, 0, score.metrics.ReadAmpObjectiveOverrides)
+	require.Greater(t, score.metrics.AvgReadAmplification, 0.0)
+	require.GreaterOrEqual(t, score.metrics.PeakReadAmplification, score.metrics.AvgReadAmplification)
+
+	config.CompactionObjective = CompactionObjectiveMinReadAmp
+	minReadAmp := run(config)
+	require.Greater(t, minReadAmp.metrics.ReadAmpObjectiveOverrides, 0, "L0 compactions ran ahead of higher-scoring deeper levels")
+	require.LessOrEqual(t, minReadAmp.metrics.AvgReadAmplification, score.metrics.AvgReadAmplification)
+
+	config.CompactionObjective = "max_throughput"
+	require.Error(t, config.Validate())
+}
+
+// TestMinReadAmpPicksL0First tests that min_read_amp picks L0 as if every deeper level were
+// paused, leaves user-paused levels paused, and falls back to the score-driven pick when L0
+// has nothing to do
+func TestMinReadAmpPicksL0First(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.RandomSeed = 42
+	config.LevelCompactionDynamicLevelBytes = false
+	config.CompactionPriority = CompactionPriorityRoundRobin
+	config.CompactionObjective = CompactionObjectiveMinReadAmp
+
+	build := func(l0Files int) *Simulator {
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		for i := 0; i < l0Files; i++ {
+			sim.lsm.CreateSSTFile(0, 64, 0)
+		}
+		for i := 0; i < 200; i++ {
+			sim.lsm.CreateSSTFile(2, 64, 0) // Scores well above L0
+		}
+		return sim
+	}
+
+	sim := build(8)
+	require.NoError(t, sim.PauseLevelCompaction(3))
+	job := sim.pickCompaction(sim.columnFamily(0))
+	require.NotNil(t, job)
+	require.Equal(t, 0, job.FromLevel, "L0 runs ahead of the higher-scoring L2")
+	require.Equal(t, 1, sim.metrics.ReadAmpObjectiveOverrides)
+	require.Equal(t, map[int]bool{0: true}, sim.compactor.(*LeveledCompactor).activeCompactions)
+	require.True(t, sim.lsm.Levels[3].CompactionPaused, "user pause survives the pick")
+	require.False(t, sim.lsm.Levels[2].CompactionPaused)
+
+	reference := build(8)
+	require.NoError(t, reference.PauseLevelCompaction(2))
+	expected := reference.compactor.PickCompaction(reference.lsm, reference.config)
+	require.Equal(t, expected, job)
+	require.Equal(t, reference.RNGState(), sim.RNGState())
+
+	// Nothing due in L0: the compactor's own pick
+	sim = build(0)
+	job = sim.pickCompaction(sim.columnFamily(0))
+	require.NotNil(t, job)
+	require.Equal(t, 2, job.FromLevel)
+	require.Zero(t, sim.metrics.ReadAmpObjectiveOverrides)
+}
+
+func TestStateSnapshot(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.RandomSeed = 42
+	config.WriteRateMBps = 50
+	config.SimulationSpeedMultiplier = 1
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	for i := 0; i < 120; i++ {
+		sim.Step()
+	}
+
+	snap := sim.StateSnapshot()
+	state := sim.State()
+	require.Equal(t, sim.VirtualTime(), snap.VirtualTime)
+	require.Equal(t, config.NumLevels, snap.NumLevels)
+	require.Len(t, snap.Levels, snap.NumLevels)
+	require.Equal(t, state["totalSizeMB"], snap.TotalSizeMB)
+	require.Equal(t, state["activeMemtableMB"], snap.ActiveMemtableMB)
+	require.Equal(t, state["activeCompactions"], snap.ActiveCompactions)
+	require.Len(t, snap.ActiveCompactionInfos, len(sim.activeCompactionInfos))
+	require.Len(t, snap.ImmutableMemtableSizesMB, sim.numImmutableMemtables)
+
+	levels := state["levels"].([]map[string]interface{})
+	var totalMB float64
+	for i, level := range snap.Levels {
+		require.Equal(t, i, level.Level)
+		require.Equal(t, levels[i]["fileCount"], level.FileCount)
+		require.Equal(t, levels[i]["totalSizeMB"], level.SizeMB)
+		require.Equal(t, levels[i]["targetSizeMB"], level.TargetSizeMB)
+		totalMB += level.SizeMB
+	}
+	require.InDelta(t, snap.TotalSizeMB, totalMB, 0.001)
+	require.Greater(t, snap.Levels[1].FileCount+snap.Levels[len(snap.Levels)-1].FileCount, 0, "data was compacted out of L0")
+
+	// The snapshot doesn't change as the simulation advances
+	levelsBefore := fmt.Sprint(snap.Levels)
+	for i := 0; i < 30; i++ {
+		sim.Step()
+	}
+	require.Equal(t, levelsBefore, fmt.Sprint(snap.Levels))
+}
+
+// TestSubcompactionParallelism tests that a 4-way subcompaction of equal pieces finishes its CPU
+// work in a quarter of the time, while pieces sharing the disk get no I/O speedup
+func TestSubcompactionParallelism(t *testing.T) {
+	// compactionDuration schedules one L0→L1 compaction of four 64 MB files and returns its duration
+	compactionDuration := func(maxSubcompactions int, ioThroughputMBps, buildThroughputMBps float64) (float64, *Metrics) {
+		config := DefaultConfig()
+		config.MaxSubcompactions = maxSubcompactions
+		config.IOThroughputMBps = ioThroughputMBps
+		config.IOLatencyMs = 0
+		config.DecompressionThroughputMBps = 0
+		config.SSTableBuildThroughputMBps = buildThroughputMBps
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		sim.placeFiles(0, 4, 64)
+
+		require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+		require.Len(t, sim.activeCompactionInfos, 1)
+		require.Equal(t, min(maxSubcompactions, 4), sim.activeCompactionInfos[0].Subcompactions)
+		completion := 0.0
+		for _, busyUntil := range sim.backgroundJobSlots {
+			completion = max(completion, busyUntil)
+		}
+		return completion, sim.Metrics()
+	}
+
+	// CPU-bound: the disk is effectively free, so four pieces take a quarter of the time
+	single, _ := compactionDuration(1, 1e6, 50)
+	parallel, metrics := compactionDuration(4, 1e6, 50)
+	require.InDelta(t, single/4, parallel, single*0.01, "single %.3fs, 4-way %.3fs", single, parallel)
+	require.Equal(t, 1, metrics.SubcompactionJobs)
+	require.InDelta(t, single-parallel, metrics.SubcompactionSecondsSaved, single*0.01)
+
+	// I/O-bound: the pieces split IOThroughputMBps between them, so there is no speedup
+	single, _ = compactionDuration(1, 100, 0)
+	parallel, metrics = compactionDuration(4, 100, 0)
+	require.InDelta(t, single, parallel, 1e-9)
+	require.Equal(t, 0.0, metrics.SubcompactionSecondsSaved)
+}
+
+// TestValidateWithWarnings tests that suspicious settings warn without failing validation, and
+// that the defaults are warning-free
+func TestValidateWithWarnings(t *testing.T) {
+	for _, config := range []SimConfig{DefaultConfig(), ThreeLevelConfig()} {
+		config.CompactionStyle = CompactionStyleLeveled
+		warnings, err := config.ValidateWithWarnings()
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	}
+
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleLeveled
+	config.LevelMultiplier = 1
+	config.MaxBackgroundJobs = 1
+	config.WriteRateMBps = config.IOThroughputMBps / 2
+	config.MaxBytesForLevelBaseMB = 64
+	warnings, err := config.ValidateWithWarnings()
+	require.NoError(t, err)
+	require.Len(t, warnings, 3)
+	require.NoError(t, config.Validate(), "warnings never fail Validate")
+
+	// Leveled-only warnings don't apply to universal
+	config.CompactionStyle = CompactionStyleUniversal
+	warnings, err = config.ValidateWithWarnings()
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+
+	config.WriteRateMBps = -1
+	warnings, err = config.ValidateWithWarnings()
+	require.Error(t, err)
+	require.Nil(t, warnings)
+}
+
+// TestPeekCompaction tests that a preview matches the job the next scheduling pass runs, and that
+// peeking leaves the run unchanged
+func TestPeekCompaction(t *testing.T) {
+	for _, style := range []CompactionStyle{CompactionStyleLeveled, CompactionStyleUniversal} {
+		t.Run(style.String(), func(t *testing.T) {
+			config := DefaultConfig()
+			config.CompactionStyle = style
+			config.RandomSeed = 42
+			sim, err := NewSimulator(config)
+			require.NoError(t, err)
+			require.NoError(t, sim.Reset())
+			require.Nil(t, sim.PeekCompaction(), "nothing to compact yet")
+
+			sim.placeFiles(0, 6, 64)
+			rngState := sim.RNGState()
+			preview := sim.PeekCompaction()
+			require.NotNil(t, preview)
+			require.Equal(t, rngState, sim.RNGState(), "peeking draws no random numbers")
+			require.Equal(t, preview, sim.PeekCompaction(), "peeking claims nothing")
+			require.True(t, preview.SlotAvailable)
+			require.Greater(t, preview.EstimatedOutputMB, 0.0)
+
+			require.True(t, sim.tryScheduleCompaction(sim.columnFamily(0)))
+			require.Len(t, sim.activeCompactionInfos, 1)
+			scheduled := sim.activeCompactionInfos[0]
+			require.Equal(t, preview.FromLevel, scheduled.FromLevel)
+			require.Equal(t, preview.ToLevel, scheduled.ToLevel)
+			require.Equal(t, preview.SourceFiles, scheduled.SourceFileCount)
+			require.Equal(t, preview.TargetFiles, scheduled.TargetFileCount)
+		})
+	}
+
+	// A run that peeks every step ends exactly where one that doesn't does
+	run := func(peek bool) *Simulator {
+		config := DefaultConfig()
+		config.CompactionStyle = CompactionStyleLeveled
+		config.RandomSeed = 7
+		config.WriteRateMBps = 40
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+		for i := 0; i < 200; i++ {
+			if peek {
+				sim.PeekCompaction()
+			}
+			sim.Step()
+		}
+		return sim
+	}
+	require.Equal(t, run(false).State(), run(true).State())
+}
+
+// TestSeed tests that a randomSeed=0 run reports the seed it drew, and that running again with
+// that seed reproduces it
+func TestSeed(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	config.RandomSeed = 42
+	seeded, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), seeded.Seed(), "An explicit seed is used as-is")
+
+	config.RandomSeed = 0
+	readWorkload := DefaultReadWorkload()
+	config.ReadWorkload = &readWorkload
+	original, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, original.Reset())
+	require.NotZero(t, original.Seed(), "A randomSeed=0 run draws a seed")
+	require.Zero(t, original.Config().RandomSeed, "The config keeps asking for a random seed")
+	for i := 0; i < 200; i++ {
+		original.Step()
+	}
+
+	config.RandomSeed = original.Seed()
+	replay, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, replay.Reset())
+	for i := 0; i < 200; i++ {
+		replay.Step()
+	}
+	require.NotEmpty(t, original.CompactionHistory())
+	require.Equal(t, original.State(), replay.State())
+	require.Equal(t, original.Metrics(), replay.Metrics())
+	require.Equal(t, original.CompactionHistory(), replay.CompactionHistory())
+
+	// Pinning the drawn seed doesn't restart the run
+	pinned := original.Config()
+	pinned.RandomSeed = original.Seed()
+	virtualTime := original.VirtualTime()
+	require.NoError(t, original.UpdateConfig(pinned))
+	require.Equal(t, virtualTime, original.VirtualTime())
+	require.Equal(t, config.RandomSeed, original.Seed())
+}
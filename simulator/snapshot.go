@@ -0,0 +1,246 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout changes incompatibly
+const snapshotVersion = 2
+
+// maxJournalEntries bounds the replay journal. A run with more external mutations since its last
+// Reset stops journaling and can't be snapshotted until the next Reset.
+const maxJournalEntries = 10000
+
+// journalEntry is one external mutation of the simulator (anything other than Step, plus the
+// steps StepToward cuts short), recorded so RestoreSnapshot can replay the run. Only the fields
+// the operation needs are set.
+type journalEntry struct {
+	Steps      int64           `json:"steps"` // Step() calls completed before the operation
+	Op         string          `json:"op"`    // "reset", "update_config", "set_speed", "pause_level", "resume_level", "manual_compaction", "ingest", "place_files", "refit_levels", "reset_metrics", "restart", "set_rng_state", "step_toward"
+	Config     *SimConfig      `json:"config,omitempty"`
+	Level      int             `json:"level,omitempty"`
+	Count      int             `json:"count,omitempty"`
+	SizeMB     float64         `json:"sizeMB,omitempty"`
+	Timestamp  float64         `json:"timestamp,omitempty"`
+	Multiplier int             `json:"multiplier,omitempty"`
+	Seed       int64           `json:"seed,omitempty"` // For "reset": the seed the run drew or was given
+	RNGState   json.RawMessage `json:"rngState,omitempty"`
+}
+
+// simulatorSnapshot is the serialized form produced by Snapshot
+type simulatorSnapshot struct {
+	Version int `json:"version"`
+
+	// Replay: the configuration the run started from, every external mutation since, and the
+	// number of steps taken. Replaying these from the same seed rebuilds all internal state -
+	// compactor bookkeeping, traffic model state and every RNG's position - exactly.
+	OriginConfig SimConfig      `json:"originConfig"`
+	Journal      []journalEntry `json:"journal"`
+	Steps        int64          `json:"steps"`
+	RandomSeed   int64          `json:"randomSeed"` // The seed the run used, drawn if originConfig's is 0
+
+	// Recorded state: checked against the replay, and readable by external tools
+	Config                   SimConfig       `json:"config"`
+	VirtualTime              float64         `json:"virtualTime"`
+	LSM                      json.RawMessage `json:"lsm"`                // Levels, files and memtable state
+	Metrics                  json.RawMessage `json:"metrics"`            // Exported fields replace the replayed ones on restore
+	RNGState                 json.RawMessage `json:"rngState,omitempty"` // Generator positions (RNGState)
+	ImmutableMemtableSizesMB []float64       `json:"immutableMemtableSizesMB"`
+	Queue                    []string        `json:"queue"` // Pending events, earliest first
+	SteadyState              *SteadyState    `json:"steadyState,omitempty"`
+}
+
+// record appends an external mutation to the replay journal. Past maxJournalEntries the journal
+// is dropped instead, and the run can't be snapshotted until the next Reset.
+func (s *Simulator) record(entry journalEntry) {
+	if len(s.journal) >= maxJournalEntries {
+		s.stopJournal(fmt.Sprintf("more than %d external changes since the last reset", maxJournalEntries))
+	}
+	if s.unjournaled != "" {
+		return
+	}
+	entry.Steps = s.steps
+	s.journal = append(s.journal, entry)
+}
+
+// stopJournal drops the replay journal because the run can no longer be replayed (reason says
+// why); Snapshot fails until the next Reset starts a new journal
+func (s *Simulator) stopJournal(reason string) {
+	if s.unjournaled == "" {
+		s.unjournaled = reason
+	}
+	s.journal = nil
+}
+
+// Snapshot serializes the simulator so the run can be checkpointed and forked with
+// RestoreSnapshot. It captures virtual time, the LSM tree (levels, files, memtables), the
+// pending event queue, metrics and the random seed, plus the number of steps taken and a
+// journal of every external mutation since the last Reset.
+//
+// The journal is what makes restoring exact: RNGState captures where each generator is, but
+// much of the compactors' and traffic models' internal state can't be read out, so
+// RestoreSnapshot re-runs the journal from the seed and then checks the result, generator
+// positions included, against the recorded state. The metrics' exported fields are restored
+// from the recorded values, so changes made directly through the *Metrics returned by Metrics(),
+// such as ResetAggregateStats, carry over.
+//
+// The journal holds at most maxJournalEntries mutations, and writes added with ScheduleWrite
+// aren't journaled (a driver adds one per write batch); a run with either can't be snapshotted
+// until its next Reset. A randomSeed=0 run is replayed with the seed it drew (see Seed). Not
+// captured: a SetClock override (restore into a simulator with the same clock).
+func (s *Simulator) Snapshot() ([]byte, error) {
+	if s.unjournaled != "" {
+		return nil, fmt.Errorf("snapshot: run can't be replayed: %s", s.unjournaled)
+	}
+	lsm, err := json.Marshal(s.lsm)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot LSM: %w", err)
+	}
+	metrics, err := json.Marshal(s.metrics)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot metrics: %w", err)
+	}
+
+	events := append([]Event(nil), s.queue.Events()...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp() < events[j].Timestamp() })
+	queue := make([]string, len(events))
+	for i, event := range events {
+		queue[i] = event.String()
+	}
+
+	return json.Marshal(simulatorSnapshot{
+		Version:                  snapshotVersion,
+		OriginConfig:             s.originConfig,
+		Journal:                  s.journal,
+		Steps:                    s.steps,
+		RandomSeed:               s.seed,
+		Config:                   s.config,
+		VirtualTime:              s.virtualTime,
+		LSM:                      lsm,
+		Metrics:                  metrics,
+		RNGState:                 s.RNGState(),
+		ImmutableMemtableSizesMB: s.immutableMemtableSizes,
+		Queue:                    queue,
+		SteadyState:              s.steadyState,
+	})
+}
+
+// RestoreSnapshot replaces the simulator's state with a snapshot taken by Snapshot. The run is
+// replayed from the snapshot's seed and journal (stdout debug output included, event log
+// suppressed), so restoring costs about as much as the original run took. The LogEvent
+// callback and clock override of this simulator are kept, as with Reset.
+//
+// Returns an error, leaving the simulator untouched, if the data isn't a snapshot or the replay
+// doesn't reproduce the recorded virtual time, LSM tree and RNG positions. The replayed
+// metrics' exported fields are then replaced by the recorded ones.
+func (s *Simulator) RestoreSnapshot(data []byte) error {
+	var snap simulatorSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("restore snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("restore snapshot: unsupported version %d (want %d)", snap.Version, snapshotVersion)
+	}
+
+	replay, err := newSimulator(snap.OriginConfig, snap.RandomSeed)
+	if err != nil {
+		return fmt.Errorf("restore snapshot: %w", err)
+	}
+	replay.clock = s.clock
+	for _, entry := range snap.Journal {
+		replay.stepTo(entry.Steps)
+		if err := replay.applyJournalEntry(entry); err != nil {
+			return fmt.Errorf("restore snapshot: replaying %s at step %d: %w", entry.Op, entry.Steps, err)
+		}
+	}
+	replay.stepTo(snap.Steps)
+
+	if replay.virtualTime != snap.VirtualTime {
+		return fmt.Errorf("restore snapshot: replay reached t=%.3fs, snapshot was taken at t=%.3fs", replay.virtualTime, snap.VirtualTime)
+	}
+	lsm, err := json.Marshal(replay.lsm)
+	if err != nil {
+		return fmt.Errorf("restore snapshot: %w", err)
+	}
+	if !bytes.Equal(lsm, snap.LSM) {
+		return fmt.Errorf("restore snapshot: replayed LSM tree differs from the snapshot at t=%.3fs", snap.VirtualTime)
+	}
+	if len(snap.RNGState) > 0 && !bytes.Equal(replay.RNGState(), snap.RNGState) {
+		return fmt.Errorf("restore snapshot: replayed RNG positions differ from the snapshot at t=%.3fs", snap.VirtualTime)
+	}
+	if err := restoreMetrics(replay.metrics, snap.Metrics); err != nil {
+		return fmt.Errorf("restore snapshot: metrics: %w", err)
+	}
+	replay.steadyState = snap.SteadyState
+
+	logEvent := s.LogEvent
+	*s = *replay
+	s.LogEvent = logEvent
+	return nil
+}
+
+// restoreMetrics sets m's exported fields to the values recorded in data (a marshaled Metrics),
+// keeping m's unexported bookkeeping. Fields are cleared first so recorded maps replace, rather
+// than merge into, the current ones.
+func restoreMetrics(m *Metrics, data []byte) error {
+	v := reflect.ValueOf(m).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() {
+			v.Field(i).SetZero()
+		}
+	}
+	return json.Unmarshal(data, m)
+}
+
+// stepTo calls Step until steps Step() calls have been made since the last Reset
+func (s *Simulator) stepTo(steps int64) {
+	for s.steps < steps {
+		s.Step()
+	}
+}
+
+// applyJournalEntry re-applies one recorded external mutation
+func (s *Simulator) applyJournalEntry(entry journalEntry) error {
+	switch entry.Op {
+	case "reset":
+		return s.reset(entry.Seed)
+	case "update_config":
+		if entry.Config == nil {
+			return fmt.Errorf("missing config")
+		}
+		return s.UpdateConfig(*entry.Config)
+	case "set_speed":
+		return s.SetSpeedMultiplier(entry.Multiplier)
+	case "pause_level":
+		return s.PauseLevelCompaction(entry.Level)
+	case "resume_level":
+		return s.ResumeLevelCompaction(entry.Level)
+	case "manual_compaction":
+		return s.RequestManualCompaction(entry.Level)
+	case "ingest":
+		return s.IngestFile(entry.Level, entry.SizeMB)
+	case "place_files":
+		s.placeFiles(entry.Level, entry.Count, entry.SizeMB)
+		return nil
+	case "refit_levels":
+		_, err := s.RefitLevels()
+		return err
+	case "reset_metrics":
+		s.ResetMetrics()
+		return nil
+	case "restart":
+		s.Restart()
+		return nil
+	case "set_rng_state":
+		return s.SetRNGState(entry.RNGState)
+	case "step_toward":
+		s.StepToward(entry.Timestamp)
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q", entry.Op)
+	}
+}
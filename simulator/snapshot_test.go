@@ -0,0 +1,180 @@
+package simulator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotRoundTrip tests that a simulator restored from a mid-run snapshot produces the
+// same future as the original
+func TestSnapshotRoundTrip(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	config.RandomSeed = 42
+	readWorkload := DefaultReadWorkload()
+	config.ReadWorkload = &readWorkload
+
+	original, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, original.Reset())
+	for i := 0; i < 100; i++ {
+		original.Step()
+	}
+	// External mutations between steps are part of the run
+	updated := original.Config()
+	updated.WriteRateMBps = 30
+	require.NoError(t, original.UpdateConfig(updated))
+	require.NoError(t, original.IngestFile(0, 32))
+	for i := 0; i < 50; i++ {
+		original.Step()
+	}
+	original.ResetMetrics()
+	for i := 0; i < 50; i++ {
+		original.Step()
+	}
+	// A step cut short at a breakpoint replays the same way
+	breakpoint := original.VirtualTime() + 0.5
+	original.StepToward(breakpoint)
+	require.Equal(t, breakpoint, original.VirtualTime())
+	original.StepToward(breakpoint)
+	require.Equal(t, breakpoint, original.VirtualTime(), "nothing left to run before the breakpoint")
+	original.StepToward(breakpoint + 1e6)
+	require.Greater(t, original.VirtualTime(), breakpoint)
+
+	require.NotEmpty(t, original.CompactionHistory(), "snapshot taken with compactions behind it")
+	// Changes made directly through Metrics() aren't journaled; the recorded values carry over
+	original.Metrics().ResetAggregateStats()
+	data, err := original.Snapshot()
+	require.NoError(t, err)
+
+	restored, err := NewSimulator(DefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, restored.RestoreSnapshot(data))
+	require.Equal(t, original.VirtualTime(), restored.VirtualTime())
+	require.Equal(t, original.Config(), restored.Config())
+	require.Empty(t, restored.Metrics().CompactionsSinceUpdate)
+	require.Equal(t, original.Metrics().TotalCompactionsCompleted, restored.Metrics().TotalCompactionsCompleted)
+
+	for i := 0; i < 100; i++ {
+		original.Step()
+		restored.Step()
+	}
+	require.Equal(t, original.State(), restored.State())
+	require.Equal(t, original.Metrics(), restored.Metrics())
+	require.Equal(t, original.CompactionHistory(), restored.CompactionHistory())
+}
+
+// TestSnapshotDrawnSeed tests that a randomSeed=0 run is replayed with the seed it drew, through
+// any number of resets
+func TestSnapshotDrawnSeed(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	config.RandomSeed = 0
+
+	original, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, original.Reset())
+	require.NoError(t, original.Reset(), "each reset draws a new seed")
+	for i := 0; i < 100; i++ {
+		original.Step()
+	}
+	data, err := original.Snapshot()
+	require.NoError(t, err)
+
+	restored, err := NewSimulator(DefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, restored.RestoreSnapshot(data))
+	require.Equal(t, original.Seed(), restored.Seed())
+	require.Equal(t, original.Config(), restored.Config(), "still a randomSeed=0 config")
+	for i := 0; i < 100; i++ {
+		original.Step()
+		restored.Step()
+	}
+	require.Equal(t, original.State(), restored.State())
+}
+
+// TestSnapshotErrors tests the cases a snapshot can't be taken or restored
+func TestSnapshotErrors(t *testing.T) {
+	config := DefaultConfig()
+	config.RandomSeed = 7
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, sim.Reset())
+	for i := 0; i < 20; i++ {
+		sim.Step()
+	}
+	data, err := sim.Snapshot()
+	require.NoError(t, err)
+
+	target, err := NewSimulator(DefaultConfig())
+	require.NoError(t, err)
+	require.Error(t, target.RestoreSnapshot([]byte("not a snapshot")))
+
+	// A snapshot whose recorded state doesn't match its replay is rejected
+	var snap simulatorSnapshot
+	require.NoError(t, json.Unmarshal(data, &snap))
+	snap.VirtualTime++
+	tampered, err := json.Marshal(snap)
+	require.NoError(t, err)
+	require.ErrorContains(t, target.RestoreSnapshot(tampered), "replay reached")
+	require.Zero(t, target.VirtualTime(), "failed restore leaves the simulator untouched")
+
+	// Writes added with ScheduleWrite aren't journaled, so the run can't be replayed until a reset
+	sim.ScheduleWrite(1, sim.VirtualTime())
+	require.Empty(t, sim.journal)
+	_, err = sim.Snapshot()
+	require.ErrorContains(t, err, "ScheduleWrite")
+	require.NoError(t, sim.Reset())
+	_, err = sim.Snapshot()
+	require.NoError(t, err)
+
+	// The journal is bounded
+	sim.journal = make([]journalEntry, maxJournalEntries)
+	require.NoError(t, sim.PauseLevelCompaction(1))
+	require.Empty(t, sim.journal)
+	_, err = sim.Snapshot()
+	require.ErrorContains(t, err, "external changes")
+}
+
+// TestRNGState tests that generator positions carry over to another simulator, so both draw
+// the same values from then on, and that a state from a differently built simulator is rejected
+func TestRNGState(t *testing.T) {
+	config := DefaultConfig()
+	config.WriteRateMBps = 20
+	config.RandomSeed = 0 // Time-seeded: the state must carry the seeds actually used
+	config.OverlapSeed = 7
+	readWorkload := DefaultReadWorkload()
+	readWorkload.Enabled = true
+	config.ReadWorkload = &readWorkload
+
+	original, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, original.Reset())
+	for i := 0; i < 100; i++ {
+		original.Step()
+	}
+	state := original.RNGState()
+
+	fork, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.NoError(t, fork.Reset())
+	require.NotEqual(t, state, fork.RNGState())
+	require.NoError(t, fork.SetRNGState(state))
+	require.Equal(t, state, fork.RNGState())
+
+	require.Equal(t, original.rng.Float64(), fork.rng.Float64())
+	originalSources := componentSources(original.compactor)
+	forkSources := componentSources(fork.compactor)
+	require.Len(t, originalSources, 4, "universal: compactor, overlap (own seed), file and sorted-run selection")
+	for i := range originalSources {
+		require.Equal(t, originalSources[i].Uint64(), forkSources[i].Uint64(), "compactor source %d", i)
+	}
+
+	config.CompactionStyle = CompactionStyleFIFO
+	other, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.Error(t, other.SetRNGState(state), "different compactor, different generators")
+	require.Error(t, other.SetRNGState([]byte("not json")))
+}
@@ -0,0 +1,184 @@
+package simulator
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+)
+
+// tieredMaxThreshold caps how many files one size-tiered compaction merges
+// (Cassandra STCS max_threshold default)
+const tieredMaxThreshold = 32
+
+// TieredCompactor implements size-tiered compaction (STCS) as in Cassandra and ScyllaDB.
+// Files are grouped into buckets of similar size; once a bucket holds TieredMinThreshold files
+// they are merged into one larger file, which in turn waits to join a bucket of its own size.
+// There are no levels: flushes land in L0 and every output stays there.
+//
+// FIDELITY: Cassandra Reference - SizeTieredCompactionStrategy
+// https://github.com/apache/cassandra/blob/trunk/src/java/org/apache/cassandra/db/compaction/SizeTieredCompactionStrategy.java
+//
+// FIDELITY: ⚠️ SIMPLIFIED - No min_sstable_size (tiny files bucket by ratio like any other),
+// no read hotness: among ready buckets the one with the smallest files is compacted first
+type TieredCompactor struct {
+	fileSelectDist filePicker        // OverlapDistribution: how many of a ready bucket's files one job merges
+	compacting     map[*SSTFile]bool // Files in a running job (several buckets may compact at once)
+	rngs           rngSet            // File selection RNG(s), for RNGState
+}
+
+// NewTieredCompactor creates a size-tiered compactor with the default (Geometric) file selection
+func NewTieredCompactor(seed int64) *TieredCompactor {
+	defaultOverlap := OverlapDistributionConfig{
+		Type:              DistGeometric,
+		GeometricP:        0.3,
+		ExponentialLambda: 0.5,
+	}
+	return NewTieredCompactorWithOverlapDist(seed, 0, defaultOverlap)
+}
+
+// NewTieredCompactorWithOverlapDist creates a size-tiered compactor that draws the number of files
+// merged per job from the given overlap distribution.
+// A non-zero overlapSeed gives file selection its own RNG.
+func NewTieredCompactorWithOverlapDist(seed, overlapSeed int64, overlapConfig OverlapDistributionConfig) *TieredCompactor {
+	var rngs rngSet
+	rngSeed := seed
+	if rngSeed == 0 {
+		rngSeed = time.Now().UnixNano()
+	}
+	rng := rngs.newRand(rngSeed)
+	fileSelectRNG := newOverlapRNG(rng, overlapSeed, &rngs)
+	return &TieredCompactor{
+		fileSelectDist: &distributionAdapter{dist: newOverlapDistribution(overlapConfig), rng: fileSelectRNG},
+		compacting:     make(map[*SSTFile]bool),
+		rngs:           rngs,
+	}
+}
+
+// randomSources returns the compactor's file selection RNG(s)
+func (c *TieredCompactor) randomSources() rngSet {
+	return c.rngs
+}
+
+// checkpoint saves the files claimed by picked jobs
+func (c *TieredCompactor) checkpoint() (restore func()) {
+	compacting := maps.Clone(c.compacting)
+	return func() {
+		c.compacting = compacting
+	}
+}
+
+// releaseJob forgets a picked job the simulator won't run, so its files can be picked again
+func (c *TieredCompactor) releaseJob(job *CompactionJob) {
+	for _, f := range job.SourceFiles {
+		delete(c.compacting, f)
+	}
+}
+
+// tieredBuckets groups files by size: sorted smallest first, each file joins the first bucket
+// whose average size it is within [bucketLow, bucketHigh] of, or starts a new bucket.
+// Buckets are returned in order of increasing average size, each sorted smallest file first.
+//
+// FIDELITY: ✓ Matches SizeTieredCompactionStrategy.getBuckets() (minus min_sstable_size)
+func tieredBuckets(files []*SSTFile, bucketLow, bucketHigh float64) [][]*SSTFile {
+	sorted := make([]*SSTFile, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SizeMB < sorted[j].SizeMB })
+
+	var buckets [][]*SSTFile
+	var averages []float64
+	for _, f := range sorted {
+		placed := false
+		for i, avg := range averages {
+			if f.SizeMB >= avg*bucketLow && f.SizeMB <= avg*bucketHigh {
+				n := float64(len(buckets[i]))
+				buckets[i] = append(buckets[i], f)
+				averages[i] = (avg*n + f.SizeMB) / (n + 1)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			buckets = append(buckets, []*SSTFile{f})
+			averages = append(averages, f.SizeMB)
+		}
+	}
+	return buckets
+}
+
+// readyBucket returns the bucket to compact next: the smallest-sized bucket with at least
+// TieredMinThreshold files not already being compacted (nil if none)
+func (c *TieredCompactor) readyBucket(lsm *LSMTree, config SimConfig) []*SSTFile {
+	if len(lsm.Levels) == 0 || lsm.Levels[0].CompactionPaused {
+		return nil
+	}
+	var available []*SSTFile
+	for _, f := range lsm.Levels[0].Files {
+		if !c.compacting[f] {
+			available = append(available, f)
+		}
+	}
+	for _, bucket := range tieredBuckets(available, config.TieredBucketLow, config.TieredBucketHigh) {
+		if len(bucket) >= config.TieredMinThreshold {
+			return bucket
+		}
+	}
+	return nil
+}
+
+// NeedsCompaction reports whether L0 (the only level size-tiered compaction uses) has a bucket
+// of at least TieredMinThreshold similarly-sized files
+func (c *TieredCompactor) NeedsCompaction(level int, lsm *LSMTree, config SimConfig) bool {
+	return level == 0 && c.readyBucket(lsm, config) != nil
+}
+
+// PickCompaction picks files from the next ready bucket: between TieredMinThreshold and
+// tieredMaxThreshold of its smallest files, the count drawn from the overlap distribution
+func (c *TieredCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
+	bucket := c.readyBucket(lsm, config)
+	if bucket == nil {
+		return nil
+	}
+	// Fixed 0% overlap samples 0: never merge fewer than min_threshold
+	count := max(config.TieredMinThreshold, pickFileCount(min(len(bucket), tieredMaxThreshold), config.TieredMinThreshold, c.fileSelectDist))
+	sourceFiles := selectFiles(bucket, count)
+	for _, f := range sourceFiles {
+		c.compacting[f] = true
+	}
+	return &CompactionJob{
+		FromLevel:   0,
+		ToLevel:     0,
+		SourceFiles: sourceFiles,
+		IsIntraL0:   true,
+		Reason:      "size_tiered",
+	}
+}
+
+// ExecuteCompaction merges the job's files into a single file that stays in L0.
+// Returns: inputSize (MB), outputSize (MB), outputFileCount
+func (c *TieredCompactor) ExecuteCompaction(job *CompactionJob, lsm *LSMTree, config SimConfig, virtualTime float64) (inputSize, outputSize float64, outputFileCount int) {
+	if job == nil {
+		return 0, 0, 0
+	}
+	defer func() {
+		for _, f := range job.SourceFiles {
+			delete(c.compacting, f)
+		}
+	}()
+
+	for _, f := range job.SourceFiles {
+		inputSize += f.SizeMB
+	}
+	outputSize = inputSize * config.CompactionReductionFactor(0) * (1 - config.CompactionGarbageFraction)
+
+	l0 := lsm.Levels[0]
+	l0.removeFiles(job.SourceFiles)
+	l0.AddFile(&SSTFile{
+		ID:        fmt.Sprintf("sst-%d", lsm.nextFileID),
+		SizeMB:    outputSize,
+		CreatedAt: virtualTime,
+	})
+	lsm.nextFileID++
+
+	return inputSize, outputSize, 1
+}
@@ -0,0 +1,106 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestTieredBuckets tests that files are grouped with others of similar size
+func TestTieredBuckets(t *testing.T) {
+	var files []*SSTFile
+	for _, size := range []float64{64, 60, 250, 70, 1000, 240, 66} {
+		files = append(files, &SSTFile{SizeMB: size})
+	}
+	buckets := tieredBuckets(files, 0.5, 1.5)
+	require.Len(t, buckets, 3)
+
+	sizes := func(bucket []*SSTFile) []float64 {
+		var out []float64
+		for _, f := range bucket {
+			out = append(out, f.SizeMB)
+		}
+		return out
+	}
+	require.Equal(t, []float64{60, 64, 66, 70}, sizes(buckets[0]))
+	require.Equal(t, []float64{240, 250}, sizes(buckets[1]))
+	require.Equal(t, []float64{1000}, sizes(buckets[2]))
+}
+
+// TestTieredCompactor tests that a bucket reaching min_threshold is merged into one file in L0,
+// that files already compacting aren't picked again, and that released files are
+func TestTieredCompactor(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleTiered
+	config.DeduplicationFactor = 1.0
+	config.OverlapDistribution = OverlapDistributionConfig{Type: DistFixed, FixedPercentage: 0}
+	require.NoError(t, config.Validate())
+
+	lsm := NewLSMTree(config.NumLevels, float64(config.MemtableFlushSizeMB))
+	compactor := NewTieredCompactorWithOverlapDist(42, 0, config.OverlapDistribution)
+	for i := 0; i < 3; i++ {
+		lsm.CreateSSTFile(0, 64, float64(i))
+	}
+	lsm.CreateSSTFile(0, 512, 3)
+	require.False(t, compactor.NeedsCompaction(0, lsm, config), "3 similar files are below min_threshold")
+	require.Nil(t, compactor.PickCompaction(lsm, config))
+
+	for i := 0; i < 3; i++ {
+		lsm.CreateSSTFile(0, 64, float64(4+i))
+	}
+	require.True(t, compactor.NeedsCompaction(0, lsm, config))
+	job := compactor.PickCompaction(lsm, config)
+	require.NotNil(t, job)
+	require.Len(t, job.SourceFiles, 4, "fixed 0%% distribution picks min_threshold files")
+	for _, f := range job.SourceFiles {
+		require.Equal(t, 64.0, f.SizeMB)
+	}
+	require.Nil(t, compactor.PickCompaction(lsm, config), "only 2 uncompacted 64 MB files remain")
+
+	// A job the simulator defers hands its files back
+	compactor.releaseJob(job)
+	repicked := compactor.PickCompaction(lsm, config)
+	require.NotNil(t, repicked)
+	require.ElementsMatch(t, job.SourceFiles, repicked.SourceFiles)
+	job = repicked
+
+	inputSize, outputSize, outputFiles := compactor.ExecuteCompaction(job, lsm, config, 10)
+	require.Equal(t, 256.0, inputSize)
+	require.Equal(t, 256.0, outputSize)
+	require.Equal(t, 1, outputFiles)
+	require.Equal(t, 4, lsm.Levels[0].FileCount, "2 × 64 MB + 512 MB + the 256 MB output")
+	require.InDelta(t, 2*64+512+256, lsm.Levels[0].TotalSize, 1e-9)
+	for _, level := range lsm.Levels[1:] {
+		require.Zero(t, level.FileCount, "size-tiered output never leaves L0")
+	}
+
+	config.TieredMinThreshold = 1
+	require.Error(t, config.Validate())
+}
+
+// TestTieredCompactionSimulation tests a size-tiered run end to end
+func TestTieredCompactionSimulation(t *testing.T) {
+	config := DefaultConfig()
+	config.CompactionStyle = CompactionStyleTiered
+	config.RandomSeed = 42
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.IsType(t, &TieredCompactor{}, sim.compactor)
+	require.NoError(t, sim.Reset())
+	sim.StepUntil(600)
+
+	require.False(t, sim.metrics.IsOOMKilled)
+	require.Greater(t, sim.metrics.TotalCompactionsCompleted, 0)
+	require.Greater(t, sim.metrics.WriteAmplification, 1.0)
+	checkFileInvariants(t, sim.lsm)
+	for _, level := range sim.lsm.Levels[1:] {
+		require.Zero(t, level.FileCount)
+	}
+	// Merged files keep growing into larger tiers
+	var largest float64
+	for _, f := range sim.lsm.Levels[0].Files {
+		largest = max(largest, f.SizeMB)
+	}
+	require.Greater(t, largest, 4*float64(config.MemtableFlushSizeMB)*0.5)
+}
@@ -8,12 +8,20 @@ import (
 // TrafficDistribution interface for generating write events
 // Generates both write size and time until next write
 type TrafficDistribution interface {
-	// NextWriteSizeMB returns the size of the next write in MB
+	// NextWriteSizeMB returns the size of the next write in MB (0 = traffic is paused)
 	NextWriteSizeMB() float64
 	// NextIntervalSeconds returns the time until the next write in seconds
 	NextIntervalSeconds() float64
 }
 
+// timedTrafficDistribution is implemented by distributions whose rate changes over time; the
+// simulator keeps them at the current virtual time and reports their current rate
+type timedTrafficDistribution interface {
+	TrafficDistribution
+	UpdateTime(currentTime float64)
+	GetCurrentRateMBps() float64
+}
+
 // ConstantTrafficDistribution generates writes at a constant rate
 type ConstantTrafficDistribution struct {
 	writeRateMBps float64
@@ -39,6 +47,67 @@ func (d *ConstantTrafficDistribution) NextIntervalSeconds() float64 {
 	return d.writeSizeMB / d.writeRateMBps
 }
 
+// BurstTrafficDistribution alternates between writing at a fixed rate for burstSeconds and not
+// writing at all for quietSeconds, starting with a burst at time 0 (e.g. a bulk load, then idle)
+type BurstTrafficDistribution struct {
+	burstRateMBps float64
+	burstSeconds  float64
+	quietSeconds  float64
+	writeSizeMB   float64
+	now           float64 // Virtual time of the last UpdateTime
+}
+
+// NewBurstTrafficDistribution creates a burst-then-drain traffic distribution
+func NewBurstTrafficDistribution(burstRateMBps, burstSeconds, quietSeconds float64) TrafficDistribution {
+	return &BurstTrafficDistribution{
+		burstRateMBps: burstRateMBps,
+		burstSeconds:  burstSeconds,
+		quietSeconds:  quietSeconds,
+		writeSizeMB:   1.0, // Fixed 1MB writes
+	}
+}
+
+// inBurst reports whether the current time falls in a burst, and how far into its burst/quiet
+// cycle it is
+func (d *BurstTrafficDistribution) inBurst() (bool, float64) {
+	offset := math.Mod(d.now, d.burstSeconds+d.quietSeconds)
+	return offset < d.burstSeconds, offset
+}
+
+// NextWriteSizeMB returns 1MB during a burst and 0 (no write) while quiet
+func (d *BurstTrafficDistribution) NextWriteSizeMB() float64 {
+	if burst, _ := d.inBurst(); !burst {
+		return 0
+	}
+	return d.writeSizeMB
+}
+
+// NextIntervalSeconds returns the time until the next write: the burst rate's interval during a
+// burst, and the time left until the next burst while quiet
+func (d *BurstTrafficDistribution) NextIntervalSeconds() float64 {
+	if d.burstRateMBps <= 0 {
+		return 0 // No writes if rate is 0
+	}
+	burst, offset := d.inBurst()
+	if !burst {
+		return d.burstSeconds + d.quietSeconds - offset
+	}
+	return d.writeSizeMB / d.burstRateMBps
+}
+
+// GetCurrentRateMBps returns the write rate at the current time: the burst rate or 0 (for display)
+func (d *BurstTrafficDistribution) GetCurrentRateMBps() float64 {
+	if burst, _ := d.inBurst(); !burst {
+		return 0
+	}
+	return d.burstRateMBps
+}
+
+// UpdateTime moves the distribution to the current virtual time
+func (d *BurstTrafficDistribution) UpdateTime(currentTime float64) {
+	d.now = currentTime
+}
+
 // AdvancedTrafficDistribution implements ON/OFF lognormal model with spikes
 type AdvancedTrafficDistribution struct {
 	// Base regime parameters
@@ -67,7 +136,8 @@ type AdvancedTrafficDistribution struct {
 	queueBacklog    float64 // Accumulated backlog in queue mode
 
 	// Random number generator
-	rng *rand.Rand
+	rng  *rand.Rand
+	rngs rngSet // rng, for RNGState
 
 	// Time tracking for state machine
 	lastUpdateTime float64 // Last virtual time when state was updated
@@ -96,12 +166,11 @@ type AdvancedTrafficDistributionConfig struct {
 
 // NewAdvancedTrafficDistribution creates an advanced ON/OFF traffic distribution
 func NewAdvancedTrafficDistribution(config AdvancedTrafficDistributionConfig, seed int64) TrafficDistribution {
-	var rng *rand.Rand
+	var rngs rngSet
 	if seed == 0 {
-		rng = rand.New(rand.NewSource(rand.Int63()))
-	} else {
-		rng = rand.New(rand.NewSource(seed))
+		seed = rand.Int63()
 	}
+	rng := rngs.newRand(seed)
 
 	// Start in OFF state
 	offDuration := exponentialSample(rng, config.OffMeanSeconds)
@@ -125,10 +194,16 @@ func NewAdvancedTrafficDistribution(config AdvancedTrafficDistributionConfig, se
 		queueBacklog:        0,
 		activeSpikes:        make([]spike, 0),
 		rng:                 rng,
+		rngs:                rngs,
 		lastUpdateTime:      0.0, // Will be set on first call
 	}
 }
 
+// randomSources returns the distribution's RNG
+func (d *AdvancedTrafficDistribution) randomSources() rngSet {
+	return d.rngs
+}
+
 // NextWriteSizeMB generates the next write size using the current regime
 func (d *AdvancedTrafficDistribution) NextWriteSizeMB() float64 {
 	// State machine is updated via UpdateTime() call from simulator
@@ -387,6 +462,8 @@ func NewTrafficDistribution(config TrafficDistributionConfig, seed int64) Traffi
 			},
 			seed,
 		)
+	case TrafficModelBurst:
+		return NewBurstTrafficDistribution(config.BurstRateMBps, config.BurstDurationSec, config.QuietDurationSec)
 	default: // TrafficModelConstant
 		return NewConstantTrafficDistribution(config.WriteRateMBps)
 	}
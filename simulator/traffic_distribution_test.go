@@ -157,6 +157,83 @@ func TestNewTrafficDistribution(t *testing.T) {
 		dist := NewTrafficDistribution(config, 42)
 		require.IsType(t, &AdvancedTrafficDistribution{}, dist)
 	})
+
+	t.Run("burst model", func(t *testing.T) {
+		config := TrafficDistributionConfig{
+			Model:            TrafficModelBurst,
+			BurstRateMBps:    50.0,
+			BurstDurationSec: 10.0,
+			QuietDurationSec: 20.0,
+		}
+		dist := NewTrafficDistribution(config, 42)
+		require.IsType(t, &BurstTrafficDistribution{}, dist)
+	})
+}
+
+func TestBurstTrafficDistribution(t *testing.T) {
+	t.Run("toggles at the burst/quiet boundary", func(t *testing.T) {
+		dist := NewBurstTrafficDistribution(50.0, 10.0, 20.0).(*BurstTrafficDistribution)
+
+		// Burst: 1MB writes at 50 MB/s
+		dist.UpdateTime(9.9)
+		require.Equal(t, 1.0, dist.NextWriteSizeMB())
+		require.InDelta(t, 0.02, dist.NextIntervalSeconds(), 1e-9)
+		require.Equal(t, 50.0, dist.GetCurrentRateMBps())
+
+		// Quiet: no writes, next wake-up at the start of the next burst
+		dist.UpdateTime(10.0)
+		require.Equal(t, 0.0, dist.NextWriteSizeMB())
+		require.InDelta(t, 20.0, dist.NextIntervalSeconds(), 1e-9)
+		require.Equal(t, 0.0, dist.GetCurrentRateMBps())
+		dist.UpdateTime(25.0)
+		require.InDelta(t, 5.0, dist.NextIntervalSeconds(), 1e-9)
+
+		// Next cycle's burst
+		dist.UpdateTime(30.0)
+		require.Equal(t, 1.0, dist.NextWriteSizeMB())
+		require.Equal(t, 50.0, dist.GetCurrentRateMBps())
+	})
+
+	t.Run("zero rate", func(t *testing.T) {
+		dist := NewBurstTrafficDistribution(0.0, 10.0, 20.0)
+		require.Equal(t, 0.0, dist.NextIntervalSeconds())
+	})
+
+	t.Run("simulated writes follow the bursts", func(t *testing.T) {
+		config := DefaultConfig()
+		config.RandomSeed = 42
+		config.TrafficDistribution = TrafficDistributionConfig{
+			Model:            TrafficModelBurst,
+			BurstRateMBps:    20.0,
+			BurstDurationSec: 10.0,
+			QuietDurationSec: 20.0,
+		}
+		sim, err := NewSimulator(config)
+		require.NoError(t, err)
+		require.NoError(t, sim.Reset())
+
+		sim.StepUntil(9)
+		require.Equal(t, 20.0, sim.getEffectiveWriteRateMBps())
+		sim.StepUntil(11)
+		require.Equal(t, 0.0, sim.getEffectiveWriteRateMBps())
+		written := sim.Metrics().TotalDataWrittenMB
+		require.Positive(t, written)
+		sim.StepUntil(29)
+		require.Equal(t, written, sim.Metrics().TotalDataWrittenMB, "no writes while quiet")
+		sim.StepUntil(35)
+		require.Equal(t, 20.0, sim.getEffectiveWriteRateMBps())
+		require.Greater(t, sim.Metrics().TotalDataWrittenMB, written, "writes resume with the next burst")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		config := DefaultConfig()
+		config.TrafficDistribution = TrafficDistributionConfig{Model: TrafficModelBurst, BurstRateMBps: 20.0}
+		require.Error(t, config.Validate(), "a burst needs a duration")
+		config.TrafficDistribution.BurstDurationSec = 10.0
+		require.NoError(t, config.Validate())
+		config.TrafficDistribution.QuietDurationSec = -1
+		require.Error(t, config.Validate())
+	})
 }
 
 func TestExponentialSample(t *testing.T) {
@@ -2,7 +2,9 @@ package simulator
 
 import (
 	"fmt"
+	"maps"
 	"math/rand"
+	"slices"
 	"time"
 )
 
@@ -27,7 +29,16 @@ type UniversalCompactor struct {
 	overlapSelectDist   filePicker   // For estimating overlaps in target level
 	sortedRunSelectDist filePicker   // DEPRECATED: No longer used - replaced with deterministic size ratio logic
 	rng                 *rand.Rand   // Random number generator for file selection
+	rngs                rngSet       // Every RNG above, for RNGState
 	activeCompactions   map[int]bool // Track levels currently being compacted
+	incrementalCursor   map[int]int  // Per-level file index where the next incremental window starts
+	virtualTime         float64      // Time file ages are measured against (set before each PickCompaction)
+	pickLogger
+}
+
+// setVirtualTime records the current time for the age-based size-amplification trigger
+func (c *UniversalCompactor) setVirtualTime(virtualTime float64) {
+	c.virtualTime = virtualTime
 }
 
 // SortedRun represents a sorted run in universal compaction
@@ -65,48 +76,39 @@ func NewUniversalCompactor(seed int64) *UniversalCompactor {
 		GeometricP:        0.3,
 		ExponentialLambda: 0.5,
 	}
-	return NewUniversalCompactorWithOverlapDist(seed, defaultOverlap)
+	return NewUniversalCompactorWithOverlapDist(seed, 0, defaultOverlap)
 }
 
-// NewUniversalCompactorWithOverlapDist creates a universal compactor with specified overlap distribution
-func NewUniversalCompactorWithOverlapDist(seed int64, overlapConfig OverlapDistributionConfig) *UniversalCompactor {
-	var rng *rand.Rand
-	if seed == 0 {
-		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
-	} else {
-		rng = rand.New(rand.NewSource(seed))
+// NewUniversalCompactorWithOverlapDist creates a universal compactor with specified overlap distribution.
+// A non-zero overlapSeed gives overlap sampling its own RNG, independent of file selection.
+func NewUniversalCompactorWithOverlapDist(seed, overlapSeed int64, overlapConfig OverlapDistributionConfig) *UniversalCompactor {
+	var rngs rngSet
+	rngSeed := seed
+	if rngSeed == 0 {
+		rngSeed = time.Now().UnixNano()
 	}
+	rng := rngs.newRand(rngSeed)
 
 	// Create overlap distribution based on config
-	var overlapDist Distribution
-	switch overlapConfig.Type {
-	case DistExponential:
-		overlapDist = &ExponentialDistribution{Lambda: overlapConfig.ExponentialLambda}
-	case DistGeometric:
-		overlapDist = &GeometricDistribution{P: overlapConfig.GeometricP}
-	case DistFixed:
-		percentage := overlapConfig.FixedPercentage
-		// Clamp to [0.0, 1.0] - allow 0.0 and 1.0 as valid extremes
-		if percentage < 0.0 {
-			percentage = 0.0
-		}
-		if percentage > 1.0 {
-			percentage = 1.0
-		}
-		overlapDist = &FixedDistribution{Percentage: percentage}
-	default: // DistUniform
-		overlapDist = &UniformDistribution{}
-	}
+	overlapPicker := &distributionAdapter{dist: newOverlapDistribution(overlapConfig), rng: newOverlapRNG(rng, overlapSeed, &rngs)}
 
 	// Use different seeds for each distribution to avoid correlation
 	// Derive seeds from base seed: fileSelect uses seed+1, sortedRun uses seed+2, overlap uses seed+0
-	return &UniversalCompactor{
-		fileSelectDist:      newDistributionAdapterWithSeed(DistGeometric, seed+1), // Favor picking fewer files, use seed+1 for reproducibility
-		overlapSelectDist:   &distributionAdapter{dist: overlapDist, rng: rng},     // Uses seed (seed+0)
-		sortedRunSelectDist: newDistributionAdapterWithSeed(DistGeometric, seed+2), // Favor picking fewer sorted runs, use seed+2 for reproducibility
+	c := &UniversalCompactor{
+		fileSelectDist:      newDistributionAdapterWithSeed(DistGeometric, seed+1, &rngs), // Favor picking fewer files, use seed+1 for reproducibility
+		overlapSelectDist:   overlapPicker,                                                // Uses overlapSeed, or shares seed (seed+0) when 0
+		sortedRunSelectDist: newDistributionAdapterWithSeed(DistGeometric, seed+2, &rngs), // Favor picking fewer sorted runs, use seed+2 for reproducibility
 		rng:                 rng,
 		activeCompactions:   make(map[int]bool),
+		incrementalCursor:   make(map[int]int),
 	}
+	c.rngs = rngs // After the adapters above have added their sources
+	return c
+}
+
+// randomSources returns the compactor's RNGs: file selection, overlap sampling and distributions
+func (c *UniversalCompactor) randomSources() rngSet {
+	return c.rngs
 }
 
 // findBaseLevel finds the base level (lowest non-empty level)
@@ -277,6 +279,18 @@ func (c *UniversalCompactor) checkSizeRatioWithAccumulated(accumulatedSizeMB flo
 //
 //	Impact: May check amplification even when compaction is in progress
 func (c *UniversalCompactor) checkSizeAmplification(lsm *LSMTree, baseLevel int, config SimConfig) bool {
+	triggered, _ := c.sizeAmplificationTrigger(lsm, baseLevel, config)
+	return triggered
+}
+
+// sizeAmplificationTrigger is checkSizeAmplification, also reporting whether the trigger fired only
+// because of UniversalAgeBasedTrigger: the threshold is divided by (1 + age/UniversalAgeThresholdSeconds),
+// where age is how long the oldest data in the base run has gone without being rewritten, so old
+// data is eventually merged (purging its obsolete versions) even when the runs above stay small.
+//
+// FIDELITY: ⚠️ NOT IN ROCKSDB - RocksDB bounds staleness with periodic_compaction_seconds, which
+// rewrites files past a fixed age; this blends age into the size-amplification threshold instead
+func (c *UniversalCompactor) sizeAmplificationTrigger(lsm *LSMTree, baseLevel int, config SimConfig) (triggered, byAge bool) {
 	// Calculate total size of all levels above base
 	var sizeAboveBase float64
 	for i := 0; i < baseLevel; i++ {
@@ -286,7 +300,7 @@ func (c *UniversalCompactor) checkSizeAmplification(lsm *LSMTree, baseLevel int,
 	// Size at base level
 	sizeAtBase := lsm.Levels[baseLevel].TotalSize
 	if sizeAtBase == 0 {
-		return false // No base level, can't check amplification
+		return false, false // No base level, can't check amplification
 	}
 
 	// Size amplification percentage
@@ -301,8 +315,23 @@ func (c *UniversalCompactor) checkSizeAmplification(lsm *LSMTree, baseLevel int,
 	if maxSizeAmpPercent == 0 {
 		maxSizeAmpPercent = 200.0 // Default threshold
 	}
+	if amplificationPercent > maxSizeAmpPercent {
+		return true, false
+	}
 
-	return amplificationPercent > maxSizeAmpPercent
+	if !config.UniversalAgeBasedTrigger || config.UniversalAgeThresholdSeconds <= 0 || sizeAboveBase == 0 || len(lsm.Levels[baseLevel].Files) == 0 {
+		return false, false
+	}
+	oldest := lsm.Levels[baseLevel].Files[0].CreatedAt
+	for _, f := range lsm.Levels[baseLevel].Files {
+		oldest = min(oldest, f.CreatedAt)
+	}
+	age := max(0, c.virtualTime-oldest)
+	agedThreshold := maxSizeAmpPercent / (1 + age/config.UniversalAgeThresholdSeconds)
+	if amplificationPercent > agedThreshold {
+		return true, true
+	}
+	return false, false
 }
 
 // NeedsCompaction checks if compaction is needed based on universal compaction rules
@@ -561,6 +590,31 @@ func (c *UniversalCompactor) calculateTargetLevelForL0Next(sortedRuns []SortedRu
 //
 //	Impact: May miss some compaction triggers, but size amplification and size ratio are primary triggers
 func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
+	job := c.pickCompaction(lsm, config)
+	if job != nil && jobReadsPausedLevel(job, lsm) {
+		// Sorted runs are picked as a unit, so a job that would merge a paused level's run
+		// waits until the level is resumed
+		delete(c.activeCompactions, job.FromLevel)
+		return nil
+	}
+	return job
+}
+
+// releaseJob forgets a picked job the simulator won't run
+func (c *UniversalCompactor) releaseJob(job *CompactionJob) {
+	delete(c.activeCompactions, job.FromLevel)
+}
+
+// checkpoint saves the claimed levels and incremental-mode cursors
+func (c *UniversalCompactor) checkpoint() (restore func()) {
+	active, cursors := maps.Clone(c.activeCompactions), maps.Clone(c.incrementalCursor)
+	return func() {
+		c.activeCompactions, c.incrementalCursor = active, cursors
+	}
+}
+
+// pickCompaction implements PickCompaction, ignoring paused levels
+func (c *UniversalCompactor) pickCompaction(lsm *LSMTree, config SimConfig) *CompactionJob {
 	// Fast path: Check if compaction is needed (moved from FindLevelToCompact)
 	// CRITICAL BUG FIX: For universal compaction, check if L0 is already compacting BEFORE picking
 	// This prevents infinite loops where we keep picking the same files
@@ -631,7 +685,11 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 	fileNumCompactionTrigger := config.L0CompactionTrigger
 	if len(sortedRuns) >= fileNumCompactionTrigger {
 		// Check if size amplification compaction is needed
-		if c.checkSizeAmplification(lsm, baseLevel, config) {
+		if sizeAmp, byAge := c.sizeAmplificationTrigger(lsm, baseLevel, config); sizeAmp {
+			reason := "size_amplification"
+			if byAge {
+				reason = "size_amplification_age"
+			}
 			// Pick size amplification compaction: compact all sorted runs from start_index to end_index (INCLUSIVE)
 			// RocksDB C++: PickCompactionToReduceSizeAmp() → PickCompactionWithSortedRunRange()
 			// GitHub: https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker_universal.cc#L1109-L1216
@@ -718,26 +776,12 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 				pickedRuns = append(pickedRuns, sortedRuns[i])
 			}
 
-			fmt.Printf("[UNIVERSAL] Picking size amplification compaction: picked %d sorted runs (from index %d to %d, INCLUDING base level L%d)\n",
+			c.logf("[UNIVERSAL] Picking size amplification compaction: picked %d sorted runs (from index %d to %d, INCLUDING base level L%d)\n",
 				len(pickedRuns), startIndex, endIndex, baseLevel)
 
 			// Build compaction job
-			sourceFiles := make([]*SSTFile, 0)
-			fromLevel := -1
-			for _, sr := range pickedRuns {
-				if fromLevel < 0 {
-					fromLevel = sr.Level
-				}
-				if sr.IsLevelRun {
-					if sr.Level < len(lsm.Levels) {
-						sourceFiles = append(sourceFiles, lsm.Levels[sr.Level].Files...)
-					}
-				} else {
-					if sr.File != nil {
-						sourceFiles = append(sourceFiles, sr.File)
-					}
-				}
-			}
+			fromLevel := pickedRuns[0].Level
+			sourceFiles, coverage := c.collectSourceFiles(pickedRuns, lsm, config)
 			if len(sourceFiles) == 0 {
 				return nil
 			}
@@ -775,6 +819,8 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 				SourceFiles: sourceFiles,
 				TargetFiles: targetFiles,
 				IsIntraL0:   false,
+				Coverage:    coverage,
+				Reason:      reason,
 			}
 		}
 	}
@@ -904,7 +950,7 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 			// For L1+ levels, check if any files in this level are being compacted
 			if sr.Level < len(lsm.Levels) && lsm.Levels[sr.Level].CompactingFileCount > 0 {
 				// This level is already being compacted → skip it
-				fmt.Printf("[UNIVERSAL] Skipping L%d sorted run (already being compacted: %d files)\n",
+				c.logf("[UNIVERSAL] Skipping L%d sorted run (already being compacted: %d files)\n",
 					sr.Level, lsm.Levels[sr.Level].CompactingFileCount)
 				continue
 			}
@@ -979,7 +1025,7 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 	}
 
 	// Debug logging: show picking decision
-	fmt.Printf("[UNIVERSAL] Picking compaction: available=%d sorted runs, picked %d runs (accumulated=%.1fMB): %v\n",
+	c.logf("[UNIVERSAL] Picking compaction: available=%d sorted runs, picked %d runs (accumulated=%.1fMB): %v\n",
 		availableRuns, len(pickedRuns), accumulatedSizeMB, sortedRunInfo[:len(pickedRuns)])
 
 	if len(pickedRuns) == 0 {
@@ -988,22 +1034,9 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 
 	// Convert picked sorted runs to files
 	// For L0 sorted runs: collect individual files
-	// For L1+ sorted runs: collect all files in the level
-	sourceFiles := make([]*SSTFile, 0)
+	// For L1+ sorted runs: collect all files in the level (or a window of them in incremental mode)
 	fromLevel := pickedRuns[0].Level
-	for _, sr := range pickedRuns {
-		if sr.IsLevelRun {
-			// Entire level: collect all files
-			if sr.Level < len(lsm.Levels) {
-				sourceFiles = append(sourceFiles, lsm.Levels[sr.Level].Files...)
-			}
-		} else {
-			// Single file (L0)
-			if sr.File != nil {
-				sourceFiles = append(sourceFiles, sr.File)
-			}
-		}
-	}
+	sourceFiles, coverage := c.collectSourceFiles(pickedRuns, lsm, config)
 
 	if len(sourceFiles) == 0 {
 		return nil
@@ -1029,7 +1062,7 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 	targetLevel, targetReason := c.calculateTargetLevel(sortedRuns, firstIndexAfter, fromLevel, baseLevel)
 
 	// Debug logging: show target level calculation
-	fmt.Printf("[UNIVERSAL] Target level calculation: picked %d sorted runs (fromLevel=%d), first_index_after=%d, reason: %s, targetLevel=%d\n",
+	c.logf("[UNIVERSAL] Target level calculation: picked %d sorted runs (fromLevel=%d), first_index_after=%d, reason: %s, targetLevel=%d\n",
 		len(pickedRuns), fromLevel, firstIndexAfter, targetReason, targetLevel)
 
 	// Safety checks and boundary validation
@@ -1045,16 +1078,16 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 	if targetLevel >= 999 || targetLevel >= numLevels {
 		if targetLevel >= 999 {
 			// This is the sentinel value indicating "max_output_level"
-			fmt.Printf("[UNIVERSAL] Target level was max_output_level sentinel, setting to numLevels - 1 = %d\n", maxOutputLevel)
+			c.logf("[UNIVERSAL] Target level was max_output_level sentinel, setting to numLevels - 1 = %d\n", maxOutputLevel)
 		} else {
-			fmt.Printf("[UNIVERSAL] Target level %d >= numLevels %d (deepest level reached), clamping to maxOutputLevel %d\n", targetLevel, numLevels, maxOutputLevel)
+			c.logf("[UNIVERSAL] Target level %d >= numLevels %d (deepest level reached), clamping to maxOutputLevel %d\n", targetLevel, numLevels, maxOutputLevel)
 		}
 		targetLevel = maxOutputLevel // Set to deepest level (RocksDB's max_output_level)
 	}
 
 	// CRITICAL BOUNDARY CHECK #2: Negative target level (should never happen)
 	if targetLevel < 0 {
-		fmt.Printf("[UNIVERSAL] Invalid target level %d (negative), returning nil\n", targetLevel)
+		c.logf("[UNIVERSAL] Invalid target level %d (negative), returning nil\n", targetLevel)
 		return nil
 	}
 
@@ -1074,10 +1107,10 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 		// Allow compacting to baseLevel - 1 even if empty (needed to populate intermediate levels)
 		if targetLevel == baseLevel-1 {
 			// Keep targetLevel as is - allow compacting to empty level to populate it
-			fmt.Printf("[UNIVERSAL] Target level %d is empty but is baseLevel-1, allowing compaction to populate it\n", targetLevel)
+			c.logf("[UNIVERSAL] Target level %d is empty but is baseLevel-1, allowing compaction to populate it\n", targetLevel)
 		} else {
 			// Skip empty levels that are NOT adjacent to base level
-			fmt.Printf("[UNIVERSAL] Target level %d is empty (not baseLevel-1), skipping to baseLevel %d\n", targetLevel, baseLevel)
+			c.logf("[UNIVERSAL] Target level %d is empty (not baseLevel-1), skipping to baseLevel %d\n", targetLevel, baseLevel)
 			targetLevel = baseLevel
 		}
 	}
@@ -1115,7 +1148,118 @@ func (c *UniversalCompactor) PickCompaction(lsm *LSMTree, config SimConfig) *Com
 		SourceFiles: sourceFiles,
 		TargetFiles: targetFiles,
 		IsIntraL0:   false,
+		Coverage:    coverage,
+		Reason:      "size_ratio",
+	}
+}
+
+// collectSourceFiles converts picked sorted runs into compaction input files and reports the
+// job's coverage: the fraction of the picked runs' bytes the job actually compacts.
+//
+// By default every picked run is compacted whole (coverage 1.0). With UniversalIncrementalMode,
+// L0 file runs are still taken whole, but each picked level run contributes only a contiguous
+// window of its files, sized so the job's input stays within max_compaction_bytes. Windows advance
+// round-robin through the level, so successive jobs sweep its whole key range in smaller steps.
+//
+// RocksDB Reference: CompactionOptionsUniversal::incremental
+// GitHub: https://github.com/facebook/rocksdb/blob/main/db/compaction/compaction_picker_universal.cc
+// (UniversalCompactionBuilder::PickIncrementalForReduceSizeAmp)
+//
+// FIDELITY: ⚠️ RocksDB picks the file range by key overlap between the last two sorted runs;
+// we use a size-bounded round-robin window over the level's files in file order
+// FIDELITY: ⚠️ Transient disk usage during a job is not modeled, so lower peak space-amp shows up
+// only as smaller per-job inputs, not in SpaceAmplification
+func (c *UniversalCompactor) collectSourceFiles(pickedRuns []SortedRun, lsm *LSMTree, config SimConfig) ([]*SSTFile, float64) {
+	budgetMB := float64(config.MaxCompactionBytesMB)
+	if budgetMB <= 0 {
+		const kDefaultMaxCompactionBytesMultiplier = 25 // RocksDB constant
+		budgetMB = float64(config.TargetFileSizeMB * kDefaultMaxCompactionBytesMultiplier)
+	}
+
+	sourceFiles := make([]*SSTFile, 0)
+	var pickedMB, includedMB float64
+	for _, sr := range pickedRuns {
+		if !sr.IsLevelRun {
+			// Single file (L0)
+			if sr.File != nil {
+				sourceFiles = append(sourceFiles, sr.File)
+				pickedMB += sr.File.SizeMB
+				includedMB += sr.File.SizeMB
+			}
+			continue
+		}
+		if sr.Level >= len(lsm.Levels) {
+			continue
+		}
+
+		level := lsm.Levels[sr.Level]
+		pickedMB += level.TotalSize
+		if !config.UniversalIncrementalMode {
+			// Entire level: collect all files
+			sourceFiles = append(sourceFiles, level.Files...)
+			includedMB += level.TotalSize
+			continue
+		}
+
+		window := c.incrementalWindow(sr.Level, level.Files, budgetMB-includedMB)
+		for _, f := range window {
+			includedMB += f.SizeMB
+		}
+		sourceFiles = append(sourceFiles, window...)
+	}
+
+	if pickedMB <= 0 {
+		return sourceFiles, 1.0
 	}
+	return sourceFiles, includedMB / pickedMB
+}
+
+// incrementalWindow returns the next contiguous run of files in a level for incremental universal
+// compaction, starting at the level's cursor and stopping before budgetMB is exceeded (always at
+// least one file so every job makes progress). The cursor wraps to the start of the level.
+func (c *UniversalCompactor) incrementalWindow(level int, files []*SSTFile, budgetMB float64) []*SSTFile {
+	if len(files) == 0 {
+		return nil
+	}
+
+	start := c.incrementalCursor[level] % len(files)
+	end := start + 1
+	totalMB := files[start].SizeMB
+	for end < len(files) && totalMB+files[end].SizeMB <= budgetMB {
+		totalMB += files[end].SizeMB
+		end++
+	}
+
+	c.incrementalCursor[level] = end % len(files)
+	return files[start:end]
+}
+
+// isTrivialMove reports whether a job moves a single sorted run into an empty level, which needs
+// no merge: the run's files can be relinked to the target level as they are.
+//
+// RocksDB Reference: Compaction::IsTrivialMove() (db/compaction/compaction.cc) allows universal
+// trivial moves when the inputs are non-overlapping and nothing in the output level overlaps them.
+//
+// FIDELITY: ⚠️ SIMPLIFIED - RocksDB checks key-range overlap with the output level; here the
+// output level must be empty, and an L0 run must be a single file
+func (c *UniversalCompactor) isTrivialMove(job *CompactionJob, lsm *LSMTree) bool {
+	if job == nil || job.IsIntraL0 || len(job.TargetFiles) > 0 || len(job.SourceFiles) == 0 {
+		return false
+	}
+	if job.ToLevel <= job.FromLevel || job.ToLevel >= len(lsm.Levels) || lsm.Levels[job.ToLevel].FileCount > 0 {
+		return false
+	}
+	// Each L0 file is its own sorted run; a level below L0 is one sorted run as a whole
+	if job.FromLevel == 0 && len(job.SourceFiles) != 1 {
+		return false
+	}
+	from := lsm.Levels[job.FromLevel]
+	for _, f := range job.SourceFiles {
+		if !slices.Contains(from.Files, f) {
+			return false
+		}
+	}
+	return true
 }
 
 // ExecuteCompaction performs universal compaction (same logic as leveled compaction)
@@ -1130,6 +1274,10 @@ func (c *UniversalCompactor) ExecuteCompaction(job *CompactionJob, lsm *LSMTree,
 		delete(c.activeCompactions, 0)
 	}()
 
+	if c.isTrivialMove(job, lsm) {
+		return trivialMoveFiles(job, lsm)
+	}
+
 	// Universal compaction execution is similar to leveled compaction
 	// Reuse the leveled compaction execution logic (including subcompaction support)
 	// Note: Subcompactions are supported for universal compaction in RocksDB
@@ -27,6 +27,7 @@ export function ConfigInput({
     const isRunning = useStore(state => state.isRunning);
     const isConnected = useStore(state => state.connectionStatus === 'connected');
     const updateConfig = useStore(state => state.updateConfig);
+    const setSpeed = useStore(state => state.setSpeed);
 
     // Static params can't be changed while running
     const isStaticParam = field !== 'writeRateMBps' && field !== 'simulationSpeedMultiplier';
@@ -48,8 +49,14 @@ export function ConfigInput({
         const num = parseFloat(localValue);
         if (!isNaN(num)) {
             const clamped = Math.max(min, Math.min(max, num));
-            updateConfig({ [field]: clamped });
-            setLocalValue(String(clamped));
+            if (field === 'simulationSpeedMultiplier') {
+                const speed = Math.round(clamped);
+                setSpeed(speed);
+                setLocalValue(String(speed));
+            } else {
+                updateConfig({ [field]: clamped });
+                setLocalValue(String(clamped));
+            }
         } else {
             setLocalValue(String(value));
         }
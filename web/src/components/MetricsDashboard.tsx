@@ -360,6 +360,7 @@ export function MetricsDashboard() {
                     </div>
                     <div className="text-xs text-gray-500 mt-1">
                         {currentState && `${formatBytes(currentState.totalSizeMB)} total`}
+                        {currentMetrics?.liveDataSizeMB !== undefined && ` / ${formatBytes(currentMetrics.liveDataSizeMB)} live`}
                     </div>
                 </div>
 
@@ -497,7 +498,7 @@ export function MetricsDashboard() {
                             if (config.compactionStyle === 'universal' && currentMetrics.inProgressDetails) {
                                 // Find the most recent compaction from this level
                                 const compactionFromLevel = currentMetrics.inProgressDetails
-                                    .filter(d => d.fromLevel === idx)
+                                    .filter(d => d.fromLevel === idx && !d.columnFamily)
                                     .sort((a, b) => b.inputMB - a.inputMB)[0]; // Use largest one as most representative
                                 if (compactionFromLevel) {
                                     targetLevelLabel = compactionFromLevel.toLevel;
@@ -303,7 +303,7 @@ export function SimulationControls() {
                   <div className="group relative">
                     <HelpCircle className="w-3 h-3 text-gray-500 cursor-help" tabIndex={-1} />
                     <div className="absolute left-0 bottom-full mb-2 hidden group-hover:block z-50 w-80 p-2 bg-gray-900 border border-gray-700 rounded text-xs text-gray-300 shadow-lg">
-                      Compaction strategy: Leveled (classic RocksDB) or Universal (space-efficient, lower write amplification)
+                      Compaction strategy: Leveled (classic RocksDB), Universal (space-efficient, lower write amplification), FIFO (drops oldest data) or Tiered (Cassandra-style size-tiered, merges similarly-sized files in place)
                     </div>
                   </div>
                 </label>
@@ -350,6 +350,20 @@ export function SimulationControls() {
                   >
                     FIFO
                   </button>
+                  <button
+                    onClick={() => {
+                      if (!isConnected || isRunning) return;
+                      updateConfig({ compactionStyle: 'tiered' });
+                    }}
+                    disabled={!isConnected || isRunning}
+                    className={`flex-1 px-3 py-2 text-sm border rounded transition-colors ${
+                      compactionStyle === 'tiered'
+                        ? 'bg-primary-500 border-primary-400 text-white font-semibold'
+                        : 'bg-dark-bg hover:bg-gray-700 border-dark-border'
+                    } disabled:opacity-50 disabled:cursor-not-allowed`}
+                  >
+                    Tiered
+                  </button>
                 </div>
               </div>
               
@@ -366,7 +380,7 @@ export function SimulationControls() {
                       tooltip="Size multiplier between levels (default: 10)" />
                     <ConfigInput label="Max Background Jobs" field="maxBackgroundJobs" min={1} max={32}
                       tooltip="RocksDB max_background_jobs: Max concurrent background threads for flushes AND compactions. Default: 2. Higher values allow more parallel operations but consume more CPU/memory." />
-                    <ConfigInput label="Number of Levels" field="numLevels" min={2} max={10}
+                    <ConfigInput label="Number of Levels" field="numLevels" min={1} max={10}
                       tooltip="Total number of LSM levels (including L0)" />
                   </>
                 )}
@@ -374,6 +388,8 @@ export function SimulationControls() {
                   <ConfigInput label="Max Background Jobs" field="maxBackgroundJobs" min={1} max={32}
                     tooltip="RocksDB max_background_jobs: Max concurrent background threads for flushes AND compactions. Default: 2. Higher values allow more parallel operations but consume more CPU/memory." />
                 )}
+                <ConfigInput label="Max Background Flushes" field="maxBackgroundFlushes" min={0} max={16}
+                  tooltip="RocksDB max_background_flushes: dedicated flush threads, so immutable memtables flush concurrently without waiting for compaction slots (they still share disk bandwidth). 0 = flushes share Max Background Jobs." />
               </div>
 
               {/* Advanced LSM Tuning (nested) */}
@@ -519,6 +535,15 @@ export function SimulationControls() {
                               baseRateMBps: clamped,
                             }
                           });
+                        } else if (trafficDist?.model === 'burst') {
+                          // Burst model: the rate is the rate during a burst
+                          updateConfig({
+                            writeRateMBps: clamped,
+                            trafficDistribution: {
+                              ...trafficDist,
+                              burstRateMBps: clamped,
+                            }
+                          });
                         } else {
                           updateConfig({
                             writeRateMBps: clamped,
@@ -766,6 +791,10 @@ export function SimulationControls() {
 
                 <ConfigInput label="Deduplication Factor" field="deduplicationFactor" min={0.1} max={1.0}
                   tooltip="Logical size after deduplication (0.9 = 10% from tombstones/overwrites, 1.0 = no dedup)" />
+                <ConfigInput label="L0 Compaction Reduction" field="l0ReductionFactor" min={0} max={1.0}
+                  tooltip="Output/input size of compactions out of L0; lower for overwrite-heavy workloads (0 = Deduplication Factor)" />
+                <ConfigInput label="Deep Compaction Reduction" field="deepReductionFactor" min={0} max={1.0}
+                  tooltip="Output/input size of compactions out of L1 and deeper (0 = 0.99)" />
               </div>
 
               {/* Advanced Traffic Parameters (collapsible) - only show when burstiness > 0 */}
@@ -1696,9 +1725,19 @@ export function SimulationControls() {
                 <ConfigInput label="I/O Latency" field="ioLatencyMs" min={0.1} max={50} unit="ms"
                   tooltip="Disk operation latency" />
                 <ConfigInput label="I/O Throughput" field="ioThroughputMBps" min={10} max={10000} unit="MB/s"
-                  tooltip="Max disk bandwidth (shared by all operations)" />
+                  tooltip="Max disk write bandwidth (flush, compaction output, WAL, ingest); also the read bandwidth unless I/O Read Throughput is set" />
+                <ConfigInput label="I/O Read Throughput" field="ioReadThroughputMBps" min={0} max={20000} unit="MB/s"
+                  tooltip="Disk read bandwidth (compaction input, read workload) for devices that read faster than they write. Reads and writes still share one disk queue. 0 = same as I/O Throughput" />
+                <ConfigInput label="Cold Tier From Level" field="tierBoundaryLevel" min={0} max={9}
+                  tooltip="First level stored on a slower, cheaper cold tier. Compactions, ingestion and reads touching it and deeper levels move at the cold tier's bandwidth. 0 = single tier" />
+                <ConfigInput label="Cold Tier Throughput" field="coldIOThroughputMBps" min={0} max={10000} unit="MB/s"
+                  tooltip="Read and write bandwidth of the cold tier. 0 = same as I/O Throughput" />
                 <ConfigInput label="SSTable Build Rate" field="sstableBuildThroughputMBps" min={0} max={1000} unit="MB/s"
                   tooltip="CPU throughput for building SSTables (compression + bloom filters + index). Includes all CPU work during flush/compaction. Set to 0 for infinite (no CPU cost). LZ4: ~75 MB/s, Snappy: ~75-100 MB/s, Zstd: ~50 MB/s, No compression: ~200 MB/s" />
+                <ConfigInput label="Compaction CPU Rate" field="compactionMBPerCPUSec" min={0} max={10000} unit="MB/s"
+                  tooltip="Compaction input one CPU thread merges and compresses per second. The merge overlaps the disk I/O, so a compaction lasts as long as the slower of the two: on a fast disk, compaction becomes CPU-bound. 0 = CPU never limits the merge" />
+                <ConfigInput label="Compaction CPU Threads" field="numCompactionThreads" min={0} max={64}
+                  tooltip="CPU threads running compactions share (one per subcompaction at most). 0 = one per background job" />
               </div>
 
               {/* WAL Configuration */}
@@ -1763,6 +1802,17 @@ export function SimulationControls() {
                         tooltip="fsync() latency in milliseconds (default: 1.5ms for NVMe/SSD)" />
                     </div>
                   )}
+
+                  <div className="ml-6">
+                    <ConfigInput
+                      label="WAL Group Commit Interval"
+                      field="walGroupCommitIntervalMs"
+                      min={0}
+                      max={1000}
+                      unit="ms"
+                      disabled={!enableWAL}
+                      tooltip="Writes arriving within this window are batched into one WAL write and one fsync, paying the sync latency once per interval instead of once per write (0 = no group commit)" />
+                  </div>
                 </div>
               </div>
             </div>
@@ -1787,7 +1837,9 @@ export function SimulationControls() {
                 <ConfigInput label="Initial LSM Size" field="initialLSMSizeMB" min={0} max={100000} unit="MB"
                   tooltip="⚠️ Pre-populate LSM tree (requires reset)" />
                 <ConfigInput label="Random Seed" field="randomSeed" min={0} max={999999}
-                  tooltip="Random seed for reproducibility (0 = random)" />
+                  tooltip="Random seed for reproducibility (0 = random; the seed a run drew is reported in its status and sim_runner results)" />
+                <ConfigInput label="Level Size History" field="maxHistorySamples" min={0} max={100000} unit="samples"
+                  tooltip="Per-step level size samples kept for charting how levels fill and drain (0 = no history, default: 600)" />
                 <ConfigInput label="Max Stalled Write Memory" field="maxStalledWriteMemoryMB" min={0} max={100000} unit="MB"
                   tooltip="OOM threshold: stop simulation if stalled write backlog exceeds this (0 = unlimited, default: 4096 MB)" />
               </div>
@@ -6,6 +6,9 @@ import type {
     SimulationEvent,
     WSMessage,
     ConnectionStatus,
+    CompactionPreview,
+    CompactionRecord,
+    Scenario,
 } from './types';
 
 const CONFIG_COOKIE_NAME = 'rollingstone-config';
@@ -83,6 +86,11 @@ interface AppStore {
     currentState: SimulationState | null;
     events: SimulationEvent[];
     logs: string[];
+    timeBreakdown: Record<string, number> | null;
+    styleRecommendation: { style: 'leveled' | 'universal' | 'fifo'; rationale: string } | null;
+    selfCheckViolations: string[] | null;
+    compactionHistory: CompactionRecord[] | null;
+    compactionPreview: CompactionPreview | null | undefined; // undefined until requested; null when no compaction is due
 
     // Actions
     connect: (url: string) => void;
@@ -91,9 +99,22 @@ interface AppStore {
     start: () => void;
     pause: () => void;
     reset: () => void;
+    resetMetrics: () => void;
+    restart: () => void;
     step: () => void;
     updateConfig: (config: Partial<SimulationConfig>) => void;
+    setSpeed: (speedMultiplier: number) => void;
     resetConfig: () => void;
+    requestTimeBreakdown: () => void;
+    requestStyleRecommendation: () => void;
+    requestSelfCheck: () => void;
+    requestCompactionHistory: () => void;
+    requestCompactionPreview: () => void;
+    pauseLevel: (level: number) => void;
+    resumeLevel: (level: number) => void;
+    requestManualCompaction: (level?: number) => void;
+    setBreakpoint: (virtualTime: number) => void;
+    loadScenario: (scenario: Scenario) => void;
 
     // Internal
     handleMessage: (data: string) => void;
@@ -112,20 +133,30 @@ const defaultConfig: SimulationConfig = {
     targetFileSizeMB: 64,
     targetFileSizeMultiplier: 2,
     deduplicationFactor: 0.9,
+    l0ReductionFactor: 0,
+    deepReductionFactor: 0,
     compressionFactor: 0.85,
     compressionThroughputMBps: 750,
     decompressionThroughputMBps: 3700,
     blockSizeKB: 4,
     sstableBuildThroughputMBps: 75,
+    compactionMBPerCPUSec: 0,
+    numCompactionThreads: 0,
     maxBackgroundJobs: 2,
+    maxBackgroundFlushes: 0,
     maxSubcompactions: 1,
     maxCompactionBytesMB: 1600,
     ioLatencyMs: 1,
     ioThroughputMBps: 125,
+    ioReadThroughputMBps: 0,
+    tierBoundaryLevel: 0,
+    coldIOThroughputMBps: 0,
     numLevels: 7,
     initialLSMSizeMB: 0,
     simulationSpeedMultiplier: 1,
+    baseStepSeconds: 1.0,
     randomSeed: 0,
+    maxHistorySamples: 600,
     maxStalledWriteMemoryMB: 4096, // 4GB default OOM threshold
     compactionStyle: 'universal', // Default to universal compaction
     maxSizeAmplificationPercent: 200, // Default RocksDB value
@@ -133,6 +164,7 @@ const defaultConfig: SimulationConfig = {
     enableWAL: true, // Enable Write-Ahead Log (RocksDB default: disableWAL=false)
     walSync: false, // Sync WAL after each write (RocksDB default: sync=false)
     walSyncLatencyMs: 1.5, // fsync() latency in milliseconds (typical for NVMe/SSD)
+    walGroupCommitIntervalMs: 0, // Each write synced on its own
     trafficDistribution: {
         model: 'constant',
         writeRateMBps: 10.0,
@@ -184,6 +216,11 @@ export const useStore = create<AppStore>((set, get) => ({
     currentState: null,
     events: [],
     logs: [],
+    timeBreakdown: null,
+    styleRecommendation: null,
+    selfCheckViolations: null,
+    compactionHistory: null,
+    compactionPreview: undefined,
 
     // Connection management
     connect: (url: string) => {
@@ -278,13 +315,81 @@ export const useStore = create<AppStore>((set, get) => ({
             logs: [],
             currentMetrics: null,
             currentState: null,
+            timeBreakdown: null,
+            styleRecommendation: null,
+            selfCheckViolations: null,
+            compactionHistory: null,
+            compactionPreview: undefined,
         });
     },
 
+    resetMetrics: () => {
+        // Keeps the LSM and queue; the server replies with the zeroed metrics
+        get().sendMessage({ type: 'reset_metrics' });
+        set({ metricsHistory: [], currentMetrics: null, timeBreakdown: null });
+    },
+
+    restart: () => {
+        // Simulated process restart: the block cache goes (partly) cold, the LSM is kept
+        get().sendMessage({ type: 'restart' });
+    },
+
     step: () => {
         get().sendMessage({ type: 'step' });
     },
 
+    requestTimeBreakdown: () => {
+        get().sendMessage({ type: 'time_breakdown' });
+    },
+
+    requestStyleRecommendation: () => {
+        get().sendMessage({ type: 'recommend_style' });
+    },
+
+    requestSelfCheck: () => {
+        get().sendMessage({ type: 'selfcheck' });
+    },
+
+    requestCompactionHistory: () => {
+        get().sendMessage({ type: 'compaction_history' });
+    },
+
+    requestCompactionPreview: () => {
+        get().sendMessage({ type: 'preview_compaction' });
+    },
+
+    pauseLevel: (level: number) => {
+        // Server replies with a state update showing the level as paused
+        get().sendMessage({ type: 'pause_level', level });
+    },
+
+    resumeLevel: (level: number) => {
+        get().sendMessage({ type: 'resume_level', level });
+    },
+
+    requestManualCompaction: (level?: number) => {
+        // Runs at the next compaction check; omitting the level compacts the whole tree
+        get().sendMessage({ type: 'manual_compaction', level });
+    },
+
+    setBreakpoint: (virtualTime: number) => {
+        // Server pauses and sends a stopped status once the breakpoint is reached (0 clears it)
+        get().sendMessage({ type: 'set_breakpoint', virtualTime });
+    },
+
+    loadScenario: (scenario: Scenario) => {
+        // Server replies with fresh metrics, state and a stopped status carrying the scenario's config
+        get().sendMessage({ type: 'load_scenario', scenario });
+    },
+
+    setSpeed: (speedMultiplier: number) => {
+        // Playback speed doesn't need the full config_update round-trip
+        const newConfig = { ...get().config, simulationSpeedMultiplier: speedMultiplier };
+        saveConfigToStorage(newConfig);
+        get().sendMessage({ type: 'set_speed', speedMultiplier });
+        set({ config: newConfig });
+    },
+
     updateConfig: (configUpdate: Partial<SimulationConfig>) => {
         try {
             console.log('[Store] updateConfig called with:', configUpdate);
@@ -480,6 +585,38 @@ export const useStore = create<AppStore>((set, get) => ({
                     }
                     break;
 
+                case 'time_breakdown':
+                    // Response to requestTimeBreakdown()
+                    set({ timeBreakdown: message.timeBreakdown ?? null });
+                    break;
+
+                case 'recommend_style':
+                    // Response to requestStyleRecommendation()
+                    if (message.recommendedStyle && message.rationale) {
+                        set({ styleRecommendation: { style: message.recommendedStyle, rationale: message.rationale } });
+                    }
+                    break;
+
+                case 'selfcheck':
+                    // Response to requestSelfCheck() - empty list means no violations
+                    set({ selfCheckViolations: message.violations ?? [] });
+                    break;
+
+                case 'compaction_history':
+                    // Response to requestCompactionHistory()
+                    set({ compactionHistory: message.compactionHistory ?? [] });
+                    break;
+
+                case 'preview':
+                    // Response to requestCompactionPreview()
+                    set({ compactionPreview: message.preview ?? null });
+                    break;
+
+                case 'ping':
+                    // Server heartbeat - reply so idle connections stay alive
+                    get().sendMessage({ type: 'pong' });
+                    break;
+
                 default:
                     console.warn('Unknown message type:', message.type);
             }
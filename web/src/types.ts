@@ -1,5 +1,5 @@
 // Traffic distribution types
-export type TrafficModel = "constant" | "advanced";
+export type TrafficModel = "constant" | "advanced" | "burst";
 
 export interface TrafficDistributionConfig {
     model: TrafficModel;
@@ -16,6 +16,9 @@ export interface TrafficDistributionConfig {
     spikeAmplitudeSigma?: number;
     capacityLimitMB?: number;
     queueMode?: "drop" | "queue";
+    burstRateMBps?: number; // For burst model: write rate during a burst
+    burstDurationSec?: number; // Length of each burst
+    quietDurationSec?: number; // Idle time between bursts (no writes)
 }
 
 export interface OverlapDistributionConfig {
@@ -54,6 +57,22 @@ export interface ReadWorkloadConfig {
 
     // Request characteristics
     avgScanSizeKB: number; // Average scan size in KB
+    avgScanLengthKeys?: number; // Keys per scan; > 0 enables the multi-level scan model (scans seek every sorted run)
+    readKeyDistribution?: "uniform" | "zipfian"; // Which keys point lookups read; zipfian favors recently written keys
+    zipfianTheta?: number; // Zipfian skew in (0, 1) (0 = 0.99)
+}
+
+// A column family beyond the default one: its own write stream, memtable and LSM tree.
+// Zero/empty settings inherit the top-level value.
+export interface ColumnFamilyConfig {
+    name: string; // Unique, not "default" ("" = unused entry)
+    writeRateMBps: number;
+    compactionStyle?: "" | "leveled" | "universal" | "fifo" | "tiered";
+    memtableFlushSizeMB?: number;
+    l0CompactionTrigger?: number;
+    maxBytesForLevelBaseMB?: number;
+    levelMultiplier?: number;
+    targetFileSizeMB?: number;
 }
 
 // Message types for WebSocket communication
@@ -68,32 +87,53 @@ export interface SimulationConfig {
     targetFileSizeMB: number;
     targetFileSizeMultiplier: number;
     deduplicationFactor: number;
+    l0ReductionFactor?: number; // Output/input ratio of compactions out of L0 (0 = deduplicationFactor)
+    deepReductionFactor?: number; // Output/input ratio of compactions out of L1+ (0 = 0.99)
+    levelReductionFactors?: number[]; // Per-source-level override of the two above (0 = use them)
     compressionFactor: number;
     compressionThroughputMBps: number;
     decompressionThroughputMBps: number;
     blockSizeKB: number;
     sstableBuildThroughputMBps: number;
+    compactionMBPerCPUSec?: number; // Compaction input one CPU thread merges per second; jobs last at least input / (this x threads) (0 = no CPU ceiling)
+    numCompactionThreads?: number; // CPU threads compactions share for merge work (0 = one per background job)
     maxBackgroundJobs: number;
+    maxBackgroundFlushes?: number; // Dedicated flush workers (0 = flushes share maxBackgroundJobs)
     maxSubcompactions: number;
     maxCompactionBytesMB: number;
     ioLatencyMs: number;
     ioThroughputMBps: number;
+    ioReadThroughputMBps?: number; // Read bandwidth for asymmetric devices (0 = same as ioThroughputMBps)
+    tierBoundaryLevel?: number; // First level on the cold storage tier (0 or >= numLevels = single tier)
+    coldIOThroughputMBps?: number; // Cold tier bandwidth (0 = same as ioThroughputMBps)
     numLevels: number;
     initialLSMSizeMB: number;
     simulationSpeedMultiplier: number;
+    baseStepSeconds?: number; // Virtual seconds advanced per Step iteration (default 1.0)
+    adaptiveStepMaxSeconds?: number; // Coarsen quiescent iterations up to this length (0 = disabled)
     randomSeed: number;
+    maxHistorySamples?: number; // Step samples kept in levelSizeHistory (0 = no history)
     maxStalledWriteMemoryMB?: number;
-    compactionStyle?: "leveled" | "universal" | "fifo"; // Compaction strategy (default "universal")
+    oomWarningSeconds?: number; // Warn this long before the stalled write backlog is projected to hit maxStalledWriteMemoryMB (0 = disabled)
+    compactionPriority?: "" | "by_size" | "oldest_largest_seq" | "oldest_smallest_seq" | "round_robin"; // Which files leveled compaction takes from an L1+ level ("" = level order)
+    compactionObjective?: "" | "min_read_amp"; // "" = score-driven picks; "min_read_amp" = leveled only, due L0 compactions run ahead of deeper levels
+    compactionStyle?: "leveled" | "universal" | "fifo" | "tiered"; // Compaction strategy (default "universal")
     maxSizeAmplificationPercent?: number; // max_size_amplification_percent for universal compaction (default 200%)
     levelCompactionDynamicLevelBytes?: boolean; // level_compaction_dynamic_level_bytes for leveled compaction (default false)
     fifoMaxTableFilesSizeMB?: number; // max_table_files_size for FIFO compaction (default 1024 MB)
     fifoAllowCompaction?: boolean; // allow_compaction for FIFO compaction (default false)
+    fifoTTLSeconds?: number; // ttl for FIFO compaction: delete files older than this (default 0 = disabled)
+    tieredMinThreshold?: number; // Similarly-sized files a bucket needs before size-tiered compaction merges it (default 4)
+    tieredBucketLow?: number; // Smallest file, as a fraction of a bucket's average size, that joins the bucket (default 0.5)
+    tieredBucketHigh?: number; // Largest file, as a multiple of a bucket's average size, that joins the bucket (default 1.5)
     enableWAL?: boolean; // Enable Write-Ahead Log (default true)
     walSync?: boolean; // Sync WAL after each write (default true)
     walSyncLatencyMs?: number; // fsync() latency in milliseconds (default 1.5ms)
+    walGroupCommitIntervalMs?: number; // Writes within this window share one WAL write and sync (0 = per-write)
     trafficDistribution?: TrafficDistributionConfig;
     overlapDistribution?: OverlapDistributionConfig;
     readWorkload?: ReadWorkloadConfig; // Read path modeling configuration (undefined = disabled)
+    columnFamilies?: ColumnFamilyConfig[]; // Up to 8 extra column families sharing the disk and background jobs
 }
 
 export interface CompactionStats {
@@ -107,13 +147,21 @@ export interface CompactionStats {
 export interface SimulationMetrics {
     timestamp: number;
     writeAmplification: number;
+    writeAmplificationWAL?: number; // WAL bytes / user bytes (0 with the WAL disabled)
+    writeAmplificationCompaction?: number; // SST bytes (flush + compaction output + ingested files) / user bytes
+    writeAmplificationTotal?: number; // writeAmplificationWAL + writeAmplificationCompaction
     readAmplification: number;
     writeLatencyMs: number;
-    readLatencyMs: number;
+    readLatencyMs: number; // Mean disk read latency over the throughput window, queueing behind flush/compaction included
+    readLatencyMsP99?: number; // P99 of the same queued disk read latency
+    oomRisk?: boolean; // Stalled write backlog projected to hit maxStalledWriteMemoryMB within oomWarningSeconds
+    stepSeconds?: number; // Length of the latest Step iteration (varies with adaptiveStepMaxSeconds)
+    adaptiveStepRefinements?: number; // Coarse stretches cut back to baseStepSeconds as a stall, OOM risk or compaction burst approached
     totalDataWrittenMB: number;
     totalDataReadMB: number;
     walBytesWritten: number;
-    spaceAmplification: number;
+    spaceAmplification: number; // Bytes on disk / estimated live bytes
+    liveDataSizeMB?: number; // User writes minus the overwrites compaction merges away
     flushThroughputMBps: number;
     compactionThroughputMBps: number;
     totalWriteThroughputMBps: number;
@@ -125,23 +173,71 @@ export interface SimulationMetrics {
     compactionsSinceUpdate?: Record<number, CompactionStats>; // Per-level aggregate compaction activity
     totalCompactionsCompleted?: number; // Monotonic counter of total compactions completed (for rate calculation)
     diskUtilizationPercent?: number; // Percentage of disk bandwidth used (0-100%)
+    diskReadUtilizationPercent?: number; // Compaction input and read workload MB as a percentage of the read bandwidth
+    diskWriteUtilizationPercent?: number; // Flush, compaction output, WAL and ingest MB as a percentage of the write bandwidth
+    hotTierUtilizationPercent?: number; // Share of recent disk time spent on hot-tier I/O (0 when single-tier)
+    coldTierUtilizationPercent?: number; // Share of recent disk time spent on cold-tier I/O (0 when single-tier)
+    intraL0Compactions?: number; // Intra-L0 compactions completed (L0 files merged back into L0)
+    intraL0BytesMB?: number; // Input MB those intra-L0 compactions rewrote
+    iopsUtilizationPercent?: number; // Disk operations issued as a percentage of diskIOPS (0 when diskIOPS is unset)
+    cpuUtilizationPercent?: number; // Compaction merge work as a percentage of the numCompactionThreads pool (0 when compactionMBPerCPUSec is unset)
+    compactionRateLimitUtilizationPercent?: number; // Compaction I/O as a percentage of compactionRateLimitMBps (0 when unlimited)
+    compactionRateLimitDelaySeconds?: number; // Cumulative time compactions waited for the rate limiter
     inProgressCount?: number;
     inProgressDetails?: Array<{
         inputMB: number;
         outputMB: number;
         fromLevel: number;
         toLevel: number;
+        columnFamily?: number; // 0 = default column family
     }>;
     activeBackgroundJobs?: number;
     maxBackgroundJobs?: number;
     stalledWriteCount?: number;
     maxStalledWriteCount?: number;
     stallDurationSeconds?: number;
+    slowdownSeconds?: number; // Time writes were slowed by memtable backpressure short of a stall
+    stallCount?: number; // Completed write stalls
+    stallsSinceUpdate?: number; // Completed write stalls since the last update
+    stallDurationP50?: number; // Median completed stall duration (seconds)
+    stallDurationP95?: number; // P95 completed stall duration (seconds)
+    stallDurationP99?: number; // P99 completed stall duration (seconds)
+    cacheWarmth?: number; // Fraction of the block cache that is warm (1.0 except while re-warming after a restart)
+    restarts?: number; // Simulated restarts
+    subcompactionJobs?: number; // Compactions that ran as more than one subcompaction (maxSubcompactions)
+    subcompactionSecondsSaved?: number; // CPU-phase time parallel subcompactions saved
+    postRestartPeakReadLatencyMs?: number; // Highest average read latency since the last restart
+    readLatencyRecoverySeconds?: number; // Time after the last restart until read latency recovered (0 until it has)
     isStalled?: boolean;
     isOOMKilled?: boolean;
     avgReadLatencyMs?: number;  // Average read latency across all request types
     p50ReadLatencyMs?: number;  // P50 (median) read latency
     p99ReadLatencyMs?: number;  // P99 read latency
+    pointReadLatencyMs?: number; // Average latency of cache-miss point lookups
+    scanLatencyMs?: number;      // Average range scan latency
+    avgLevelsTouchedPerRead?: number; // Sorted runs a read probes before resolving (first hit for lookups, all for scans)
+    bottommostCompactionThroughputMBps?: number; // Input MB/s of compactions into the deepest level
+    upperCompactionThroughputMBps?: number; // Input MB/s of all other compactions
+    recompactionBytes?: number; // MB compacted again within recompactionWindowSeconds of being compacted
+    compactionDebtMB?: number; // Sum over levels (except the last) of bytes above the level target; L0 is measured against maxBytesForLevelBaseMB
+    compactionDebtRatio?: number; // compactionDebtMB / total tree size
+    compactionsDeferredForBusyTargets?: number; // Compaction picks deferred because a target file was in a running compaction
+    compactionQueueDepth?: number; // Levels needing compaction with none scheduled, at the last compaction check
+    avgCompactionWaitSec?: number; // Mean time from a level first needing compaction until one is scheduled for it
+    manualCompactions?: number; // Manual compactions scheduled (manual_compaction message)
+    repickedBusyTargetFiles?: number; // Busy target files swapped for free ones (repickBusyTargetFiles)
+    ageTriggeredSizeAmpCompactions?: number; // Universal size-amp compactions fired only by universalAgeBasedTrigger
+    fifoDroppedMB?: number; // MB of files FIFO deleted (size cap or TTL)
+    fifoTTLDroppedMB?: number; // MB of files FIFO deleted because they outlived fifoTTLSeconds
+    metadataBytes?: number; // MB of SST index/filter blocks written by flushes and compactions (metadataOverheadPercent, plus key-count-sized filter blocks)
+    readAmplificationEMA?: number; // Read amplification sampled per read batch, smoothed
+    avgReadAmplification?: number; // Time-weighted mean read amplification since start/reset
+    peakReadAmplification?: number; // Highest read amplification since start/reset
+    readAmpObjectiveOverrides?: number; // L0 compactions min_read_amp started while a deeper level was also due
+    bloomFilterFPR?: number; // Chance a sorted run without the key is still probed (0 when bloom filters are off)
+    bloomAdmittedReadAmp?: number; // Files point lookups actually probed after bloom filtering (0 when off)
+    readMemtableHitRatio?: number; // Fraction of point lookups whose key is still in the memtable
+    readKeySkewFactor?: number; // Point-lookup read amplification relative to uniform key access (1 = uniform, < 1 = hot keys resolve higher up)
     readBandwidthMBps?: number; // Disk bandwidth consumed by reads
     currentReadReqsPerSec?: number; // Current actual read requests/sec (with variability applied)
     // Read request type breakdown (requests per second)
@@ -163,14 +259,39 @@ export interface LevelState {
     targetSizeMB?: number;
     fileCount: number;
     files: SSTFile[];
+    paused?: boolean; // Compaction out of this level is paused (pause_level)
 }
 
 export interface ActiveCompactionInfo {
+    columnFamily?: number; // 0 = default column family, i = columnFamilies entry i-1
     fromLevel: number;
     toLevel: number;
     sourceFileCount: number;
     targetFileCount: number;
     isIntraL0: boolean;
+    subcompactions?: number; // Parallel pieces the job runs as (1 = no subcompactions)
+}
+
+// Per-file L0 view, present in state when the detailedL0State config option is on
+export interface L0FileDetail {
+    id: string;
+    sizeMB: number;
+    ageSeconds: number;
+    subLevel: number; // Index of the file's L0 sub-level, 0 = oldest
+    overlappingFiles: number; // Other L0 files whose key ranges overlap this one
+    beingCompacted: boolean;
+    compactionID: number; // 0 when not being compacted
+}
+
+export interface L0Detail {
+    files: L0FileDetail[]; // Capped at 100 files
+    fileCount: number;
+    totalSizeMB: number;
+    subLevelCount: number;
+    subLevelsEnabled: boolean;
+    filesBeingCompacted: number;
+    compactionTrigger: number;
+    urgentCompactionTrigger: number; // 0 = disabled
 }
 
 export interface SimulationState {
@@ -181,9 +302,34 @@ export interface SimulationState {
     activeCompactions?: number; // Count of currently scheduled/pending compactions
     activeCompactionInfos?: ActiveCompactionInfo[]; // Detailed compaction info
     numImmutableMemtables?: number; // Number of immutable memtables waiting to flush
+    activeMemtableMB?: number; // Data in the active (mutable) memtable
+    memtableFlushSizeMB?: number; // Active memtable size that triggers a flush (fill gauge capacity)
     immutableMemtableSizesMB?: number[]; // Sizes of immutable memtables waiting to flush
+    estimatedCompactionsToClearL0?: number; // Compactions needed to drain the current L0 backlog
+    flushRateMBps?: number; // MB/s flushed to L0 over the metrics window
+    achievedWriteRateMBps?: number; // MB/s of user writes accepted over the metrics window (flush below this means memtables are piling up)
+    avgLevelsTouchedPerRead?: number; // Sorted runs a read probes before resolving (first hit for lookups, all for scans)
+    bloomFilterFPR?: number; // Effective bloom filter false-positive rate (only when bloomFilterBitsPerKey > 0)
+    l0SubLevelCount?: number; // L0 sub-levels derived from file age and key overlap (what enableL0SubLevels reads and scores L0 by)
+    physicalSizeMBPerLevel?: number[]; // Stored MB per level
+    logicalSizeMBPerLevel?: number[]; // MB per level at L0's compression factor (differs under a per-level compression model)
+    liveDataSizeMB?: number; // Estimated live bytes (see metrics.liveDataSizeMB)
+    spaceAmplification?: number; // totalSizeMB / liveDataSizeMB
+    levelSizeHistory?: number[][]; // One sample per step, oldest first: [virtualTime, L0 MB, L1 MB, ...] (at most maxHistorySamples)
+    l0Detail?: L0Detail; // Only when detailedL0State is enabled
     baseLevel?: number; // Base level for universal compaction and leveled compaction with dynamic level bytes (lowest non-empty level below L0)
     currentIncomingRateMBps?: number; // Current incoming write rate (for advanced traffic models, shows actual current rate)
+    columnFamilies?: ColumnFamilyState[]; // Only when extra column families are configured; "default" first
+}
+
+export interface ColumnFamilyState {
+    name: string;
+    compactionStyle?: string; // Extra column families only
+    writeRateMBps: number;
+    levels: LevelState[];
+    totalSizeMB: number;
+    activeMemtableMB: number;
+    numImmutableMemtables: number;
 }
 
 export interface SimulationEvent {
@@ -193,20 +339,81 @@ export interface SimulationEvent {
     level?: number;
 }
 
+export interface CompactionRecord {
+    columnFamily?: number; // 0 = default column family, i = columnFamilies entry i-1
+    startTime: number; // Virtual time the job started
+    endTime: number; // Virtual time the job completed
+    fromLevel: number;
+    toLevel: number;
+    inputMB: number;
+    outputMB: number;
+    trivialMove: boolean;
+    reason: string; // e.g. 'level_score', 'size_ratio', 'size_amplification', 'intra_l0'
+}
+
+// The compaction the compactor would pick next, without running it (simulator.CompactionPreview)
+export interface CompactionPreview {
+    fromLevel: number;
+    toLevel: number;
+    sourceFiles: number;
+    targetFiles: number;
+    inputMB: number;
+    estimatedOutputMB: number; // After dedup, compression and dropped garbage
+    isIntraL0: boolean;
+    reason: string; // Same values as CompactionRecord.reason
+    slotAvailable: boolean; // A background slot is free, so it would start on the next pass
+    runningCount: number; // Compactions already running
+}
+
+// A shareable run: config, starting LSM files, scheduled changes and duration (simulator.Scenario)
+export interface Scenario {
+    name?: string;
+    description?: string;
+    config: Partial<SimulationConfig>; // Omitted fields take the server's defaults
+    durationSeconds: number;
+    initialFiles?: { level: number; count: number; sizeMB: number }[];
+    timeline?: ScenarioChange[];
+}
+
+// One scheduled scenario change; only the fields its op needs are set
+export interface ScenarioChange {
+    atSeconds: number;
+    op: 'config' | 'pause_level' | 'resume_level' | 'ingest' | 'reset_metrics' | 'restart';
+    config?: Partial<SimulationConfig>; // For 'config': dynamic params only
+    level?: number; // For 'pause_level', 'resume_level' and 'ingest'
+    sizeMB?: number; // For 'ingest'
+}
+
 // WebSocket message types
 export type WSMessage =
     | { type: 'start' }
     | { type: 'pause' }
     | { type: 'reset' }
+    | { type: 'reset_metrics' }
+    | { type: 'restart' }
     | { type: 'step' }
     | { type: 'config_update'; config: Partial<SimulationConfig> }
+    | { type: 'set_speed'; speedMultiplier: number }
+    | { type: 'pause_level'; level: number }
+    | { type: 'resume_level'; level: number }
+    | { type: 'manual_compaction'; level?: number } // Compact one level into the next (omitted = whole tree)
+    | { type: 'set_breakpoint'; virtualTime: number } // Pause once virtual time reaches it (0 clears)
+    | { type: 'load_scenario'; scenario: Scenario } // Replace the simulation with the scenario's run
     | { type: 'reset_config' }
     | { type: 'status'; running: boolean; config: SimulationConfig }
     | { type: 'metrics'; metrics: SimulationMetrics }
     | { type: 'state'; state: SimulationState }
     | { type: 'event'; event: SimulationEvent }
     | { type: 'log'; log: string }
-    | { type: 'error'; error: string };
+    | { type: 'error'; error: string }
+    | { type: 'ping' }
+    | { type: 'pong' }
+    | { type: 'time_breakdown'; timeBreakdown?: Record<string, number> }
+    | { type: 'recommend_style'; recommendedStyle?: 'leveled' | 'universal' | 'fifo'; rationale?: string }
+    | { type: 'selfcheck'; violations?: string[] }
+    | { type: 'compaction_history'; compactionHistory?: CompactionRecord[] }
+    | { type: 'preview_compaction' }
+    | { type: 'preview'; preview?: CompactionPreview }; // No preview: no compaction is due
 
 export type ConnectionStatus = 'connecting' | 'connected' | 'disconnected' | 'error';
 